@@ -0,0 +1,462 @@
+// Package filesystem is an implementation of Source which loads assets from a directory of JSON files.
+package filesystem
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/static"
+	"github.com/nyaruka/goflow/assets/static/types"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/buger/jsonparser"
+	"github.com/pkg/errors"
+)
+
+// FlowsDir is the directory of flow files in a source directory
+const FlowsDir = "flows"
+
+// asset types which are each loaded from a single JSON file containing an array, e.g. fields.json
+var arrayFiles = []string{"channels", "classifiers", "exchange_rates", "fields", "globals", "groups", "labels", "locations", "resthooks", "templates", "ticketers", "users"}
+
+// ChangeCallback is called when a file in a watched source has been reloaded, with any error reading it
+type ChangeCallback func(path string, err error)
+
+// tracks whether a file has changed
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Source is an asset source which loads assets from a directory like:
+//
+//   channels.json
+//   fields.json
+//   groups.json
+//   ...
+//   flows/
+//     registration.json
+//     survey.json
+//
+// Each flow file contains a single flow definition, which is only read and validated when that flow is requested.
+// Other asset types are read from a file containing an array of those assets. All files are optional.
+type Source struct {
+	dir string
+
+	mutex     sync.RWMutex
+	arrays    map[string]*static.StaticSource // by file name, e.g. fields.json
+	flowPaths map[assets.FlowUUID]string      // relative paths of flow files
+	flows     map[assets.FlowUUID]*types.Flow // flows which have been loaded
+	stamps    map[string]fileStamp
+	errors    map[string]error
+
+	watchMutex   sync.Mutex
+	stopWatching chan bool
+	watchWait    sync.WaitGroup
+}
+
+// NewSource creates a new source from the given directory. An error is returned if any of the array files or
+// the UUIDs of the flow files can't be read.
+func NewSource(dir string) (*Source, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading asset directory '%s'", dir)
+	}
+	if !info.IsDir() {
+		return nil, errors.Errorf("'%s' is not a directory", dir)
+	}
+
+	s := &Source{
+		dir:       dir,
+		arrays:    make(map[string]*static.StaticSource),
+		flowPaths: make(map[assets.FlowUUID]string),
+		flows:     make(map[assets.FlowUUID]*types.Flow),
+		stamps:    make(map[string]fileStamp),
+		errors:    make(map[string]error),
+	}
+
+	if _, err := s.refresh(false); err != nil {
+		return nil, err
+	}
+
+	if len(s.errors) > 0 {
+		return nil, errors.Errorf("unable to read assets: %s", s.formatErrors())
+	}
+
+	return s, nil
+}
+
+var _ assets.Source = (*Source)(nil)
+
+// Refresh checks for files which have been added, changed or deleted and reloads them, returning the errors of
+// the changed files by their paths
+func (s *Source) Refresh() (map[string]error, error) {
+	return s.refresh(true)
+}
+
+// Watch starts polling the directory for changes at the given interval. The given callback, which can be nil,
+// is called for each changed file. An error is returned if the source is already being watched.
+func (s *Source) Watch(interval time.Duration, callback ChangeCallback) error {
+	s.watchMutex.Lock()
+	defer s.watchMutex.Unlock()
+
+	if s.stopWatching != nil {
+		return errors.New("source is already being watched")
+	}
+
+	s.stopWatching = make(chan bool)
+	s.watchWait.Add(1)
+
+	go func() {
+		defer s.watchWait.Done()
+
+		for {
+			select {
+			case <-s.stopWatching:
+				return
+			case <-time.After(interval):
+				changes, _ := s.Refresh()
+				if callback != nil {
+					for _, path := range sortedKeys(changes) {
+						callback(path, changes[path])
+					}
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops watching for changes
+func (s *Source) Close() {
+	s.watchMutex.Lock()
+	defer s.watchMutex.Unlock()
+
+	if s.stopWatching != nil {
+		close(s.stopWatching)
+		s.watchWait.Wait()
+		s.stopWatching = nil
+	}
+}
+
+// Errors returns the current errors by the relative paths of the files which have them
+func (s *Source) Errors() map[string]error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	errs := make(map[string]error, len(s.errors))
+	for path, err := range s.errors {
+		errs[path] = err
+	}
+	return errs
+}
+
+// FlowUUIDs returns the UUIDs of all flows ordered by the paths of their files
+func (s *Source) FlowUUIDs() []assets.FlowUUID {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	uuids := make([]assets.FlowUUID, 0, len(s.flowPaths))
+	for uuid := range s.flowPaths {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool { return s.flowPaths[uuids[i]] < s.flowPaths[uuids[j]] })
+	return uuids
+}
+
+// Flow returns the flow asset with the given UUID, reading it from its file if it hasn't been read already
+func (s *Source) Flow(uuid assets.FlowUUID) (assets.Flow, error) {
+	s.mutex.RLock()
+	flow := s.flows[uuid]
+	path, exists := s.flowPaths[uuid]
+	s.mutex.RUnlock()
+
+	if flow != nil {
+		return flow, nil
+	}
+	if !exists {
+		return nil, errors.Errorf("no such flow with UUID '%s'", uuid)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// check again as the flow may have been read or removed before we took the write lock
+	flow = s.flows[uuid]
+	path, exists = s.flowPaths[uuid]
+
+	if flow != nil {
+		return flow, nil
+	}
+	if !exists {
+		return nil, errors.Errorf("no such flow with UUID '%s'", uuid)
+	}
+
+	flow, err := s.loadFlow(path)
+	if err != nil {
+		s.errors[path] = err
+		return nil, errors.Wrapf(err, "error reading flow file '%s'", path)
+	}
+
+	s.flows[uuid] = flow
+	return flow, nil
+}
+
+// Channels returns all channel assets
+func (s *Source) Channels() ([]assets.Channel, error) {
+	return s.array("channels").Channels()
+}
+
+// Classifiers returns all classifier assets
+func (s *Source) Classifiers() ([]assets.Classifier, error) {
+	return s.array("classifiers").Classifiers()
+}
+
+// ExchangeRates returns all exchange rate assets
+func (s *Source) ExchangeRates() ([]assets.ExchangeRate, error) {
+	return s.array("exchange_rates").ExchangeRates()
+}
+
+// Fields returns all field assets
+func (s *Source) Fields() ([]assets.Field, error) {
+	return s.array("fields").Fields()
+}
+
+// Globals returns all global assets
+func (s *Source) Globals() ([]assets.Global, error) {
+	return s.array("globals").Globals()
+}
+
+// Groups returns all group assets
+func (s *Source) Groups() ([]assets.Group, error) {
+	return s.array("groups").Groups()
+}
+
+// Labels returns all label assets
+func (s *Source) Labels() ([]assets.Label, error) {
+	return s.array("labels").Labels()
+}
+
+// Locations returns all location assets
+func (s *Source) Locations() ([]assets.LocationHierarchy, error) {
+	return s.array("locations").Locations()
+}
+
+// Resthooks returns all resthook assets
+func (s *Source) Resthooks() ([]assets.Resthook, error) {
+	return s.array("resthooks").Resthooks()
+}
+
+// Templates returns all template assets
+func (s *Source) Templates() ([]assets.Template, error) {
+	return s.array("templates").Templates()
+}
+
+// Ticketers returns all ticketer assets
+func (s *Source) Ticketers() ([]assets.Ticketer, error) {
+	return s.array("ticketers").Ticketers()
+}
+
+// Users returns all user assets
+func (s *Source) Users() ([]assets.User, error) {
+	return s.array("users").Users()
+}
+
+// gets the static source for the given array file, or an empty source if it doesn't exist
+func (s *Source) array(name string) *static.StaticSource {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if source := s.arrays[name+".json"]; source != nil {
+		return source
+	}
+	return static.NewEmptySource()
+}
+
+// checks all files for changes and reloads those which have changed. Flow files are only fully read if eager
+// is true, otherwise we just index their UUIDs.
+func (s *Source) refresh(eager bool) (map[string]error, error) {
+	current, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changes := make(map[string]error)
+
+	// look for deleted files
+	for path := range s.stamps {
+		if _, exists := current[path]; !exists {
+			s.unload(path)
+			delete(s.stamps, path)
+			delete(s.errors, path)
+			changes[path] = nil
+		}
+	}
+
+	// and new or modified files
+	paths := make([]string, 0, len(current))
+	for path := range current {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		stamp := current[path]
+		if previous, exists := s.stamps[path]; exists && previous == stamp {
+			continue
+		}
+
+		s.stamps[path] = stamp
+
+		if err := s.load(path, eager); err != nil {
+			s.errors[path] = err
+		} else {
+			delete(s.errors, path)
+		}
+		changes[path] = s.errors[path]
+	}
+
+	return changes, nil
+}
+
+// scans the directory for asset files, returning their stamps by relative path
+func (s *Source) scan() (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+
+	for _, name := range arrayFiles {
+		info, err := os.Stat(filepath.Join(s.dir, name+".json"))
+		if err == nil && !info.IsDir() {
+			stamps[name+".json"] = fileStamp{info.ModTime(), info.Size()}
+		}
+	}
+
+	flowsDir := filepath.Join(s.dir, FlowsDir)
+	if _, err := os.Stat(flowsDir); os.IsNotExist(err) {
+		return stamps, nil
+	}
+
+	err := filepath.Walk(flowsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".json") {
+			relPath, _ := filepath.Rel(s.dir, path)
+			stamps[filepath.ToSlash(relPath)] = fileStamp{info.ModTime(), info.Size()}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading asset directory '%s'", s.dir)
+	}
+
+	return stamps, nil
+}
+
+// loads the file at the given relative path, keeping the previously loaded assets if it can't be read
+func (s *Source) load(path string, eager bool) error {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, path))
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(path, FlowsDir+"/") {
+		uuid, err := readFlowUUID(data)
+		if err != nil {
+			return err
+		}
+
+		var flow *types.Flow
+		if eager {
+			if flow, err = s.loadFlow(path); err != nil {
+				return err
+			}
+		}
+
+		if otherPath, exists := s.flowPaths[uuid]; exists && otherPath != path {
+			return errors.Errorf("flow with UUID '%s' is already defined in '%s'", uuid, otherPath)
+		}
+
+		// if this file was previously a different flow, forget that flow
+		s.unload(path)
+
+		s.flowPaths[uuid] = path
+		if flow != nil {
+			s.flows[uuid] = flow
+		}
+		return nil
+	}
+
+	name := strings.TrimSuffix(path, ".json")
+	source, err := static.NewSource([]byte(fmt.Sprintf(`{"%s": %s}`, name, data)))
+	if err != nil {
+		return err
+	}
+
+	s.arrays[path] = source
+	return nil
+}
+
+// forgets the assets loaded from the file at the given relative path
+func (s *Source) unload(path string) {
+	for uuid, flowPath := range s.flowPaths {
+		if flowPath == path {
+			delete(s.flowPaths, uuid)
+			delete(s.flows, uuid)
+		}
+	}
+	delete(s.arrays, path)
+}
+
+// reads and validates the flow file at the given relative path
+func (s *Source) loadFlow(path string) (*types.Flow, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, path))
+	if err != nil {
+		return nil, err
+	}
+
+	flow := &types.Flow{}
+	if err := utils.UnmarshalAndValidate(data, flow); err != nil {
+		return nil, err
+	}
+	return flow, nil
+}
+
+func (s *Source) formatErrors() string {
+	msgs := make([]string, 0, len(s.errors))
+	for _, path := range sortedKeys(s.errors) {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", path, s.errors[path]))
+	}
+	return strings.Join(msgs, ", ")
+}
+
+// reads just the UUID from a flow definition, which may be in the legacy format
+func readFlowUUID(data []byte) (assets.FlowUUID, error) {
+	uuid, err := jsonparser.GetString(data, "uuid")
+	if err != nil {
+		uuid, err = jsonparser.GetString(data, "metadata", "uuid")
+	}
+	if err != nil || uuid == "" {
+		return "", errors.New("unable to read flow UUID")
+	}
+	return assets.FlowUUID(uuid), nil
+}
+
+func sortedKeys(m map[string]error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
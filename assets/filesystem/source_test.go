@@ -0,0 +1,265 @@
+package filesystem_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flow1JSON = `{
+	"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+	"name": "Registration",
+	"spec_version": "13.0",
+	"language": "eng",
+	"type": "messaging",
+	"nodes": []
+}`
+
+const flow2JSON = `{
+	"metadata": {
+		"uuid": "a4d15ed4-5b24-407f-b86e-4b881f09a186",
+		"name": "Legacy Survey"
+	},
+	"base_language": "eng",
+	"flow_type": "F",
+	"action_sets": [],
+	"rule_sets": []
+}`
+
+const fieldsJSON = `[
+	{"uuid": "d66a7823-eada-40e5-9a3a-57239d4690bf", "key": "gender", "name": "Gender", "type": "text"}
+]`
+
+func writeFile(t *testing.T, dir, path, content string) {
+	fullPath := filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+	require.NoError(t, ioutil.WriteFile(fullPath, []byte(content), 0644))
+}
+
+func TestSource(t *testing.T) {
+	_, err := filesystem.NewSource("/tmp/doesnt-exist")
+	assert.EqualError(t, err, "error reading asset directory '/tmp/doesnt-exist': stat /tmp/doesnt-exist: no such file or directory")
+
+	dir := t.TempDir()
+	writeFile(t, dir, "fields.json", fieldsJSON)
+	writeFile(t, dir, "flows/registration.json", flow1JSON)
+	writeFile(t, dir, "flows/legacy/survey.json", flow2JSON)
+	writeFile(t, dir, "flows/README.txt", "not a flow")
+
+	_, err = filesystem.NewSource(filepath.Join(dir, "fields.json"))
+	assert.EqualError(t, err, "'"+filepath.Join(dir, "fields.json")+"' is not a directory")
+
+	source, err := filesystem.NewSource(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []assets.FlowUUID{"a4d15ed4-5b24-407f-b86e-4b881f09a186", "76f0a02f-3b75-4b86-9064-e9195e1b3a02"}, source.FlowUUIDs())
+
+	fields, err := source.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(fields))
+	assert.Equal(t, "gender", fields[0].Key())
+
+	// missing files are treated as empty
+	groups, err := source.Groups()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(groups))
+
+	flow, err := source.Flow("76f0a02f-3b75-4b86-9064-e9195e1b3a02")
+	assert.NoError(t, err)
+	assert.Equal(t, "Registration", flow.Name())
+
+	flow, err = source.Flow("a4d15ed4-5b24-407f-b86e-4b881f09a186")
+	assert.NoError(t, err)
+	assert.Equal(t, "Legacy Survey", flow.Name())
+
+	_, err = source.Flow("d2ae3b6e-ad4a-4f4d-8d6b-6b3b1a2dbb4d")
+	assert.EqualError(t, err, "no such flow with UUID 'd2ae3b6e-ad4a-4f4d-8d6b-6b3b1a2dbb4d'")
+
+	assert.Equal(t, map[string]error{}, source.Errors())
+
+	// concurrent requests for a flow which hasn't been read yet all get the same flow
+	source, err = filesystem.NewSource(dir)
+	require.NoError(t, err)
+
+	flows := make([]assets.Flow, 10)
+	wg := &sync.WaitGroup{}
+	for i := range flows {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			flows[i], _ = source.Flow("76f0a02f-3b75-4b86-9064-e9195e1b3a02")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, f := range flows {
+		assert.Same(t, flows[0], f)
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "fields.json", `[{"uuid": "d66a7823-eada-40e5-9a3a-57239d4690bf", "name": "Gender", "type": "text"}]`)
+	writeFile(t, dir, "groups.json", `{`)
+	writeFile(t, dir, "flows/bad.json", `{"name": "No UUID"}`)
+
+	_, err := filesystem.NewSource(dir)
+	assert.EqualError(t, err, "unable to read assets: fields.json: unable to read assets: field 'key' is required, flows/bad.json: unable to read flow UUID, groups.json: unable to read assets: unexpected end of JSON input")
+
+	// flow files are only validated when they're loaded
+	dir = t.TempDir()
+	writeFile(t, dir, "flows/invalid.json", `{"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02", "name": "Bad", "nodes": [}`)
+
+	source, err := filesystem.NewSource(dir)
+	require.NoError(t, err)
+
+	_, err = source.Flow("76f0a02f-3b75-4b86-9064-e9195e1b3a02")
+	assert.EqualError(t, err, "error reading flow file 'flows/invalid.json': invalid character '}' looking for beginning of value")
+	assert.Equal(t, 1, len(source.Errors()))
+	assert.Contains(t, source.Errors(), "flows/invalid.json")
+
+	// a duplicate flow UUID is an error
+	dir = t.TempDir()
+	writeFile(t, dir, "flows/a.json", flow1JSON)
+	writeFile(t, dir, "flows/b.json", flow1JSON)
+
+	_, err = filesystem.NewSource(dir)
+	assert.EqualError(t, err, "unable to read assets: flows/b.json: flow with UUID '76f0a02f-3b75-4b86-9064-e9195e1b3a02' is already defined in 'flows/a.json'")
+}
+
+func TestSourceRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "fields.json", fieldsJSON)
+	writeFile(t, dir, "flows/registration.json", flow1JSON)
+
+	source, err := filesystem.NewSource(dir)
+	require.NoError(t, err)
+
+	// load the flow so it's cached
+	flow, err := source.Flow("76f0a02f-3b75-4b86-9064-e9195e1b3a02")
+	require.NoError(t, err)
+	assert.Equal(t, "Registration", flow.Name())
+
+	// nothing changed
+	changes, err := source.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]error{}, changes)
+
+	// change the flow, add a new one and add some groups
+	writeFile(t, dir, "flows/registration.json", `{"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02", "name": "Registration 2", "nodes": []}`)
+	writeFile(t, dir, "flows/survey.json", flow2JSON)
+	writeFile(t, dir, "groups.json", `[{"uuid": "1e1ce1e1-9288-4504-869e-022d1003c72a", "name": "Testers"}]`)
+
+	changes, err = source.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]error{"flows/registration.json": nil, "flows/survey.json": nil, "groups.json": nil}, changes)
+
+	flow, err = source.Flow("76f0a02f-3b75-4b86-9064-e9195e1b3a02")
+	require.NoError(t, err)
+	assert.Equal(t, "Registration 2", flow.Name())
+
+	flow, err = source.Flow("a4d15ed4-5b24-407f-b86e-4b881f09a186")
+	require.NoError(t, err)
+	assert.Equal(t, "Legacy Survey", flow.Name())
+
+	groups, _ := source.Groups()
+	assert.Equal(t, 1, len(groups))
+
+	// break the fields file - we should keep the previous fields and report the error
+	writeFile(t, dir, "fields.json", `[{"uuid": "d66a7823-eada-40e5-9a3a-57239d4690bf", "key": "gender"}]`)
+
+	changes, err = source.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.EqualError(t, changes["fields.json"], "unable to read assets: field 'type' is required")
+	assert.Equal(t, changes, source.Errors())
+
+	fields, _ := source.Fields()
+	assert.Equal(t, 1, len(fields))
+	assert.Equal(t, "Gender", fields[0].Name())
+
+	// fix it and delete a flow
+	writeFile(t, dir, "fields.json", `[]`)
+	require.NoError(t, os.Remove(filepath.Join(dir, "flows/survey.json")))
+
+	changes, err = source.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]error{"fields.json": nil, "flows/survey.json": nil}, changes)
+	assert.Equal(t, map[string]error{}, source.Errors())
+
+	fields, _ = source.Fields()
+	assert.Equal(t, 0, len(fields))
+
+	_, err = source.Flow("a4d15ed4-5b24-407f-b86e-4b881f09a186")
+	assert.EqualError(t, err, "no such flow with UUID 'a4d15ed4-5b24-407f-b86e-4b881f09a186'")
+
+	// change a flow file to have the UUID of another flow - we should keep the previous flow and report the error
+	writeFile(t, dir, "flows/survey.json", flow2JSON)
+
+	changes, err = source.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]error{"flows/survey.json": nil}, changes)
+
+	writeFile(t, dir, "flows/survey.json", flow1JSON)
+
+	changes, err = source.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.EqualError(t, changes["flows/survey.json"], "flow with UUID '76f0a02f-3b75-4b86-9064-e9195e1b3a02' is already defined in 'flows/registration.json'")
+
+	flow, err = source.Flow("a4d15ed4-5b24-407f-b86e-4b881f09a186")
+	require.NoError(t, err)
+	assert.Equal(t, "Legacy Survey", flow.Name())
+
+	flow, err = source.Flow("76f0a02f-3b75-4b86-9064-e9195e1b3a02")
+	require.NoError(t, err)
+	assert.Equal(t, "Registration 2", flow.Name())
+}
+
+func TestSourceWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "flows/registration.json", flow1JSON)
+
+	source, err := filesystem.NewSource(dir)
+	require.NoError(t, err)
+
+	mutex := &sync.Mutex{}
+	changed := make([]string, 0)
+
+	err = source.Watch(10*time.Millisecond, func(path string, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		changed = append(changed, path)
+	})
+	require.NoError(t, err)
+	defer source.Close()
+
+	// can't start a second watch while the first is running
+	err = source.Watch(10*time.Millisecond, nil)
+	assert.EqualError(t, err, "source is already being watched")
+
+	writeFile(t, dir, "groups.json", `[]`)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(changed) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"groups.json"}, changed)
+
+	// but can once it's been stopped
+	source.Close()
+
+	err = source.Watch(10*time.Millisecond, nil)
+	assert.NoError(t, err)
+}
@@ -5,6 +5,9 @@ import (
 
 	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/shopspring/decimal"
 )
 
 // ChannelUUID is the UUID of a channel
@@ -22,7 +25,9 @@ const (
 	ChannelRoleUSSD    ChannelRole = "ussd"
 )
 
-// Channel is something that can send/receive messages.
+// Channel is something that can send/receive messages. A channel can optionally have a `weight` used when balancing
+// sends across channels, a table of `costs` of sending to numbers by prefix, the `max_length` of messages it can send
+// and the `encoding` (`gsm7` or `ucs2`) it uses to send them.
 //
 //   {
 //     "uuid": "14782905-81a6-4910-bc9f-93ad287b23c3",
@@ -44,6 +49,10 @@ type Channel interface {
 	Country() envs.Country
 	MatchPrefixes() []string
 	AllowInternational() bool
+	Weight() int
+	Costs() map[string]decimal.Decimal
+	MaxLength() int
+	Encoding() utils.SMSEncoding
 }
 
 // ClassifierUUID is the UUID of an NLU classifier
@@ -58,6 +67,9 @@ type ClassifierUUID uuids.UUID
 //     "intents": ["book_flight", "book_hotel"]
 //   }
 //
+// Classifiers which are evaluated in-process rather than by an external service (e.g. type `rules`) can also
+// include their training data in a `training` property.
+//
 // @asset classifier
 type Classifier interface {
 	UUID() ClassifierUUID
@@ -66,6 +78,30 @@ type Classifier interface {
 	Intents() []string
 }
 
+// TrainedClassifier is a classifier which also provides its own training data. Asset implementations only need to
+// implement this if they provide classifiers which are evaluated in-process.
+type TrainedClassifier interface {
+	Classifier
+
+	Training() json.RawMessage
+}
+
+// ExchangeRate is the rate for converting amounts from one currency to another. Conversions in the opposite
+// direction use the inverse of the rate.
+//
+//   {
+//     "from": "USD",
+//     "to": "RWF",
+//     "rate": 1020.5
+//   }
+//
+// @asset exchange_rate
+type ExchangeRate interface {
+	From() envs.Currency
+	To() envs.Currency
+	Rate() decimal.Decimal
+}
+
 // FieldUUID is the UUID of a field
 type FieldUUID uuids.UUID
 
@@ -304,6 +340,7 @@ type User interface {
 type Source interface {
 	Channels() ([]Channel, error)
 	Classifiers() ([]Classifier, error)
+	ExchangeRates() ([]ExchangeRate, error)
 	Fields() ([]Field, error)
 	Flow(FlowUUID) (Flow, error)
 	Globals() ([]Global, error)
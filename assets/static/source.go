@@ -16,18 +16,19 @@ import (
 // StaticSource is an asset source which loads assets from a static JSON file
 type StaticSource struct {
 	s struct {
-		Channels    []*types.Channel          `json:"channels" validate:"omitempty,dive"`
-		Classifiers []*types.Classifier       `json:"classifiers" validate:"omitempty,dive"`
-		Fields      []*types.Field            `json:"fields" validate:"omitempty,dive"`
-		Flows       []*types.Flow             `json:"flows" validate:"omitempty,dive"`
-		Globals     []*types.Global           `json:"globals" validate:"omitempty,dive"`
-		Groups      []*types.Group            `json:"groups" validate:"omitempty,dive"`
-		Labels      []*types.Label            `json:"labels" validate:"omitempty,dive"`
-		Locations   []*envs.LocationHierarchy `json:"locations"`
-		Resthooks   []*types.Resthook         `json:"resthooks" validate:"omitempty,dive"`
-		Templates   []*types.Template         `json:"templates" validate:"omitempty,dive"`
-		Ticketers   []*types.Ticketer         `json:"ticketers" validate:"omitempty,dive"`
-		Users       []*types.User             `json:"users" validate:"omitempty,dive"`
+		Channels      []*types.Channel          `json:"channels" validate:"omitempty,dive"`
+		Classifiers   []*types.Classifier       `json:"classifiers" validate:"omitempty,dive"`
+		ExchangeRates []*types.ExchangeRate     `json:"exchange_rates" validate:"omitempty,dive"`
+		Fields        []*types.Field            `json:"fields" validate:"omitempty,dive"`
+		Flows         []*types.Flow             `json:"flows" validate:"omitempty,dive"`
+		Globals       []*types.Global           `json:"globals" validate:"omitempty,dive"`
+		Groups        []*types.Group            `json:"groups" validate:"omitempty,dive"`
+		Labels        []*types.Label            `json:"labels" validate:"omitempty,dive"`
+		Locations     []*envs.LocationHierarchy `json:"locations"`
+		Resthooks     []*types.Resthook         `json:"resthooks" validate:"omitempty,dive"`
+		Templates     []*types.Template         `json:"templates" validate:"omitempty,dive"`
+		Ticketers     []*types.Ticketer         `json:"ticketers" validate:"omitempty,dive"`
+		Users         []*types.User             `json:"users" validate:"omitempty,dive"`
 	}
 }
 
@@ -74,6 +75,15 @@ func (s *StaticSource) Classifiers() ([]assets.Classifier, error) {
 	return set, nil
 }
 
+// ExchangeRates returns all exchange rate assets
+func (s *StaticSource) ExchangeRates() ([]assets.ExchangeRate, error) {
+	set := make([]assets.ExchangeRate, len(s.s.ExchangeRates))
+	for i := range s.s.ExchangeRates {
+		set[i] = s.s.ExchangeRates[i]
+	}
+	return set, nil
+}
+
 // Fields returns all field assets
 func (s *StaticSource) Fields() ([]assets.Field, error) {
 	set := make([]assets.Field, len(s.s.Fields))
@@ -4,19 +4,26 @@ import (
 	"github.com/nyaruka/gocommon/urns"
 	"github.com/nyaruka/goflow/assets"
 	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/shopspring/decimal"
 )
 
 // Channel is a JSON serializable implementation of a channel asset
 type Channel struct {
-	UUID_               assets.ChannelUUID       `json:"uuid" validate:"required,uuid"`
-	Name_               string                   `json:"name"`
-	Address_            string                   `json:"address"`
-	Schemes_            []string                 `json:"schemes" validate:"min=1"`
-	Roles_              []assets.ChannelRole     `json:"roles" validate:"min=1,dive,eq=send|eq=receive|eq=call|eq=answer|eq=ussd"`
-	Parent_             *assets.ChannelReference `json:"parent" validate:"omitempty,dive"`
-	Country_            envs.Country             `json:"country,omitempty"`
-	MatchPrefixes_      []string                 `json:"match_prefixes,omitempty"`
-	AllowInternational_ bool                     `json:"allow_international,omitempty"`
+	UUID_               assets.ChannelUUID         `json:"uuid" validate:"required,uuid"`
+	Name_               string                     `json:"name"`
+	Address_            string                     `json:"address"`
+	Schemes_            []string                   `json:"schemes" validate:"min=1"`
+	Roles_              []assets.ChannelRole       `json:"roles" validate:"min=1,dive,eq=send|eq=receive|eq=call|eq=answer|eq=ussd"`
+	Parent_             *assets.ChannelReference   `json:"parent" validate:"omitempty,dive"`
+	Country_            envs.Country               `json:"country,omitempty"`
+	MatchPrefixes_      []string                   `json:"match_prefixes,omitempty"`
+	AllowInternational_ bool                       `json:"allow_international,omitempty"`
+	Weight_             int                        `json:"weight,omitempty" validate:"omitempty,min=1"`
+	Costs_              map[string]decimal.Decimal `json:"costs,omitempty"`
+	MaxLength_          int                        `json:"max_length,omitempty" validate:"omitempty,min=1"`
+	Encoding_           utils.SMSEncoding          `json:"encoding,omitempty" validate:"omitempty,eq=gsm7|eq=ucs2"`
 }
 
 // NewChannel creates a new channel
@@ -73,3 +80,20 @@ func (c *Channel) MatchPrefixes() []string { return c.MatchPrefixes_ }
 
 // AllowInternational returns whether this channel allows sending internationally (only applies to TEL schemes)
 func (c *Channel) AllowInternational() bool { return c.AllowInternational_ }
+
+// Weight returns this channel's weight when balancing sends across channels, which defaults to 1
+func (c *Channel) Weight() int {
+	if c.Weight_ > 0 {
+		return c.Weight_
+	}
+	return 1
+}
+
+// Costs returns this channel's costs of sending to numbers, keyed by number prefix (if any)
+func (c *Channel) Costs() map[string]decimal.Decimal { return c.Costs_ }
+
+// MaxLength returns the maximum length of messages sent on this channel (if any)
+func (c *Channel) MaxLength() int { return c.MaxLength_ }
+
+// Encoding returns the encoding of messages sent on this channel (if any)
+func (c *Channel) Encoding() utils.SMSEncoding { return c.Encoding_ }
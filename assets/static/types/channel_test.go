@@ -3,12 +3,15 @@ package types_test
 import (
 	"testing"
 
+	"github.com/nyaruka/gocommon/jsonx"
 	"github.com/nyaruka/goflow/assets"
 	"github.com/nyaruka/goflow/assets/static/types"
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/utils"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestChannel(t *testing.T) {
@@ -29,6 +32,10 @@ func TestChannel(t *testing.T) {
 	assert.Equal(t, envs.NilCountry, channel.Country())
 	assert.Nil(t, channel.MatchPrefixes())
 	assert.True(t, channel.AllowInternational())
+	assert.Equal(t, 1, channel.Weight())
+	assert.Nil(t, channel.Costs())
+	assert.Equal(t, 0, channel.MaxLength())
+	assert.Equal(t, utils.SMSEncoding(""), channel.Encoding())
 
 	// check that UUIDs aren't required to be valid UUID4s
 	assert.Nil(t, utils.Validate(channel))
@@ -47,4 +54,23 @@ func TestChannel(t *testing.T) {
 	assert.Equal(t, envs.Country("RW"), channel.Country())
 	assert.Equal(t, []string{"+25079"}, channel.MatchPrefixes())
 	assert.False(t, channel.AllowInternational())
+
+	channel = &types.Channel{}
+	err := jsonx.Unmarshal([]byte(`{
+		"uuid": "ffffffff-9b24-92e1-ffff-ffffb207cdb4",
+		"name": "Android",
+		"address": "+250788000001",
+		"schemes": ["tel"],
+		"roles": ["send"],
+		"weight": 3,
+		"costs": {"250": 0.05, "25078": 0.02},
+		"max_length": 640,
+		"encoding": "gsm7"
+	}`), channel)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, channel.Weight())
+	assert.Equal(t, 640, channel.MaxLength())
+	assert.Equal(t, utils.SMSEncodingGSM7, channel.Encoding())
+	assert.Equal(t, map[string]decimal.Decimal{"250": decimal.RequireFromString("0.05"), "25078": decimal.RequireFromString("0.02")}, channel.Costs())
 }
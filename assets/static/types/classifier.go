@@ -1,15 +1,18 @@
 package types
 
 import (
+	"encoding/json"
+
 	"github.com/nyaruka/goflow/assets"
 )
 
 // Classifier is a JSON serializable implementation of a classifier asset
 type Classifier struct {
-	UUID_    assets.ClassifierUUID `json:"uuid" validate:"required,uuid"`
-	Name_    string                `json:"name"`
-	Type_    string                `json:"type"`
-	Intents_ []string              `json:"intents"`
+	UUID_     assets.ClassifierUUID `json:"uuid" validate:"required,uuid"`
+	Name_     string                `json:"name"`
+	Type_     string                `json:"type"`
+	Intents_  []string              `json:"intents"`
+	Training_ json.RawMessage       `json:"training,omitempty"`
 }
 
 // NewClassifier creates a new classifier
@@ -22,6 +25,17 @@ func NewClassifier(uuid assets.ClassifierUUID, name string, type_ string, intent
 	}
 }
 
+// NewTrainedClassifier creates a new classifier which includes its own training data
+func NewTrainedClassifier(uuid assets.ClassifierUUID, name string, type_ string, intents []string, training json.RawMessage) assets.TrainedClassifier {
+	return &Classifier{
+		UUID_:     uuid,
+		Name_:     name,
+		Type_:     type_,
+		Intents_:  intents,
+		Training_: training,
+	}
+}
+
 // UUID returns the UUID of this channel
 func (c *Classifier) UUID() assets.ClassifierUUID { return c.UUID_ }
 
@@ -33,3 +47,6 @@ func (c *Classifier) Type() string { return c.Type_ }
 
 // Intents returns the intents of this classifier
 func (c *Classifier) Intents() []string { return c.Intents_ }
+
+// Training returns the training data of this classifier (if any)
+func (c *Classifier) Training() json.RawMessage { return c.Training_ }
@@ -20,4 +20,18 @@ func TestClassifier(t *testing.T) {
 	assert.Equal(t, "Booking", classifier.Name())
 	assert.Equal(t, "wit", classifier.Type())
 	assert.Equal(t, []string{"book_flight", "book_hotel"}, classifier.Intents())
+	assert.Nil(t, classifier.(assets.TrainedClassifier).Training())
+}
+
+func TestTrainedClassifier(t *testing.T) {
+	classifier := types.NewTrainedClassifier(
+		assets.ClassifierUUID("1c06c884-39dd-4ce4-ad9f-9a01cbe6c000"),
+		"Offline",
+		"rules",
+		[]string{"yes", "no"},
+		[]byte(`{"intents":[{"name":"yes","keywords":["yes"]}]}`),
+	)
+	assert.Equal(t, "rules", classifier.Type())
+	assert.Equal(t, []string{"yes", "no"}, classifier.Intents())
+	assert.JSONEq(t, `{"intents":[{"name":"yes","keywords":["yes"]}]}`, string(classifier.Training()))
 }
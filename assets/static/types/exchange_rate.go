@@ -0,0 +1,33 @@
+package types
+
+import (
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/envs"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate is a JSON serializable implementation of an exchange rate asset
+type ExchangeRate struct {
+	From_ envs.Currency   `json:"from" validate:"required,currency"`
+	To_   envs.Currency   `json:"to" validate:"required,currency"`
+	Rate_ decimal.Decimal `json:"rate" validate:"required"`
+}
+
+// NewExchangeRate creates a new exchange rate
+func NewExchangeRate(from, to envs.Currency, rate decimal.Decimal) assets.ExchangeRate {
+	return &ExchangeRate{
+		From_: from,
+		To_:   to,
+		Rate_: rate,
+	}
+}
+
+// From returns the currency converted from
+func (r *ExchangeRate) From() envs.Currency { return r.From_ }
+
+// To returns the currency converted to
+func (r *ExchangeRate) To() envs.Currency { return r.To_ }
+
+// Rate returns the rate of conversion
+func (r *ExchangeRate) Rate() decimal.Decimal { return r.Rate_ }
@@ -0,0 +1,18 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/assets/static/types"
+	"github.com/nyaruka/goflow/envs"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRate(t *testing.T) {
+	rate := types.NewExchangeRate("USD", "RWF", decimal.RequireFromString("1020.5"))
+	assert.Equal(t, envs.Currency("USD"), rate.From())
+	assert.Equal(t, envs.Currency("RWF"), rate.To())
+	assert.Equal(t, decimal.RequireFromString("1020.5"), rate.Rate())
+}
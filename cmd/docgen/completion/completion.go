@@ -3,6 +3,7 @@ package completion
 import (
 	"fmt"
 
+	"github.com/nyaruka/gocommon/jsonx"
 	"github.com/pkg/errors"
 )
 
@@ -34,6 +35,39 @@ func NewCompletion(types []Type, root []*Property) *Completion {
 	return &Completion{Types: types, Root: root, RootNoSession: rootNoSession}
 }
 
+type completionEnvelope struct {
+	Types         []*typeEnvelope `json:"types"`
+	Root          []*Property     `json:"root"`
+	RootNoSession []*Property     `json:"root_no_session"`
+}
+
+type typeEnvelope struct {
+	Name             string      `json:"name"`
+	Properties       []*Property `json:"properties"`
+	KeySource        string      `json:"key_source"`
+	PropertyTemplate *Property   `json:"property_template"`
+}
+
+// UnmarshalJSON unmarshals a completion from JSON, e.g. the context of an editor support file
+func (c *Completion) UnmarshalJSON(data []byte) error {
+	e := &completionEnvelope{}
+	if err := jsonx.Unmarshal(data, e); err != nil {
+		return err
+	}
+
+	c.Types = make([]Type, len(e.Types))
+	for i, te := range e.Types {
+		if te.KeySource != "" {
+			c.Types[i] = NewDynamicType(te.Name, te.KeySource, te.PropertyTemplate)
+		} else {
+			c.Types[i] = NewStaticType(te.Name, te.Properties)
+		}
+	}
+	c.Root = e.Root
+	c.RootNoSession = e.RootNoSession
+	return nil
+}
+
 // Validate checks that all type references are valid
 func (c *Completion) Validate() error {
 	knownTypes := make(map[string]bool, len(c.Types))
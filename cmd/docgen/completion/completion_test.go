@@ -3,9 +3,11 @@ package completion_test
 import (
 	"testing"
 
+	"github.com/nyaruka/gocommon/jsonx"
 	"github.com/nyaruka/goflow/cmd/docgen/completion"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompletion(t *testing.T) {
@@ -48,3 +50,77 @@ func TestCompletion(t *testing.T) {
 		{Path: "contact.groups[0].name", Help: "the name of the group"},
 	}, nodes)
 }
+
+func TestPropertyLookups(t *testing.T) {
+	groupType := completion.NewStaticType("group", []*completion.Property{
+		completion.NewProperty("uuid", "the UUID of the group", "text"),
+		completion.NewProperty("name", "the name of the group", "text"),
+	})
+
+	fieldsType := completion.NewDynamicType("fields", "fields", completion.NewProperty("{key}", "the value of {key}", "any"))
+
+	contactType := completion.NewStaticType("contact", []*completion.Property{
+		completion.NewProperty("name", "the full name of the contact", "text"),
+		completion.NewProperty("fields", "the custom field values of the contact", "fields"),
+		completion.NewArrayProperty("groups", "the groups that the contact belongs to", "group"),
+	})
+
+	c := completion.NewCompletion(
+		[]completion.Type{groupType, fieldsType, contactType},
+		[]*completion.Property{
+			completion.NewProperty("contact", "the run contact", "contact"),
+		},
+	)
+
+	withFields := completion.NewContext(map[string][]string{"fields": {"age", "gender"}})
+	withoutFields := completion.NewContext(nil)
+
+	assert.Equal(t, "the run contact", c.PropertyAt(withFields, []string{"contact"}).Help)
+	assert.Equal(t, "the full name of the contact", c.PropertyAt(withFields, []string{"Contact", "NAME"}).Help)
+	assert.Equal(t, "the name of the group", c.PropertyAt(withFields, []string{"contact", "groups", "0", "name"}).Help)
+	assert.Equal(t, "the value of age", c.PropertyAt(withFields, []string{"contact", "fields", "age"}).Help)
+	assert.Equal(t, "any", c.PropertyAt(withFields, []string{"contact", "fields", "age", "foo"}).Type)
+	assert.Equal(t, "the value of height", c.PropertyAt(withoutFields, []string{"contact", "fields", "height"}).Help)
+	assert.Nil(t, c.PropertyAt(withFields, []string{"contact", "fields", "height"}))
+	assert.Nil(t, c.PropertyAt(withFields, []string{"contact", "groups", "name"}))
+	assert.Nil(t, c.PropertyAt(withFields, []string{"contact", "name", "first"}))
+	assert.Nil(t, c.PropertyAt(withFields, []string{"foo"}))
+
+	keys := func(props []*completion.Property) []string {
+		ks := make([]string, len(props))
+		for i := range props {
+			ks[i] = props[i].Key
+		}
+		return ks
+	}
+
+	assert.Equal(t, []string{"contact"}, keys(c.PropertiesAt(withFields, nil)))
+	assert.Equal(t, []string{"name", "fields", "groups"}, keys(c.PropertiesAt(withFields, []string{"contact"})))
+	assert.Equal(t, []string{"age", "gender"}, keys(c.PropertiesAt(withFields, []string{"contact", "fields"})))
+	assert.Equal(t, []string{"uuid", "name"}, keys(c.PropertiesAt(withFields, []string{"contact", "groups", "0"})))
+	assert.Nil(t, c.PropertiesAt(withFields, []string{"contact", "groups"}))
+	assert.Nil(t, c.PropertiesAt(withFields, []string{"foo"}))
+}
+
+func TestCompletionJSON(t *testing.T) {
+	c := completion.NewCompletion(
+		[]completion.Type{
+			completion.NewStaticType("contact", []*completion.Property{
+				completion.NewProperty("name", "the full name of the contact", "text"),
+				completion.NewProperty("fields", "the custom field values of the contact", "fields"),
+			}),
+			completion.NewDynamicType("fields", "fields", completion.NewProperty("{key}", "the value of {key}", "any")),
+		},
+		[]*completion.Property{
+			completion.NewProperty("contact", "the run contact", "contact"),
+		},
+	)
+
+	marshaled, err := jsonx.Marshal(c)
+	require.NoError(t, err)
+
+	unmarshaled := &completion.Completion{}
+	require.NoError(t, jsonx.Unmarshal(marshaled, unmarshaled))
+
+	assert.Equal(t, c, unmarshaled)
+}
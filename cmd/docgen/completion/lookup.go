@@ -0,0 +1,105 @@
+package completion
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PropertiesAt returns the properties of the value at the given path, or of the root of the context if the path is
+// empty. Returns nil if the path doesn't exist.
+func (c *Completion) PropertiesAt(context *Context, path []string) []*Property {
+	if len(path) == 0 {
+		return c.Root
+	}
+
+	p := c.PropertyAt(context, path)
+	if p == nil || p.Array {
+		return nil
+	}
+
+	t := c.typeByName(p.Type)
+	if t == nil {
+		return nil
+	}
+
+	props := make([]*Property, 0)
+	for _, pp := range t.EnumerateProperties(context) {
+		if pp.Key != "__default__" {
+			props = append(props, pp)
+		}
+	}
+	return props
+}
+
+// PropertyAt returns the property at the given path in the context, or nil if no such property exists. Properties
+// of dynamic types whose keys aren't known in the given context, and properties of values of any type, are assumed
+// to exist.
+func (c *Completion) PropertyAt(context *Context, path []string) *Property {
+	var current *Property
+
+	for i, key := range path {
+		key = strings.ToLower(key)
+
+		if i == 0 {
+			current = findProperty(c.Root, key)
+		} else if current.Array {
+			// arrays can only be indexed into
+			if _, err := strconv.Atoi(key); err != nil {
+				return nil
+			}
+			current = &Property{Key: key, Help: current.Help, Type: current.Type}
+		} else if current.Type == "any" {
+			current = &Property{Key: key, Type: "any"}
+		} else {
+			current = c.childProperty(context, current, key)
+		}
+
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+func (c *Completion) childProperty(context *Context, parent *Property, key string) *Property {
+	t := c.typeByName(parent.Type)
+	if t == nil {
+		return nil
+	}
+
+	// if we don't know the keys of a dynamic type, any key is allowed
+	if dt, isDynamic := t.(*dynamicType); isDynamic && context.KeySources[dt.KeySource] == nil {
+		template := dt.PropertyTemplate
+		return &Property{
+			Key:  key,
+			Help: strings.Replace(template.Help, "{key}", key, -1),
+			Type: template.Type,
+		}
+	}
+
+	return findProperty(t.EnumerateProperties(context), key)
+}
+
+func (c *Completion) typeByName(name string) Type {
+	for _, t := range primitiveTypes {
+		if t.Name() == name {
+			return t
+		}
+	}
+	for _, t := range c.Types {
+		if t.Name() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func findProperty(props []*Property, key string) *Property {
+	for _, p := range props {
+		if strings.ToLower(p.Key) == key {
+			return p
+		}
+	}
+	return nil
+}
@@ -51,6 +51,9 @@ func TestGenerateDocs(t *testing.T) {
 
 	root := context["root"].([]interface{})
 	assert.Equal(t, 14, len(root))
+
+	flowSchema := readJSONOutput(t, outputDir, "en-us", "schemas", "flow.json").(map[string]interface{})
+	assert.Equal(t, "#/definitions/flow", flowSchema["$ref"])
 }
 
 func readJSONOutput(t *testing.T, file ...string) interface{} {
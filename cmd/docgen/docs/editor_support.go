@@ -18,21 +18,34 @@ func init() {
 	RegisterGenerator(&editorSupportGenerator{})
 }
 
-type functionExample struct {
+// FunctionExample is an example of a function call and its output
+type FunctionExample struct {
 	Template string `json:"template"`
 	Output   string `json:"output"`
 }
 
-type functionListing struct {
+// FunctionListing is the documentation of a function for editors
+type FunctionListing struct {
 	Signature string             `json:"signature"`
 	Summary   string             `json:"summary"`
 	Detail    string             `json:"detail"`
-	Examples  []*functionExample `json:"examples"`
+	Examples  []*FunctionExample `json:"examples"`
 }
 
-type editorSupport struct {
+// EditorSupport is the information editors need to provide completion for expressions
+type EditorSupport struct {
 	Context   *completion.Completion `json:"context"`
-	Functions []*functionListing     `json:"functions"`
+	Functions []*FunctionListing     `json:"functions"`
+}
+
+// NewEditorSupport creates editor support information from the given tagged items
+func NewEditorSupport(items map[string][]*TaggedItem, gettext func(string) string) (*EditorSupport, error) {
+	context, err := buildContextCompletion(items, gettext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EditorSupport{Context: context, Functions: buildFunctionListing(items, gettext)}, nil
 }
 
 type editorSupportGenerator struct{}
@@ -42,16 +55,11 @@ func (g *editorSupportGenerator) Name() string {
 }
 
 func (g *editorSupportGenerator) Generate(baseDir, outputDir string, items map[string][]*TaggedItem, gettext func(string) string) error {
-	es := &editorSupport{}
-	var err error
-
-	es.Context, err = g.buildContextCompletion(items, gettext)
+	es, err := NewEditorSupport(items, gettext)
 	if err != nil {
 		return err
 	}
 
-	es.Functions = g.buildFunctionListing(items, gettext)
-
 	outputPath := path.Join(outputDir, "editor.json")
 	marshaled, err := jsonx.MarshalPretty(es)
 	if err != nil {
@@ -70,7 +78,7 @@ func (g *editorSupportGenerator) Generate(baseDir, outputDir string, items map[s
 	return nil
 }
 
-func (g *editorSupportGenerator) buildContextCompletion(items map[string][]*TaggedItem, gettext func(string) string) (*completion.Completion, error) {
+func buildContextCompletion(items map[string][]*TaggedItem, gettext func(string) string) (*completion.Completion, error) {
 	types := []completion.Type{
 		// the dynamic types in the context aren't described in the code so we add them manually here
 		completion.NewDynamicType("fields", "fields", completion.NewProperty("{key}", gettext("{key} for the contact"), "any")),
@@ -112,21 +120,21 @@ func (g *editorSupportGenerator) buildContextCompletion(items map[string][]*Tagg
 	return c, nil
 }
 
-func (g *editorSupportGenerator) buildFunctionListing(items map[string][]*TaggedItem, gettext func(string) string) []*functionListing {
+func buildFunctionListing(items map[string][]*TaggedItem, gettext func(string) string) []*FunctionListing {
 	funcItems := items["function"]
-	listings := make([]*functionListing, len(funcItems))
+	listings := make([]*FunctionListing, len(funcItems))
 
 	for i, funcItem := range funcItems {
 		summary := funcItem.description[0]
 		detail := strings.TrimSpace(strings.Join(funcItem.description[1:len(funcItem.description)-1], "\n"))
 
-		examples := make([]*functionExample, len(funcItem.examples))
+		examples := make([]*FunctionExample, len(funcItem.examples))
 		for j := range funcItem.examples {
 			parts := strings.Split(funcItem.examples[j], "→")
-			examples[j] = &functionExample{Template: strings.TrimSpace(parts[0]), Output: strings.TrimSpace(parts[1])}
+			examples[j] = &FunctionExample{Template: strings.TrimSpace(parts[0]), Output: strings.TrimSpace(parts[1])}
 		}
 
-		listings[i] = &functionListing{
+		listings[i] = &FunctionListing{
 			Signature: funcItem.tagValue + funcItem.tagExtra,
 			Summary:   gettext(summary),
 			Detail:    gettext(detail),
@@ -0,0 +1,142 @@
+package docs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/flows/actions"
+	"github.com/nyaruka/goflow/flows/definition"
+	"github.com/nyaruka/goflow/flows/engine"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/flows/modifiers"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/flows/routers"
+	"github.com/nyaruka/goflow/flows/routers/cases"
+	"github.com/nyaruka/goflow/flows/routers/waits"
+	"github.com/nyaruka/goflow/flows/routers/waits/hints"
+	"github.com/nyaruka/goflow/flows/runs"
+	"github.com/nyaruka/goflow/flows/triggers"
+	"github.com/nyaruka/goflow/utils/jsonschema"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterGenerator(&schemasGenerator{})
+}
+
+// the definitions which we create schema documents for
+var schemaRoots = []string{"flow", "session", "trigger", "resume", "event", "modifier"}
+
+type schemasGenerator struct{}
+
+func (g *schemasGenerator) Name() string {
+	return "JSON schemas"
+}
+
+func (g *schemasGenerator) Generate(baseDir, outputDir string, items map[string][]*TaggedItem, gettext func(string) string) error {
+	schemasDir := path.Join(outputDir, "schemas")
+	if err := os.MkdirAll(schemasDir, 0755); err != nil {
+		return errors.Wrap(err, "error creating schemas directory")
+	}
+
+	schemas := BuildSchemas()
+
+	for _, name := range schemaRoots {
+		marshaled, err := jsonx.MarshalPretty(schemas[name])
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path.Join(schemasDir, name+".json"), marshaled, 0755); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf(" > %d JSON schemas written to %s\n", len(schemas), schemasDir)
+	return nil
+}
+
+// BuildSchemas builds JSON schema documents for flow definitions, sessions, triggers, resumes, events and modifiers,
+// keyed by the name of their root definition
+func BuildSchemas() map[string]*jsonschema.Schema {
+	r := jsonschema.NewReflector()
+
+	actionTypes := make(map[string]interface{})
+	for name, fn := range actions.RegisteredTypes() {
+		actionTypes[name] = fn()
+	}
+	eventTypes := make(map[string]interface{})
+	for name, fn := range events.RegisteredTypes() {
+		eventTypes[name] = fn()
+	}
+	hintTypes := make(map[string]interface{})
+	for name, fn := range hints.RegisteredTypes() {
+		hintTypes[name] = fn()
+	}
+
+	defineUnion(r, "action", actionTypes)
+	defineUnion(r, "router", routers.RegisteredEnvelopes())
+	defineUnion(r, "wait", waits.RegisteredEnvelopes())
+	defineUnion(r, "hint", hintTypes)
+	defineUnion(r, "trigger", triggers.RegisteredEnvelopes())
+	defineUnion(r, "resume", resumes.RegisteredEnvelopes())
+	defineUnion(r, "event", eventTypes)
+	defineUnion(r, "modifier", modifiers.RegisteredEnvelopes)
+
+	defineAll(r, definition.SchemaEnvelopes())
+	defineAll(r, routers.SchemaEnvelopes())
+	defineAll(r, runs.SchemaEnvelopes())
+	defineAll(r, engine.SchemaEnvelopes())
+
+	// cases are a single type whose type property can be the name of any router test
+	testNames := make([]string, 0, len(cases.XTESTS))
+	for name := range cases.XTESTS {
+		testNames = append(testNames, name)
+	}
+	sort.Strings(testNames)
+
+	caseType := r.Definitions()["case"].Properties["type"]
+	for _, name := range testNames {
+		caseType.Enum = append(caseType.Enum, name)
+	}
+
+	schemas := make(map[string]*jsonschema.Schema, len(schemaRoots))
+	for _, root := range schemaRoots {
+		schemas[root] = jsonschema.NewDocument(r.Definitions(), root)
+	}
+	return schemas
+}
+
+// defines each of the given types, and a union definition of them all which uses the type property to tell them apart
+func defineUnion(r *jsonschema.Reflector, name string, types map[string]interface{}) {
+	members := make([]string, 0, len(types))
+
+	for _, typeName := range sortedKeys(types) {
+		member := name + "." + typeName
+		def := r.Define(member, types[typeName])
+		def.Properties["type"] = &jsonschema.Schema{Type: jsonschema.Types{"string"}, Const: typeName}
+
+		members = append(members, member)
+	}
+
+	r.DefineUnion(name, members)
+}
+
+func defineAll(r *jsonschema.Reflector, types map[string]interface{}) {
+	for name, v := range types {
+		r.Define(name, v)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
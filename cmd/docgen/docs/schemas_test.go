@@ -0,0 +1,153 @@
+package docs_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/cmd/docgen/docs"
+	"github.com/nyaruka/goflow/flows/actions"
+	"github.com/nyaruka/goflow/flows/definition/migrations"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/utils/jsonschema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSchemas(t *testing.T) {
+	schemas := docs.BuildSchemas()
+
+	assert.Equal(t, 6, len(schemas))
+
+	flowSchema := schemas["flow"]
+	assert.Equal(t, jsonschema.Draft, flowSchema.Schema)
+	assert.Equal(t, "#/definitions/flow", flowSchema.Ref)
+
+	// every type of action should be a member of the action union
+	assert.Equal(t, len(actions.RegisteredTypes()), len(flowSchema.Definitions["action"].OneOf))
+	for typeName := range actions.RegisteredTypes() {
+		assert.Contains(t, flowSchema.Definitions, "action."+typeName)
+	}
+	for typeName := range events.RegisteredTypes() {
+		assert.Contains(t, schemas["event"].Definitions, "event."+typeName)
+	}
+
+	// and their properties should be derived from their struct tags
+	sendMsg := flowSchema.Definitions["action.send_msg"]
+	assert.Equal(t, "send_msg", sendMsg.Properties["type"].Const)
+	assert.Equal(t, "uuid", sendMsg.Properties["uuid"].Format)
+	assert.Contains(t, sendMsg.Required, "text")
+
+	assert.Contains(t, flowSchema.Definitions["case"].Properties["type"].Enum, "has_any_word")
+
+	// flow schema shouldn't include definitions only used by sessions
+	assert.NotContains(t, flowSchema.Definitions, "session")
+	assert.NotContains(t, flowSchema.Definitions, "event")
+	assert.Contains(t, schemas["session"].Definitions, "event")
+
+	// check invalid flows are caught
+	assert.EqualError(t, flowSchema.Validate([]byte(`{
+		"uuid": "8ca44c09-791d-453a-9799-a70dd3303306",
+		"name": "Test",
+		"spec_version": "13.1.0",
+		"language": "eng",
+		"type": "messaging",
+		"nodes": [{"uuid": "a58be63b-907d-4a1a-856b-0bb5579d7507", "exits": []}]
+	}`)), "/nodes/0/exits: must have a minimum of 1 items")
+}
+
+func TestSchemasMatchTestdata(t *testing.T) {
+	schemas := docs.BuildSchemas()
+
+	// the schemas for component types are the definitions in the document which includes them
+	for _, name := range []string{"action", "router"} {
+		schemas[name] = &jsonschema.Schema{Ref: "#/definitions/" + name, Definitions: schemas["flow"].Definitions}
+	}
+
+	validate := func(root string, data json.RawMessage, source string) {
+		if len(data) == 0 {
+			return
+		}
+		assert.NoError(t, schemas[root].Validate(data), "%s in %s doesn't match schema", root, source)
+	}
+
+	// flows and sessions in the runner tests
+	runnerFiles, err := filepath.Glob("../../../test/testdata/runner/*.json")
+	require.NoError(t, err)
+
+	for _, path := range runnerFiles {
+		if strings.Contains(path, ".test") {
+			test := &struct {
+				Trigger json.RawMessage   `json:"trigger"`
+				Resumes []json.RawMessage `json:"resumes"`
+				Outputs []struct {
+					Session json.RawMessage   `json:"session"`
+					Events  []json.RawMessage `json:"events"`
+				} `json:"outputs"`
+			}{}
+			readJSONFile(t, path, test)
+
+			validate("trigger", test.Trigger, path)
+			for _, resume := range test.Resumes {
+				validate("resume", resume, path)
+			}
+			for _, output := range test.Outputs {
+				validate("session", output.Session, path)
+				for _, event := range output.Events {
+					validate("event", event, path)
+				}
+			}
+		} else {
+			assets := &struct {
+				Flows []json.RawMessage `json:"flows"`
+			}{}
+			readJSONFile(t, path, assets)
+
+			for _, flow := range assets.Flows {
+				migrated, err := migrations.MigrateToLatest(flow, &migrations.Config{BaseMediaURL: "http://temba.io"})
+				require.NoError(t, err)
+
+				validate("flow", migrated, path)
+			}
+		}
+	}
+
+	// and the individual types in the test cases for each package
+	for pkg, root := range map[string]string{"actions": "action", "modifiers": "modifier", "resumes": "resume", "routers": "router", "triggers": "trigger"} {
+		testFiles, err := filepath.Glob("../../../flows/" + pkg + "/testdata/*.json")
+		require.NoError(t, err)
+
+		for _, path := range testFiles {
+			if strings.HasPrefix(filepath.Base(path), "_") {
+				continue
+			}
+
+			var cases []map[string]json.RawMessage
+			readJSONFile(t, path, &cases)
+
+			for _, tc := range cases {
+				if _, hasError := tc["read_error"]; !hasError {
+					validate(root, tc[root], path)
+				}
+
+				var events []json.RawMessage
+				if tc["events"] != nil {
+					require.NoError(t, jsonx.Unmarshal(tc["events"], &events))
+				}
+				for _, event := range events {
+					validate("event", event, path)
+				}
+			}
+		}
+	}
+}
+
+func readJSONFile(t *testing.T, path string, v interface{}) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, jsonx.Unmarshal(data, v), "unable to read %s", path)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/goflow/cmd/docgen/completion"
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/tools"
+	"github.com/nyaruka/goflow/flows"
+)
+
+// a token in a template with its byte offsets
+type token struct {
+	tokenType excellent.XTokenType
+	value     string
+	start     int
+	end       int
+}
+
+// scans the given template into tokens, keeping track of where each token is in the original text
+func scanTemplate(text string) []*token {
+	scanner := excellent.NewXScanner(strings.NewReader(text), flows.RunContextTopLevels)
+	scanner.SetUnescapeBody(false)
+
+	tokens := make([]*token, 0)
+	offset := 0
+
+	for tokenType, value := scanner.Scan(); tokenType != excellent.EOF; tokenType, value = scanner.Scan() {
+		length := len(value)
+
+		switch tokenType {
+		case excellent.IDENTIFIER:
+			length++ // @
+		case excellent.EXPRESSION:
+			length += 3 // @( and )
+		}
+
+		tokens = append(tokens, &token{tokenType: tokenType, value: value, start: offset, end: offset + length})
+		offset += length
+	}
+
+	return tokens
+}
+
+// what's before the cursor if it's somewhere we can complete
+type cursorContext struct {
+	inExpression bool   // in @(...) rather than an @identifier
+	before       string // the expression text before the cursor
+}
+
+// gets the context of the cursor at the given offset, or nil if it isn't in an expression or identifier
+func cursorContextAt(text string, offset int) *cursorContext {
+	for _, t := range scanTemplate(text) {
+		if offset <= t.start || offset > t.end {
+			continue
+		}
+
+		switch t.tokenType {
+		case excellent.IDENTIFIER:
+			return &cursorContext{before: text[t.start+1 : offset]}
+		case excellent.EXPRESSION:
+			if offset < t.end {
+				return &cursorContext{inExpression: true, before: text[t.start+2 : offset]}
+			}
+		case excellent.BODY:
+			// an unterminated expression is scanned as body text
+			if strings.HasPrefix(t.value, "@(") && offset >= t.start+2 {
+				return &cursorContext{inExpression: true, before: text[t.start+2 : offset]}
+			}
+
+			// as is an identifier which isn't complete or doesn't have a valid top-level
+			body := text[t.start:offset]
+			path := trailingPath(body)
+			if strings.HasSuffix(body[:len(body)-len(path)], "@") && !strings.HasSuffix(body[:len(body)-len(path)], "@@") {
+				return &cursorContext{before: path}
+			}
+		}
+	}
+	return nil
+}
+
+// whether the given expression text ends inside a string literal
+func (c *cursorContext) inString() bool {
+	inString, escaped := false, false
+	for _, ch := range c.before {
+		if inString && ch == '\\' {
+			escaped = !escaped
+			continue
+		}
+		if ch == '"' && !escaped {
+			inString = !inString
+		}
+		escaped = false
+	}
+	return inString
+}
+
+// finds the innermost function call that the cursor is inside of, returning its name and the index of the argument
+// that the cursor is in
+func (c *cursorContext) currentCall() (string, int) {
+	type call struct {
+		name string
+		arg  int
+	}
+	stack := make([]*call, 0)
+	inString, escaped := false, false
+
+	for i, ch := range c.before {
+		if inString {
+			if ch == '\\' {
+				escaped = !escaped
+				continue
+			}
+			if ch == '"' && !escaped {
+				inString = false
+			}
+			escaped = false
+			continue
+		}
+
+		switch ch {
+		case '"':
+			inString = true
+		case '(':
+			name := trailingPath(strings.TrimRightFunc(c.before[:i], unicode.IsSpace))
+			stack = append(stack, &call{name: name})
+		case ')':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			if len(stack) > 0 {
+				stack[len(stack)-1].arg++
+			}
+		}
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].name != "" && !strings.Contains(stack[i].name, ".") {
+			return strings.ToLower(stack[i].name), stack[i].arg
+		}
+	}
+	return "", 0
+}
+
+// gets the trailing part of the given text which looks like a context path, e.g. "contact.na" in "upper(contact.na"
+func trailingPath(s string) string {
+	i := len(s)
+	for i > 0 {
+		ch := rune(s[i-1])
+		if s[i-1] >= 0x80 || !(isNameChar(ch) || ch == '.') {
+			break
+		}
+		i--
+	}
+	return s[i:]
+}
+
+// finds the context path or function name at the given offset, returning it as a path and its byte offsets. The
+// path ends with the segment that includes the offset, e.g. "contact" if offset is in "contact" of "contact.name".
+func pathAt(text string, offset int) ([]string, int, int) {
+	start, end := offset, offset
+	for start > 0 && (isNameChar(rune(text[start-1])) || text[start-1] == '.') {
+		start--
+	}
+	for end < len(text) && isNameChar(rune(text[end])) {
+		end++
+	}
+	if start == end {
+		return nil, 0, 0
+	}
+
+	path := strings.Split(strings.Trim(text[start:end], "."), ".")
+	for _, part := range path {
+		if part == "" {
+			return nil, 0, 0
+		}
+	}
+	return path, start, end
+}
+
+func isNameChar(ch rune) bool {
+	return ch < 0x80 && (unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_')
+}
+
+// finds the problems in the given template
+func diagnose(text string, c *completion.Completion, context *completion.Context) []*Diagnostic {
+	diagnostics := make([]*Diagnostic, 0)
+
+	add := func(t *token, severity int, message string) {
+		diagnostics = append(diagnostics, &Diagnostic{
+			Range:    Range{Start: offsetToPosition(text, t.start), End: offsetToPosition(text, t.end)},
+			Severity: severity,
+			Source:   serverName,
+			Message:  message,
+		})
+	}
+
+	for _, t := range scanTemplate(text) {
+		if t.tokenType == excellent.BODY {
+			if strings.HasPrefix(t.value, "@(") {
+				add(t, severityError, "expression is missing a closing parenthesis")
+			}
+			continue
+		}
+
+		unknown := make([]string, 0)
+
+		err := tools.FindContextRefsInTemplate(text[t.start:t.end], flows.RunContextTopLevels, func(path []string) {
+			// only report the first part of a path which doesn't exist
+			if c.PropertyAt(context, path) == nil && (len(path) == 1 || c.PropertyAt(context, path[:len(path)-1]) != nil) {
+				unknown = append(unknown, strings.Join(path, "."))
+			}
+		})
+
+		if err != nil {
+			add(t, severityError, err.Error())
+			continue
+		}
+
+		for _, ref := range unknown {
+			add(t, severityWarning, fmt.Sprintf("unknown context reference '%s'", ref))
+		}
+	}
+
+	return diagnostics
+}
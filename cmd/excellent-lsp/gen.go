@@ -0,0 +1,35 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// generates the editor support file which is embedded in the server, run via go generate
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/cmd/docgen/docs"
+)
+
+func main() {
+	items, err := docs.FindAllTaggedItems("../../")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	support, err := docs.NewEditorSupport(items, func(s string) string { return s })
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	marshaled, err := jsonx.MarshalPretty(support)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("editor.json", marshaled, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
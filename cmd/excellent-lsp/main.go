@@ -0,0 +1,62 @@
+package main
+
+// go install github.com/nyaruka/goflow/cmd/excellent-lsp; excellent-lsp
+//
+// Editors should launch this as a language server over stdio. Keys of dynamic context types like fields and results
+// can be provided as initialization options, e.g. {"keySources": {"fields": ["age", "gender"]}}
+//
+// Functions and context types are read from editor.json which is embedded in the binary. This is regenerated from the
+// docstrings with go generate, or they can be read from a source checkout at runtime with -src /path/to/goflow.
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/cmd/docgen/docs"
+)
+
+//go:generate go run gen.go
+
+//go:embed editor.json
+var editorJSON []byte
+
+func main() {
+	var srcDir string
+	flag.StringVar(&srcDir, "src", "", "the goflow source directory to read docstrings from instead of the embedded editor support file")
+	flag.Parse()
+
+	if err := run(srcDir); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(srcDir string) error {
+	support, err := loadEditorSupport(srcDir)
+	if err != nil {
+		return err
+	}
+
+	return NewServer(support).Serve(os.Stdin, os.Stdout)
+}
+
+// loads editor support from the docstrings in the given source directory, or the embedded file if that's empty
+func loadEditorSupport(srcDir string) (*docs.EditorSupport, error) {
+	if srcDir == "" {
+		support := &docs.EditorSupport{}
+		if err := jsonx.Unmarshal(editorJSON, support); err != nil {
+			return nil, err
+		}
+		return support, nil
+	}
+
+	items, err := docs.FindAllTaggedItems(srcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return docs.NewEditorSupport(items, func(s string) string { return s })
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// message is a JSON-RPC 2.0 request, response or notification
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// reads a single message with its Content-Length header from the given reader
+func readMessage(r *bufio.Reader) (*message, error) {
+	contentLength := -1
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "Content-Length") {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, errors.Errorf("invalid content length: %s", parts[1])
+			}
+		}
+	}
+
+	if contentLength < 0 {
+		return nil, errors.New("message has no content length header")
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return msg, nil
+}
+
+// writes a single message with its Content-Length header to the given writer
+func writeMessage(w io.Writer, msg *message) error {
+	msg.JSONRPC = "2.0"
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+//------------------------------------------------------------------------------------------
+// LSP types, see https://microsoft.github.io/language-server-protocol/specification
+//------------------------------------------------------------------------------------------
+
+// Position is a zero-based line and UTF-16 character offset in a document
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a document
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// TextDocumentIdentifier identifies a document
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// TextDocumentItem is a document that has been opened
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentPositionParams are the params of requests about a position in a document
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// InitializeParams are the params of an initialize request
+type InitializeParams struct {
+	InitializationOptions *InitializationOptions `json:"initializationOptions"`
+}
+
+// InitializationOptions are our server specific options, which are the keys of dynamic context types like fields
+// and results
+type InitializationOptions struct {
+	KeySources map[string][]string `json:"keySources"`
+}
+
+// DidOpenTextDocumentParams are the params of a textDocument/didOpen notification
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams are the params of a textDocument/didChange notification
+type DidChangeTextDocumentParams struct {
+	TextDocument   TextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// DidCloseTextDocumentParams are the params of a textDocument/didClose notification
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// completion item kinds
+const (
+	completionKindFunction = 3
+	completionKindProperty = 10
+)
+
+// CompletionItem is a single completion suggestion
+type CompletionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *MarkupContent `json:"documentation,omitempty"`
+}
+
+// CompletionList is the result of a completion request
+type CompletionList struct {
+	IsIncomplete bool              `json:"isIncomplete"`
+	Items        []*CompletionItem `json:"items"`
+}
+
+// MarkupContent is formatted documentation
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Hover is the result of a hover request
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// ParameterInformation is a parameter of a function signature
+type ParameterInformation struct {
+	Label string `json:"label"`
+}
+
+// SignatureInformation is a function signature
+type SignatureInformation struct {
+	Label         string                  `json:"label"`
+	Documentation *MarkupContent          `json:"documentation,omitempty"`
+	Parameters    []*ParameterInformation `json:"parameters"`
+}
+
+// SignatureHelp is the result of a signature help request
+type SignatureHelp struct {
+	Signatures      []*SignatureInformation `json:"signatures"`
+	ActiveSignature int                     `json:"activeSignature"`
+	ActiveParameter int                     `json:"activeParameter"`
+}
+
+// diagnostic severities
+const (
+	severityError   = 1
+	severityWarning = 2
+)
+
+// Diagnostic is a problem found in a document
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of a textDocument/publishDiagnostics notification
+type PublishDiagnosticsParams struct {
+	URI         string        `json:"uri"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+// converts a byte offset in the given text to an LSP position
+func offsetToPosition(text string, offset int) Position {
+	pos := Position{}
+	for i, r := range text {
+		if i >= offset {
+			break
+		}
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character += utf16Len(r)
+		}
+	}
+	return pos
+}
+
+// converts an LSP position to a byte offset in the given text
+func positionToOffset(text string, pos Position) int {
+	line, char := 0, 0
+	for i, r := range text {
+		if line == pos.Line && char >= pos.Character {
+			return i
+		}
+		if r == '\n' {
+			if line == pos.Line {
+				return i // position is beyond end of line
+			}
+			line++
+			char = 0
+		} else if line == pos.Line {
+			char += utf16Len(r)
+		}
+	}
+	return len(text)
+}
+
+// the number of UTF-16 code units needed to encode the given rune
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
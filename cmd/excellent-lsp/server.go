@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nyaruka/goflow/cmd/docgen/completion"
+	"github.com/nyaruka/goflow/cmd/docgen/docs"
+
+	"github.com/pkg/errors"
+)
+
+const serverName = "excellent-lsp"
+
+// a handler of a request or notification, which returns a result for requests
+type handlerFunc func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handlerFunc{
+	"initialize":                 handleInitialize,
+	"initialized":                handleNoop,
+	"shutdown":                   handleNoop,
+	"textDocument/didOpen":       handleDidOpen,
+	"textDocument/didChange":     handleDidChange,
+	"textDocument/didClose":      handleDidClose,
+	"textDocument/completion":    handleCompletion,
+	"textDocument/hover":         handleHover,
+	"textDocument/signatureHelp": handleSignatureHelp,
+}
+
+// Server is a language server for Excellent templates
+type Server struct {
+	completion *completion.Completion
+	functions  map[string]*docs.FunctionListing
+	context    *completion.Context
+	documents  map[string]string
+
+	out   io.Writer
+	outMu sync.Mutex
+}
+
+// NewServer creates a new server from the given editor support information
+func NewServer(support *docs.EditorSupport) *Server {
+	functions := make(map[string]*docs.FunctionListing, len(support.Functions))
+	for _, f := range support.Functions {
+		functions[functionName(f)] = f
+	}
+
+	return &Server{
+		completion: support.Context,
+		functions:  functions,
+		context:    completion.NewContext(nil),
+		documents:  make(map[string]string),
+	}
+}
+
+// Serve reads messages from the given reader and writes responses to the given writer until it receives an exit
+// notification or the reader is closed
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	reader := bufio.NewReader(in)
+
+	for {
+		msg, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		} else if rerr, isResponseError := err.(*responseError); isResponseError {
+			s.write(&message{ID: nil, Error: rerr})
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		s.handle(msg)
+	}
+}
+
+func (s *Server) handle(msg *message) {
+	handler := handlers[msg.Method]
+
+	// notifications don't get responses, even if they fail
+	if msg.ID == nil {
+		if handler != nil {
+			handler(s, msg.Params)
+		}
+		return
+	}
+
+	if handler == nil {
+		s.write(&message{ID: msg.ID, Error: &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}})
+		return
+	}
+
+	result, err := handler(s, msg.Params)
+	if err != nil {
+		s.write(&message{ID: msg.ID, Error: &responseError{Code: codeInvalidParams, Message: err.Error()}})
+		return
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		s.write(&message{ID: msg.ID, Error: &responseError{Code: codeInvalidParams, Message: err.Error()}})
+		return
+	}
+
+	s.write(&message{ID: msg.ID, Result: resultJSON})
+}
+
+func (s *Server) write(msg *message) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	writeMessage(s.out, msg)
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	paramsJSON, _ := json.Marshal(params)
+	s.write(&message{Method: method, Params: paramsJSON})
+}
+
+func (s *Server) publishDiagnostics(uri string) {
+	s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnose(s.documents[uri], s.completion, s.context),
+	})
+}
+
+// reads the params of a request, and the text and cursor offset of the document it refers to
+func (s *Server) readPositionParams(params json.RawMessage) (string, int, error) {
+	p := &TextDocumentPositionParams{}
+	if err := json.Unmarshal(params, p); err != nil {
+		return "", 0, err
+	}
+
+	text, exists := s.documents[p.TextDocument.URI]
+	if !exists {
+		return "", 0, errors.Errorf("no such document: %s", p.TextDocument.URI)
+	}
+
+	return text, positionToOffset(text, p.Position), nil
+}
+
+//------------------------------------------------------------------------------------------
+// Handlers
+//------------------------------------------------------------------------------------------
+
+func handleNoop(s *Server, params json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+func handleInitialize(s *Server, params json.RawMessage) (interface{}, error) {
+	p := &InitializeParams{}
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, err
+	}
+
+	if p.InitializationOptions != nil {
+		s.context = completion.NewContext(p.InitializationOptions.KeySources)
+	}
+
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":      1, // full document sync
+			"completionProvider":    map[string]interface{}{"triggerCharacters": []string{"@", ".", "("}},
+			"signatureHelpProvider": map[string]interface{}{"triggerCharacters": []string{"(", ","}},
+			"hoverProvider":         true,
+		},
+		"serverInfo": map[string]interface{}{"name": serverName},
+	}, nil
+}
+
+func handleDidOpen(s *Server, params json.RawMessage) (interface{}, error) {
+	p := &DidOpenTextDocumentParams{}
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, err
+	}
+
+	s.documents[p.TextDocument.URI] = p.TextDocument.Text
+	s.publishDiagnostics(p.TextDocument.URI)
+	return nil, nil
+}
+
+func handleDidChange(s *Server, params json.RawMessage) (interface{}, error) {
+	p := &DidChangeTextDocumentParams{}
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, err
+	}
+
+	// we only support full document sync so the last change is the new text
+	if len(p.ContentChanges) > 0 {
+		s.documents[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		s.publishDiagnostics(p.TextDocument.URI)
+	}
+	return nil, nil
+}
+
+func handleDidClose(s *Server, params json.RawMessage) (interface{}, error) {
+	p := &DidCloseTextDocumentParams{}
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, err
+	}
+
+	delete(s.documents, p.TextDocument.URI)
+	return nil, nil
+}
+
+func handleCompletion(s *Server, params json.RawMessage) (interface{}, error) {
+	text, offset, err := s.readPositionParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &CompletionList{Items: make([]*CompletionItem, 0)}
+
+	cursor := cursorContextAt(text, offset)
+	if cursor == nil || (cursor.inExpression && cursor.inString()) {
+		return list, nil
+	}
+
+	path := strings.Split(trailingPath(cursor.before), ".")
+	parent, prefix := path[:len(path)-1], strings.ToLower(path[len(path)-1])
+
+	for _, p := range s.completion.PropertiesAt(s.context, parent) {
+		if strings.HasPrefix(strings.ToLower(p.Key), prefix) {
+			list.Items = append(list.Items, &CompletionItem{
+				Label:         p.Key,
+				Kind:          completionKindProperty,
+				Detail:        propertyType(p),
+				Documentation: &MarkupContent{Kind: "markdown", Value: p.Help},
+			})
+		}
+	}
+
+	// functions can only be called from inside expressions
+	if cursor.inExpression && len(parent) == 0 {
+		for _, name := range s.functionNames() {
+			if strings.HasPrefix(name, prefix) {
+				f := s.functions[name]
+				list.Items = append(list.Items, &CompletionItem{
+					Label:         name,
+					Kind:          completionKindFunction,
+					Detail:        f.Signature,
+					Documentation: &MarkupContent{Kind: "markdown", Value: f.Summary},
+				})
+			}
+		}
+	}
+
+	return list, nil
+}
+
+func handleHover(s *Server, params json.RawMessage) (interface{}, error) {
+	text, offset, err := s.readPositionParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursorContextAt(text, offset) == nil {
+		return nil, nil
+	}
+
+	path, start, end := pathAt(text, offset)
+	if path == nil {
+		return nil, nil
+	}
+
+	hoverRange := &Range{Start: offsetToPosition(text, start), End: offsetToPosition(text, end)}
+
+	// a name followed by a parenthesis is a function call
+	if len(path) == 1 && strings.HasPrefix(strings.TrimLeft(text[end:], " \t"), "(") {
+		if f := s.functions[strings.ToLower(path[0])]; f != nil {
+			return &Hover{Contents: MarkupContent{Kind: "markdown", Value: functionDocs(f)}, Range: hoverRange}, nil
+		}
+		return nil, nil
+	}
+
+	p := s.completion.PropertyAt(s.context, path)
+	if p == nil {
+		return nil, nil
+	}
+
+	value := fmt.Sprintf("`%s` *%s*\n\n%s", strings.Join(path, "."), propertyType(p), p.Help)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: hoverRange}, nil
+}
+
+func handleSignatureHelp(s *Server, params json.RawMessage) (interface{}, error) {
+	text, offset, err := s.readPositionParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := cursorContextAt(text, offset)
+	if cursor == nil || !cursor.inExpression {
+		return nil, nil
+	}
+
+	name, arg := cursor.currentCall()
+	f := s.functions[name]
+	if f == nil {
+		return nil, nil
+	}
+
+	paramNames := functionParams(f)
+	parameters := make([]*ParameterInformation, len(paramNames))
+	for i := range paramNames {
+		parameters[i] = &ParameterInformation{Label: paramNames[i]}
+	}
+
+	// variadic functions keep the last parameter active
+	if arg >= len(paramNames) && len(paramNames) > 0 && strings.HasSuffix(paramNames[len(paramNames)-1], "...") {
+		arg = len(paramNames) - 1
+	}
+
+	return &SignatureHelp{
+		Signatures: []*SignatureInformation{
+			{
+				Label:         f.Signature,
+				Documentation: &MarkupContent{Kind: "markdown", Value: f.Summary},
+				Parameters:    parameters,
+			},
+		},
+		ActiveParameter: arg,
+	}, nil
+}
+
+func (s *Server) functionNames() []string {
+	names := make([]string, 0, len(s.functions))
+	for name := range s.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// gets the name of a function from its signature, e.g. "upper" from "upper(text)"
+func functionName(f *docs.FunctionListing) string {
+	return strings.SplitN(f.Signature, "(", 2)[0]
+}
+
+// gets the parameter names of a function from its signature, e.g. ["text", "delimiters"] from "split(text, [,delimiters])"
+func functionParams(f *docs.FunctionListing) []string {
+	params := make([]string, 0)
+
+	start, end := strings.Index(f.Signature, "("), strings.LastIndex(f.Signature, ")")
+	if start < 0 || end < start {
+		return params
+	}
+
+	inner := strings.NewReplacer("[", "", "]", "").Replace(f.Signature[start+1 : end])
+	for _, p := range strings.Split(inner, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+func functionDocs(f *docs.FunctionListing) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "```\n%s\n```\n\n%s", f.Signature, f.Summary)
+	if f.Detail != "" {
+		fmt.Fprintf(b, "\n\n%s", f.Detail)
+	}
+	if len(f.Examples) > 0 {
+		b.WriteString("\n\n```\n")
+		for _, e := range f.Examples {
+			fmt.Fprintf(b, "%s → %s\n", e.Template, e.Output)
+		}
+		b.WriteString("```")
+	}
+	return b.String()
+}
+
+func propertyType(p *completion.Property) string {
+	if p.Array {
+		return "[]" + p.Type
+	}
+	return p.Type
+}
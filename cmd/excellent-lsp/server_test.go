@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a client which talks to a server over in-memory pipes
+type testClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	nextID int
+	done   chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	support, err := loadEditorSupport("")
+	require.NoError(t, err)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	c := &testClient{t: t, in: inW, out: bufio.NewReader(outR), done: make(chan error, 1)}
+
+	go func() {
+		c.done <- NewServer(support).Serve(inR, outW)
+		outW.Close()
+	}()
+
+	return c
+}
+
+func (c *testClient) send(msg *message) {
+	require.NoError(c.t, writeMessage(c.in, msg))
+}
+
+func (c *testClient) read() *message {
+	msg, err := readMessage(c.out)
+	require.NoError(c.t, err)
+	return msg
+}
+
+// sends a request and returns the response
+func (c *testClient) request(method string, params interface{}, result interface{}) *responseError {
+	c.nextID++
+	id := json.RawMessage(mustMarshal(c.t, c.nextID))
+	c.send(&message{ID: &id, Method: method, Params: mustMarshal(c.t, params)})
+
+	resp := c.read()
+	require.NotNil(c.t, resp.ID)
+	assert.Equal(c.t, string(id), string(*resp.ID))
+
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil {
+		require.NoError(c.t, json.Unmarshal(resp.Result, result))
+	}
+	return nil
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.send(&message{Method: method, Params: mustMarshal(c.t, params)})
+}
+
+// opens a document and returns the diagnostics published for it
+func (c *testClient) open(uri, text string) []*Diagnostic {
+	c.notify("textDocument/didOpen", &DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, LanguageID: "excellent", Text: text}})
+
+	return c.readDiagnostics(uri)
+}
+
+func (c *testClient) readDiagnostics(uri string) []*Diagnostic {
+	msg := c.read()
+	require.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+
+	params := &PublishDiagnosticsParams{}
+	require.NoError(c.t, json.Unmarshal(msg.Params, params))
+	assert.Equal(c.t, uri, params.URI)
+	return params.Diagnostics
+}
+
+func (c *testClient) position(uri string, line, char int) *TextDocumentPositionParams {
+	return &TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: line, Character: char}}
+}
+
+func (c *testClient) close() {
+	c.notify("exit", nil)
+	assert.NoError(c.t, <-c.done)
+	c.in.Close()
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	j, err := json.Marshal(v)
+	require.NoError(t, err)
+	return j
+}
+
+func completionLabels(list *CompletionList) []string {
+	labels := make([]string, len(list.Items))
+	for i := range list.Items {
+		labels[i] = list.Items[i].Label
+	}
+	return labels
+}
+
+func TestEmbeddedEditorSupport(t *testing.T) {
+	embedded, err := loadEditorSupport("")
+	require.NoError(t, err)
+
+	fromSource, err := loadEditorSupport("../../")
+	require.NoError(t, err)
+
+	// if this fails, run go generate to update editor.json
+	assert.JSONEq(t, string(mustMarshal(t, fromSource)), string(mustMarshal(t, embedded)))
+}
+
+func TestServer(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+
+	initResult := map[string]interface{}{}
+	err := c.request("initialize", map[string]interface{}{
+		"initializationOptions": map[string]interface{}{
+			"keySources": map[string][]string{"fields": {"age", "gender"}, "results": {"favorite_color"}},
+		},
+	}, &initResult)
+	require.Nil(t, err)
+	assert.Equal(t, true, initResult["capabilities"].(map[string]interface{})["hoverProvider"])
+
+	c.notify("initialized", map[string]interface{}{})
+
+	// unknown methods are errors
+	err = c.request("textDocument/formatting", map[string]interface{}{}, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, codeMethodNotFound, err.Code)
+
+	// requests about documents that aren't open are errors
+	err = c.request("textDocument/hover", c.position("file:///none.txt", 0, 0), nil)
+	require.NotNil(t, err)
+	assert.Equal(t, codeInvalidParams, err.Code)
+
+	// a valid document has no diagnostics
+	diagnostics := c.open("file:///1.txt", "Hi @contact.name, you are @fields.age")
+	assert.Equal(t, 0, len(diagnostics))
+
+	// completion of context paths in identifiers
+	list := &CompletionList{}
+	require.Nil(t, c.request("textDocument/completion", c.position("file:///1.txt", 0, 15), list))
+	assert.Equal(t, []string{"name"}, completionLabels(list))
+
+	require.Nil(t, c.request("textDocument/completion", c.position("file:///1.txt", 0, 34), list))
+	assert.Equal(t, []string{"age", "gender"}, completionLabels(list))
+
+	// nothing to complete in body text
+	require.Nil(t, c.request("textDocument/completion", c.position("file:///1.txt", 0, 2), list))
+	assert.Equal(t, []string{}, completionLabels(list))
+
+	// hovering over a context path
+	hover := &Hover{}
+	require.Nil(t, c.request("textDocument/hover", c.position("file:///1.txt", 0, 14), hover))
+	assert.Equal(t, "`contact.name` *text*\n\nthe name of the contact", hover.Contents.Value)
+	assert.Equal(t, &Range{Start: Position{0, 4}, End: Position{0, 16}}, hover.Range)
+
+	// a document with problems
+	diagnostics = c.open("file:///2.txt", "@contact.nmae @fields.height\n@(1 +) @(upper(contact.name)")
+	require.Equal(t, 4, len(diagnostics))
+	assert.Equal(t, "unknown context reference 'contact.nmae'", diagnostics[0].Message)
+	assert.Equal(t, severityWarning, diagnostics[0].Severity)
+	assert.Equal(t, Range{Start: Position{0, 0}, End: Position{0, 13}}, diagnostics[0].Range)
+	assert.Equal(t, "unknown context reference 'fields.height'", diagnostics[1].Message)
+	assert.Equal(t, severityError, diagnostics[2].Severity)
+	assert.Equal(t, Range{Start: Position{1, 0}, End: Position{1, 6}}, diagnostics[2].Range)
+	assert.Equal(t, "expression is missing a closing parenthesis", diagnostics[3].Message)
+
+	// fixing the document clears them
+	c.notify("textDocument/didChange", &DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///2.txt"},
+		ContentChanges: []struct {
+			Text string `json:"text"`
+		}{{Text: "@(upper(contact.name) & \"x\")"}},
+	})
+	assert.Equal(t, 0, len(c.readDiagnostics("file:///2.txt")))
+
+	// completion of functions and top-level properties inside expressions
+	require.Nil(t, c.request("textDocument/completion", c.position("file:///2.txt", 0, 4), list))
+	assert.Contains(t, completionLabels(list), "upper")
+	assert.NotContains(t, completionLabels(list), "contact")
+
+	require.Nil(t, c.request("textDocument/completion", c.position("file:///2.txt", 0, 10), list))
+	assert.Contains(t, completionLabels(list), "contact")
+	assert.Contains(t, completionLabels(list), "count")
+
+	// nothing inside strings
+	require.Nil(t, c.request("textDocument/completion", c.position("file:///2.txt", 0, 26), list))
+	assert.Equal(t, []string{}, completionLabels(list))
+
+	// hovering over a function
+	require.Nil(t, c.request("textDocument/hover", c.position("file:///2.txt", 0, 3), hover))
+	assert.Contains(t, hover.Contents.Value, "upper(text)")
+
+	// signature help for the current function call
+	c.notify("textDocument/didChange", &DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///2.txt"},
+		ContentChanges: []struct {
+			Text string `json:"text"`
+		}{{Text: "@(replace(contact.name, \"a,b\", upper("}},
+	})
+	c.readDiagnostics("file:///2.txt")
+
+	help := &SignatureHelp{}
+	require.Nil(t, c.request("textDocument/signatureHelp", c.position("file:///2.txt", 0, 30), help))
+	require.Equal(t, 1, len(help.Signatures))
+	assert.Equal(t, "replace(text, needle, replacement [, count])", help.Signatures[0].Label)
+	assert.Equal(t, 4, len(help.Signatures[0].Parameters))
+	assert.Equal(t, 2, help.ActiveParameter)
+
+	require.Nil(t, c.request("textDocument/signatureHelp", c.position("file:///2.txt", 0, 37), help))
+	assert.Equal(t, "upper(text)", help.Signatures[0].Label)
+	assert.Equal(t, 0, help.ActiveParameter)
+
+	c.notify("textDocument/didClose", &DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///2.txt"}})
+
+	require.Nil(t, c.request("shutdown", nil, nil))
+}
@@ -1,22 +1,89 @@
 package main
 
 // go install github.com/nyaruka/goflow/cmd/exptester; exptester "@(lower(contact.name))"
+//
+// add -trace to print a trace of how the expression was evaluated
+//
+// or for an interactive session against a saved session:
+//
+// exptester -assets assets.json -session session.json
+//
+// or against a new session started from a trigger, optionally with a different contact:
+//
+// exptester -assets assets.json -trigger trigger.json -contact contact.json
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/filesystem"
+	"github.com/nyaruka/goflow/assets/static"
 	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/engine"
+	"github.com/nyaruka/goflow/flows/triggers"
 	"github.com/nyaruka/goflow/test"
+
+	"github.com/buger/jsonparser"
+	"github.com/pkg/errors"
 )
 
+const usage = `usage: exptester [flags] [expression]`
+
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("usage: exptester <expression>")
+	var assetsPath, sessionPath, triggerPath, contactPath string
+	var trace bool
+	flags := flag.NewFlagSet("", flag.ExitOnError)
+	flags.StringVar(&assetsPath, "assets", "", "assets file or directory to load session from")
+	flags.StringVar(&sessionPath, "session", "", "session file to load")
+	flags.StringVar(&triggerPath, "trigger", "", "trigger file to start a new session with")
+	flags.StringVar(&contactPath, "contact", "", "contact file to replace the contact in the trigger")
+	flags.BoolVar(&trace, "trace", false, "print a trace of each evaluation")
+	flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	if len(args) > 1 {
+		fmt.Println(usage)
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+
+	session, err := loadSession(assetsPath, sessionPath, triggerPath, contactPath)
+	if err != nil {
+		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 
-	output, err := expTester(os.Args[1])
+	// with no expression, we go into interactive mode
+	if len(args) == 0 {
+		repl := NewREPL(session, os.Stdout)
+		repl.SetTrace(trace)
+
+		if err := repl.RunTerminal(); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if trace {
+		output, trace, err := expTrace(session, args[0])
+		fmt.Print(trace.String())
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Println(output)
+		}
+		return
+	}
+
+	output, err := expTester(session, args[0])
 	if err != nil {
 		fmt.Println(err)
 	} else {
@@ -24,13 +91,101 @@ func main() {
 	}
 }
 
-func expTester(template string) (string, error) {
-	session, _, err := test.CreateTestSession("http://localhost:49995", envs.RedactionPolicyNone)
+func expTester(session flows.Session, template string) (string, error) {
+	run := session.Runs()[0]
+
+	return run.EvaluateTemplate(template)
+}
+
+func expTrace(session flows.Session, template string) (string, *excellent.Trace, error) {
+	run := session.Runs()[0]
+	context := types.NewXObject(run.RootContext(run.Environment()))
+
+	return excellent.EvaluateTemplateWithTrace(run.Environment(), context, template, nil)
+}
+
+// loads a session from the given files, or if none are provided, creates the standard test session
+func loadSession(assetsPath, sessionPath, triggerPath, contactPath string) (flows.Session, error) {
+	if assetsPath == "" {
+		if sessionPath != "" || triggerPath != "" || contactPath != "" {
+			return nil, errors.New("assets must be provided to load a session or trigger")
+		}
+
+		session, _, err := test.CreateTestSession("http://localhost:49995", envs.RedactionPolicyNone)
+		return session, err
+	}
+
+	if (sessionPath == "") == (triggerPath == "") {
+		return nil, errors.New("one of session or trigger must be provided with assets")
+	}
+	if contactPath != "" && triggerPath == "" {
+		return nil, errors.New("contact can only be provided with a trigger")
+	}
+
+	source, err := loadSource(assetsPath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	run := session.Runs()[0]
+	sa, err := engine.NewSessionAssets(envs.NewBuilder().Build(), source, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing assets")
+	}
 
-	return run.EvaluateTemplate(template)
+	eng := engine.NewBuilder().Build()
+	var session flows.Session
+
+	if sessionPath != "" {
+		sessionJSON, err := ioutil.ReadFile(sessionPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading session file '%s'", sessionPath)
+		}
+
+		session, err = eng.ReadSession(sa, sessionJSON, assets.IgnoreMissing)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading session")
+		}
+	} else {
+		triggerJSON, err := ioutil.ReadFile(triggerPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading trigger file '%s'", triggerPath)
+		}
+
+		if contactPath != "" {
+			contactJSON, err := ioutil.ReadFile(contactPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error reading contact file '%s'", contactPath)
+			}
+
+			triggerJSON, err = jsonparser.Set(triggerJSON, contactJSON, "contact")
+			if err != nil {
+				return nil, errors.Wrap(err, "error setting contact on trigger")
+			}
+		}
+
+		trigger, err := triggers.ReadTrigger(sa, json.RawMessage(triggerJSON), assets.IgnoreMissing)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading trigger")
+		}
+
+		session, _, err = eng.NewSession(sa, trigger)
+		if err != nil {
+			return nil, errors.Wrap(err, "error starting session")
+		}
+	}
+
+	if len(session.Runs()) == 0 {
+		return nil, errors.New("session has no runs to evaluate against")
+	}
+
+	return session, nil
+}
+
+// loads assets from a single JSON file or a directory
+func loadSource(path string) (assets.Source, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filesystem.NewSource(path)
+	}
+
+	return static.LoadSource(path)
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/gocommon/jsonx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAssets = "../../test/testdata/runner/two_questions.json"
+
+func TestLoadSession(t *testing.T) {
+	// with no files we get the test session
+	session, err := loadSession("", "", "", "")
+	require.NoError(t, err)
+
+	output, err := expTester(session, "@(upper(contact.name))")
+	assert.NoError(t, err)
+	assert.Equal(t, "RYAN LEWIS", output)
+
+	// start a new session from a trigger
+	session, err = loadSession(testAssets, "", "testdata/trigger.json", "")
+	require.NoError(t, err)
+
+	output, err = expTester(session, "@contact.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ben Haggerty", output)
+
+	// save that session and load it back
+	sessionJSON, err := jsonx.Marshal(session)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "exptester")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sessionPath := filepath.Join(dir, "session.json")
+	require.NoError(t, ioutil.WriteFile(sessionPath, sessionJSON, 0644))
+
+	session, err = loadSession(testAssets, sessionPath, "", "")
+	require.NoError(t, err)
+
+	output, err = expTester(session, "@contact.name @run.flow.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ben Haggerty Two Questions", output)
+
+	// start a new session from a trigger with a different contact
+	session, err = loadSession(testAssets, "", "testdata/trigger.json", "testdata/contact.json")
+	require.NoError(t, err)
+
+	output, err = expTester(session, "@contact.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Cathy Quincy", output)
+
+	// evaluate with a trace
+	output, trace, err := expTrace(session, "@(title(contact.name))")
+	assert.NoError(t, err)
+	assert.Equal(t, "Cathy Quincy", output)
+	assert.Equal(t, `template @(title(contact.name)) → text "Cathy Quincy"
+└── expression title(contact.name) → text "Cathy Quincy"
+    └── function title(contact.name) → text "Cathy Quincy"
+        └── lookup contact.name → text "Cathy Quincy"
+            └── lookup contact → object "Cathy Quincy"
+`, trace.String())
+
+	// invalid combinations of files
+	_, err = loadSession("", sessionPath, "", "")
+	assert.EqualError(t, err, "assets must be provided to load a session or trigger")
+
+	_, err = loadSession(testAssets, sessionPath, "testdata/trigger.json", "")
+	assert.EqualError(t, err, "one of session or trigger must be provided with assets")
+
+	_, err = loadSession(testAssets, sessionPath, "", "testdata/contact.json")
+	assert.EqualError(t, err, "contact can only be provided with a trigger")
+}
+
+func TestREPL(t *testing.T) {
+	session, err := loadSession(testAssets, "", "testdata/trigger.json", "")
+	require.NoError(t, err)
+
+	input := strings.Join([]string{
+		`@contact.name`,
+		`@(1 + 2)`,
+		`@(contact.fields.first_name = "Ben")`,
+		`@(upper(contact.name`,
+		`@(1 / 0)`,
+		`:runs`,
+		`:run 3`,
+		`:ls contact.urns`,
+		`:ls contact.name`,
+		`:tz`,
+		`:tz Africa/Kigali`,
+		`@(format_datetime(datetime("2020-01-01T12:00:00Z"), "tt:mm"))`,
+		`:lang fra`,
+		`:env`,
+		`:trace on`,
+		`@(1 + 2)`,
+		`:trace off`,
+		`:trace`,
+		`:foo`,
+		`:quit`,
+		`@contact.name`,
+	}, "\n")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, NewREPL(session, out).Run(strings.NewReader(input)))
+
+	assert.Equal(t, `Type :help for help
+> Ben Haggerty (types.XText)
+> 3 (types.XNumber)
+> true (types.XBoolean)
+> @(upper(contact.name (types.XText)
+> error: division by zero (types.XError)
+> * 1. Two Questions (waiting)
+> usage: :run <n> where n is between 1 and 1
+> 0 (types.XText)
+1 (types.XText)
+2 (types.XText)
+> types.XText has no properties
+> America/Los_Angeles
+> timezone set to Africa/Kigali
+> 14:00 (types.XText)
+> language set to fra
+> timezone: Africa/Kigali
+language: fra
+> tracing is on
+> template @(1 + 2) → text "3"
+└── expression 1 + 2 → number "3"
+    └── operator 1 + 2 → number "3"
+        ├── literal 1 → number "1"
+        └── literal 2 → number "2"
+3 (types.XNumber)
+> tracing is off
+> usage: :trace on|off
+> unknown command :foo, type :help for help
+> `, out.String())
+}
+
+func TestComplete(t *testing.T) {
+	session, err := loadSession(testAssets, "", "testdata/trigger.json", "")
+	require.NoError(t, err)
+
+	repl := NewREPL(session, &bytes.Buffer{})
+
+	assert.Equal(t, []string{"contact.name"}, repl.Complete("Hi @contact.na"))
+	assert.Equal(t, []string{"contact.fields.first_name"}, repl.Complete("Hi @contact.fields.f"))
+	assert.Equal(t, []string{"contact"}, repl.Complete("@cont"))
+	assert.Equal(t, []string{"code(", "contact", "convert_currency(", "count("}, repl.Complete("@(upper(co"))
+	assert.Equal(t, []string{}, repl.Complete("@contact.name.x"))
+
+	assert.Equal(t, []string{"Hi @contact.name"}, repl.CompleteLine("Hi @contact.na"))
+	assert.Equal(t, []string{"@(upper(code(", "@(upper(contact", "@(upper(convert_currency(", "@(upper(count("}, repl.CompleteLine("@(upper(co"))
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/functions"
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/peterh/liner"
+)
+
+const replHelp = `Enter a template to evaluate it, e.g. @(upper(contact.name)), or one of the following commands:
+
+  :runs             list the runs in the session
+  :run <n>          switch to the nth run
+  :tz [timezone]    show or set the environment timezone, e.g. :tz Africa/Kigali
+  :lang [language]  show or set the environment language, e.g. :lang fra
+  :env              show the environment
+  :trace on|off     turn on or off printing a trace of each evaluation
+  :ls [path]        list the properties in the context at the given path, e.g. :ls contact.urns
+  :help             show this help
+  :quit             exit
+
+Press TAB to complete context paths and function names.
+`
+
+// REPL evaluates templates interactively against a session
+type REPL struct {
+	session  flows.Session
+	run      flows.FlowRun
+	timezone *time.Location
+	language envs.Language
+	trace    bool
+	out      io.Writer
+}
+
+// NewREPL creates a new REPL for the given session
+func NewREPL(session flows.Session, out io.Writer) *REPL {
+	return &REPL{session: session, out: out}
+}
+
+// SetTrace sets whether a trace of each evaluation should be printed
+func (r *REPL) SetTrace(trace bool) {
+	r.trace = trace
+}
+
+// Run reads and executes lines from the given reader until it's exhausted or the user quits
+func (r *REPL) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+
+	r.printf("Type :help for help\n> ")
+
+	for scanner.Scan() {
+		if !r.Execute(scanner.Text()) {
+			return nil
+		}
+
+		r.printf("> ")
+	}
+
+	return scanner.Err()
+}
+
+// RunTerminal reads and executes lines from the terminal with line editing, history and tab completion, until the
+// user quits
+func (r *REPL) RunTerminal() error {
+	term := liner.NewLiner()
+	defer term.Close()
+
+	term.SetCtrlCAborts(true)
+	term.SetCompleter(r.CompleteLine)
+
+	r.printf("Type :help for help\n")
+
+	for {
+		line, err := term.Prompt("> ")
+		if err == io.EOF || err == liner.ErrPromptAborted {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		term.AppendHistory(line)
+
+		if !r.Execute(line) {
+			return nil
+		}
+	}
+}
+
+// Execute executes a single line, returning false if the REPL should exit
+func (r *REPL) Execute(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+
+	if !strings.HasPrefix(line, ":") {
+		r.evaluate(line)
+		return true
+	}
+
+	fields := strings.Fields(line)
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		r.printf(replHelp)
+	case ":runs":
+		r.listRuns()
+	case ":run":
+		r.switchRun(args)
+	case ":tz":
+		r.setTimezone(args)
+	case ":lang":
+		r.setLanguage(args)
+	case ":env":
+		r.printf("timezone: %s\nlanguage: %s\n", r.environment().Timezone(), r.environment().DefaultLanguage())
+	case ":trace":
+		r.setTrace(args)
+	case ":ls":
+		r.listProperties(strings.Join(args, ""))
+	default:
+		r.printf("unknown command %s, type :help for help\n", command)
+	}
+	return true
+}
+
+// Complete returns the possible completions of the context path or function name at the end of the given line
+func (r *REPL) Complete(line string) []string {
+	path := trailingPath(line)
+
+	var parent, prefix string
+	var value types.XValue
+	if dot := strings.LastIndex(path, "."); dot >= 0 {
+		parent, prefix = path[:dot], strings.ToLower(path[dot+1:])
+		value = excellent.EvaluateExpression(r.environment(), r.context(), parent)
+		parent += "."
+	} else {
+		prefix = strings.ToLower(path)
+		value = r.context()
+	}
+
+	completions := make([]string, 0)
+	for _, key := range propertyNames(value) {
+		if strings.HasPrefix(strings.ToLower(key), prefix) {
+			completions = append(completions, parent+key)
+		}
+	}
+
+	// functions can only be used inside of expressions
+	if parent == "" && !strings.HasSuffix(line[:len(line)-len(path)], "@") {
+		for name := range functions.XFUNCTIONS {
+			if strings.HasPrefix(name, prefix) {
+				completions = append(completions, name+"(")
+			}
+		}
+	}
+
+	sort.Strings(completions)
+	return completions
+}
+
+// CompleteLine returns the given line with each of the possible completions of its trailing context path or function
+// name, as used for tab completion
+func (r *REPL) CompleteLine(line string) []string {
+	head := line[:len(line)-len(trailingPath(line))]
+	completions := r.Complete(line)
+
+	lines := make([]string, len(completions))
+	for i, c := range completions {
+		lines[i] = head + c
+	}
+	return lines
+}
+
+func (r *REPL) evaluate(template string) {
+	if r.trace {
+		_, trace, _ := excellent.EvaluateTemplateWithTrace(r.environment(), r.context(), template, nil)
+		r.printf("%s", trace.String())
+	}
+
+	value, err := excellent.EvaluateTemplateValue(r.environment(), r.context(), template)
+	if err != nil {
+		r.printf("error: %s\n", err.Error())
+		return
+	}
+
+	if utils.IsNil(value) {
+		r.printf("null\n")
+	} else if types.IsXError(value) {
+		r.printf("error: %s (%s)\n", value.(types.XError).Error(), typeName(value))
+	} else {
+		r.printf("%s (%s)\n", value.Format(r.environment()), typeName(value))
+	}
+}
+
+func (r *REPL) listRuns() {
+	current := r.currentRun()
+
+	for i, run := range r.session.Runs() {
+		marker := " "
+		if run == current {
+			marker = "*"
+		}
+		r.printf("%s %d. %s (%s)\n", marker, i+1, run.FlowReference().Name, run.Status())
+	}
+}
+
+func (r *REPL) switchRun(args []string) {
+	runs := r.session.Runs()
+
+	n, err := strconv.Atoi(strings.Join(args, ""))
+	if err != nil || n < 1 || n > len(runs) {
+		r.printf("usage: :run <n> where n is between 1 and %d\n", len(runs))
+		return
+	}
+
+	r.run = runs[n-1]
+	r.printf("switched to run %d. %s\n", n, r.run.FlowReference().Name)
+}
+
+func (r *REPL) setTimezone(args []string) {
+	if len(args) == 0 {
+		r.printf("%s\n", r.environment().Timezone())
+		return
+	}
+
+	tz, err := time.LoadLocation(args[0])
+	if err != nil {
+		r.printf("error: %s\n", err.Error())
+		return
+	}
+
+	r.timezone = tz
+	r.printf("timezone set to %s\n", tz)
+}
+
+func (r *REPL) setLanguage(args []string) {
+	if len(args) == 0 {
+		r.printf("%s\n", r.environment().DefaultLanguage())
+		return
+	}
+
+	lang, err := envs.ParseLanguage(args[0])
+	if err != nil {
+		r.printf("error: %s\n", err.Error())
+		return
+	}
+
+	r.language = lang
+	r.printf("language set to %s\n", lang)
+}
+
+func (r *REPL) setTrace(args []string) {
+	switch strings.Join(args, "") {
+	case "on":
+		r.trace = true
+	case "off":
+		r.trace = false
+	default:
+		r.printf("usage: :trace on|off\n")
+		return
+	}
+	r.printf("tracing is %s\n", args[0])
+}
+
+func (r *REPL) listProperties(path string) {
+	value := types.XValue(r.context())
+	if path != "" {
+		value = excellent.EvaluateExpression(r.environment(), r.context(), path)
+	}
+
+	if types.IsXError(value) {
+		r.printf("error: %s\n", value.(types.XError).Error())
+		return
+	}
+
+	obj, isObject := value.(*types.XObject)
+	arr, isArray := value.(*types.XArray)
+
+	if isObject {
+		for _, key := range obj.Properties() {
+			v, _ := obj.Get(key)
+			r.printf("%s (%s)\n", key, typeName(v))
+		}
+	} else if isArray {
+		for i := 0; i < arr.Count(); i++ {
+			r.printf("%d (%s)\n", i, typeName(arr.Get(i)))
+		}
+	} else {
+		r.printf("%s has no properties\n", typeName(value))
+	}
+}
+
+// gets the run we're evaluating against, which unless the user has chosen one, is the one the session considers current
+func (r *REPL) currentRun() flows.FlowRun {
+	if r.run != nil {
+		return r.run
+	}
+
+	var lastRun flows.FlowRun
+	for _, run := range r.session.Runs() {
+		if lastRun == nil || run.ModifiedOn().After(lastRun.ModifiedOn()) {
+			lastRun = run
+		}
+	}
+	return lastRun
+}
+
+func (r *REPL) context() *types.XObject {
+	if r.run == nil && r.timezone == nil && r.language == envs.NilLanguage {
+		return r.session.CurrentContext()
+	}
+
+	return types.NewXObject(r.currentRun().RootContext(r.environment()))
+}
+
+func (r *REPL) environment() envs.Environment {
+	var env envs.Environment
+	if r.run != nil {
+		env = r.run.Environment()
+	} else {
+		env = r.session.Environment()
+	}
+
+	if r.timezone == nil && r.language == envs.NilLanguage {
+		return env
+	}
+	return &replEnvironment{Environment: env, timezone: r.timezone, language: r.language}
+}
+
+func (r *REPL) printf(format string, a ...interface{}) {
+	fmt.Fprintf(r.out, format, a...)
+}
+
+// an environment with the timezone and language overridden by the user
+type replEnvironment struct {
+	envs.Environment
+
+	timezone *time.Location
+	language envs.Language
+}
+
+func (e *replEnvironment) Timezone() *time.Location {
+	if e.timezone != nil {
+		return e.timezone
+	}
+	return e.Environment.Timezone()
+}
+
+func (e *replEnvironment) DefaultLanguage() envs.Language {
+	if e.language != envs.NilLanguage {
+		return e.language
+	}
+	return e.Environment.DefaultLanguage()
+}
+
+func (e *replEnvironment) DefaultLocale() envs.Locale {
+	return envs.NewLocale(e.DefaultLanguage(), e.DefaultCountry())
+}
+
+func (e *replEnvironment) Now() time.Time {
+	return dates.Now().In(e.Timezone())
+}
+
+// gets the name of the type of the given value, e.g. "types.XText"
+func typeName(value types.XValue) string {
+	if utils.IsNil(value) {
+		return "null"
+	}
+	if types.IsXError(value) {
+		return "types.XError"
+	}
+	return strings.TrimPrefix(reflect.TypeOf(value).String(), "*")
+}
+
+// gets the names of the properties of the given value if it's an object
+func propertyNames(value types.XValue) []string {
+	if obj, isObject := value.(*types.XObject); isObject {
+		return obj.Properties()
+	}
+	return nil
+}
+
+// gets the trailing part of the given text which looks like a context path, e.g. "contact.na" in "@(upper(contact.na"
+func trailingPath(s string) string {
+	i := len(s)
+	for i > 0 {
+		ch := s[i-1]
+		if !(ch == '_' || ch == '.' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')) {
+			break
+		}
+		i--
+	}
+	return s[i:]
+}
@@ -3,6 +3,8 @@ package main
 // go install github.com/nyaruka/goflow/cmd/flowmigrate
 // cat legacy_flow.json | flowmigrate
 // cat legacy_export.json | jq '.flows[0]' | flowmigrate
+// cat flow.json | flowmigrate -to 13.0.0
+// flowmigrate path/to/assets_dir
 
 import (
 	"bufio"
@@ -12,12 +14,16 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/assets/filesystem"
 	"github.com/nyaruka/goflow/flows/definition"
 	"github.com/nyaruka/goflow/flows/definition/migrations"
 
 	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
 )
 
 func main() {
@@ -25,26 +31,48 @@ func main() {
 	var pretty bool
 
 	flags := flag.NewFlagSet("", flag.ExitOnError)
-	flags.StringVar(&toVersion, "to", definition.CurrentSpecVersion.String(), "Target flow spec version")
+	flags.StringVar(&toVersion, "to", definition.CurrentSpecVersion.String(), "Target flow spec version, which can be older than the flow's version")
 	flags.StringVar(&baseMediaURL, "base-media-url", "", "Base URL for media files")
 	flags.BoolVar(&pretty, "pretty", false, "Pretty format output")
 	flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	to, err := semver.NewVersion(toVersion)
+	if err != nil {
+		fmt.Printf("%s isn't a valid spec version\n", toVersion)
+		os.Exit(1)
+	}
+
+	// if we've been given an assets directory, migrate its flow files in place
+	if len(args) > 0 {
+		migrated, err := MigrateDir(args[0], to, baseMediaURL, pretty, os.Stderr)
+		for _, path := range migrated {
+			fmt.Printf("migrated %s\n", path)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	reader := bufio.NewReader(os.Stdin)
 
-	output, err := Migrate(reader, semver.MustParse(toVersion), baseMediaURL, pretty)
+	output, losses, err := Migrate(reader, to, baseMediaURL, pretty)
 	if err != nil {
 		fmt.Println(err)
 	} else {
+		printLosses(os.Stderr, "", losses)
 		fmt.Println(string(output))
 	}
 }
 
-// Migrate reads a flow definition as JSON and migrates it
-func Migrate(reader io.Reader, toVersion *semver.Version, baseMediaURL string, pretty bool) ([]byte, error) {
+// Migrate reads a flow definition as JSON and migrates it, returning anything that was lost if it was migrated
+// to an older version
+func Migrate(reader io.Reader, toVersion *semver.Version, baseMediaURL string, pretty bool) ([]byte, []*migrations.Loss, error) {
 	data, err := ioutil.ReadAll(reader)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var migConfig *migrations.Config
@@ -52,19 +80,78 @@ func Migrate(reader io.Reader, toVersion *semver.Version, baseMediaURL string, p
 		migConfig = &migrations.Config{BaseMediaURL: baseMediaURL}
 	}
 
-	migrated, err := migrations.MigrateToVersion(data, toVersion, migConfig)
+	migrated, losses, err := migrations.MigrateToVersionWithLosses(data, toVersion, migConfig)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// if we've migrated to the engine version, validate the flow can be read by the engine
 	if toVersion == nil || toVersion.Equal(definition.CurrentSpecVersion) {
 		_, err = definition.ReadFlow(migrated, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	if pretty {
-		return jsonx.MarshalPretty(json.RawMessage(migrated))
+		migrated, err = jsonx.MarshalPretty(json.RawMessage(migrated))
+	}
+
+	return migrated, losses, err
+}
+
+// MigrateDir migrates each flow file in the flows directory of the given assets directory in place, returning
+// the paths of the files which were migrated. Anything lost by migrating to an older version is written to the
+// given writer.
+func MigrateDir(dir string, toVersion *semver.Version, baseMediaURL string, pretty bool, lossesOut io.Writer) ([]string, error) {
+	paths := make([]string, 0)
+
+	err := filepath.Walk(filepath.Join(dir, filesystem.FlowsDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading asset directory '%s'", dir)
+	}
+
+	migrated := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return migrated, err
+		}
+
+		output, losses, err := Migrate(file, toVersion, baseMediaURL, pretty)
+		file.Close()
+
+		if err != nil {
+			return migrated, errors.Wrapf(err, "error migrating flow file '%s'", path)
+		}
+
+		printLosses(lossesOut, path, losses)
+
+		if err := ioutil.WriteFile(path, append(output, '\n'), 0644); err != nil {
+			return migrated, err
+		}
+
+		migrated = append(migrated, path)
 	}
 
 	return migrated, nil
 }
+
+func printLosses(out io.Writer, path string, losses []*migrations.Loss) {
+	prefix := ""
+	if path != "" {
+		prefix = path + ": "
+	}
+	for _, loss := range losses {
+		fmt.Fprintf(out, "⚠️ %s%s can't be represented in %s\n", prefix, loss.Description, loss.Version)
+	}
+}
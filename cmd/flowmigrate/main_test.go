@@ -2,6 +2,9 @@ package main_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -9,6 +12,8 @@ import (
 	"github.com/nyaruka/goflow/flows/definition"
 	"github.com/nyaruka/goflow/test"
 
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -80,9 +85,85 @@ func TestMigrate(t *testing.T) {
 	for _, tc := range testCases {
 		input := strings.NewReader(tc.input)
 
-		migrated, err := main.Migrate(input, nil, "http://temba.io/", true)
+		migrated, losses, err := main.Migrate(input, nil, "http://temba.io/", true)
 		require.NoError(t, err)
+		assert.Len(t, losses, 0)
 
 		test.AssertEqualJSON(t, []byte(tc.output), migrated, "Migrated flow mismatch")
 	}
 }
+
+func TestMigrateToOlderVersion(t *testing.T) {
+	input := strings.NewReader(`{
+		"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+		"name": "Templated",
+		"spec_version": "13.1.0",
+		"language": "eng",
+		"type": "messaging",
+		"localization": {
+			"spa": {
+				"d2f852ec-7b4e-457f-ae7f-f8b243c49ff5": {"variables": ["@contact.name"]}
+			}
+		},
+		"nodes": [
+			{
+				"uuid": "365293c7-633c-45bd-96b7-0b059766588d",
+				"actions": [
+					{
+						"uuid": "8eebd020-1af5-431c-b943-aa670fc74da9",
+						"type": "send_msg",
+						"text": "Hi",
+						"templating": {
+							"uuid": "d2f852ec-7b4e-457f-ae7f-f8b243c49ff5",
+							"template": {"uuid": "3ce100b7-a734-4b4e-891b-350b1279ade2", "name": "revive_issue"},
+							"variables": ["@contact.name"]
+						}
+					}
+				],
+				"exits": [{"uuid": "b6f4caf3-ec99-44d5-a40c-8600ac0e2eac"}]
+			}
+		]
+	}`)
+
+	migrated, losses, err := main.Migrate(input, semver.MustParse("13.0.0"), "", false)
+	require.NoError(t, err)
+	assert.Contains(t, string(migrated), `"spec_version":"13.0.0"`)
+	assert.NotContains(t, string(migrated), "d2f852ec-7b4e-457f-ae7f-f8b243c49ff5")
+	assert.Len(t, losses, 1)
+	assert.Equal(t, "13.0.0: localized template variables in language 'spa' for send_msg action '8eebd020-1af5-431c-b943-aa670fc74da9'", losses[0].String())
+}
+
+func TestMigrateDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "flows", "legacy"), 0755))
+
+	legacyPath := filepath.Join(dir, "flows", "legacy", "empty.json")
+	require.NoError(t, ioutil.WriteFile(legacyPath, []byte(`{
+		"metadata": {"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02", "name": "Empty", "revision": 1},
+		"base_language": "eng",
+		"flow_type": "F",
+		"action_sets": [],
+		"rule_sets": []
+	}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "flows", "README.txt"), []byte("not a flow"), 0644))
+
+	lossesOut := &strings.Builder{}
+	migrated, err := main.MigrateDir(dir, nil, "http://temba.io/", false, lossesOut)
+	require.NoError(t, err)
+	assert.Equal(t, []string{legacyPath}, migrated)
+	assert.Equal(t, "", lossesOut.String())
+
+	data, err := ioutil.ReadFile(legacyPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf(`"spec_version":"%s"`, definition.CurrentSpecVersion))
+
+	// an invalid flow file is an error
+	require.NoError(t, ioutil.WriteFile(legacyPath, []byte(`{`), 0644))
+
+	_, err = main.MigrateDir(dir, nil, "http://temba.io/", false, lossesOut)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error migrating flow file '"+legacyPath+"'")
+
+	_, err = main.MigrateDir(filepath.Join(dir, "missing"), nil, "", false, lossesOut)
+	assert.Error(t, err)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/nyaruka/gocommon/urns"
 	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/filesystem"
 	"github.com/nyaruka/goflow/assets/static"
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/flows"
@@ -46,7 +47,7 @@ const contactJSON = `{
 }
 `
 
-const usage = `usage: flowrunner [flags] <assets.json> [flow_uuid]`
+const usage = `usage: flowrunner [flags] <assets.json|assets_dir> [flow_uuid]`
 
 func main() {
 	var initialMsg, contactLang, witToken string
@@ -103,23 +104,9 @@ func createEngine(witToken string) flows.Engine {
 	return builder.Build()
 }
 
-// RunFlow steps through a flow
+// RunFlow steps through a flow. Assets can be loaded from a single JSON file or a directory.
 func RunFlow(eng flows.Engine, assetsPath string, flowUUID assets.FlowUUID, initialMsg string, contactLang envs.Language, in io.Reader, out io.Writer) (*Repro, error) {
-	assetsJSON, err := ioutil.ReadFile(assetsPath)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error reading assets file '%s'", assetsPath)
-	}
-
-	// if user didn't provide a flow UUID, look for the UUID of the first flow
-	if flowUUID == "" {
-		uuidBytes, _, _, err := jsonparser.Get(assetsJSON, "flows", "[0]", "uuid")
-		if err != nil {
-			return nil, errors.New("no flows found in assets file")
-		}
-		flowUUID = assets.FlowUUID(uuidBytes)
-	}
-
-	source, err := static.NewSource(assetsJSON)
+	source, flowUUID, err := loadSource(assetsPath, flowUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +192,47 @@ func RunFlow(eng flows.Engine, assetsPath string, flowUUID assets.FlowUUID, init
 	return repro, nil
 }
 
+// loads an asset source from a directory or a JSON file, and if no flow UUID is given, gets the UUID of the first flow
+func loadSource(path string, flowUUID assets.FlowUUID) (assets.Source, assets.FlowUUID, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		source, err := filesystem.NewSource(path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if flowUUID == "" {
+			flowUUIDs := source.FlowUUIDs()
+			if len(flowUUIDs) == 0 {
+				return nil, "", errors.New("no flows found in assets directory")
+			}
+			flowUUID = flowUUIDs[0]
+		}
+
+		return source, flowUUID, nil
+	}
+
+	assetsJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error reading assets file '%s'", path)
+	}
+
+	// if user didn't provide a flow UUID, look for the UUID of the first flow
+	if flowUUID == "" {
+		uuidBytes, _, _, err := jsonparser.Get(assetsJSON, "flows", "[0]", "uuid")
+		if err != nil {
+			return nil, "", errors.New("no flows found in assets file")
+		}
+		flowUUID = assets.FlowUUID(uuidBytes)
+	}
+
+	source, err := static.NewSource(assetsJSON)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return source, flowUUID, nil
+}
+
 func createMessage(contact *flows.Contact, text string) *flows.MsgIn {
 	return flows.NewMsgIn(flows.MsgUUID(uuids.New()), contact.URNs()[0].URN(), nil, text, []utils.Attachment{})
 }
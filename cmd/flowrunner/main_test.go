@@ -53,6 +53,17 @@ func TestRunFlow(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Contains(t, out.String(), "Starting flow 'Two Questions'")
+
+	// run again but load assets from a directory
+	in = strings.NewReader("I like red\npepsi\n")
+	out = &strings.Builder{}
+	_, err = main.RunFlow(test.NewEngine(), "testdata/two_questions", "", "", "eng", in, out)
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.Join(lines, "\n"), strings.Replace(out.String(), "> ", "", -1))
+
+	_, err = main.RunFlow(test.NewEngine(), "testdata", "", "", "eng", in, out)
+	assert.EqualError(t, err, "no flows found in assets directory")
 }
 
 func TestPrintEvent(t *testing.T) {
@@ -78,7 +89,7 @@ func TestPrintEvent(t *testing.T) {
 		{events.NewContactTimezoneChanged(session.Environment().Timezone()), `🕑 timezone changed to 'America/Guayaquil'`},
 		{events.NewDialEnded(flows.NewDial(flows.DialStatusBusy, 3)), `☎️ dial ended with 'busy'`},
 		{events.NewDialWait(urns.URN(`tel:+1234567890`)), `⏳ waiting for dial (type /dial <answered|no_answer|busy|failed>)...`},
-		{events.NewEmailSent([]string{"code@example.com"}, "Hi", "What up?"), `✉️ email sent with subject 'Hi'`},
+		{events.NewEmailSent(&flows.Email{To: []string{"code@example.com"}, Subject: "Hi", Body: "What up?"}), `✉️ email sent with subject 'Hi'`},
 		{events.NewEnvironmentRefreshed(session.Environment()), `⚙️ environment refreshed on resume`},
 		{events.NewErrorf("this didn't work"), `⚠️ this didn't work`},
 		{events.NewFailure(errors.New("this really didn't work")), `🛑 this really didn't work`},
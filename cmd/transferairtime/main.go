@@ -3,38 +3,49 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 
 	"github.com/nyaruka/gocommon/httpx"
 	"github.com/nyaruka/gocommon/jsonx"
 	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/assets"
 	"github.com/nyaruka/goflow/assets/static"
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/actions"
 	"github.com/nyaruka/goflow/flows/engine"
 	"github.com/nyaruka/goflow/flows/triggers"
 	"github.com/nyaruka/goflow/services/airtime/dtone"
+	"github.com/nyaruka/goflow/services/airtime/rest"
 
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 )
 
-const usage = `usage: transferairtime [flags] <destnumber> <amount> <currency>`
+const usage = `usage: transferairtime [flags] <destnumber> [<amount> <currency>]`
 
 var verbose bool
 
 func main() {
-	var dtoneKey, dtoneSecret string
+	var provider, dtoneKey, dtoneSecret, restURL, restToken, productType, sku string
+	var mock bool
 	flags := flag.NewFlagSet("", flag.ExitOnError)
+	flags.StringVar(&provider, "provider", "dtone", "airtime provider to use: dtone or rest")
 	flags.StringVar(&dtoneKey, "dtone.key", "", "API key for DTOne service")
 	flags.StringVar(&dtoneSecret, "dtone.secret", "", "API secret for DTOne service")
+	flags.StringVar(&restURL, "rest.url", "", "base URL of generic REST airtime service")
+	flags.StringVar(&restToken, "rest.token", "", "authorization token for generic REST airtime service")
+	flags.StringVar(&productType, "type", "airtime", "type of product to transfer: airtime or data")
+	flags.StringVar(&sku, "sku", "", "SKU of a specific product to transfer")
+	flags.BoolVar(&mock, "mock", false, "use a local mock server instead of the real service")
 	flags.BoolVar(&verbose, "v", false, "enable verbose logging")
 	flags.Parse(os.Args[1:])
 	args := flags.Args()
 
-	if len(args) != 3 {
+	if len(args) != 3 && !(len(args) == 1 && sku != "") {
 		fmt.Println(usage)
 		flags.PrintDefaults()
 		os.Exit(1)
@@ -46,27 +57,65 @@ func main() {
 		os.Exit(1)
 	}
 
-	amount, err := decimal.NewFromString(args[1])
-	if err != nil {
-		fmt.Printf("%s isn't a valid amount\n", args[1])
-		os.Exit(1)
-	}
-
-	if dtoneKey == "" || dtoneSecret == "" {
-		fmt.Println("no airtime service credentials provided")
-		os.Exit(1)
+	var amounts map[string]decimal.Decimal
+	if len(args) == 3 {
+		amount, err := decimal.NewFromString(args[1])
+		if err != nil {
+			fmt.Printf("%s isn't a valid amount\n", args[1])
+			os.Exit(1)
+		}
+		amounts = map[string]decimal.Decimal{args[2]: amount}
 	}
 
 	httpx.SetDebug(verbose)
 
-	svcFactory := func(flows.Session) (flows.AirtimeService, error) {
-		return dtone.NewService(http.DefaultClient, nil, dtoneKey, dtoneSecret), nil
+	httpClient := http.DefaultClient
+
+	if mock {
+		server := NewMockServer()
+		defer server.Close()
+
+		httpClient = server.Client()
+		restURL = server.URL + "/rest"
+		dtoneKey, dtoneSecret, restToken = "mock", "mock", "mock"
 	}
 
-	if err := transferAirtime(destination, amount, args[2], svcFactory); err != nil {
+	svcFactory, err := createServiceFactory(provider, httpClient, dtoneKey, dtoneSecret, restURL, restToken)
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+
+	request := &flows.AirtimeProductRequest{Type: flows.AirtimeProductType(productType), SKU: sku, Amounts: amounts}
+
+	if err := TransferAirtime(destination, request, svcFactory, os.Stdout); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func createServiceFactory(provider string, httpClient *http.Client, dtoneKey, dtoneSecret, restURL, restToken string) (engine.AirtimeServiceFactory, error) {
+	switch provider {
+	case "dtone":
+		if dtoneKey == "" || dtoneSecret == "" {
+			return nil, errors.New("no airtime service credentials provided")
+		}
+		return func(flows.Session) (flows.AirtimeService, error) {
+			return dtone.NewService(httpClient, nil, dtoneKey, dtoneSecret), nil
+		}, nil
+	case "rest":
+		if restURL == "" {
+			return nil, errors.New("no airtime service URL provided")
+		}
+		config := &rest.Config{BaseURL: restURL}
+		if restToken != "" {
+			config.Headers = map[string]string{"Authorization": "Token " + restToken}
+		}
+		return func(flows.Session) (flows.AirtimeService, error) {
+			return rest.NewService(httpClient, nil, config), nil
+		}, nil
+	}
+	return nil, errors.Errorf("unknown airtime provider: %s", provider)
 }
 
 const assetsTemplate = `
@@ -81,14 +130,7 @@ const assetsTemplate = `
             "nodes": [
 				{
 					"uuid": "f365a3fb-cc94-4f93-9263-f3dc9d94c0d3",
-					"actions": [
-						{
-							"uuid": "ad1b1c41-553a-413d-9619-813cdc578933",
-							"type": "transfer_airtime",
-							"amounts": {"%s": %s},
-							"result_name": "Transfer"
-						}
-					],
+					"actions": [%s],
 					"exits": [
 						{
 							"uuid": "d3add939-33e3-43f9-bb27-0e7100830c8a"
@@ -102,9 +144,20 @@ const assetsTemplate = `
 }
 `
 
-func transferAirtime(destination urns.URN, amount decimal.Decimal, currency string, svcFactory engine.AirtimeServiceFactory) error {
+// TransferAirtime makes a transfer to the given destination by running a flow with a transfer_airtime action,
+// and writes the generated events to the given writer
+func TransferAirtime(destination urns.URN, request *flows.AirtimeProductRequest, svcFactory engine.AirtimeServiceFactory, out io.Writer) error {
 	// create a flow to do make the transfer
-	assetsJSON := fmt.Sprintf(assetsTemplate, currency, amount.String())
+	action := actions.NewTransferAirtime(flows.ActionUUID(uuids.New()), request.Amounts, "Transfer")
+	action.ProductType = request.Type
+	action.SKU = request.SKU
+
+	actionJSON, err := jsonx.Marshal(action)
+	if err != nil {
+		return err
+	}
+
+	assetsJSON := fmt.Sprintf(assetsTemplate, string(actionJSON))
 	source, err := static.NewSource([]byte(assetsJSON))
 	if err != nil {
 		return err
@@ -130,7 +183,7 @@ func transferAirtime(destination urns.URN, amount decimal.Decimal, currency stri
 
 	for _, event := range sprint.Events() {
 		marshaled, _ := jsonx.Marshal(event)
-		fmt.Println(string(marshaled))
+		fmt.Fprintln(out, string(marshaled))
 	}
 
 	return nil
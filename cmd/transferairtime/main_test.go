@@ -0,0 +1,66 @@
+package main_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/gocommon/urns"
+	main "github.com/nyaruka/goflow/cmd/transferairtime"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/services/airtime/dtone"
+	"github.com/nyaruka/goflow/services/airtime/rest"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferAirtime(t *testing.T) {
+	server := main.NewMockServer()
+	defer server.Close()
+
+	dtoneFactory := func(flows.Session) (flows.AirtimeService, error) {
+		return dtone.NewService(server.Client(), nil, "key", "secret"), nil
+	}
+	restFactory := func(flows.Session) (flows.AirtimeService, error) {
+		return rest.NewService(server.Client(), nil, &rest.Config{BaseURL: server.URL + "/rest"}), nil
+	}
+
+	testCases := []struct {
+		factory  func(flows.Session) (flows.AirtimeService, error)
+		request  *flows.AirtimeProductRequest
+		contains []string
+	}{
+		{
+			factory:  dtoneFactory,
+			request:  &flows.AirtimeProductRequest{Amounts: map[string]decimal.Decimal{"USD": decimal.RequireFromString("5")}},
+			contains: []string{`"type":"airtime_transferred"`, `"sku":"6035"`, `"actual_amount":3`, `"category":"Success"`},
+		},
+		{
+			factory:  dtoneFactory,
+			request:  &flows.AirtimeProductRequest{SKU: "6036"},
+			contains: []string{`"type":"airtime_transferred"`, `"sku":"6036"`, `"actual_amount":6`, `"category":"Success"`},
+		},
+		{
+			factory:  restFactory,
+			request:  &flows.AirtimeProductRequest{Amounts: map[string]decimal.Decimal{"USD": decimal.RequireFromString("7")}},
+			contains: []string{`"type":"airtime_transferred"`, `"sku":"MOCK-6"`, `"actual_amount":6`, `"category":"Success"`},
+		},
+		{
+			factory:  restFactory,
+			request:  &flows.AirtimeProductRequest{Type: flows.AirtimeProductTypeData, SKU: "MOCK-5GB"},
+			contains: []string{`"product_type":"data"`, `"product_name":"5GB"`, `"actual_amount":15`, `"category":"Success"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		out := &bytes.Buffer{}
+		err := main.TransferAirtime(urns.URN("tel:+593979123456"), tc.request, tc.factory, out)
+		require.NoError(t, err)
+
+		for _, c := range tc.contains {
+			assert.True(t, strings.Contains(out.String(), c), "expected output to contain %s, got %s", c, out.String())
+		}
+	}
+}
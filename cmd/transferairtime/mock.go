@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+)
+
+// mocked responses for the DTOne API
+const (
+	mockDTOneLookup   = `[{"id": 1596, "name": "Mock Operator", "identified": true}]`
+	mockDTOneProducts = `[
+		{"id": 6035, "name": "3 USD", "type": "FIXED_VALUE_RECHARGE", "destination": {"amount": 3, "unit": "USD", "unit_type": "CURRENCY"}},
+		{"id": 6036, "name": "6 USD", "type": "FIXED_VALUE_RECHARGE", "destination": {"amount": 6, "unit": "USD", "unit_type": "CURRENCY"}}
+	]`
+	mockDTOneTransaction = `{"id": 2237512891, "status": {"id": 20000, "message": "CONFIRMED", "class": {"id": 2, "message": "CONFIRMED"}}}`
+)
+
+// mocked responses for the generic REST contract
+const (
+	mockRESTLookup          = `{"id": "mock-op", "name": "Mock Operator", "country": "EC"}`
+	mockRESTAirtimeProducts = `{"products": [
+		{"sku": "MOCK-3", "name": "3 USD", "type": "airtime", "currency": "USD", "amount": 3},
+		{"sku": "MOCK-6", "name": "6 USD", "type": "airtime", "currency": "USD", "amount": 6}
+	]}`
+	mockRESTDataProducts = `{"products": [
+		{"sku": "MOCK-1GB", "name": "1GB", "type": "data", "currency": "USD", "amount": 5},
+		{"sku": "MOCK-5GB", "name": "5GB", "type": "data", "currency": "USD", "amount": 15}
+	]}`
+	mockRESTTransferPending = `{"id": "T123", "status": "pending"}`
+	mockRESTTransferSuccess = `{"id": "T123", "status": "success"}`
+)
+
+// NewMockServer creates a local HTTP server which mocks both the DTOne API and the generic REST airtime contract
+// (under /rest). Its client sends all requests to the server regardless of their host.
+func NewMockServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		path := r.URL.Path
+		switch {
+		case strings.HasPrefix(path, "/v1/lookup/mobile-number/"):
+			fmt.Fprint(w, mockDTOneLookup)
+		case path == "/v1/products":
+			fmt.Fprint(w, mockDTOneProducts)
+		case path == "/v1/sync/transactions" && r.Method == http.MethodPost:
+			fmt.Fprint(w, mockDTOneTransaction)
+		case path == "/rest/operators/lookup":
+			fmt.Fprint(w, mockRESTLookup)
+		case path == "/rest/products" && r.URL.Query().Get("type") == "data":
+			fmt.Fprint(w, mockRESTDataProducts)
+		case path == "/rest/products":
+			fmt.Fprint(w, mockRESTAirtimeProducts)
+		case path == "/rest/transfers" && r.Method == http.MethodPost:
+			fmt.Fprint(w, mockRESTTransferPending)
+		case strings.HasPrefix(path, "/rest/transfers/"):
+			fmt.Fprint(w, mockRESTTransferSuccess)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": "not found"}`)
+		}
+	}))
+
+	serverURL, _ := url.Parse(server.URL)
+	server.Client().Transport = &redirectTransport{target: serverURL, wrapped: server.Client().Transport}
+
+	return server
+}
+
+// a transport which sends all requests to the target host
+type redirectTransport struct {
+	target  *url.URL
+	wrapped http.RoundTripper
+}
+
+func (t *redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return t.wrapped.RoundTrip(r)
+}
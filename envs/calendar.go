@@ -0,0 +1,374 @@
+package envs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+func init() {
+	utils.RegisterValidatorTag("calendar", func(fl validator.FieldLevel) bool {
+		return Calendar(fl.Field().String()).IsValid()
+	}, func(validator.FieldError) string {
+		return "is not a valid calendar"
+	})
+}
+
+// Calendar is the calendar system used to parse and format dates
+type Calendar string
+
+// supported calendars
+const (
+	CalendarGregorian    Calendar = "gregorian"
+	CalendarEthiopian    Calendar = "ethiopian"
+	CalendarBikramSambat Calendar = "bikram_sambat"
+	CalendarHijri        Calendar = "hijri"
+)
+
+// a non-Gregorian calendar which converts dates via the number of days since the Unix epoch
+type calendarSystem struct {
+	toDays      func(dates.Date) (int, bool)
+	fromDays    func(int) (dates.Date, bool)
+	months      []string
+	shortMonths []string
+}
+
+var calendarSystems = map[Calendar]*calendarSystem{
+	CalendarEthiopian: {
+		toDays:      ethiopianToDays,
+		fromDays:    ethiopianFromDays,
+		months:      []string{"Meskerem", "Tikimt", "Hidar", "Tahsas", "Tir", "Yekatit", "Megabit", "Miyazya", "Ginbot", "Sene", "Hamle", "Nehase", "Pagume"},
+		shortMonths: []string{"Mes", "Tik", "Hid", "Tah", "Tir", "Yek", "Meg", "Miy", "Gin", "Sen", "Ham", "Neh", "Pag"},
+	},
+	CalendarBikramSambat: {
+		toDays:      bikramSambatToDays,
+		fromDays:    bikramSambatFromDays,
+		months:      []string{"Baisakh", "Jestha", "Asar", "Shrawan", "Bhadra", "Ashwin", "Kartik", "Mangsir", "Poush", "Magh", "Falgun", "Chaitra"},
+		shortMonths: []string{"Bai", "Jes", "Asa", "Shr", "Bha", "Ash", "Kar", "Man", "Pou", "Mag", "Fal", "Cha"},
+	},
+	CalendarHijri: {
+		toDays:      hijriToDays,
+		fromDays:    hijriFromDays,
+		months:      []string{"Muharram", "Safar", "Rabi al-Awwal", "Rabi al-Thani", "Jumada al-Awwal", "Jumada al-Thani", "Rajab", "Shaban", "Ramadan", "Shawwal", "Dhu al-Qadah", "Dhu al-Hijjah"},
+		shortMonths: []string{"Muh", "Saf", "Rab I", "Rab II", "Jum I", "Jum II", "Raj", "Sha", "Ram", "Shaw", "Dhu I-Q", "Dhu I-H"},
+	},
+}
+
+// IsValid returns whether this is a calendar we support
+func (c Calendar) IsValid() bool {
+	_, supported := calendarSystems[c]
+	return c == CalendarGregorian || supported
+}
+
+// FromGregorian converts the given Gregorian date to a date in this calendar
+func (c Calendar) FromGregorian(d dates.Date) (dates.Date, error) {
+	system := calendarSystems[c]
+	if system == nil {
+		return d, nil
+	}
+
+	converted, ok := system.fromDays(gregorianToDays(d))
+	if !ok {
+		return dates.ZeroDate, errors.Errorf("%s can't be represented in the %s calendar", d, c)
+	}
+	return converted, nil
+}
+
+// ToGregorian converts the given date in this calendar to a Gregorian date
+func (c Calendar) ToGregorian(d dates.Date) (dates.Date, error) {
+	system := calendarSystems[c]
+	if system == nil {
+		if d.Month < 1 || d.Month > 12 || d.Day < 1 || d.Day > 31 {
+			return dates.ZeroDate, invalidDateError(d, CalendarGregorian)
+		}
+		return d, nil
+	}
+
+	days, ok := system.toDays(d)
+	if !ok {
+		return dates.ZeroDate, invalidDateError(d, c)
+	}
+	return gregorianFromDays(days), nil
+}
+
+func invalidDateError(d dates.Date, c Calendar) error {
+	return errors.Errorf("%04d-%02d-%02d isn't a valid date in the %s calendar", d.Year, d.Month, d.Day, c)
+}
+
+// Format formats the given datetime using a layout string, with the year, month and day in this calendar. The day of
+// week and time sequences are formatted as they are in the Gregorian calendar.
+func (c Calendar) Format(t time.Time, layout string, locale string, type_ dates.LayoutType) (string, error) {
+	system := calendarSystems[c]
+	if system == nil {
+		return dates.Format(t, layout, locale, type_)
+	}
+
+	if err := dates.ValidateFormat(layout, type_, dates.FormattingMode); err != nil {
+		return "", err
+	}
+
+	d, err := c.FromGregorian(dates.ExtractDate(t))
+	if err != nil {
+		return "", err
+	}
+
+	output := &strings.Builder{}
+	runes := []rune(layout)
+	var seqLen int
+
+	for i := 0; i < len(runes); i += seqLen {
+		for seqLen = 1; i+seqLen < len(runes) && runes[i+seqLen] == runes[i]; seqLen++ {
+		}
+		seq := string(runes[i : i+seqLen])
+
+		switch seq {
+		case "YY":
+			fmt.Fprintf(output, "%02d", d.Year%100)
+		case "YYYY":
+			fmt.Fprintf(output, "%04d", d.Year)
+		case "M":
+			fmt.Fprintf(output, "%d", d.Month)
+		case "MM":
+			fmt.Fprintf(output, "%02d", d.Month)
+		case "MMM":
+			output.WriteString(system.shortMonths[d.Month-1])
+		case "MMMM":
+			output.WriteString(system.months[d.Month-1])
+		case "D":
+			fmt.Fprintf(output, "%d", d.Day)
+		case "DD":
+			fmt.Fprintf(output, "%02d", d.Day)
+		default:
+			// anything else isn't affected by the calendar
+			formatted, err := dates.Format(t, seq, locale, type_)
+			if err != nil {
+				return "", err
+			}
+			output.WriteString(formatted)
+		}
+	}
+
+	return output.String(), nil
+}
+
+//------------------------------------------------------------------------------------------
+// Conversions
+//------------------------------------------------------------------------------------------
+
+const secondsPerDay = 24 * 60 * 60
+
+// the Julian day number of the Unix epoch
+const unixEpochJDN = 2440588
+
+func gregorianToDays(d dates.Date) int {
+	return int(time.Date(d.Year, time.Month(d.Month), d.Day, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay)
+}
+
+func gregorianFromDays(days int) dates.Date {
+	return dates.ExtractDate(time.Unix(int64(days)*secondsPerDay, 0).UTC())
+}
+
+// the Julian day number of the day before 1 Meskerem 1 in the Ethiopian (Amete Mihret) calendar
+const ethiopianEpochJDN = 1723856
+
+func ethiopianToDays(d dates.Date) (int, bool) {
+	if d.Month < 1 || d.Month > 13 || d.Day < 1 || d.Day > 30 {
+		return 0, false
+	}
+	// the 13th month has 5 days, or 6 in a leap year
+	if d.Month == 13 && (d.Day > 6 || (d.Day == 6 && d.Year%4 != 3)) {
+		return 0, false
+	}
+
+	jdn := ethiopianEpochJDN + 365*d.Year + d.Year/4 + 30*int(d.Month) + d.Day - 31
+	return jdn - unixEpochJDN, true
+}
+
+func ethiopianFromDays(days int) (dates.Date, bool) {
+	jdn := days + unixEpochJDN
+	r := (jdn - ethiopianEpochJDN) % 1461
+	n := r%365 + 365*(r/1460)
+
+	year := 4*((jdn-ethiopianEpochJDN)/1461) + r/365 - r/1460
+	return dates.NewDate(year, n/30+1, n%30+1), true
+}
+
+// the Julian day number of 1 Muharram 1 in the tabular Islamic calendar
+const hijriEpochJDN = 1948440
+
+// the Hijri calendar is the tabular Islamic calendar, which can differ by a day or two from calendars based on
+// sightings of the moon
+func hijriToDays(d dates.Date) (int, bool) {
+	if d.Year < 1 || d.Month < 1 || d.Month > 12 || d.Day < 1 || d.Day > hijriMonthLength(d.Year, int(d.Month)) {
+		return 0, false
+	}
+
+	jdn := d.Day + (59*int(d.Month-1)+1)/2 + (d.Year-1)*354 + (3+11*d.Year)/30 + hijriEpochJDN - 1
+	return jdn - unixEpochJDN, true
+}
+
+func hijriFromDays(days int) (dates.Date, bool) {
+	jdn := days + unixEpochJDN
+	if jdn < hijriEpochJDN {
+		return dates.ZeroDate, false
+	}
+
+	year := (30*(jdn-hijriEpochJDN) + 10646) / 10631
+
+	month := 12
+	for m := 1; m < 12; m++ {
+		nextStart, _ := hijriToDays(dates.NewDate(year, m+1, 1))
+		if days < nextStart {
+			month = m
+			break
+		}
+	}
+
+	monthStart, _ := hijriToDays(dates.NewDate(year, month, 1))
+	return dates.NewDate(year, month, days-monthStart+1), true
+}
+
+// odd months have 30 days and even months 29, except in leap years when the last month has 30
+func hijriMonthLength(year, month int) int {
+	if month%2 == 1 || (month == 12 && (14+11*year)%30 < 11) {
+		return 30
+	}
+	return 29
+}
+
+// Bikram Sambat months don't follow a formula so we use a table of month lengths
+const bikramSambatFirstYear = 2000
+
+// the Gregorian date of 1 Baisakh 2000
+var bikramSambatEpoch = dates.NewDate(1943, 4, 14)
+
+var bikramSambatMonthLengths = [][12]int{
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31}, // 2000
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30}, // 2010
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30}, // 2020
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 32, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31}, // 2030
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{30, 32, 31, 32, 31, 31, 29, 30, 30, 29, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30}, // 2040
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31}, // 2050
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 32, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30}, // 2060
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{30, 32, 31, 32, 31, 31, 29, 30, 29, 30, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 29, 31},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 30, 30}, // 2070
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31},
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31},
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30},
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30}, // 2080
+	{31, 31, 32, 32, 31, 30, 30, 30, 29, 30, 30, 30},
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 30, 30},
+	{31, 31, 32, 31, 31, 30, 30, 30, 29, 30, 30, 30},
+	{31, 31, 32, 31, 31, 30, 30, 30, 29, 30, 30, 30},
+	{31, 32, 31, 32, 30, 31, 30, 30, 29, 30, 30, 30},
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 30, 30}, // 2086
+}
+
+func bikramSambatToDays(d dates.Date) (int, bool) {
+	y := d.Year - bikramSambatFirstYear
+	if y < 0 || y >= len(bikramSambatMonthLengths) || d.Month < 1 || d.Month > 12 || d.Day < 1 || d.Day > bikramSambatMonthLengths[y][d.Month-1] {
+		return 0, false
+	}
+
+	days := gregorianToDays(bikramSambatEpoch)
+	for i := 0; i < y; i++ {
+		for _, length := range bikramSambatMonthLengths[i] {
+			days += length
+		}
+	}
+	for m := 0; m < int(d.Month)-1; m++ {
+		days += bikramSambatMonthLengths[y][m]
+	}
+	return days + d.Day - 1, true
+}
+
+func bikramSambatFromDays(days int) (dates.Date, bool) {
+	remaining := days - gregorianToDays(bikramSambatEpoch)
+	if remaining < 0 {
+		return dates.ZeroDate, false
+	}
+
+	for y, lengths := range bikramSambatMonthLengths {
+		for m, length := range lengths {
+			if remaining < length {
+				return dates.NewDate(bikramSambatFirstYear+y, m+1, remaining+1), true
+			}
+			remaining -= length
+		}
+	}
+	return dates.ZeroDate, false
+}
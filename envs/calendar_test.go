@@ -0,0 +1,131 @@
+package envs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+	"github.com/nyaruka/goflow/envs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarConversion(t *testing.T) {
+	tcs := []struct {
+		calendar  envs.Calendar
+		gregorian dates.Date
+		converted dates.Date
+	}{
+		{envs.CalendarGregorian, dates.NewDate(2018, 4, 11), dates.NewDate(2018, 4, 11)},
+
+		{envs.CalendarEthiopian, dates.NewDate(2018, 4, 11), dates.NewDate(2010, 8, 3)},
+		{envs.CalendarEthiopian, dates.NewDate(2000, 1, 1), dates.NewDate(1992, 4, 22)},
+		{envs.CalendarEthiopian, dates.NewDate(2018, 9, 11), dates.NewDate(2011, 1, 1)},  // Enkutatash
+		{envs.CalendarEthiopian, dates.NewDate(2018, 9, 27), dates.NewDate(2011, 1, 17)}, // Meskel
+		{envs.CalendarEthiopian, dates.NewDate(2019, 1, 7), dates.NewDate(2011, 4, 29)},  // Genna
+		{envs.CalendarEthiopian, dates.NewDate(2019, 1, 19), dates.NewDate(2011, 5, 11)}, // Timkat
+		{envs.CalendarEthiopian, dates.NewDate(2019, 9, 11), dates.NewDate(2011, 13, 6)}, // leap day
+		{envs.CalendarEthiopian, dates.NewDate(2019, 9, 12), dates.NewDate(2012, 1, 1)},
+		{envs.CalendarEthiopian, dates.NewDate(2023, 9, 12), dates.NewDate(2016, 1, 1)},
+
+		{envs.CalendarBikramSambat, dates.NewDate(1943, 4, 14), dates.NewDate(2000, 1, 1)},
+		{envs.CalendarBikramSambat, dates.NewDate(2006, 4, 24), dates.NewDate(2063, 1, 11)}, // Loktantra Diwas
+		{envs.CalendarBikramSambat, dates.NewDate(2008, 5, 28), dates.NewDate(2065, 2, 15)}, // Republic Day
+		{envs.CalendarBikramSambat, dates.NewDate(2013, 4, 14), dates.NewDate(2070, 1, 1)},
+		{envs.CalendarBikramSambat, dates.NewDate(2015, 9, 20), dates.NewDate(2072, 6, 3)}, // Constitution Day
+		{envs.CalendarBikramSambat, dates.NewDate(2018, 4, 11), dates.NewDate(2074, 12, 28)},
+		{envs.CalendarBikramSambat, dates.NewDate(2020, 4, 13), dates.NewDate(2077, 1, 1)},
+		{envs.CalendarBikramSambat, dates.NewDate(2024, 4, 13), dates.NewDate(2081, 1, 1)},
+		{envs.CalendarBikramSambat, dates.NewDate(2025, 4, 14), dates.NewDate(2082, 1, 1)},
+
+		{envs.CalendarHijri, dates.NewDate(622, 7, 19), dates.NewDate(1, 1, 1)},
+		{envs.CalendarHijri, dates.NewDate(2018, 4, 11), dates.NewDate(1439, 7, 25)},
+		{envs.CalendarHijri, dates.NewDate(2018, 9, 11), dates.NewDate(1439, 12, 30)}, // leap day
+		{envs.CalendarHijri, dates.NewDate(2020, 4, 24), dates.NewDate(1441, 9, 1)},
+		{envs.CalendarHijri, dates.NewDate(2020, 5, 24), dates.NewDate(1441, 10, 1)},
+		{envs.CalendarHijri, dates.NewDate(2023, 3, 23), dates.NewDate(1444, 9, 1)},
+	}
+
+	for _, tc := range tcs {
+		converted, err := tc.calendar.FromGregorian(tc.gregorian)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.converted, converted, "conversion mismatch for %s to %s", tc.gregorian, tc.calendar)
+
+		gregorian, err := tc.calendar.ToGregorian(tc.converted)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.gregorian, gregorian, "conversion mismatch for %s from %s", tc.converted, tc.calendar)
+	}
+
+	// every day over a century should round-trip
+	for _, calendar := range []envs.Calendar{envs.CalendarEthiopian, envs.CalendarBikramSambat, envs.CalendarHijri} {
+		day := time.Date(1944, 1, 1, 0, 0, 0, 0, time.UTC)
+		for day.Year() < 2030 {
+			gregorian := dates.ExtractDate(day)
+
+			converted, err := calendar.FromGregorian(gregorian)
+			require.NoError(t, err)
+			back, err := calendar.ToGregorian(converted)
+			require.NoError(t, err)
+			require.Equal(t, gregorian, back, "round trip mismatch for %s in %s", gregorian, calendar)
+
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+
+	// invalid dates
+	_, err := envs.CalendarGregorian.ToGregorian(dates.NewDate(2018, 13, 1))
+	assert.EqualError(t, err, "2018-13-01 isn't a valid date in the gregorian calendar")
+	_, err = envs.CalendarEthiopian.ToGregorian(dates.NewDate(2010, 13, 6))
+	assert.EqualError(t, err, "2010-13-06 isn't a valid date in the ethiopian calendar")
+	_, err = envs.CalendarBikramSambat.ToGregorian(dates.NewDate(2074, 12, 31))
+	assert.EqualError(t, err, "2074-12-31 isn't a valid date in the bikram_sambat calendar")
+	_, err = envs.CalendarBikramSambat.ToGregorian(dates.NewDate(2100, 1, 1))
+	assert.EqualError(t, err, "2100-01-01 isn't a valid date in the bikram_sambat calendar")
+	_, err = envs.CalendarHijri.ToGregorian(dates.NewDate(1439, 2, 30))
+	assert.EqualError(t, err, "1439-02-30 isn't a valid date in the hijri calendar")
+
+	// dates outside of the range of our Bikram Sambat data
+	_, err = envs.CalendarBikramSambat.FromGregorian(dates.NewDate(1900, 1, 1))
+	assert.EqualError(t, err, "1900-01-01 can't be represented in the bikram_sambat calendar")
+	_, err = envs.CalendarBikramSambat.FromGregorian(dates.NewDate(2050, 1, 1))
+	assert.EqualError(t, err, "2050-01-01 can't be represented in the bikram_sambat calendar")
+
+	assert.True(t, envs.CalendarHijri.IsValid())
+	assert.False(t, envs.Calendar("julian").IsValid())
+}
+
+func TestCalendarFormat(t *testing.T) {
+	dt := time.Date(2018, 4, 11, 13, 24, 30, 123456000, time.UTC)
+
+	tcs := []struct {
+		calendar  envs.Calendar
+		layout    string
+		formatted string
+	}{
+		{envs.CalendarGregorian, "EEEE, D MMMM YYYY tt:mm", "Wednesday, 11 April 2018 13:24"},
+		{envs.CalendarEthiopian, "EEEE, D MMMM YYYY tt:mm", "Wednesday, 3 Miyazya 2010 13:24"},
+		{envs.CalendarEthiopian, "DD/MM/YY", "03/08/10"},
+		{envs.CalendarEthiopian, "D MMM", "3 Miy"},
+		{envs.CalendarBikramSambat, "YYYY-MM-DD h:mm aa", "2074-12-28 1:24 pm"},
+		{envs.CalendarBikramSambat, "D MMMM YYYY", "28 Chaitra 2074"},
+		{envs.CalendarHijri, "D MMMM YYYY", "25 Rajab 1439"},
+		{envs.CalendarHijri, "EEE M/D/YY", "Wed 7/25/39"},
+	}
+
+	for _, tc := range tcs {
+		formatted, err := tc.calendar.Format(dt, tc.layout, "en-US", dates.DateTimeLayouts)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.formatted, formatted, "format mismatch for %s in %s", tc.layout, tc.calendar)
+	}
+
+	// layouts are still validated
+	_, err := envs.CalendarEthiopian.Format(dt, "YYYYY", "en-US", dates.DateTimeLayouts)
+	assert.EqualError(t, err, "'YYYYY' is not valid in a datetime formatting layout")
+	_, err = envs.CalendarEthiopian.Format(dt, "YYYY tt", "en-US", dates.DateOnlyLayouts)
+	assert.EqualError(t, err, "'tt' is not valid in a date formatting layout")
+
+	// can't format dates outside of the range of our Bikram Sambat data
+	_, err = envs.CalendarBikramSambat.Format(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), "YYYY", "en-US", dates.DateTimeLayouts)
+	assert.EqualError(t, err, "1900-01-01 can't be represented in the bikram_sambat calendar")
+}
@@ -0,0 +1,131 @@
+package envs
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/shopspring/decimal"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+func init() {
+	utils.RegisterValidatorAlias("currency", "len=3,alpha", func(validator.FieldError) string {
+		return "is not a valid currency code"
+	})
+}
+
+// Currency is a ISO 4217 currency code, e.g. USD
+type Currency string
+
+// NilCurrency represents our nil, or unknown currency
+var NilCurrency = Currency("")
+
+// CurrencyConverter is used to convert amounts between currencies
+type CurrencyConverter interface {
+	Convert(amount decimal.Decimal, from Currency, to Currency) (decimal.Decimal, error)
+}
+
+type currencyInfo struct {
+	symbol   string
+	decimals int32
+}
+
+// the currencies we know the symbols and decimal places of
+var currencies = map[Currency]currencyInfo{
+	"BDT": {"৳", 2},
+	"BRL": {"R$", 2},
+	"CDF": {"FC", 2},
+	"CNY": {"CN¥", 2},
+	"EUR": {"€", 2},
+	"ETB": {"Br", 2},
+	"GBP": {"£", 2},
+	"GHS": {"GH₵", 2},
+	"IDR": {"Rp", 2},
+	"INR": {"₹", 2},
+	"JPY": {"¥", 0},
+	"KES": {"KSh", 2},
+	"MWK": {"MK", 2},
+	"MXN": {"MX$", 2},
+	"NGN": {"₦", 2},
+	"PHP": {"₱", 2},
+	"PKR": {"Rs", 2},
+	"RWF": {"RF", 0},
+	"TZS": {"TSh", 2},
+	"UGX": {"USh", 0},
+	"USD": {"$", 2},
+	"XAF": {"FCFA", 0},
+	"XOF": {"CFA", 0},
+	"ZAR": {"R", 2},
+	"ZMW": {"ZK", 2},
+}
+
+// languages which write currency symbols after amounts, e.g. 10,50 €
+var symbolAfterLanguages = map[Language]bool{
+	"deu": true, "fra": true, "ita": true, "nld": true, "rus": true, "spa": true,
+}
+
+// IsKnown returns whether we know the symbol and decimal places of this currency
+func (c Currency) IsKnown() bool {
+	_, known := currencies[c]
+	return known
+}
+
+// Symbol returns the symbol of this currency, e.g. $ for USD, or the code itself if we don't know it
+func (c Currency) Symbol() string {
+	if info, known := currencies[c]; known {
+		return info.symbol
+	}
+	return string(c)
+}
+
+// Decimals returns the number of decimal places used for this currency
+func (c Currency) Decimals() int32 {
+	if info, known := currencies[c]; known {
+		return info.decimals
+	}
+	return 2
+}
+
+// Place adds the symbol of this currency to the given formatted amount as is appropriate for the given locale,
+// e.g. $10.50 or 10,50 €
+func (c Currency) Place(amount string, locale Locale) string {
+	symbol := c.Symbol()
+
+	if symbolAfterLanguages[locale.Language] {
+		return amount + " " + symbol
+	}
+
+	// alphabetic symbols like KSh need a space between them and the amount
+	last, _ := utf8.DecodeLastRuneInString(symbol)
+	if unicode.IsLetter(last) {
+		return symbol + " " + amount
+	}
+
+	return symbol + amount
+}
+
+// CurrencyFromSymbol returns the currency with the given symbol or code (case-insensitive)
+func CurrencyFromSymbol(symbol string) Currency {
+	if c := Currency(strings.ToUpper(symbol)); c.IsKnown() {
+		return c
+	}
+
+	for c, info := range currencies {
+		if strings.EqualFold(info.symbol, symbol) {
+			return c
+		}
+	}
+	return NilCurrency
+}
+
+// CurrencySymbols returns the codes and symbols of all the currencies we know
+func CurrencySymbols() []string {
+	symbols := make([]string, 0, len(currencies)*2)
+	for c, info := range currencies {
+		symbols = append(symbols, string(c), info.symbol)
+	}
+	return symbols
+}
@@ -0,0 +1,38 @@
+package envs_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/envs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrency(t *testing.T) {
+	assert.True(t, envs.Currency("USD").IsKnown())
+	assert.False(t, envs.Currency("XYZ").IsKnown())
+
+	assert.Equal(t, "$", envs.Currency("USD").Symbol())
+	assert.Equal(t, "KSh", envs.Currency("KES").Symbol())
+	assert.Equal(t, "XYZ", envs.Currency("XYZ").Symbol())
+
+	assert.Equal(t, int32(2), envs.Currency("USD").Decimals())
+	assert.Equal(t, int32(0), envs.Currency("RWF").Decimals())
+	assert.Equal(t, int32(2), envs.Currency("XYZ").Decimals())
+
+	eng := envs.NewLocale("eng", "US")
+	fra := envs.NewLocale("fra", "CD")
+
+	assert.Equal(t, "$10.50", envs.Currency("USD").Place("10.50", eng))
+	assert.Equal(t, "10,50 $", envs.Currency("USD").Place("10,50", fra))
+	assert.Equal(t, "KSh 100.00", envs.Currency("KES").Place("100.00", eng))
+	assert.Equal(t, "XYZ 100.00", envs.Currency("XYZ").Place("100.00", eng))
+
+	assert.Equal(t, envs.Currency("USD"), envs.CurrencyFromSymbol("usd"))
+	assert.Equal(t, envs.Currency("USD"), envs.CurrencyFromSymbol("$"))
+	assert.Equal(t, envs.Currency("KES"), envs.CurrencyFromSymbol("ksh"))
+	assert.Equal(t, envs.NilCurrency, envs.CurrencyFromSymbol("dollars"))
+
+	assert.Contains(t, envs.CurrencySymbols(), "RWF")
+	assert.Contains(t, envs.CurrencySymbols(), "€")
+}
@@ -71,37 +71,36 @@ var isoFormats = []string{iso8601Format, iso8601NoSecondsFormat}
 // ZeroDateTime is our uninitialized datetime value
 var ZeroDateTime = time.Time{}
 
-func dateFromFormats(currentYear int, pattern *regexp.Regexp, d int, m int, y int, str string) (dates.Date, string, error) {
+func dateFromFormats(calendar Calendar, currentYear int, pattern *regexp.Regexp, d int, m int, y int, str string) (dates.Date, string, error) {
 
 	matches := pattern.FindAllStringSubmatchIndex(str, -1)
 	for _, match := range matches {
 		groups := utils.StringSlices(str, match)
 
-		// does our day look believable?
 		day, _ := strconv.Atoi(groups[d])
-		if day == 0 || day > 31 {
-			continue
-		}
 		month, _ := strconv.Atoi(groups[m])
-		if month == 0 || month > 12 {
-			continue
-		}
-
 		year, _ := strconv.Atoi(groups[y])
 
 		// convert to four digit year if necessary
 		if len(groups[y]) == 2 {
-			if year > currentYear%1000 {
-				year += 1900
+			century := currentYear - currentYear%100
+			if year > currentYear%100 {
+				year += century - 100
 			} else {
-				year += 2000
+				year += century
 			}
 		}
 
+		// does our date look believable in this calendar?
+		date, err := calendar.ToGregorian(dates.NewDate(year, month, day))
+		if err != nil {
+			continue
+		}
+
 		remainder := str[match[1]:]
 
 		// looks believable, go for it
-		return dates.NewDate(year, month, day), remainder, nil
+		return date, remainder, nil
 	}
 
 	return dates.ZeroDate, str, errors.Errorf("string '%s' couldn't be parsed as a date", str)
@@ -164,16 +163,20 @@ func parseDate(env Environment, str string) (dates.Date, string, error) {
 		return dates.ExtractDate(asISO), str[len(iso8601DateOnlyFormat):], nil
 	}
 
-	// otherwise, try to parse according to their env settings
-	currentYear := dates.Now().Year()
+	// otherwise, try to parse according to their env settings, in their calendar
+	calendar := env.Calendar()
+	today, err := calendar.FromGregorian(dates.ExtractDate(dates.Now()))
+	if err != nil {
+		return dates.ZeroDate, "", err
+	}
 
 	switch env.DateFormat() {
 	case DateFormatYearMonthDay:
-		return dateFromFormats(currentYear, patternYearMonthDay, 3, 2, 1, str)
+		return dateFromFormats(calendar, today.Year, patternYearMonthDay, 3, 2, 1, str)
 	case DateFormatDayMonthYear:
-		return dateFromFormats(currentYear, patternDayMonthYear, 1, 2, 3, str)
+		return dateFromFormats(calendar, today.Year, patternDayMonthYear, 1, 2, 3, str)
 	case DateFormatMonthDayYear:
-		return dateFromFormats(currentYear, patternMonthDayYear, 2, 1, 3, str)
+		return dateFromFormats(calendar, today.Year, patternMonthDayYear, 2, 1, 3, str)
 	}
 
 	return dates.ZeroDate, "", errors.Errorf("unknown date format: %s", env.DateFormat())
@@ -152,6 +152,55 @@ func TestDateFromString(t *testing.T) {
 	}
 }
 
+func TestDateFromStringWithCalendar(t *testing.T) {
+	dates.SetNowSource(dates.NewFixedNowSource(time.Date(2018, 4, 11, 13, 24, 30, 123456789, time.UTC)))
+	defer dates.SetNowSource(dates.DefaultNowSource)
+
+	testCases := []struct {
+		calendar envs.Calendar
+		value    string
+		expected dates.Date
+		hasError bool
+	}{
+		{envs.CalendarEthiopian, "it's 03-08-2010 ok", dates.NewDate(2018, 4, 11), false},
+		{envs.CalendarEthiopian, "it's 3/8/10 ok", dates.NewDate(2018, 4, 11), false},
+		{envs.CalendarEthiopian, "6-13-2011", dates.NewDate(2019, 9, 11), false},
+		{envs.CalendarBikramSambat, "it's 28-12-2074 ok", dates.NewDate(2018, 4, 11), false},
+		{envs.CalendarBikramSambat, "32-3-2075", dates.NewDate(2018, 7, 16), false},
+		{envs.CalendarHijri, "it's 25-07-1439 ok", dates.NewDate(2018, 4, 11), false},
+		{envs.CalendarHijri, "25-7-39", dates.NewDate(2018, 4, 11), false},
+
+		// ISO dates are always Gregorian
+		{envs.CalendarEthiopian, "2018-04-11", dates.NewDate(2018, 4, 11), false},
+
+		// only the first believable date is used
+		{envs.CalendarEthiopian, "6-13-2010 or 5-13-2010", dates.NewDate(2018, 9, 10), false},
+
+		{envs.CalendarEthiopian, "6-13-2010", dates.ZeroDate, true},
+		{envs.CalendarBikramSambat, "31-12-2074", dates.ZeroDate, true},
+		{envs.CalendarBikramSambat, "1-1-2100", dates.ZeroDate, true},
+		{envs.CalendarHijri, "30-2-1439", dates.ZeroDate, true},
+	}
+
+	for _, tc := range testCases {
+		env := envs.NewBuilder().WithDateFormat(envs.DateFormatDayMonthYear).WithCalendar(tc.calendar).Build()
+		parsed, err := envs.DateFromString(env, tc.value)
+
+		if tc.hasError {
+			assert.Error(t, err, "expected error for input %s in %s", tc.value, tc.calendar)
+		} else {
+			require.NoError(t, err, "error parsing date %s in %s", tc.value, tc.calendar)
+			assert.Equal(t, tc.expected, parsed, "mismatch for date input %s in %s", tc.value, tc.calendar)
+		}
+	}
+
+	// datetimes are also parsed in the calendar
+	env := envs.NewBuilder().WithDateFormat(envs.DateFormatDayMonthYear).WithCalendar(envs.CalendarEthiopian).Build()
+	parsed, err := envs.DateTimeFromString(env, "03-08-2010 10:30", false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 4, 11, 10, 30, 0, 0, time.UTC), parsed)
+}
+
 func TestTimeFromString(t *testing.T) {
 	testCases := []struct {
 		value    string
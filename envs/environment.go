@@ -16,6 +16,20 @@ const (
 	RedactionPolicyURNs RedactionPolicy = "urns"
 )
 
+// ChannelSelection is the strategy used to select a channel for sending to a URN which doesn't have a channel. The
+// hashed strategy spreads URNs evenly across channels using a hash of each URN, so that a URN always gets the same
+// channel without that choice having to be stored, and weighted does the same in proportion to channel weights.
+type ChannelSelection string
+
+// the different channel selection strategies
+const (
+	ChannelSelectionDefault  ChannelSelection = ""
+	ChannelSelectionHashed   ChannelSelection = "hashed"
+	ChannelSelectionWeighted ChannelSelection = "weighted"
+	ChannelSelectionCheapest ChannelSelection = "cheapest"
+	ChannelSelectionSticky   ChannelSelection = "sticky"
+)
+
 // NumberFormat describes how numbers should be parsed and formatted
 type NumberFormat struct {
 	DecimalSymbol       string `json:"decimal_symbol"`
@@ -30,17 +44,20 @@ var DefaultNumberFormat = &NumberFormat{DecimalSymbol: `.`, DigitGroupingSymbol:
 type Environment interface {
 	DateFormat() DateFormat
 	TimeFormat() TimeFormat
+	Calendar() Calendar
 	Timezone() *time.Location
 	AllowedLanguages() []Language
 	DefaultCountry() Country
 	NumberFormat() *NumberFormat
 	RedactionPolicy() RedactionPolicy
 	MaxValueLength() int
+	ChannelSelection() ChannelSelection
 
 	DefaultLanguage() Language
 	DefaultLocale() Locale
 
 	LocationResolver() LocationResolver
+	CurrencyConverter() CurrencyConverter
 
 	// Convenience method to get the current time in the env timezone
 	Now() time.Time
@@ -51,22 +68,26 @@ type Environment interface {
 type environment struct {
 	dateFormat       DateFormat
 	timeFormat       TimeFormat
+	calendar         Calendar
 	timezone         *time.Location
 	allowedLanguages []Language
 	defaultCountry   Country
 	numberFormat     *NumberFormat
 	redactionPolicy  RedactionPolicy
 	maxValueLength   int
+	channelSelection ChannelSelection
 }
 
-func (e *environment) DateFormat() DateFormat           { return e.dateFormat }
-func (e *environment) TimeFormat() TimeFormat           { return e.timeFormat }
-func (e *environment) Timezone() *time.Location         { return e.timezone }
-func (e *environment) AllowedLanguages() []Language     { return e.allowedLanguages }
-func (e *environment) DefaultCountry() Country          { return e.defaultCountry }
-func (e *environment) NumberFormat() *NumberFormat      { return e.numberFormat }
-func (e *environment) RedactionPolicy() RedactionPolicy { return e.redactionPolicy }
-func (e *environment) MaxValueLength() int              { return e.maxValueLength }
+func (e *environment) DateFormat() DateFormat             { return e.dateFormat }
+func (e *environment) TimeFormat() TimeFormat             { return e.timeFormat }
+func (e *environment) Calendar() Calendar                 { return e.calendar }
+func (e *environment) Timezone() *time.Location           { return e.timezone }
+func (e *environment) AllowedLanguages() []Language       { return e.allowedLanguages }
+func (e *environment) DefaultCountry() Country            { return e.defaultCountry }
+func (e *environment) NumberFormat() *NumberFormat        { return e.numberFormat }
+func (e *environment) RedactionPolicy() RedactionPolicy   { return e.redactionPolicy }
+func (e *environment) MaxValueLength() int                { return e.maxValueLength }
+func (e *environment) ChannelSelection() ChannelSelection { return e.channelSelection }
 
 // DefaultLanguage is the first allowed language
 func (e *environment) DefaultLanguage() Language {
@@ -81,7 +102,8 @@ func (e *environment) DefaultLocale() Locale {
 	return NewLocale(e.DefaultLanguage(), e.DefaultCountry())
 }
 
-func (e *environment) LocationResolver() LocationResolver { return nil }
+func (e *environment) LocationResolver() LocationResolver   { return nil }
+func (e *environment) CurrencyConverter() CurrencyConverter { return nil }
 
 // Now gets the current time in the eonvironment's timezone
 func (e *environment) Now() time.Time { return dates.Now().In(e.Timezone()) }
@@ -98,14 +120,16 @@ func (e *environment) Equal(other Environment) bool {
 //------------------------------------------------------------------------------------------
 
 type envEnvelope struct {
-	DateFormat       DateFormat      `json:"date_format" validate:"date_format"`
-	TimeFormat       TimeFormat      `json:"time_format" validate:"time_format"`
-	Timezone         string          `json:"timezone"`
-	AllowedLanguages []Language      `json:"allowed_languages,omitempty" validate:"omitempty,dive,language"`
-	NumberFormat     *NumberFormat   `json:"number_format,omitempty"`
-	DefaultCountry   Country         `json:"default_country,omitempty" validate:"omitempty,country"`
-	RedactionPolicy  RedactionPolicy `json:"redaction_policy" validate:"omitempty,eq=none|eq=urns"`
-	MaxValuelength   int             `json:"max_value_length"`
+	DateFormat       DateFormat       `json:"date_format" validate:"date_format"`
+	TimeFormat       TimeFormat       `json:"time_format" validate:"time_format"`
+	Calendar         Calendar         `json:"calendar,omitempty" validate:"omitempty,calendar"`
+	Timezone         string           `json:"timezone"`
+	AllowedLanguages []Language       `json:"allowed_languages,omitempty" validate:"omitempty,dive,language"`
+	NumberFormat     *NumberFormat    `json:"number_format,omitempty"`
+	DefaultCountry   Country          `json:"default_country,omitempty" validate:"omitempty,country"`
+	RedactionPolicy  RedactionPolicy  `json:"redaction_policy" validate:"omitempty,eq=none|eq=urns"`
+	MaxValuelength   int              `json:"max_value_length"`
+	ChannelSelection ChannelSelection `json:"channel_selection,omitempty" validate:"omitempty,eq=hashed|eq=weighted|eq=cheapest|eq=sticky"`
 }
 
 // ReadEnvironment reads an environment from the given JSON
@@ -120,11 +144,15 @@ func ReadEnvironment(data json.RawMessage) (Environment, error) {
 
 	env.dateFormat = envelope.DateFormat
 	env.timeFormat = envelope.TimeFormat
+	if envelope.Calendar != "" {
+		env.calendar = envelope.Calendar
+	}
 	env.allowedLanguages = envelope.AllowedLanguages
 	env.defaultCountry = envelope.DefaultCountry
 	env.numberFormat = envelope.NumberFormat
 	env.redactionPolicy = envelope.RedactionPolicy
 	env.maxValueLength = envelope.MaxValuelength
+	env.channelSelection = envelope.ChannelSelection
 
 	tz, err := time.LoadLocation(envelope.Timezone)
 	if err != nil {
@@ -136,7 +164,7 @@ func ReadEnvironment(data json.RawMessage) (Environment, error) {
 }
 
 func (e *environment) toEnvelope() *envEnvelope {
-	return &envEnvelope{
+	envelope := &envEnvelope{
 		DateFormat:       e.dateFormat,
 		TimeFormat:       e.timeFormat,
 		Timezone:         e.timezone.String(),
@@ -145,7 +173,14 @@ func (e *environment) toEnvelope() *envEnvelope {
 		NumberFormat:     e.numberFormat,
 		RedactionPolicy:  e.redactionPolicy,
 		MaxValuelength:   e.maxValueLength,
+		ChannelSelection: e.channelSelection,
+	}
+
+	// the Gregorian calendar is the default so only write the calendar if it's something else
+	if e.calendar != CalendarGregorian {
+		envelope.Calendar = e.calendar
 	}
+	return envelope
 }
 
 // MarshalJSON marshals this environment into JSON
@@ -168,6 +203,7 @@ func NewBuilder() *EnvironmentBuilder {
 		env: &environment{
 			dateFormat:       DateFormatYearMonthDay,
 			timeFormat:       TimeFormatHourMinute,
+			calendar:         CalendarGregorian,
 			timezone:         time.UTC,
 			allowedLanguages: nil,
 			defaultCountry:   NilCountry,
@@ -190,6 +226,12 @@ func (b *EnvironmentBuilder) WithTimeFormat(timeFormat TimeFormat) *EnvironmentB
 	return b
 }
 
+// WithCalendar sets the calendar used to parse and format dates
+func (b *EnvironmentBuilder) WithCalendar(calendar Calendar) *EnvironmentBuilder {
+	b.env.calendar = calendar
+	return b
+}
+
 func (b *EnvironmentBuilder) WithTimezone(timezone *time.Location) *EnvironmentBuilder {
 	b.env.timezone = timezone
 	return b
@@ -220,5 +262,11 @@ func (b *EnvironmentBuilder) WithMaxValueLength(maxValueLength int) *Environment
 	return b
 }
 
+// WithChannelSelection sets the strategy used to select channels for URNs without a channel
+func (b *EnvironmentBuilder) WithChannelSelection(channelSelection ChannelSelection) *EnvironmentBuilder {
+	b.env.channelSelection = channelSelection
+	return b
+}
+
 // Build returns the final environment
 func (b *EnvironmentBuilder) Build() Environment { return b.env }
@@ -32,6 +32,14 @@ func TestEnvironmentMarshaling(t *testing.T) {
 	_, err = envs.ReadEnvironment(json.RawMessage(`{"date_format": "DD-MM-YYYY", "time_format": "tttttt", "default_country": "Narnia"}`))
 	assert.Error(t, err)
 
+	// can't create with invalid calendar
+	_, err = envs.ReadEnvironment(json.RawMessage(`{"date_format": "DD-MM-YYYY", "time_format": "tt:mm", "calendar": "julian"}`))
+	assert.EqualError(t, err, "field 'calendar' is not a valid calendar")
+
+	// can't create with invalid channel selection
+	_, err = envs.ReadEnvironment(json.RawMessage(`{"date_format": "DD-MM-YYYY", "time_format": "tt:mm", "channel_selection": "random"}`))
+	assert.Error(t, err)
+
 	// can't create with invalid timzeone
 	_, err = envs.ReadEnvironment(json.RawMessage(`{"date_format": "DD-MM-YYYY", "time_format": "tttttt", "timezone": "Cuenca"}`))
 	assert.Error(t, err)
@@ -41,11 +49,13 @@ func TestEnvironmentMarshaling(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, envs.DateFormatYearMonthDay, env.DateFormat())
 	assert.Equal(t, envs.TimeFormatHourMinute, env.TimeFormat())
+	assert.Equal(t, envs.CalendarGregorian, env.Calendar())
 	assert.Equal(t, envs.DefaultNumberFormat, env.NumberFormat())
 	assert.Equal(t, envs.NilLanguage, env.DefaultLanguage())
 	assert.Nil(t, env.AllowedLanguages())
 	assert.Equal(t, envs.NilCountry, env.DefaultCountry())
 	assert.Equal(t, 640, env.MaxValueLength())
+	assert.Equal(t, envs.ChannelSelectionDefault, env.ChannelSelection())
 	assert.Nil(t, env.LocationResolver())
 
 	// can create with valid values
@@ -69,6 +79,24 @@ func TestEnvironmentMarshaling(t *testing.T) {
 	data, err := jsonx.Marshal(env)
 	require.NoError(t, err)
 	assert.Equal(t, string(data), `{"date_format":"DD-MM-YYYY","time_format":"tt:mm:ss","timezone":"Africa/Kigali","allowed_languages":["eng","fra"],"number_format":{"decimal_symbol":".","digit_grouping_symbol":","},"default_country":"RW","redaction_policy":"none","max_value_length":640}`)
+
+	// can create with a channel selection strategy
+	env, err = envs.ReadEnvironment(json.RawMessage(`{"date_format": "DD-MM-YYYY", "time_format": "tt:mm", "timezone": "Africa/Kigali", "channel_selection": "hashed"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, envs.ChannelSelectionHashed, env.ChannelSelection())
+
+	data, err = jsonx.Marshal(env)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), `{"date_format":"DD-MM-YYYY","time_format":"tt:mm","timezone":"Africa/Kigali","number_format":{"decimal_symbol":".","digit_grouping_symbol":","},"redaction_policy":"none","max_value_length":640,"channel_selection":"hashed"}`)
+
+	// can create with a non-Gregorian calendar
+	env, err = envs.ReadEnvironment(json.RawMessage(`{"date_format": "DD-MM-YYYY", "time_format": "tt:mm", "calendar": "ethiopian", "timezone": "Africa/Addis_Ababa"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, envs.CalendarEthiopian, env.Calendar())
+
+	data, err = jsonx.Marshal(env)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), `{"date_format":"DD-MM-YYYY","time_format":"tt:mm","calendar":"ethiopian","timezone":"Africa/Addis_Ababa","number_format":{"decimal_symbol":".","digit_grouping_symbol":","},"redaction_policy":"none","max_value_length":640}`)
 }
 
 func TestEnvironmentEqual(t *testing.T) {
@@ -107,6 +135,7 @@ func TestEnvironmentBuilder(t *testing.T) {
 		WithNumberFormat(&envs.NumberFormat{DecimalSymbol: "'"}).
 		WithRedactionPolicy(envs.RedactionPolicyURNs).
 		WithMaxValueLength(1024).
+		WithChannelSelection(envs.ChannelSelectionCheapest).
 		Build()
 
 	assert.Equal(t, envs.DateFormatDayMonthYear, env.DateFormat())
@@ -117,5 +146,6 @@ func TestEnvironmentBuilder(t *testing.T) {
 	assert.Equal(t, &envs.NumberFormat{DecimalSymbol: "'"}, env.NumberFormat())
 	assert.Equal(t, envs.RedactionPolicyURNs, env.RedactionPolicy())
 	assert.Equal(t, 1024, env.MaxValueLength())
+	assert.Equal(t, envs.ChannelSelectionCheapest, env.ChannelSelection())
 	assert.Nil(t, env.LocationResolver())
 }
@@ -0,0 +1,196 @@
+package excellent
+
+import (
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/functions"
+	"github.com/nyaruka/goflow/excellent/operators"
+	"github.com/nyaruka/goflow/excellent/types"
+)
+
+// a node in a compiled expression. Nodes are immutable so can be evaluated concurrently.
+type node interface {
+	// evaluates this node, adding the evaluation of any child nodes to the given trace if it's non-nil
+	evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue
+
+	traceType() TraceType
+	source() string
+}
+
+// evaluates the given node, recording it as a child of the given trace if there is one
+func evaluateNode(n node, env envs.Environment, context *types.XObject, parent *Trace) types.XValue {
+	if parent == nil {
+		return n.evaluate(env, context, nil)
+	}
+
+	trace := newTrace(n.traceType(), n.source())
+	parent.addChild(trace)
+
+	value := n.evaluate(env, context, trace)
+	trace.setResult(value)
+	return value
+}
+
+type baseNode struct {
+	text string
+}
+
+func (n *baseNode) source() string { return n.text }
+
+//------------------------------------------------------------------------------------------
+// Literals
+//------------------------------------------------------------------------------------------
+
+// a literal like "abc", 123, true or null
+type literal struct {
+	baseNode
+	value types.XValue
+}
+
+func (n *literal) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	return n.value
+}
+
+func (n *literal) traceType() TraceType { return TraceTypeLiteral }
+
+//------------------------------------------------------------------------------------------
+// Lookups
+//------------------------------------------------------------------------------------------
+
+// an identifier which is a function name or root variable in the context
+type contextReference struct {
+	baseNode
+	name string
+}
+
+func (n *contextReference) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	// first of all try to look this up as a function
+	function := functions.Lookup(n.name)
+	if function != nil {
+		return function
+	}
+
+	value, exists := context.Get(n.name)
+	if !exists {
+		return types.NewXErrorf("context has no property '%s'", n.name)
+	}
+
+	return value
+}
+
+func (n *contextReference) traceType() TraceType { return TraceTypeLookup }
+
+// a property lookup like foo.bar
+type dotLookup struct {
+	baseNode
+	container node
+	lookup    types.XText
+}
+
+func (n *dotLookup) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	container := evaluateNode(n.container, env, context, trace)
+	if types.IsXError(container) {
+		return container
+	}
+
+	return resolveLookup(env, container, n.lookup, lookupNotationDot)
+}
+
+func (n *dotLookup) traceType() TraceType { return TraceTypeLookup }
+
+// a lookup like foo[5] or foo["key with spaces"]
+type arrayLookup struct {
+	baseNode
+	container node
+	lookup    node
+}
+
+func (n *arrayLookup) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	container := evaluateNode(n.container, env, context, trace)
+	if types.IsXError(container) {
+		return container
+	}
+
+	lookup := evaluateNode(n.lookup, env, context, trace)
+
+	return resolveLookup(env, container, lookup, lookupNotationArray)
+}
+
+func (n *arrayLookup) traceType() TraceType { return TraceTypeLookup }
+
+//------------------------------------------------------------------------------------------
+// Function calls
+//------------------------------------------------------------------------------------------
+
+// a function call like TITLE(foo.bar)
+type functionCall struct {
+	baseNode
+	function     node
+	functionText string
+	name         string
+	params       []node
+}
+
+func (n *functionCall) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	// the function itself isn't interesting so only the arguments are traced as children of the call
+	function := evaluateNode(n.function, env, context, nil)
+	if types.IsXError(function) {
+		return function
+	}
+
+	asFunction, isFunction := function.(types.XFunction)
+	if !isFunction {
+		return types.NewXErrorf("%s is not a function", n.functionText)
+	}
+
+	if trace != nil {
+		trace.Function = n.name
+	}
+
+	var params []types.XValue
+	if n.params != nil {
+		params = make([]types.XValue, len(n.params))
+		for i := range n.params {
+			params[i] = evaluateNode(n.params[i], env, context, trace)
+		}
+	}
+
+	return functions.Call(env, n.name, asFunction, params)
+}
+
+func (n *functionCall) traceType() TraceType { return TraceTypeFunction }
+
+//------------------------------------------------------------------------------------------
+// Operators
+//------------------------------------------------------------------------------------------
+
+// a unary operation like -5
+type unaryOperation struct {
+	baseNode
+	operator operators.UnaryOperator
+	arg      node
+}
+
+func (n *unaryOperation) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	arg := evaluateNode(n.arg, env, context, trace)
+
+	return n.operator(env, arg)
+}
+
+func (n *unaryOperation) traceType() TraceType { return TraceTypeOperator }
+
+// a binary operation like 5 + 5 or "foo" & "bar"
+type binaryOperation struct {
+	baseNode
+	operator operators.BinaryOperator
+	arg1     node
+	arg2     node
+}
+
+func (n *binaryOperation) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	arg1 := evaluateNode(n.arg1, env, context, trace)
+	arg2 := evaluateNode(n.arg2, env, context, trace)
+
+	return n.operator(env, arg1, arg2)
+}
+
+func (n *binaryOperation) traceType() TraceType { return TraceTypeOperator }
@@ -0,0 +1,84 @@
+package excellent
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// TemplateCache is a concurrency-safe LRU cache of compiled templates
+type TemplateCache struct {
+	size int
+
+	mutex   sync.Mutex
+	entries *list.List
+	index   map[templateCacheKey]*list.Element
+}
+
+// compiled templates depend on the allowed top-levels so they are part of the key
+type templateCacheKey struct {
+	template  string
+	topLevels string
+}
+
+type templateCacheEntry struct {
+	key      templateCacheKey
+	template *Template
+}
+
+// NewTemplateCache creates a new template cache which holds up to the given number of templates. A size of zero
+// disables caching so that templates are compiled every time.
+func NewTemplateCache(size int) *TemplateCache {
+	return &TemplateCache{
+		size:    size,
+		entries: list.New(),
+		index:   make(map[templateCacheKey]*list.Element),
+	}
+}
+
+// Get returns the compiled version of the given template, compiling it if it isn't already in the cache
+func (c *TemplateCache) Get(template string, allowedTopLevels []string) *Template {
+	if c.size <= 0 {
+		return CompileTemplate(template, allowedTopLevels)
+	}
+
+	key := templateCacheKey{template: template, topLevels: strings.Join(allowedTopLevels, ",")}
+
+	c.mutex.Lock()
+	if elem, exists := c.index[key]; exists {
+		c.entries.MoveToFront(elem)
+		compiled := elem.Value.(*templateCacheEntry).template
+		c.mutex.Unlock()
+		return compiled
+	}
+	c.mutex.Unlock()
+
+	// compile outside of the lock as that's the slow part.. if another goroutine beats us to it, we use its version
+	compiled := CompileTemplate(template, allowedTopLevels)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, exists := c.index[key]; exists {
+		c.entries.MoveToFront(elem)
+		return elem.Value.(*templateCacheEntry).template
+	}
+
+	c.index[key] = c.entries.PushFront(&templateCacheEntry{key: key, template: compiled})
+
+	if c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(*templateCacheEntry).key)
+	}
+
+	return compiled
+}
+
+// Len returns the number of templates in the cache
+func (c *TemplateCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.entries.Len()
+}
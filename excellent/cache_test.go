@@ -0,0 +1,94 @@
+package excellent_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileTemplate(t *testing.T) {
+	env := envs.NewBuilder().Build()
+	template := excellent.CompileTemplate(`Hi @contact.name, you are @(contact.age + 1)`, []string{"contact"})
+
+	// a compiled template can be evaluated against different contexts
+	for _, tc := range []struct {
+		name   string
+		age    int
+		output string
+	}{
+		{"Bob", 22, "Hi Bob, you are 23"},
+		{"Ann", 40, "Hi Ann, you are 41"},
+	} {
+		context := types.NewXObject(map[string]types.XValue{
+			"contact": types.NewXObject(map[string]types.XValue{
+				"name": types.NewXText(tc.name),
+				"age":  types.NewXNumberFromInt(tc.age),
+			}),
+		})
+
+		output, err := template.Evaluate(env, context, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.output, output)
+	}
+
+	// errors are reported on evaluation
+	output, err := template.Evaluate(env, types.NewXObject(map[string]types.XValue{}), nil)
+	assert.EqualError(t, err, "error evaluating @contact.name: context has no property 'contact', error evaluating @(contact.age + 1): context has no property 'contact'")
+	assert.Equal(t, "Hi , you are ", output)
+}
+
+func TestTemplateCache(t *testing.T) {
+	cache := excellent.NewTemplateCache(2)
+	assert.Equal(t, 0, cache.Len())
+
+	t1 := cache.Get("@foo", []string{"foo"})
+	assert.Equal(t, 1, cache.Len())
+	assert.Same(t, t1, cache.Get("@foo", []string{"foo"}))
+
+	// allowed top-levels are part of the key
+	t2 := cache.Get("@foo", []string{"bar"})
+	assert.NotSame(t, t1, t2)
+	assert.Equal(t, 2, cache.Len())
+
+	// touch t1 so that t2 becomes the least recently used
+	cache.Get("@foo", []string{"foo"})
+
+	cache.Get("@bar", []string{"bar"})
+	assert.Equal(t, 2, cache.Len())
+	assert.Same(t, t1, cache.Get("@foo", []string{"foo"}))
+	assert.NotSame(t, t2, cache.Get("@foo", []string{"bar"}))
+
+	// a size of zero disables caching
+	cache = excellent.NewTemplateCache(0)
+	assert.NotSame(t, cache.Get("@foo", []string{"foo"}), cache.Get("@foo", []string{"foo"}))
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestTemplateCacheConcurrency(t *testing.T) {
+	env := envs.NewBuilder().Build()
+	context := types.NewXObject(map[string]types.XValue{"name": types.NewXText("Bob")})
+	cache := excellent.NewTemplateCache(10)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				output, err := cache.Get(`Hi @(upper(name))`, []string{"name"}).Evaluate(env, context, nil)
+				require.NoError(t, err)
+				assert.Equal(t, "Hi BOB", output)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, cache.Len())
+}
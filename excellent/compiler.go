@@ -0,0 +1,366 @@
+package excellent
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/gen"
+	"github.com/nyaruka/goflow/excellent/operators"
+	"github.com/nyaruka/goflow/excellent/types"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+// Expression is a compiled expression which can be evaluated many times, and concurrently
+type Expression struct {
+	source string
+	root   node
+	err    error
+}
+
+// CompileExpression parses the given expression. Syntax errors aren't returned here but when the expression is
+// evaluated.
+func CompileExpression(expression string) *Expression {
+	compiler := &compiler{expression: []rune(expression)}
+
+	output, err := VisitExpression(expression, compiler)
+	if err != nil {
+		return &Expression{source: expression, err: err}
+	}
+
+	return &Expression{source: expression, root: output.(node)}
+}
+
+// Evaluate evaluates this expression, returning the typed value it evaluates to, which might be an error
+func (e *Expression) Evaluate(env envs.Environment, context *types.XObject) types.XValue {
+	return e.evaluate(env, context, nil)
+}
+
+// evaluates this expression, adding a node for it to the given trace if there is one
+func (e *Expression) evaluate(env envs.Environment, context *types.XObject, trace *Trace) types.XValue {
+	var node *Trace
+	if trace != nil {
+		node = newTrace(TraceTypeExpression, e.source)
+		trace.addChild(node)
+	}
+
+	var value types.XValue
+	if e.err != nil {
+		value = types.NewXError(e.err)
+	} else {
+		value = evaluateNode(e.root, env, context, node)
+	}
+
+	if node != nil {
+		node.setResult(value)
+	}
+
+	return value
+}
+
+// a part of a template which is either body text or an expression
+type templatePart struct {
+	tokenType  XTokenType
+	body       string
+	expression *Expression
+}
+
+// Template is a compiled template which can be evaluated many times, and concurrently
+type Template struct {
+	source string
+	parts  []*templatePart
+}
+
+// CompileTemplate parses the given template. Which identifiers are treated as expressions depends on the allowed
+// top-levels, so a compiled template should only be evaluated against contexts with those top-levels.
+func CompileTemplate(template string, allowedTopLevels []string) *Template {
+	t := &Template{source: template}
+
+	// nothing todo for an empty template
+	if template == "" {
+		return t
+	}
+
+	scanner := NewXScanner(strings.NewReader(template), allowedTopLevels)
+
+	for tokenType, token := scanner.Scan(); tokenType != EOF; tokenType, token = scanner.Scan() {
+		part := &templatePart{tokenType: tokenType}
+
+		switch tokenType {
+		case BODY:
+			part.body = token
+		case IDENTIFIER, EXPRESSION:
+			part.expression = CompileExpression(token)
+		}
+
+		t.parts = append(t.parts, part)
+	}
+
+	return t
+}
+
+// Evaluate evaluates this template as text
+func (t *Template) Evaluate(env envs.Environment, context *types.XObject, escaping Escaping) (string, error) {
+	return t.evaluate(env, context, escaping, nil)
+}
+
+// EvaluateValue is equivalent to Evaluate except in the case where the template contains a single identifier or
+// expression, ie: "@contact" or "@(first(contact.urns))". In these cases we return the typed value of that expression
+// instead of stringifying the result.
+func (t *Template) EvaluateValue(env envs.Environment, context *types.XObject) (types.XValue, error) {
+	if len(t.parts) == 1 && t.parts[0].expression != nil {
+		return t.parts[0].expression.Evaluate(env, context), nil
+	}
+
+	asStr, err := t.Evaluate(env, context, nil)
+	return types.NewXText(asStr), err
+}
+
+// EvaluateWithTrace is equivalent to Evaluate but also returns a trace of the evaluation of every expression
+func (t *Template) EvaluateWithTrace(env envs.Environment, context *types.XObject, escaping Escaping) (string, *Trace, error) {
+	trace := newTrace(TraceTypeTemplate, t.source)
+
+	output, err := t.evaluate(env, context, escaping, trace)
+	if err != nil {
+		trace.setError(err)
+	} else {
+		trace.setResult(types.NewXText(output))
+	}
+
+	return output, trace, err
+}
+
+func (t *Template) evaluate(env envs.Environment, context *types.XObject, escaping Escaping, trace *Trace) (string, error) {
+	var buf strings.Builder
+	errors := NewTemplateErrors()
+
+	for _, part := range t.parts {
+		if part.expression == nil {
+			buf.WriteString(part.body)
+			continue
+		}
+
+		value := part.expression.evaluate(env, context, trace)
+
+		// if we got an error, record that
+		if types.IsXError(value) {
+			var repr string
+			if part.tokenType == IDENTIFIER {
+				repr = "@" + part.expression.source
+			} else {
+				repr = "@(" + part.expression.source + ")"
+			}
+
+			errors.Add(repr, value.(error).Error())
+			continue
+		}
+
+		// if not, stringify value and append to the output
+		asText, _ := types.ToXText(env, value)
+		asString := asText.Native()
+
+		if escaping != nil {
+			asString = escaping(asString)
+		}
+
+		buf.WriteString(asString)
+	}
+
+	if errors.HasErrors() {
+		return buf.String(), errors
+	}
+	return buf.String(), nil
+}
+
+//------------------------------------------------------------------------------------------
+// Compiler
+//------------------------------------------------------------------------------------------
+
+// visitor which compiles an expression parse tree into nodes
+type compiler struct {
+	gen.BaseExcellent2Visitor
+
+	expression []rune
+}
+
+// Visit the top level parse tree
+func (c *compiler) Visit(tree antlr.ParseTree) interface{} {
+	return tree.Accept(c)
+}
+
+// gets the original text of the given part of the expression
+func (c *compiler) sourceOf(ctx antlr.ParserRuleContext) baseNode {
+	if ctx.GetStop() != nil {
+		start, stop := ctx.GetStart().GetStart(), ctx.GetStop().GetStop()
+		if start >= 0 && stop < len(c.expression) && start <= stop {
+			return baseNode{text: string(c.expression[start : stop+1])}
+		}
+	}
+	return baseNode{text: ctx.GetText()}
+}
+
+func (c *compiler) visitNode(tree antlr.ParseTree) node {
+	return c.Visit(tree).(node)
+}
+
+// VisitParse handles our top level parser
+func (c *compiler) VisitParse(ctx *gen.ParseContext) interface{} {
+	return c.Visit(ctx.Expression())
+}
+
+// VisitTextLiteral deals with string literals such as "asdf"
+func (c *compiler) VisitTextLiteral(ctx *gen.TextLiteralContext) interface{} {
+	value := ctx.GetText()
+
+	// unquote, this takes care of escape sequences as well
+	unquoted, err := strconv.Unquote(value)
+
+	// if we had an error, just strip surrounding quotes
+	if err != nil {
+		unquoted = value[1 : len(value)-1]
+	}
+
+	return &literal{baseNode: c.sourceOf(ctx), value: types.NewXText(unquoted)}
+}
+
+// VisitNumberLiteral deals with numbers like 123 or 1.5
+func (c *compiler) VisitNumberLiteral(ctx *gen.NumberLiteralContext) interface{} {
+	return &literal{baseNode: c.sourceOf(ctx), value: types.RequireXNumberFromString(ctx.GetText())}
+}
+
+// VisitTrue deals with the `true` reserved word
+func (c *compiler) VisitTrue(ctx *gen.TrueContext) interface{} {
+	return &literal{baseNode: c.sourceOf(ctx), value: types.XBooleanTrue}
+}
+
+// VisitFalse deals with the `false` reserved word
+func (c *compiler) VisitFalse(ctx *gen.FalseContext) interface{} {
+	return &literal{baseNode: c.sourceOf(ctx), value: types.XBooleanFalse}
+}
+
+// VisitNull deals with the `null` reserved word
+func (c *compiler) VisitNull(ctx *gen.NullContext) interface{} {
+	return &literal{baseNode: c.sourceOf(ctx), value: nil}
+}
+
+// VisitContextReference deals with identifiers which are function names or root variables in the context
+func (c *compiler) VisitContextReference(ctx *gen.ContextReferenceContext) interface{} {
+	return &contextReference{baseNode: c.sourceOf(ctx), name: strings.ToLower(ctx.GetText())}
+}
+
+// VisitDotLookup deals with property lookups like foo.bar
+func (c *compiler) VisitDotLookup(ctx *gen.DotLookupContext) interface{} {
+	var lookup types.XText
+
+	if ctx.NAME() != nil {
+		lookup = types.NewXText(ctx.NAME().GetText())
+	} else {
+		lookup = types.NewXText(ctx.INTEGER().GetText())
+	}
+
+	return &dotLookup{baseNode: c.sourceOf(ctx), container: c.visitNode(ctx.Atom()), lookup: lookup}
+}
+
+// VisitArrayLookup deals with lookups such as foo[5] or foo["key with spaces"]
+func (c *compiler) VisitArrayLookup(ctx *gen.ArrayLookupContext) interface{} {
+	return &arrayLookup{baseNode: c.sourceOf(ctx), container: c.visitNode(ctx.Atom()), lookup: c.visitNode(ctx.Expression())}
+}
+
+// VisitFunctionCall deals with function calls like TITLE(foo.bar)
+func (c *compiler) VisitFunctionCall(ctx *gen.FunctionCallContext) interface{} {
+	call := &functionCall{
+		baseNode:     c.sourceOf(ctx),
+		function:     c.visitNode(ctx.Atom()),
+		functionText: ctx.Atom().GetText(),
+		name:         strings.ToLower(ctx.Atom().GetText()),
+	}
+
+	if ctx.Parameters() != nil {
+		call.params = c.Visit(ctx.Parameters()).([]node)
+	}
+
+	return call
+}
+
+// VisitFunctionParameters deals with the parameters to a function call
+func (c *compiler) VisitFunctionParameters(ctx *gen.FunctionParametersContext) interface{} {
+	expressions := ctx.AllExpression()
+	params := make([]node, len(expressions))
+
+	for i := range expressions {
+		params[i] = c.visitNode(expressions[i])
+	}
+	return params
+}
+
+// VisitParentheses deals with expressions in parentheses such as (1+2)
+func (c *compiler) VisitParentheses(ctx *gen.ParenthesesContext) interface{} {
+	return c.Visit(ctx.Expression())
+}
+
+// VisitAtomReference deals with visiting a single atom in our expression
+func (c *compiler) VisitAtomReference(ctx *gen.AtomReferenceContext) interface{} {
+	return c.Visit(ctx.Atom())
+}
+
+// VisitNegation deals with negations such as -5
+func (c *compiler) VisitNegation(ctx *gen.NegationContext) interface{} {
+	return &unaryOperation{baseNode: c.sourceOf(ctx), operator: operators.Negate, arg: c.visitNode(ctx.Expression())}
+}
+
+// VisitExponent deals with exponenets such as 5^5
+func (c *compiler) VisitExponent(ctx *gen.ExponentContext) interface{} {
+	return c.binaryOperation(ctx, operators.Exponent, ctx.Expression(0), ctx.Expression(1))
+}
+
+// VisitConcatenation deals with string concatenations like "foo" & "bar"
+func (c *compiler) VisitConcatenation(ctx *gen.ConcatenationContext) interface{} {
+	return c.binaryOperation(ctx, operators.Concatenate, ctx.Expression(0), ctx.Expression(1))
+}
+
+// VisitAdditionOrSubtraction deals with addition and subtraction like 5+5 and 5-3
+func (c *compiler) VisitAdditionOrSubtraction(ctx *gen.AdditionOrSubtractionContext) interface{} {
+	if ctx.PLUS() != nil {
+		return c.binaryOperation(ctx, operators.Add, ctx.Expression(0), ctx.Expression(1))
+	}
+	return c.binaryOperation(ctx, operators.Subtract, ctx.Expression(0), ctx.Expression(1))
+}
+
+// VisitMultiplicationOrDivision deals with division and multiplication such as 5*5 or 5/2
+func (c *compiler) VisitMultiplicationOrDivision(ctx *gen.MultiplicationOrDivisionContext) interface{} {
+	if ctx.TIMES() != nil {
+		return c.binaryOperation(ctx, operators.Multiply, ctx.Expression(0), ctx.Expression(1))
+	}
+	return c.binaryOperation(ctx, operators.Divide, ctx.Expression(0), ctx.Expression(1))
+}
+
+// VisitEquality deals with equality or inequality tests 5 = 5 and 5 != 5
+func (c *compiler) VisitEquality(ctx *gen.EqualityContext) interface{} {
+	if ctx.EQ() != nil {
+		return c.binaryOperation(ctx, operators.Equal, ctx.Expression(0), ctx.Expression(1))
+	}
+	return c.binaryOperation(ctx, operators.NotEqual, ctx.Expression(0), ctx.Expression(1))
+}
+
+// VisitComparison deals with visiting a comparison between two values, such as 5<3 or 3>5
+func (c *compiler) VisitComparison(ctx *gen.ComparisonContext) interface{} {
+	var operator operators.BinaryOperator
+
+	switch {
+	case ctx.LT() != nil:
+		operator = operators.LessThan
+	case ctx.LTE() != nil:
+		operator = operators.LessThanOrEqual
+	case ctx.GTE() != nil:
+		operator = operators.GreaterThanOrEqual
+	default:
+		operator = operators.GreaterThan
+	}
+
+	return c.binaryOperation(ctx, operator, ctx.Expression(0), ctx.Expression(1))
+}
+
+func (c *compiler) binaryOperation(ctx antlr.ParserRuleContext, operator operators.BinaryOperator, arg1, arg2 gen.IExpressionContext) node {
+	return &binaryOperation{baseNode: c.sourceOf(ctx), operator: operator, arg1: c.visitNode(arg1), arg2: c.visitNode(arg2)}
+}
@@ -2,11 +2,13 @@ package functions
 
 import (
 	"bytes"
+	"encoding/csv"
 	"fmt"
 	"html"
 	"math"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 	"unicode"
@@ -34,6 +36,8 @@ func init() {
 		"date":     OneArgFunction(Date),
 		"datetime": OneArgFunction(DateTime),
 		"time":     OneArgFunction(Time),
+		"duration": OneArgFunction(Duration),
+		"money":    MinAndMaxArgsCheck(1, 2, Money),
 		"array":    Array,
 		"object":   Object,
 
@@ -55,6 +59,7 @@ func init() {
 		"lower":             OneTextFunction(Lower),
 		"regex_match":       InitialTextFunction(1, 2, RegexMatch),
 		"text_length":       OneTextFunction(TextLength),
+		"segment_count":     TextAndOptionalTextFunction(SegmentCount, types.NewXText(string(utils.SMSEncodingGSM7))),
 		"text_compare":      TwoTextFunction(TextCompare),
 		"repeat":            TextAndIntegerFunction(Repeat),
 		"replace":           MinAndMaxArgsCheck(3, 4, Replace),
@@ -83,8 +88,8 @@ func init() {
 		// datetime functions
 		"parse_datetime":      MinAndMaxArgsCheck(2, 3, ParseDateTime),
 		"datetime_from_epoch": OneNumberFunction(DateTimeFromEpoch),
-		"datetime_diff":       ThreeArgFunction(DateTimeDiff),
-		"datetime_add":        DateTimeAdd,
+		"datetime_diff":       MinAndMaxArgsCheck(2, 3, DateTimeDiff),
+		"datetime_add":        MinAndMaxArgsCheck(2, 3, DateTimeAdd),
 		"replace_time":        TwoArgFunction(ReplaceTime),
 		"tz":                  OneDateTimeFunction(TZ),
 		"tz_offset":           OneDateTimeFunction(TZOffset),
@@ -101,6 +106,12 @@ func init() {
 		"parse_time":      TwoArgFunction(ParseTime),
 		"time_from_parts": ThreeIntegerFunction(TimeFromParts),
 
+		// duration functions
+		"duration_from_parts": NumArgsCheck(4, DurationFromParts),
+
+		// money functions
+		"convert_currency": TwoArgFunction(ConvertCurrency),
+
 		// array functions
 		"join": TwoArgFunction(Join),
 		"sum":  OneArgFunction(Sum),
@@ -113,6 +124,14 @@ func init() {
 		"json":       OneArgFunction(JSON),
 		"parse_json": OneTextFunction(ParseJSON),
 
+		// xml functions
+		"parse_xml": OneTextFunction(ParseXML),
+		"xpath":     TwoArgFunction(XPath),
+
+		// csv functions
+		"parse_csv":  TextAndOptionalTextFunction(ParseCSV, types.NewXText(",")),
+		"format_csv": MinAndMaxArgsCheck(1, 2, FormatCSV),
+
 		// formatting functions
 		"format":          OneArgFunction(Format),
 		"format_date":     MinAndMaxArgsCheck(1, 2, FormatDate),
@@ -121,6 +140,7 @@ func init() {
 		"format_location": OneTextFunction(FormatLocation),
 		"format_number":   MinAndMaxArgsCheck(1, 3, FormatNumber),
 		"format_urn":      OneTextFunction(FormatURN),
+		"format_currency": MinAndMaxArgsCheck(1, 2, FormatCurrency),
 
 		// utility functions
 		"is_error":       OneArgFunction(IsError),
@@ -251,6 +271,59 @@ func Time(env envs.Environment, value types.XValue) types.XValue {
 	return t
 }
 
+// Duration tries to convert `value` to a duration.
+//
+// If it is text then it will be parsed as either an ISO 8601 duration, or as amounts of weeks, days,
+// hours, minutes and seconds. An error is returned if the value can't be converted.
+//
+//   @(duration("P2DT3H")) -> P2DT3H
+//   @(duration("2 days 3 hours")) -> P2DT3H
+//   @(duration("1h30m")) -> PT1H30M
+//   @(format(duration("PT90M"))) -> 1 hour 30 minutes
+//   @(duration("soon")) -> ERROR
+//
+// @function duration(value)
+func Duration(env envs.Environment, value types.XValue) types.XValue {
+	d, xerr := types.ToXDuration(env, value)
+	if xerr != nil {
+		return xerr
+	}
+	return d
+}
+
+// Money tries to convert `value` to an amount of money, or creates one from an `amount` and a `currency`.
+//
+// If it is text then the first amount with a currency code or symbol in it is used. An error is returned
+// if the value can't be converted.
+//
+//   @(money(20, "USD")) -> 20.00 USD
+//   @(money("$20.50")) -> 20.50 USD
+//   @(money("it costs 20 000 RWF")) -> 20000 RWF
+//   @(money("20")) -> ERROR
+//   @(money(20, "dollars")) -> ERROR
+//
+// @function money(value [,currency])
+func Money(env envs.Environment, args ...types.XValue) types.XValue {
+	if len(args) == 1 {
+		m, xerr := types.ToXMoney(env, args[0])
+		if xerr != nil {
+			return xerr
+		}
+		return m
+	}
+
+	amount, xerr := types.ToXNumber(env, args[0])
+	if xerr != nil {
+		return xerr
+	}
+	currency, xerr := toCurrency(env, args[1])
+	if xerr != nil {
+		return xerr
+	}
+
+	return types.NewXMoney(amount.Native(), currency)
+}
+
 // Array takes multiple `values` and returns them as an array.
 //
 //   @(array("a", "b", 356)[1]) -> b
@@ -799,6 +872,27 @@ func TextLength(env envs.Environment, value types.XText) types.XValue {
 	return types.NewXNumberFromInt(value.Length())
 }
 
+// SegmentCount returns the number of SMS segments needed to send `text`.
+//
+// The optional `encoding` can be `gsm7` (the default) or `ucs2`. Text which can't be encoded with GSM-7 is
+// always counted as UCS-2, which allows fewer characters per segment.
+//
+//   @(segment_count("hello")) -> 1
+//   @(segment_count(repeat("a", 161))) -> 2
+//   @(segment_count(repeat("a", 161), "ucs2")) -> 3
+//   @(segment_count("")) -> 0
+//   @(segment_count("hello", "utf8")) -> ERROR
+//
+// @function segment_count(text [,encoding])
+func SegmentCount(env envs.Environment, text types.XText, encoding types.XText) types.XValue {
+	enc := utils.SMSEncoding(strings.ToLower(encoding.Native()))
+	if enc != utils.SMSEncodingGSM7 && enc != utils.SMSEncodingUCS2 {
+		return types.NewXErrorf("%s is not a valid SMS encoding", encoding.Native())
+	}
+
+	return types.NewXNumberFromInt(utils.SMSSegments(text.Native(), enc))
+}
+
 // TextCompare returns the dictionary order of `text1` and `text2`.
 //
 // The return value will be -1 if `text1` comes before `text2`, 0 if they are equal
@@ -1202,34 +1296,39 @@ func DateTimeFromEpoch(env envs.Environment, num types.XNumber) types.XValue {
 // DateTimeDiff returns the duration between `date1` and `date2` in the `unit` specified.
 //
 // Valid durations are "Y" for years, "M" for months, "W" for weeks, "D" for days, "h" for hour,
-// "m" for minutes, "s" for seconds.
+// "m" for minutes, "s" for seconds. If `unit` is omitted then the result is a duration.
 //
+//   @(datetime_diff("2017-01-15 10:00", "2017-01-17 12:30")) -> P2DT2H30M
 //   @(datetime_diff("2017-01-15", "2017-01-17", "D")) -> 2
 //   @(datetime_diff("2017-01-15", "2017-05-15", "W")) -> 17
 //   @(datetime_diff("2017-01-15", "2017-05-15", "M")) -> 4
 //   @(datetime_diff("2017-01-17 10:50", "2017-01-17 12:30", "h")) -> 1
 //   @(datetime_diff("2017-01-17", "2015-12-17", "Y")) -> -2
 //
-// @function datetime_diff(date1, date2, unit)
-func DateTimeDiff(env envs.Environment, arg1 types.XValue, arg2 types.XValue, arg3 types.XValue) types.XValue {
-	date1, xerr := types.ToXDateTime(env, arg1)
+// @function datetime_diff(date1, date2 [,unit])
+func DateTimeDiff(env envs.Environment, args ...types.XValue) types.XValue {
+	date1, xerr := types.ToXDateTime(env, args[0])
 	if xerr != nil {
 		return xerr
 	}
 
-	date2, xerr := types.ToXDateTime(env, arg2)
+	date2, xerr := types.ToXDateTime(env, args[1])
 	if xerr != nil {
 		return xerr
 	}
 
-	unit, xerr := types.ToXText(env, arg3)
+	// find the duration between our dates
+	duration := date2.Native().Sub(date1.Native())
+
+	if len(args) == 2 {
+		return types.NewXDuration(duration)
+	}
+
+	unit, xerr := types.ToXText(env, args[2])
 	if xerr != nil {
 		return xerr
 	}
 
-	// find the duration between our dates
-	duration := date2.Native().Sub(date1.Native())
-
 	// then convert based on our unit
 	switch unit.Native() {
 	case "s":
@@ -1254,22 +1353,27 @@ func DateTimeDiff(env envs.Environment, arg1 types.XValue, arg2 types.XValue, ar
 // DateTimeAdd calculates the date value arrived at by adding `offset` number of `unit` to the `datetime`
 //
 // Valid durations are "Y" for years, "M" for months, "W" for weeks, "D" for days, "h" for hour,
-// "m" for minutes, "s" for seconds
+// "m" for minutes, "s" for seconds. If `unit` is omitted then `offset` should be a duration.
 //
 //   @(datetime_add("2017-01-15", 5, "D")) -> 2017-01-20T00:00:00.000000-05:00
 //   @(datetime_add("2017-01-15 10:45", 30, "m")) -> 2017-01-15T11:15:00.000000-05:00
+//   @(datetime_add("2017-01-15 10:45", duration("PT1H30M"))) -> 2017-01-15T12:15:00.000000-05:00
 //
-// @function datetime_add(datetime, offset, unit)
+// @function datetime_add(datetime, offset [,unit])
 func DateTimeAdd(env envs.Environment, args ...types.XValue) types.XValue {
-	if len(args) != 3 {
-		return types.NewXErrorf("takes exactly three arguments, received %d", len(args))
-	}
-
 	date, xerr := types.ToXDateTime(env, args[0])
 	if xerr != nil {
 		return xerr
 	}
 
+	if len(args) == 2 {
+		offset, xerr := types.ToXDuration(env, args[1])
+		if xerr != nil {
+			return xerr
+		}
+		return types.NewXDateTime(date.Native().Add(offset.Native()))
+	}
+
 	duration, xerr := types.ToInteger(env, args[1])
 	if xerr != nil {
 		return xerr
@@ -1502,6 +1606,67 @@ func TimeFromParts(env envs.Environment, hour, minute, second int) types.XValue
 	return types.NewXTime(dates.NewTimeOfDay(hour, minute, second, 0))
 }
 
+//------------------------------------------------------------------------------------------
+// Duration Functions
+//------------------------------------------------------------------------------------------
+
+// DurationFromParts creates a duration from `days`, `hours`, `minutes` and `seconds`.
+//
+//   @(duration_from_parts(2, 3, 0, 0)) -> P2DT3H
+//   @(duration_from_parts(0, 1, 30, 15)) -> PT1H30M15S
+//   @(format(duration_from_parts(1, 0, 5, 0))) -> 1 day 5 minutes
+//   @(duration_from_parts(1, "x", 0, 0)) -> ERROR
+//
+// @function duration_from_parts(days, hours, minutes, seconds)
+func DurationFromParts(env envs.Environment, args ...types.XValue) types.XValue {
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		amount, xerr := types.ToInteger(env, args[i])
+		if xerr != nil {
+			return xerr
+		}
+		d += time.Duration(amount) * unit
+	}
+
+	return types.NewXDuration(d)
+}
+
+//------------------------------------------------------------------------------------------
+// Money Functions
+//------------------------------------------------------------------------------------------
+
+// ConvertCurrency converts `money` to the given `currency` using the exchange rates available in the
+// workspace. The result is rounded to the number of decimal places used by the new currency.
+//
+//   @(convert_currency(money(20, "USD"), "RWF")) -> 20000 RWF
+//   @(convert_currency("5000 RWF", "USD")) -> 5.00 USD
+//   @(convert_currency("5000 RWF", "EUR")) -> 4.25 EUR
+//   @(convert_currency("5000 RWF", "GBP")) -> ERROR
+//
+// @function convert_currency(money, currency)
+func ConvertCurrency(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	money, xerr := types.ToXMoney(env, arg1)
+	if xerr != nil {
+		return xerr
+	}
+	currency, xerr := toCurrency(env, arg2)
+	if xerr != nil {
+		return xerr
+	}
+
+	converter := env.CurrencyConverter()
+	if converter == nil {
+		return types.NewXErrorf("no exchange rates available")
+	}
+
+	converted, err := converter.Convert(money.Amount(), money.Currency(), currency)
+	if err != nil {
+		return types.NewXError(err)
+	}
+
+	return types.NewXMoney(converted.Round(currency.Decimals()), currency)
+}
+
 //------------------------------------------------------------------------------------------
 // Array Functions
 //------------------------------------------------------------------------------------------
@@ -1637,6 +1802,205 @@ func JSON(env envs.Environment, value types.XValue) types.XValue {
 	return asJSON
 }
 
+//------------------------------------------------------------------------------------------
+// XML Functions
+//------------------------------------------------------------------------------------------
+
+// ParseXML tries to parse `text` as an XML document.
+//
+// The result is an object with a single property for the root element. Elements which only contain text
+// become text values, and other elements become objects whose properties are their child elements, with
+// repeated elements becoming arrays. Attributes become properties prefixed with an underscore, and any
+// other text becomes a `_text` property. Text values longer than the environment's maximum value length
+// are truncated. If the given `text` is not valid XML, then an error is returned.
+//
+//   @(parse_xml("<contact><name>Bob</name></contact>").contact.name) -> Bob
+//   @(parse_xml("<balance currency=\"RWF\">1250</balance>").balance._currency) -> RWF
+//   @(parse_xml("<balance currency=\"RWF\">1250</balance>").balance._text) -> 1250
+//   @(parse_xml("<items><item>A</item><item>B</item></items>").items.item[1]) -> B
+//   @(parse_xml("invalid xml")) -> ERROR
+//
+// @function parse_xml(text)
+func ParseXML(env envs.Environment, text types.XText) types.XValue {
+	return types.XMLToXValue([]byte(text.Native()), env.MaxValueLength())
+}
+
+// XPath returns an array of the values in `xml` which match `path`.
+//
+// The `xml` can be text, which will be parsed as XML, or an object returned from [function:parse_xml].
+// The `path` supports a subset of XPath: child steps separated by `/`, `//` to match descendants at any
+// depth, `*` to match any element, `@name` to match attributes, `text()` to match text, and `[n]` to
+// select the nth match of a step (starting from 1).
+//
+//   @(xpath("<items><item>A</item><item>B</item></items>", "/items/item")) -> [A, B]
+//   @(xpath("<items><item>A</item><item>B</item></items>", "//item[2]")) -> [B]
+//   @(xpath("<balance currency=\"RWF\">1250</balance>", "/balance/@currency")) -> [RWF]
+//   @(xpath("<balance currency=\"RWF\">1250</balance>", "/balance/text()")) -> [1250]
+//   @(xpath("<a><b>1</b><c><b>2</b></c></a>", "//b")) -> [1, 2]
+//   @(xpath("<a></a>", "/a/[")) -> ERROR
+//
+// @function xpath(xml, path)
+func XPath(env envs.Environment, xml types.XValue, path types.XValue) types.XValue {
+	if types.IsXError(xml) {
+		return xml
+	}
+
+	doc, isObject := xml.(*types.XObject)
+	if !isObject {
+		text, xerr := types.ToXText(env, xml)
+		if xerr != nil {
+			return xerr
+		}
+
+		parsed := ParseXML(env, text)
+		if types.IsXError(parsed) {
+			return parsed
+		}
+		doc = parsed.(*types.XObject)
+	}
+
+	pathText, xerr := types.ToXText(env, path)
+	if xerr != nil {
+		return xerr
+	}
+
+	matches, err := evaluateXPath(doc, pathText.Native())
+	if err != nil {
+		return types.NewXError(err)
+	}
+
+	return types.NewXArray(matches...)
+}
+
+//------------------------------------------------------------------------------------------
+// CSV Functions
+//------------------------------------------------------------------------------------------
+
+// ParseCSV parses `text` as CSV and returns an array of objects, one for each row after the first.
+//
+// The first row is used as the header, and its values become the property names of the objects. There
+// is an optional final parameter `delimiter` which is the character used to separate values, and which
+// defaults to a comma. Values longer than the environment's maximum value length are truncated. If the
+// given `text` is not valid CSV, then an error is returned.
+//
+//   @(parse_csv("name,age\nBob,32\nAnn,28")[1].name) -> Ann
+//   @(parse_csv("name;age\nBob;32", ";")[0].age) -> 32
+//   @(count(parse_csv("name,age\nBob,32\nAnn,28"))) -> 2
+//   @(parse_csv("name,age\n\"Bob")) -> ERROR
+//
+// @function parse_csv(text [,delimiter])
+func ParseCSV(env envs.Environment, text types.XText, delimiter types.XText) types.XValue {
+	comma, xerr := toCSVDelimiter(delimiter)
+	if xerr != nil {
+		return xerr
+	}
+
+	reader := csv.NewReader(strings.NewReader(text.Native()))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return types.NewXErrorf("unable to parse CSV: %s", err)
+	}
+	if len(records) == 0 {
+		return types.NewXArray()
+	}
+
+	header := records[0]
+	rows := make([]types.XValue, len(records)-1)
+
+	for r, record := range records[1:] {
+		properties := make(map[string]types.XValue, len(header))
+		for i, name := range header {
+			value := ""
+			if i < len(record) {
+				value = utils.Truncate(record[i], env.MaxValueLength())
+			}
+			properties[strings.TrimSpace(name)] = types.NewXText(value)
+		}
+		rows[r] = types.NewXObject(properties)
+	}
+
+	return types.NewXArray(rows...)
+}
+
+// FormatCSV formats `array` of objects as CSV text.
+//
+// The first row is a header containing the names of all the properties of the objects in alphabetical
+// order, and there is a row for each object. There is an optional final parameter `delimiter` which is
+// the character used to separate values, and which defaults to a comma. Like all evaluated text, the
+// result is truncated if it's longer than the maximum number of characters allowed from a template.
+//
+//   @(format_csv(parse_csv("name,age\nBob,32"))) -> age,name\n32,Bob
+//   @(format_csv(array(object("name", "Bob", "tags", "a,b")), ";")) -> name;tags\nBob;a,b
+//   @(format_csv(array(object("name", "Bob, Jr.")))) -> name\n"Bob, Jr."
+//   @(format_csv("abc")) -> ERROR
+//
+// @function format_csv(array [,delimiter])
+func FormatCSV(env envs.Environment, args ...types.XValue) types.XValue {
+	array, xerr := types.ToXArray(env, args[0])
+	if xerr != nil {
+		return xerr
+	}
+
+	delimiter := types.NewXText(",")
+	if len(args) == 2 {
+		if delimiter, xerr = types.ToXText(env, args[1]); xerr != nil {
+			return xerr
+		}
+	}
+	comma, xerr := toCSVDelimiter(delimiter)
+	if xerr != nil {
+		return xerr
+	}
+
+	// collect rows and the names of all properties which will be our columns
+	rows := make([]*types.XObject, array.Count())
+	columnSet := make(map[string]bool)
+	for i := 0; i < array.Count(); i++ {
+		row, isObject := array.Get(i).(*types.XObject)
+		if !isObject {
+			return types.NewXErrorf("item %d of array isn't an object", i)
+		}
+		for _, p := range row.Properties() {
+			columnSet[p] = true
+		}
+		rows[i] = row
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for c := range columnSet {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	b := &strings.Builder{}
+	writer := csv.NewWriter(b)
+	writer.Comma = comma
+	writer.Write(columns)
+
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			value, _ := row.Get(c)
+			if !utils.IsNil(value) {
+				text, xerr := types.ToXText(env, value)
+				if xerr != nil {
+					return xerr
+				}
+				record[i] = text.Native()
+			}
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+
+	return types.NewXText(strings.TrimSuffix(b.String(), "\n"))
+}
+
 //----------------------------------------------------------------------------------------
 // Formatting Functions
 //----------------------------------------------------------------------------------------
@@ -1659,7 +2023,8 @@ func Format(env envs.Environment, value types.XValue) types.XValue {
 //
 // If `format` is not specified then the environment's default format is used. The format
 // string can consist of the following characters. The characters ' ', ':', ',', 'T', '-'
-// and '_' are ignored. Any other character is an error.
+// and '_' are ignored. Any other character is an error. Years, months and days are in the
+// calendar of the environment.
 //
 // * `YY`        - last two digits of year 0-99
 // * `YYYY`      - four digits of year 0000-9999
@@ -1707,7 +2072,8 @@ func FormatDate(env envs.Environment, args ...types.XValue) types.XValue {
 //
 // If `format` is not specified then the environment's default format is used. The format
 // string can consist of the following characters. The characters ' ', ':', ',', 'T', '-'
-// and '_' are ignored. Any other character is an error.
+// and '_' are ignored. Any other character is an error. Years, months and days are in the
+// calendar of the environment.
 //
 // * `YY`        - last two digits of year 0-99
 // * `YYYY`      - four digits of year 0000-9999
@@ -1873,6 +2239,24 @@ func FormatNumber(env envs.Environment, args ...types.XValue) types.XValue {
 	return types.NewXText(num.FormatCustom(env.NumberFormat(), places, human.Native()))
 }
 
+// FormatCurrency formats `money` as text using the symbol of its currency and the number format and
+// language of the environment. If a `currency` is given then `money` can be a number.
+//
+//   @(format_currency(money(1234.5, "USD"))) -> $1,234.50
+//   @(format_currency("20000 RWF")) -> RF 20,000
+//   @(format_currency(15.5, "EUR")) -> €15.50
+//   @(format_currency(15.5)) -> ERROR
+//
+// @function format_currency(money [,currency])
+func FormatCurrency(env envs.Environment, args ...types.XValue) types.XValue {
+	money := Money(env, args...)
+	if types.IsXError(money) {
+		return money
+	}
+
+	return types.NewXText(money.(types.XMoney).Format(env))
+}
+
 // FormatLocation formats the given `location` as its name.
 //
 //   @(format_location("Rwanda")) -> Rwanda
@@ -3,6 +3,7 @@ package functions_test
 import (
 	"fmt"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/nyaruka/goflow/excellent/types"
 	"github.com/nyaruka/goflow/test"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -26,10 +28,26 @@ var xi = types.NewXNumberFromInt
 var xdt = types.NewXDateTime
 var xd = types.NewXDate
 var xt = types.NewXTime
+var xdu = types.NewXDuration
 var xa = types.NewXArray
+var xm = func(amount string, currency envs.Currency) types.XMoney {
+	return types.NewXMoney(decimal.RequireFromString(amount), currency)
+}
 var xf = functions.Lookup
 var ERROR = types.NewXErrorf("any error")
 
+// creates an object from pairs of property names and values
+var xo = func(pairs ...interface{}) *types.XObject {
+	properties := make(map[string]types.XValue, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		value, _ := pairs[i+1].(types.XValue)
+		properties[pairs[i].(string)] = value
+	}
+	return types.NewXObject(properties)
+}
+
+const xmlDoc = `<order id="123"><item sku="A1">Apple</item><item>Banana</item><customer><name>Bob</name></customer></order>`
+
 func TestFunctions(t *testing.T) {
 	dmy := envs.NewBuilder().WithDateFormat(envs.DateFormatDayMonthYear).Build()
 	mdy := envs.NewBuilder().
@@ -37,6 +55,8 @@ func TestFunctions(t *testing.T) {
 		WithTimeFormat(envs.TimeFormatHourMinuteAmPm).
 		WithTimezone(la).
 		Build()
+	eth := envs.NewBuilder().WithDateFormat(envs.DateFormatDayMonthYear).WithCalendar(envs.CalendarEthiopian).Build()
+	short := envs.NewBuilder().WithMaxValueLength(3).Build()
 
 	var funcTests = []struct {
 		name     string
@@ -148,6 +168,9 @@ func TestFunctions(t *testing.T) {
 		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xs("105"), xs("m")}, xdt(time.Date(2017, 12, 4, 0, 0, 0, 0, time.UTC))},
 		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xs("-20"), xs("m")}, xdt(time.Date(2017, 12, 3, 21, 55, 0, 0, time.UTC))},
 		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xs("2"), xs("s")}, xdt(time.Date(2017, 12, 3, 22, 15, 2, 0, time.UTC))},
+		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xdu(90 * time.Minute)}, xdt(time.Date(2017, 12, 3, 23, 45, 0, 0, time.UTC))},
+		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xs("-P1D")}, xdt(time.Date(2017, 12, 2, 22, 15, 0, 0, time.UTC))},
+		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xs("2")}, ERROR},
 		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15pm"), xs("-2"), xs("s")}, xdt(time.Date(2017, 12, 3, 22, 14, 58, 0, time.UTC))},
 		{"datetime_add", dmy, []types.XValue{xs("xxx"), xs("2"), xs("D")}, ERROR},
 		{"datetime_add", dmy, []types.XValue{xs("03-12-2017 10:15"), xs("xxx"), xs("D")}, ERROR},
@@ -184,6 +207,9 @@ func TestFunctions(t *testing.T) {
 		{"datetime_diff", dmy, []types.XValue{xs("01-12-2017"), xs("01-12-2017"), xs("xxx")}, ERROR},
 		{"datetime_diff", dmy, []types.XValue{xs("01-12-2017"), xs("01-12-2017"), ERROR}, ERROR},
 		{"datetime_diff", dmy, []types.XValue{}, ERROR},
+		{"datetime_diff", dmy, []types.XValue{xs("04-12-2018 10:15"), xs("04-12-2018 14:00")}, xdu(225 * time.Minute)},
+		{"datetime_diff", dmy, []types.XValue{xs("05-12-2018 10:15"), xs("04-12-2018 10:00")}, xdu(-24*time.Hour - 15*time.Minute)},
+		{"datetime_diff", dmy, []types.XValue{xs("05-12-2018 10:15"), xs("xxx")}, ERROR},
 
 		// check across DST boundaries
 		{"datetime_diff", mdy, []types.XValue{xs("03-10-2019 1:00am"), xs("03-10-2019 5:00am"), xs("h")}, xi(3)},
@@ -277,10 +303,23 @@ func TestFunctions(t *testing.T) {
 		{"format", dmy, []types.XValue{xdt(time.Date(2017, 6, 12, 16, 56, 59, 0, time.UTC))}, xs("12-06-2017 16:56")},
 		{"format", dmy, []types.XValue{nil}, xs("")},
 
+		{"format_csv", dmy, []types.XValue{xa(xo("b", xs("1"), "a", xs("x")), xo("c", xi(3)))}, xs("a,b,c\nx,1,\n,,3")},
+		{"format_csv", dmy, []types.XValue{xa(xo("a", xs("x y"), "b", nil)), xs("\t")}, xs("a\tb\nx y\t")},
+		{"format_csv", dmy, []types.XValue{xa(xo("a", xs(`say "hi"`)))}, xs("a\n\"say \"\"hi\"\"\"")},
+		{"format_csv", dmy, []types.XValue{xa()}, xs("")},
+		{"format_csv", dmy, []types.XValue{xa(xo("a", xs("x"))), xs("||")}, ERROR},
+		{"format_csv", dmy, []types.XValue{xa(xs("x"))}, ERROR},
+		{"format_csv", dmy, []types.XValue{xa(xo("a", ERROR))}, ERROR},
+		{"format_csv", dmy, []types.XValue{ERROR}, ERROR},
+		{"format_csv", dmy, []types.XValue{}, ERROR},
+
 		{"format_date", dmy, []types.XValue{xs("1977-06-23T15:34:00.000000Z")}, xs("23-06-1977")},
 		{"format_date", mdy, []types.XValue{xs("1977-06-23T15:34:00.000000Z")}, xs("06-23-1977")},
 		{"format_date", dmy, []types.XValue{xs("1977-06-23T15:34:00.000000Z"), xs("YYYY-MM-DD")}, xs("1977-06-23")},
 		{"format_date", dmy, []types.XValue{xs("1977-06-23"), xs("YYYY/MM/DD")}, xs("1977/06/23")},
+		{"format_date", eth, []types.XValue{xs("1977-06-23")}, xs("16-10-1969")},
+		{"format_date", eth, []types.XValue{xs("1977-06-23"), xs("EEE, D MMMM YYYY")}, xs("Thu, 16 Sene 1969")},
+		{"format_date", eth, []types.XValue{xs("16-10-1969"), xs("YYYY-MM-DD")}, xs("1969-10-16")},
 		{"format_date", dmy, []types.XValue{xs("NOT DATE")}, ERROR},
 		{"format_date", dmy, []types.XValue{ERROR}, ERROR},
 		{"format_date", dmy, []types.XValue{xs("1977-06-23T15:34:00.000000Z"), ERROR}, ERROR},
@@ -290,6 +329,8 @@ func TestFunctions(t *testing.T) {
 
 		{"format_datetime", dmy, []types.XValue{xs("1977-06-23T15:34:00.000000Z")}, xs("23-06-1977 15:34")},
 		{"format_datetime", mdy, []types.XValue{xs("1977-06-23T15:34:00.000000Z")}, xs("06-23-1977 8:34 am")},
+		{"format_datetime", eth, []types.XValue{xs("1977-06-23T15:34:00.000000Z")}, xs("16-10-1969 15:34")},
+		{"format_datetime", eth, []types.XValue{xs("1977-06-23T23:34:00.000000Z"), xs("D MMM YYYY tt:mm"), xs("Africa/Addis_Ababa")}, xs("17 Sen 1969 02:34")},
 		{"format_datetime", dmy, []types.XValue{xs("1977-06-23T15:34:00.000000Z"), xs("YYYY-MM-DDTtt:mm:ss.fffZZZ"), xs("America/Los_Angeles")}, xs("1977-06-23T08:34:00.000-07:00")},
 		{"format_datetime", dmy, []types.XValue{xs("1977-06-23T15:34:00.123000Z"), xs("YYYY-MM-DDTtt:mm:ss.fffZ"), xs("America/Los_Angeles")}, xs("1977-06-23T08:34:00.123-07:00")},
 		{"format_datetime", dmy, []types.XValue{xs("1977-06-23T15:34:00.000000Z"), xs("YYYY-MM-DDTtt:mm:ss.ffffffZ"), xs("America/Los_Angeles")}, xs("1977-06-23T08:34:00.000000-07:00")},
@@ -313,6 +354,13 @@ func TestFunctions(t *testing.T) {
 		{"format_time", dmy, []types.XValue{xs("15:34:00.000000"), ERROR}, ERROR},
 		{"format_time", dmy, []types.XValue{}, ERROR},
 
+		{"format_currency", dmy, []types.XValue{xm("1234.5", "USD")}, xs("$1,234.50")},
+		{"format_currency", dmy, []types.XValue{xm("1234.567", "USD")}, xs("$1,234.57")},
+		{"format_currency", dmy, []types.XValue{xi(1500), xs("KES")}, xs("KSh 1,500.00")},
+		{"format_currency", dmy, []types.XValue{xi(1500), xs("ABC")}, xs("ABC 1,500.00")},
+		{"format_currency", dmy, []types.XValue{xi(1500)}, ERROR},
+		{"format_currency", dmy, []types.XValue{ERROR}, ERROR},
+
 		{"format_location", dmy, []types.XValue{xs("Rwanda")}, xs("Rwanda")},
 		{"format_location", dmy, []types.XValue{xs("Rwanda > Kigali")}, xs("Kigali")},
 		{"format_location", dmy, []types.XValue{ERROR}, ERROR},
@@ -449,10 +497,26 @@ func TestFunctions(t *testing.T) {
 		{"parse_datetime", dmy, []types.XValue{xs("1977-06-23 15:34"), xs("YYYY-MM-DD"), ERROR}, ERROR},        // error as timezone
 		{"parse_datetime", dmy, []types.XValue{}, ERROR},
 
+		{"parse_csv", dmy, []types.XValue{xs("name, age\nBob,32\nAnn")}, xa(xo("name", xs("Bob"), "age", xs("32")), xo("name", xs("Ann"), "age", xs("")))},
+		{"parse_csv", dmy, []types.XValue{xs("name|note\n\"Bob|Jr\"|\"a\nb\""), xs("|")}, xa(xo("name", xs("Bob|Jr"), "note", xs("a\nb")))},
+		{"parse_csv", short, []types.XValue{xs("name\nBobby")}, xa(xo("name", xs("Bob")))},
+		{"parse_csv", dmy, []types.XValue{xs("name")}, xa()},
+		{"parse_csv", dmy, []types.XValue{xs("")}, xa()},
+		{"parse_csv", dmy, []types.XValue{xs("name\n\"Bob")}, ERROR},
+		{"parse_csv", dmy, []types.XValue{xs("name\nBob"), xs("")}, ERROR},
+		{"parse_csv", dmy, []types.XValue{ERROR}, ERROR},
+		{"parse_csv", dmy, []types.XValue{}, ERROR},
+
 		{"parse_json", dmy, []types.XValue{xs(`"hello"`)}, xs(`hello`)},
 		{"parse_json", dmy, []types.XValue{xs(`{a: b}`)}, ERROR},
 		{"parse_json", dmy, []types.XValue{ERROR}, ERROR},
 
+		{"parse_xml", dmy, []types.XValue{xs(`<name first="Bob">Bob Smith</name>`)}, xo("name", xo("_first", xs("Bob"), "_text", xs("Bob Smith")))},
+		{"parse_xml", short, []types.XValue{xs(`<name>Bobby</name>`)}, xo("name", xs("Bob"))},
+		{"parse_xml", dmy, []types.XValue{xs(`<name>`)}, ERROR},
+		{"parse_xml", dmy, []types.XValue{ERROR}, ERROR},
+		{"parse_xml", dmy, []types.XValue{}, ERROR},
+
 		{"percent", dmy, []types.XValue{xs(".54")}, xs("54%")},
 		{"percent", dmy, []types.XValue{xs("1.246")}, xs("125%")},
 		{"percent", dmy, []types.XValue{xs("")}, ERROR},
@@ -588,6 +652,17 @@ func TestFunctions(t *testing.T) {
 		{"text_length", dmy, []types.XValue{xs("hello")}, xi(5)},
 		{"text_length", dmy, []types.XValue{xs("")}, xi(0)},
 		{"text_length", dmy, []types.XValue{xs("😁😁")}, xi(2)},
+
+		{"segment_count", dmy, []types.XValue{xs("")}, xi(0)},
+		{"segment_count", dmy, []types.XValue{xs("hello")}, xi(1)},
+		{"segment_count", dmy, []types.XValue{xs(strings.Repeat("a", 160))}, xi(1)},
+		{"segment_count", dmy, []types.XValue{xs(strings.Repeat("a", 161))}, xi(2)},
+		{"segment_count", dmy, []types.XValue{xs(strings.Repeat("ê", 71))}, xi(2)},
+		{"segment_count", dmy, []types.XValue{xs(strings.Repeat("a", 70)), xs("UCS2")}, xi(1)},
+		{"segment_count", dmy, []types.XValue{xs(strings.Repeat("a", 71)), xs("ucs2")}, xi(2)},
+		{"segment_count", dmy, []types.XValue{xs("hello"), xs("utf8")}, ERROR},
+		{"segment_count", dmy, []types.XValue{ERROR}, ERROR},
+		{"segment_count", dmy, []types.XValue{}, ERROR},
 		{"text_length", dmy, []types.XValue{xs(" 2♣️ ")}, xi(5)},     // emoji color modifier
 		{"text_length", dmy, []types.XValue{xa(xs("hello"))}, xi(7)}, // [hello]
 		{"text_length", dmy, []types.XValue{xa()}, xi(2)},            // []
@@ -601,6 +676,30 @@ func TestFunctions(t *testing.T) {
 		{"time", dmy, []types.XValue{xs("12:00pm")}, xt(dates.NewTimeOfDay(12, 0, 0, 0))},
 		{"time", dmy, []types.XValue{ERROR}, ERROR},
 
+		{"duration", dmy, []types.XValue{xs("P1DT2H")}, xdu(26 * time.Hour)},
+		{"duration", dmy, []types.XValue{xs("3 hours and 15 mins")}, xdu(3*time.Hour + 15*time.Minute)},
+		{"duration", dmy, []types.XValue{xdu(time.Hour)}, xdu(time.Hour)},
+		{"duration", dmy, []types.XValue{xi(12)}, ERROR},
+		{"duration", dmy, []types.XValue{xs("P1Y")}, ERROR},
+		{"duration", dmy, []types.XValue{ERROR}, ERROR},
+
+		{"duration_from_parts", dmy, []types.XValue{xi(1), xi(2), xi(3), xi(4)}, xdu(26*time.Hour + 3*time.Minute + 4*time.Second)},
+		{"duration_from_parts", dmy, []types.XValue{xi(0), xi(-2), xi(0), xi(0)}, xdu(-2 * time.Hour)},
+		{"duration_from_parts", dmy, []types.XValue{xi(0), xi(0), ERROR, xi(0)}, ERROR},
+		{"duration_from_parts", dmy, []types.XValue{xi(1), xi(2), xi(3)}, ERROR},
+
+		{"money", dmy, []types.XValue{xi(20), xs("usd")}, xm("20", "USD")},
+		{"money", dmy, []types.XValue{xs("that's 1,000.50 KSh")}, xm("1000.50", "KES")},
+		{"money", dmy, []types.XValue{xm("3", "RWF")}, xm("3", "RWF")},
+		{"money", dmy, []types.XValue{xi(20), xs("US Dollars")}, ERROR},
+		{"money", dmy, []types.XValue{xs("20")}, ERROR},
+		{"money", dmy, []types.XValue{ERROR, xs("USD")}, ERROR},
+		{"money", dmy, []types.XValue{}, ERROR},
+
+		{"convert_currency", dmy, []types.XValue{xm("20", "USD"), xs("USD")}, ERROR}, // no exchange rates in environment
+		{"convert_currency", dmy, []types.XValue{xs("20"), xs("USD")}, ERROR},
+		{"convert_currency", dmy, []types.XValue{xm("20", "USD")}, ERROR},
+
 		{"time_from_parts", dmy, []types.XValue{xi(14), xi(40), xi(15)}, xt(dates.NewTimeOfDay(14, 40, 15, 0))},
 		{"time_from_parts", dmy, []types.XValue{xi(25), xi(40), xi(15)}, ERROR},
 		{"time_from_parts", dmy, []types.XValue{xi(14), xi(61), xi(15)}, ERROR},
@@ -714,6 +813,24 @@ func TestFunctions(t *testing.T) {
 		{"week_number", dmy, []types.XValue{xs("xxx")}, ERROR},
 		{"week_number", dmy, []types.XValue{}, ERROR},
 
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/item")}, xa(xo("_sku", xs("A1"), "_text", xs("Apple")), xs("Banana"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("order/item[2]")}, xa(xs("Banana"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/item[3]")}, xa()},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/item/@sku")}, xa(xs("A1"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/@*")}, xa(xs("123"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/item/text()")}, xa(xs("Apple"), xs("Banana"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/*/name")}, xa(xs("Bob"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("//name")}, xa(xs("Bob"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/Order")}, xa()},
+		{"xpath", dmy, []types.XValue{xo("customer", xo("name", xs("Bob"))), xs("customer/name")}, xa(xs("Bob"))},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/")}, ERROR},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("/order/item[")}, ERROR},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), xs("")}, ERROR},
+		{"xpath", dmy, []types.XValue{xs("<order>"), xs("/order")}, ERROR},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc), ERROR}, ERROR},
+		{"xpath", dmy, []types.XValue{ERROR, xs("/order")}, ERROR},
+		{"xpath", dmy, []types.XValue{xs(xmlDoc)}, ERROR},
+
 		{"url_encode", dmy, []types.XValue{xs(`hi-% ?/`)}, xs(`hi-%25%20%3F%2F`)},
 		{"url_encode", dmy, []types.XValue{ERROR}, ERROR},
 		{"url_encode", dmy, []types.XValue{}, ERROR},
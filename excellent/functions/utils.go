@@ -1,6 +1,16 @@
 package functions
 
-import "github.com/nyaruka/goflow/utils"
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+)
 
 func extractWords(text string, delimiters string) []string {
 	if delimiters != "" {
@@ -9,3 +19,145 @@ func extractWords(text string, delimiters string) []string {
 		return utils.TokenizeString(text)
 	}
 }
+
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// converts the given value to a currency code, e.g. "usd" to USD
+func toCurrency(env envs.Environment, x types.XValue) (envs.Currency, types.XError) {
+	code, xerr := types.ToXText(env, x)
+	if xerr != nil {
+		return envs.NilCurrency, xerr
+	}
+
+	normalized := strings.ToUpper(strings.TrimSpace(code.Native()))
+	if !currencyCodeRegex.MatchString(normalized) {
+		return envs.NilCurrency, types.NewXErrorf("%s is not a valid currency code", code.Describe())
+	}
+
+	return envs.Currency(normalized), nil
+}
+
+// converts the given text to a CSV delimiter which must be a single character
+func toCSVDelimiter(delimiter types.XText) (rune, types.XError) {
+	runes := []rune(delimiter.Native())
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, types.NewXErrorf("%s is not a valid CSV delimiter", delimiter.Describe())
+	}
+	return runes[0], nil
+}
+
+var xpathStepRegex = regexp.MustCompile(`^(\*|@\*|@?[\w\-.]+|text\(\))(?:\[(\d+)\])?$`)
+
+// evaluates a subset of XPath against a value parsed from XML, returning all matching values
+func evaluateXPath(doc *types.XObject, path string) ([]types.XValue, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("XPath expression can't be empty")
+	}
+
+	// absolute and relative paths are both evaluated from the given value
+	steps := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	nodes := []types.XValue{doc}
+	descendants := false
+
+	for _, step := range steps {
+		if step == "" {
+			descendants = true
+			continue
+		}
+
+		parts := xpathStepRegex.FindStringSubmatch(step)
+		if parts == nil {
+			return nil, errors.Errorf("invalid XPath step '%s'", step)
+		}
+
+		if descendants {
+			nodes = xmlDescendants(nodes)
+			descendants = false
+		}
+
+		matches := make([]types.XValue, 0)
+		for _, node := range nodes {
+			stepMatches := xpathStep(node, parts[1])
+
+			if parts[2] != "" {
+				index, _ := strconv.Atoi(parts[2])
+				if index >= 1 && index <= len(stepMatches) {
+					stepMatches = stepMatches[index-1 : index]
+				} else {
+					stepMatches = nil
+				}
+			}
+
+			matches = append(matches, stepMatches...)
+		}
+		nodes = matches
+	}
+
+	if descendants {
+		return nil, errors.New("XPath expression can't end with a step separator")
+	}
+
+	return nodes, nil
+}
+
+// evaluates a single XPath step against a node
+func xpathStep(node types.XValue, test string) []types.XValue {
+	obj, isObject := node.(*types.XObject)
+
+	switch {
+	case test == "text()":
+		if isObject {
+			if text, exists := obj.Get("_text"); exists {
+				return []types.XValue{text}
+			}
+		} else if text, isText := node.(types.XText); isText {
+			return []types.XValue{text}
+		}
+	case isObject && test == "@*":
+		matches := make([]types.XValue, 0)
+		for _, p := range obj.Properties() {
+			if strings.HasPrefix(p, "_") && p != "_text" {
+				value, _ := obj.Get(p)
+				matches = append(matches, value)
+			}
+		}
+		return matches
+	case isObject && strings.HasPrefix(test, "@"):
+		if value, exists := obj.Get("_" + test[1:]); exists {
+			return []types.XValue{value}
+		}
+	case isObject:
+		matches := make([]types.XValue, 0)
+		for _, p := range obj.Properties() {
+			if strings.HasPrefix(p, "_") || (test != "*" && p != test) {
+				continue
+			}
+			value, _ := obj.Get(p)
+			matches = append(matches, xmlElements(value)...)
+		}
+		return matches
+	}
+	return nil
+}
+
+// returns the given nodes and all their descendant elements
+func xmlDescendants(nodes []types.XValue) []types.XValue {
+	all := make([]types.XValue, 0)
+	for _, node := range nodes {
+		all = append(all, node)
+		all = append(all, xmlDescendants(xpathStep(node, "*"))...)
+	}
+	return all
+}
+
+// repeated elements are parsed as arrays, so expand those into their individual elements
+func xmlElements(value types.XValue) []types.XValue {
+	if array, isArray := value.(*types.XArray); isArray {
+		elements := make([]types.XValue, array.Count())
+		for i := range elements {
+			elements[i] = array.Get(i)
+		}
+		return elements
+	}
+	return []types.XValue{value}
+}
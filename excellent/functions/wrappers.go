@@ -181,6 +181,22 @@ func TextAndDateFunction(f func(envs.Environment, types.XText, types.XDateTime)
 	})
 }
 
+// TextAndDurationFunction creates an XFunction from a function that takes a text and a duration arg
+func TextAndDurationFunction(f func(envs.Environment, types.XText, types.XDuration) types.XValue) types.XFunction {
+	return NumArgsCheck(2, func(env envs.Environment, args ...types.XValue) types.XValue {
+		str, xerr := types.ToXText(env, args[0])
+		if xerr != nil {
+			return xerr
+		}
+		duration, xerr := types.ToXDuration(env, args[1])
+		if xerr != nil {
+			return xerr
+		}
+
+		return f(env, str, duration)
+	})
+}
+
 // InitialTextFunction creates an XFunction from a function that takes an initial text arg followed by other args
 func InitialTextFunction(minOtherArgs int, maxOtherArgs int, f func(envs.Environment, types.XText, ...types.XValue) types.XValue) types.XFunction {
 	return MinAndMaxArgsCheck(minOtherArgs+1, maxOtherArgs+1, func(env envs.Environment, args ...types.XValue) types.XValue {
@@ -44,54 +44,179 @@ var NotEqual = textualBinary(func(env envs.Environment, text1 types.XText, text2
 	return types.NewXBoolean(!text1.Equals(text2))
 })
 
-// Negate negates a number
+// Negate negates a number or a duration
 //
 //   @(-fields.age) -> -23
+//   @(-duration("P1D")) -> -P1D
 //
 // @operator negate "- (unary)"
-var Negate = numericalUnary(func(env envs.Environment, num types.XNumber) types.XValue {
+var Negate UnaryOperator = func(env envs.Environment, arg types.XValue) types.XValue {
+	if dur, isDuration := withoutDefault(arg).(types.XDuration); isDuration {
+		return types.NewXDuration(-dur.Native())
+	}
+
+	return negateNumber(env, arg)
+}
+
+var negateNumber = numericalUnary(func(env envs.Environment, num types.XNumber) types.XValue {
 	return types.NewXNumber(num.Native().Neg())
 })
 
-// Add adds two numbers.
+// Add adds two numbers, two durations, or a duration to a datetime.
 //
 //   @(2 + 3) -> 5
 //   @(fields.age + 10) -> 33
+//   @(datetime("2017-01-15T10:00:00Z") + duration("P1DT2H")) -> 2017-01-16T12:00:00.000000Z
+//   @(duration("PT1H") + duration("PT30M")) -> PT1H30M
 //
 // @operator add "+"
-var Add = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
+var Add BinaryOperator = func(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	if isTemporal(arg1) || isTemporal(arg2) {
+		return addTemporal(env, arg1, arg2)
+	}
+
+	return addNumbers(env, arg1, arg2)
+}
+
+var addNumbers = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
 	return types.NewXNumber(num1.Native().Add(num2.Native()))
 })
 
-// Subtract subtracts two numbers.
+func addTemporal(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	dur1, isDuration1 := withoutDefault(arg1).(types.XDuration)
+	dur2, isDuration2 := withoutDefault(arg2).(types.XDuration)
+	_, isDateTime2 := withoutDefault(arg2).(types.XDateTime)
+
+	if isDuration1 && isDuration2 {
+		return types.NewXDuration(dur1.Native() + dur2.Native())
+	}
+
+	// addition is commutative so ensure the datetime is the first argument
+	if isDuration1 || isDateTime2 {
+		arg1, arg2 = arg2, arg1
+	}
+
+	dt, xerr := types.ToXDateTime(env, arg1)
+	if xerr != nil {
+		return xerr
+	}
+	dur, xerr := types.ToXDuration(env, arg2)
+	if xerr != nil {
+		return xerr
+	}
+
+	return types.NewXDateTime(dt.Native().Add(dur.Native()))
+}
+
+// Subtract subtracts two numbers or two durations, a duration from a datetime, or a datetime from another
+// datetime to give the duration between them.
 //
 //   @(3 - 2) -> 1
 //   @(2 - 3) -> -1
+//   @(datetime("2017-01-15T10:00:00Z") - duration("PT30M")) -> 2017-01-15T09:30:00.000000Z
+//   @(datetime("2017-01-17T12:00:00Z") - datetime("2017-01-15T10:00:00Z")) -> P2DT2H
 //
 // @operator subtract "- (binary)"
-var Subtract = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
+var Subtract BinaryOperator = func(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	if isTemporal(arg1) || isTemporal(arg2) {
+		return subtractTemporal(env, arg1, arg2)
+	}
+
+	return subtractNumbers(env, arg1, arg2)
+}
+
+var subtractNumbers = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
 	return types.NewXNumber(num1.Native().Sub(num2.Native()))
 })
 
-// Multiply multiplies two numbers.
+func subtractTemporal(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	if dur1, isDuration := withoutDefault(arg1).(types.XDuration); isDuration {
+		dur2, xerr := types.ToXDuration(env, arg2)
+		if xerr != nil {
+			return xerr
+		}
+		return types.NewXDuration(dur1.Native() - dur2.Native())
+	}
+
+	dt1, xerr := types.ToXDateTime(env, arg1)
+	if xerr != nil {
+		return xerr
+	}
+
+	// subtracting a duration gives a datetime, subtracting another datetime gives a duration
+	if _, isDateTime := withoutDefault(arg2).(types.XDateTime); !isDateTime {
+		if dur2, xerr := types.ToXDuration(env, arg2); xerr == nil {
+			return types.NewXDateTime(dt1.Native().Add(-dur2.Native()))
+		}
+	}
+
+	dt2, xerr := types.ToXDateTime(env, arg2)
+	if xerr != nil {
+		return xerr
+	}
+
+	return types.NewXDuration(dt1.Native().Sub(dt2.Native()))
+}
+
+// Multiply multiplies two numbers, or a duration by a number.
 //
 //   @(3 * 2) -> 6
 //   @(fields.age * 3) -> 69
+//   @(duration("PT20M") * 3) -> PT1H
 //
 // @operator multiply "*"
-var Multiply = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
+var Multiply BinaryOperator = func(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	if isDuration(arg2) {
+		arg1, arg2 = arg2, arg1
+	}
+	if dur, isDuration := withoutDefault(arg1).(types.XDuration); isDuration {
+		num, xerr := types.ToXNumber(env, arg2)
+		if xerr != nil {
+			return xerr
+		}
+		return types.NewXDuration(scaleDuration(dur.Native(), num.Native()))
+	}
+
+	return multiplyNumbers(env, arg1, arg2)
+}
+
+var multiplyNumbers = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
 	return types.NewXNumber(num1.Native().Mul(num2.Native()))
 })
 
-// Divide divides a number by another.
+// Divide divides a number by another, a duration by a number, or a duration by another duration.
 //
 //   @(4 / 2) -> 2
 //   @(3 / 2) -> 1.5
 //   @(46 / fields.age) -> 2
 //   @(3 / 0) -> ERROR
+//   @(duration("PT1H") / 4) -> PT15M
+//   @(duration("P1D") / duration("PT1H")) -> 24
 //
 // @operator divide "/"
-var Divide = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
+var Divide BinaryOperator = func(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+	if dur1, isDuration := withoutDefault(arg1).(types.XDuration); isDuration {
+		if dur2, isDuration := withoutDefault(arg2).(types.XDuration); isDuration {
+			if dur2.Native() == 0 {
+				return types.NewXErrorf("division by zero")
+			}
+			return types.NewXNumber(decimal.NewFromInt(int64(dur1.Native())).Div(decimal.NewFromInt(int64(dur2.Native()))))
+		}
+
+		num, xerr := types.ToXNumber(env, arg2)
+		if xerr != nil {
+			return xerr
+		}
+		if num.Equals(types.XNumberZero) {
+			return types.NewXErrorf("division by zero")
+		}
+		return types.NewXDuration(scaleDuration(dur1.Native(), decimal.NewFromInt(1).Div(num.Native())))
+	}
+
+	return divideNumbers(env, arg1, arg2)
+}
+
+var divideNumbers = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
 	if num2.Equals(types.XNumberZero) {
 		return types.NewXErrorf("division by zero")
 	}
@@ -122,46 +247,41 @@ var Exponent = numericalBinary(func(env envs.Environment, num1 types.XNumber, nu
 	return types.NewXNumber(decimal.NewFromFloat(math.Pow(f1, f2)))
 })
 
-// LessThan returns true if the first number is less than the second.
+// LessThan returns true if the first number, datetime or duration is less than the second.
 //
 //   @(2 < 3) -> true
 //   @(3 < 3) -> false
 //   @(4 < 3) -> false
+//   @(duration("PT90M") < duration("PT2H")) -> true
+//   @(datetime("2017-01-15") < datetime("2017-01-16")) -> true
 //
 // @operator lessthan "<"
-var LessThan = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
-	return types.NewXBoolean(num1.Compare(num2) < 0)
-})
+var LessThan = comparison(func(c int) bool { return c < 0 })
 
-// LessThanOrEqual returns true if the first number is less than or equal to the second.
+// LessThanOrEqual returns true if the first number, datetime or duration is less than or equal to the second.
 //
 //   @(2 <= 3) -> true
 //   @(3 <= 3) -> true
 //   @(4 <= 3) -> false
 //
 // @operator lessthanorequal "<="
-var LessThanOrEqual = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
-	return types.NewXBoolean(num1.Compare(num2) <= 0)
-})
+var LessThanOrEqual = comparison(func(c int) bool { return c <= 0 })
 
-// GreaterThan returns true if the first number is greater than the second.
+// GreaterThan returns true if the first number, datetime or duration is greater than the second.
 //
 //   @(2 > 3) -> false
 //   @(3 > 3) -> false
 //   @(4 > 3) -> true
+//   @(duration("P1D") > "PT12H") -> true
 //
 // @operator greaterthan ">"
-var GreaterThan = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
-	return types.NewXBoolean(num1.Compare(num2) > 0)
-})
+var GreaterThan = comparison(func(c int) bool { return c > 0 })
 
-// GreaterThanOrEqual returns true if the first number is greater than or equal to the second.
+// GreaterThanOrEqual returns true if the first number, datetime or duration is greater than or equal to the second.
 //
 //   @(2 >= 3) -> false
 //   @(3 >= 3) -> true
 //   @(4 >= 3) -> true
 //
 // @operator greaterthanorequal ">="
-var GreaterThanOrEqual = numericalBinary(func(env envs.Environment, num1 types.XNumber, num2 types.XNumber) types.XValue {
-	return types.NewXBoolean(num1.Compare(num2) >= 0)
-})
+var GreaterThanOrEqual = comparison(func(c int) bool { return c >= 0 })
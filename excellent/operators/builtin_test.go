@@ -3,6 +3,7 @@ package operators_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/excellent/operators"
@@ -16,6 +17,8 @@ var xs = types.NewXText
 var xn = types.RequireXNumberFromString
 var xi = types.NewXNumberFromInt
 var xa = types.NewXArray
+var xdt = types.NewXDateTime
+var xdu = types.NewXDuration
 var ERROR = types.NewXErrorf("any error")
 
 func TestBinaryOperators(t *testing.T) {
@@ -51,24 +54,49 @@ func TestBinaryOperators(t *testing.T) {
 		{operators.Add, xs("1"), xs("3"), xi(4)},
 		{operators.Add, ERROR, xi(1), ERROR},
 		{operators.Add, xi(1), ERROR, ERROR},
+		{operators.Add, xdu(time.Hour), xdu(time.Minute), xdu(61 * time.Minute)},
+		{operators.Add, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xdu(time.Hour), xdt(time.Date(2017, 1, 15, 11, 0, 0, 0, time.UTC))},
+		{operators.Add, xdu(time.Hour), xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xdt(time.Date(2017, 1, 15, 11, 0, 0, 0, time.UTC))},
+		{operators.Add, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xs("P1D"), xdt(time.Date(2017, 1, 16, 10, 0, 0, 0, time.UTC))},
+		{operators.Add, xs("2017-01-15T10:00:00Z"), xdu(time.Hour), xdt(time.Date(2017, 1, 15, 11, 0, 0, 0, time.UTC))},
+		{operators.Add, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xi(1), ERROR},
+		{operators.Add, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), ERROR},
+		{operators.Add, xdu(time.Hour), ERROR, ERROR},
 
 		{operators.Subtract, xi(1), xi(3), xi(-2)},
 		{operators.Subtract, xi(3), xi(1), xi(2)},
 		{operators.Subtract, xs("3"), xs("1"), xi(2)},
 		{operators.Subtract, ERROR, xi(1), ERROR},
 		{operators.Subtract, xi(1), ERROR, ERROR},
+		{operators.Subtract, xdu(time.Hour), xdu(time.Minute), xdu(59 * time.Minute)},
+		{operators.Subtract, xdu(time.Hour), xs("PT2H"), xdu(-time.Hour)},
+		{operators.Subtract, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xdu(time.Hour), xdt(time.Date(2017, 1, 15, 9, 0, 0, 0, time.UTC))},
+		{operators.Subtract, xdt(time.Date(2017, 1, 17, 12, 0, 0, 0, time.UTC)), xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xdu(50 * time.Hour)},
+		{operators.Subtract, xdt(time.Date(2017, 1, 17, 12, 0, 0, 0, time.UTC)), xs("2017-01-15T10:00:00Z"), xdu(50 * time.Hour)},
+		{operators.Subtract, xs("2017-01-15T10:00:00Z"), xdt(time.Date(2017, 1, 17, 12, 0, 0, 0, time.UTC)), xdu(-50 * time.Hour)},
+		{operators.Subtract, xdu(time.Hour), xdt(time.Date(2017, 1, 17, 12, 0, 0, 0, time.UTC)), ERROR},
+		{operators.Subtract, xdt(time.Date(2017, 1, 17, 12, 0, 0, 0, time.UTC)), xi(1), ERROR},
 
 		{operators.Multiply, xi(2), xi(3), xi(6)},
 		{operators.Multiply, xn("1.5"), xn("2.3"), xn("3.45")},
 		{operators.Multiply, xs("2"), xs("3"), xi(6)},
 		{operators.Multiply, ERROR, xi(1), ERROR},
 		{operators.Multiply, xi(1), ERROR, ERROR},
+		{operators.Multiply, xdu(time.Hour), xi(3), xdu(3 * time.Hour)},
+		{operators.Multiply, xn("1.5"), xdu(time.Hour), xdu(90 * time.Minute)},
+		{operators.Multiply, xdu(time.Hour), xdu(time.Hour), ERROR},
 
 		{operators.Divide, xi(3), xi(2), xn("1.5")},
 		{operators.Divide, xs("3"), xs("2"), xn("1.5")},
 		{operators.Divide, xi(3), xi(0), ERROR},
 		{operators.Divide, ERROR, xi(1), ERROR},
 		{operators.Divide, xi(1), ERROR, ERROR},
+		{operators.Divide, xdu(time.Hour), xi(4), xdu(15 * time.Minute)},
+		{operators.Divide, xdu(time.Hour), xi(3), xdu(20 * time.Minute)},
+		{operators.Divide, xdu(time.Hour), xdu(30 * time.Minute), xi(2)},
+		{operators.Divide, xdu(time.Hour), xi(0), ERROR},
+		{operators.Divide, xdu(time.Hour), xdu(0), ERROR},
+		{operators.Divide, xi(1), xdu(time.Hour), ERROR},
 
 		{operators.Exponent, xi(3), xi(2), xi(9)},
 		{operators.Exponent, xs("3"), xs("2"), xi(9)},
@@ -83,6 +111,11 @@ func TestBinaryOperators(t *testing.T) {
 		{operators.LessThan, xi(4), xi(3), types.XBooleanFalse},
 		{operators.LessThan, ERROR, xi(1), ERROR},
 		{operators.LessThan, xi(1), ERROR, ERROR},
+		{operators.LessThan, xdu(time.Hour), xdu(2 * time.Hour), types.XBooleanTrue},
+		{operators.LessThan, xdu(time.Hour), xs("PT30M"), types.XBooleanFalse},
+		{operators.LessThan, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xs("2017-01-16T10:00:00Z"), types.XBooleanTrue},
+		{operators.LessThan, xdu(time.Hour), xi(2), ERROR},
+		{operators.LessThan, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xi(2), ERROR},
 
 		{operators.LessThanOrEqual, xi(2), xi(3), types.XBooleanTrue},
 		{operators.LessThanOrEqual, xi(3), xi(3), types.XBooleanTrue},
@@ -101,6 +134,8 @@ func TestBinaryOperators(t *testing.T) {
 		{operators.GreaterThanOrEqual, xi(4), xi(3), types.XBooleanTrue},
 		{operators.GreaterThanOrEqual, ERROR, xi(1), ERROR},
 		{operators.GreaterThanOrEqual, xi(1), ERROR, ERROR},
+		{operators.GreaterThanOrEqual, xdu(time.Hour), xdu(time.Hour), types.XBooleanTrue},
+		{operators.GreaterThanOrEqual, xdt(time.Date(2017, 1, 15, 10, 0, 0, 0, time.UTC)), xdt(time.Date(2017, 1, 16, 10, 0, 0, 0, time.UTC)), types.XBooleanFalse},
 	}
 
 	for _, tc := range testCases {
@@ -129,6 +164,7 @@ func TestUnaryOperators(t *testing.T) {
 		{operators.Negate, xs("123"), xi(-123)},
 		{operators.Negate, xn("123.45"), xn("-123.45")},
 		{operators.Negate, ERROR, ERROR},
+		{operators.Negate, xdu(time.Hour), xdu(-time.Hour)},
 	}
 
 	for _, tc := range testCases {
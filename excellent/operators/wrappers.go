@@ -1,8 +1,12 @@
 package operators
 
 import (
+	"time"
+
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/excellent/types"
+
+	"github.com/shopspring/decimal"
 )
 
 // UnaryOperator is an operator which takes a single argument
@@ -51,3 +55,71 @@ func numericalBinary(f func(envs.Environment, types.XNumber, types.XNumber) type
 		return f(env, num1, num2)
 	}
 }
+
+// creates a comparison operator which compares datetimes or durations if either argument is one, and numbers otherwise
+func comparison(f func(int) bool) BinaryOperator {
+	return func(env envs.Environment, arg1 types.XValue, arg2 types.XValue) types.XValue {
+		if isDuration(arg1) || isDuration(arg2) {
+			dur1, xerr := types.ToXDuration(env, arg1)
+			if xerr != nil {
+				return xerr
+			}
+			dur2, xerr := types.ToXDuration(env, arg2)
+			if xerr != nil {
+				return xerr
+			}
+			return types.NewXBoolean(f(dur1.Compare(dur2)))
+		}
+
+		if isTemporal(arg1) || isTemporal(arg2) {
+			dt1, xerr := types.ToXDateTime(env, arg1)
+			if xerr != nil {
+				return xerr
+			}
+			dt2, xerr := types.ToXDateTime(env, arg2)
+			if xerr != nil {
+				return xerr
+			}
+			return types.NewXBoolean(f(dt1.Compare(dt2)))
+		}
+
+		num1, xerr := types.ToXNumber(env, arg1)
+		if xerr != nil {
+			return xerr
+		}
+		num2, xerr := types.ToXNumber(env, arg2)
+		if xerr != nil {
+			return xerr
+		}
+
+		return types.NewXBoolean(f(num1.Compare(num2)))
+	}
+}
+
+// gets the default value of the given value if it's an object which has one
+func withoutDefault(x types.XValue) types.XValue {
+	if obj, isObject := x.(*types.XObject); isObject {
+		return obj.Default()
+	}
+	return x
+}
+
+// whether the given value is a duration
+func isDuration(x types.XValue) bool {
+	_, is := withoutDefault(x).(types.XDuration)
+	return is
+}
+
+// whether the given value is a datetime or a duration
+func isTemporal(x types.XValue) bool {
+	switch withoutDefault(x).(type) {
+	case types.XDateTime, types.XDuration:
+		return true
+	}
+	return false
+}
+
+// multiplies a duration by a decimal factor
+func scaleDuration(d time.Duration, factor decimal.Decimal) time.Duration {
+	return time.Duration(decimal.NewFromInt(int64(d)).Mul(factor).Round(0).IntPart())
+}
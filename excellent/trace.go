@@ -0,0 +1,123 @@
+package excellent
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/utils"
+)
+
+// TraceType is the type of a node in an evaluation trace
+type TraceType string
+
+// possible types of trace nodes
+const (
+	TraceTypeTemplate   TraceType = "template"
+	TraceTypeExpression TraceType = "expression"
+	TraceTypeLiteral    TraceType = "literal"
+	TraceTypeLookup     TraceType = "lookup"
+	TraceTypeFunction   TraceType = "function"
+	TraceTypeOperator   TraceType = "operator"
+)
+
+// the maximum length of a rendered result in a trace
+const traceMaxResultLength = 100
+
+// Trace is a node in a tree which records how a template or expression was evaluated. Function call nodes have
+// the evaluated arguments as their children.
+type Trace struct {
+	Type       TraceType `json:"type"`
+	Text       string    `json:"text"`
+	Function   string    `json:"function,omitempty"`
+	Result     string    `json:"result,omitempty"`
+	ResultType string    `json:"result_type,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Children   []*Trace  `json:"children,omitempty"`
+}
+
+func newTrace(traceType TraceType, text string) *Trace {
+	return &Trace{Type: traceType, Text: text}
+}
+
+func (t *Trace) addChild(child *Trace) {
+	t.Children = append(t.Children, child)
+}
+
+// records the given value as the result of this node
+func (t *Trace) setResult(value types.XValue) {
+	if types.IsXError(value) {
+		t.setError(value.(error))
+		return
+	}
+
+	t.ResultType = traceTypeName(value)
+	t.Result = utils.TruncateEllipsis(types.Render(value), traceMaxResultLength)
+}
+
+func (t *Trace) setError(err error) {
+	t.ResultType = "error"
+	t.Error = err.Error()
+}
+
+// String returns a pretty-printed representation of this trace as a tree, e.g.
+//
+//   expression upper(contact.name) → text "BOB"
+//   └── function upper(contact.name) → text "BOB"
+//       └── lookup contact.name → text "Bob"
+func (t *Trace) String() string {
+	b := &strings.Builder{}
+	t.format(b, "", "")
+	return b.String()
+}
+
+func (t *Trace) format(b *strings.Builder, prefix, childPrefix string) {
+	b.WriteString(prefix)
+	b.WriteString(string(t.Type))
+	b.WriteString(" ")
+	b.WriteString(t.Text)
+
+	if t.Error != "" {
+		fmt.Fprintf(b, " → error %q", t.Error)
+	} else if t.ResultType == "null" {
+		b.WriteString(" → null")
+	} else if t.ResultType != "" {
+		fmt.Fprintf(b, " → %s %q", t.ResultType, t.Result)
+	}
+	b.WriteString("\n")
+
+	for i, child := range t.Children {
+		if i == len(t.Children)-1 {
+			child.format(b, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.format(b, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// TracedError is an error from evaluating a template which carries the trace of that evaluation
+type TracedError struct {
+	err   error
+	Trace *Trace
+}
+
+// NewTracedError creates a new traced error
+func NewTracedError(err error, trace *Trace) *TracedError {
+	return &TracedError{err: err, Trace: trace}
+}
+
+func (e *TracedError) Error() string {
+	return e.err.Error()
+}
+
+// gets the name of the type of the given value for a trace, e.g. "text" for XText
+func traceTypeName(value types.XValue) string {
+	if utils.IsNil(value) {
+		return "null"
+	}
+
+	name := reflect.TypeOf(value).String()
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.ToLower(strings.TrimPrefix(name, "X"))
+}
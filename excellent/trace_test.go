@@ -0,0 +1,87 @@
+package excellent_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateTemplateWithTrace(t *testing.T) {
+	context := types.NewXObject(map[string]types.XValue{
+		"contact": types.NewXObject(map[string]types.XValue{
+			"name": types.NewXText("Bob"),
+			"age":  types.NewXNumberFromInt(23),
+		}),
+		"names": types.NewXArray(types.NewXText("Ann"), types.NewXText("Jim")),
+	})
+	env := envs.NewBuilder().Build()
+
+	output, trace, err := excellent.EvaluateTemplateWithTrace(env, context, `Hi @(upper(contact.name) & "!") @names.1`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `Hi BOB! Jim`, output)
+	assert.Equal(t, `template Hi @(upper(contact.name) & "!") @names.1 → text "Hi BOB! Jim"
+├── expression upper(contact.name) & "!" → text "BOB!"
+│   └── operator upper(contact.name) & "!" → text "BOB!"
+│       ├── function upper(contact.name) → text "BOB"
+│       │   └── lookup contact.name → text "Bob"
+│       │       └── lookup contact → object "{age: 23, name: Bob}"
+│       └── literal "!" → text "!"
+└── expression names.1 → text "Jim"
+    └── lookup names.1 → text "Jim"
+        └── lookup names → array "[Ann, Jim]"
+`, trace.String())
+
+	// errors are recorded on the nodes where they occur and on every node they propagate to
+	_, trace, err = excellent.EvaluateTemplateWithTrace(env, context, `@(contact.age / (2 - 2))`, nil)
+	assert.EqualError(t, err, "error evaluating @(contact.age / (2 - 2)): division by zero")
+	assert.Equal(t, `template @(contact.age / (2 - 2)) → error "error evaluating @(contact.age / (2 - 2)): division by zero"
+└── expression contact.age / (2 - 2) → error "division by zero"
+    └── operator contact.age / (2 - 2) → error "division by zero"
+        ├── lookup contact.age → number "23"
+        │   └── lookup contact → object "{age: 23, name: Bob}"
+        └── operator 2 - 2 → number "0"
+            ├── literal 2 → number "2"
+            └── literal 2 → number "2"
+`, trace.String())
+
+	// as are syntax errors
+	_, trace, err = excellent.EvaluateTemplateWithTrace(env, context, `@(contact.age +)`, nil)
+	assert.EqualError(t, err, "error evaluating @(contact.age +): syntax error at ")
+	assert.Equal(t, `template @(contact.age +) → error "error evaluating @(contact.age +): syntax error at "
+└── expression contact.age + → error "syntax error at "
+`, trace.String())
+
+	// and calls to functions with invalid arguments
+	value, trace := excellent.EvaluateExpressionWithTrace(env, context, `if(true, null, contact.foo)`)
+	assert.Nil(t, value)
+	assert.Equal(t, `expression if(true, null, contact.foo) → null
+└── function if(true, null, contact.foo) → null
+    ├── literal true → boolean "true"
+    ├── literal null → null
+    └── lookup contact.foo → error "object has no property 'foo'"
+        └── lookup contact → object "{age: 23, name: Bob}"
+`, trace.String())
+
+	// traces can be serialized to JSON
+	traceJSON, err := json.Marshal(trace.Children[0].Children[2])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "lookup",
+		"text": "contact.foo",
+		"result_type": "error",
+		"error": "object has no property 'foo'",
+		"children": [
+			{"type": "lookup", "text": "contact", "result": "{age: 23, name: Bob}", "result_type": "object"}
+		]
+	}`, string(traceJSON))
+
+	// and the error returned to sessions carries the trace
+	tracedErr := excellent.NewTracedError(err, trace)
+	assert.Equal(t, trace, tracedErr.Trace)
+}
@@ -60,10 +60,14 @@ func Equals(x1 XValue, x2 XValue) bool {
 		return typed.Equals(x2.(XDate))
 	case XDateTime:
 		return typed.Equals(x2.(XDateTime))
+	case XDuration:
+		return typed.Equals(x2.(XDuration))
 	case XError:
 		return typed.Equals(x2.(XError))
 	case XFunction:
 		return typed.Equals(x2.(XFunction))
+	case XMoney:
+		return typed.Equals(x2.(XMoney))
 	case XNumber:
 		return typed.Equals(x2.(XNumber))
 	case *XObject:
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/nyaruka/gocommon/dates"
 	"github.com/nyaruka/gocommon/jsonx"
@@ -9,7 +10,8 @@ import (
 	"github.com/nyaruka/goflow/utils"
 )
 
-// XDate is a Gregorian calendar date value.
+// XDate is a Gregorian calendar date value. If the environment uses another calendar then dates are parsed
+// from and formatted in that calendar, but are always rendered as ISO 8601 Gregorian dates.
 //
 //   @(date_from_parts(2019, 4, 11)) -> 2019-04-11
 //   @(format_date(date_from_parts(2019, 4, 11))) -> 11-04-2019
@@ -44,7 +46,7 @@ func (x XDate) Format(env envs.Environment) string {
 
 // FormatCustom provides customised formatting
 func (x XDate) FormatCustom(env envs.Environment, layout string) (string, error) {
-	return x.Native().Format(layout, env.DefaultLocale().ToBCP47())
+	return env.Calendar().Format(x.Native().Combine(dates.ZeroTimeOfDay, time.UTC), layout, env.DefaultLocale().ToBCP47(), dates.DateOnlyLayouts)
 }
 
 // MarshalJSON is called when a struct containing this type is marshaled
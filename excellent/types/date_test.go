@@ -32,6 +32,15 @@ func TestXDate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "mié, 20-02-2019", formatted)
 
+	// years, months and days are formatted in the environment's calendar
+	hijri := envs.NewBuilder().WithDateFormat(envs.DateFormatDayMonthYear).WithCalendar(envs.CalendarHijri).Build()
+	assert.Equal(t, `2019-02-20`, d1.Render())
+	assert.Equal(t, `14-06-1440`, d1.Format(hijri))
+
+	formatted, err = d1.FormatCustom(hijri, "EEE, D MMMM YYYY")
+	assert.NoError(t, err)
+	assert.Equal(t, "Wed, 14 Jumada al-Thani 1440", formatted)
+
 	formatted, err = d1.FormatCustom(env, "YYYYYY")
 	assert.EqualError(t, err, "'YYYYYY' is not valid in a date formatting layout")
 
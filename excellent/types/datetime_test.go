@@ -49,6 +49,14 @@ func TestXDateTime(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "lun, 09-04-2018", formatted)
 
+	// years, months and days are formatted in the environment's calendar
+	eth := envs.NewBuilder().WithDateFormat(envs.DateFormatDayMonthYear).WithCalendar(envs.CalendarEthiopian).Build()
+	assert.Equal(t, `02-08-2010 00:01`, d1.Format(eth))
+
+	formatted, err = d1.FormatCustom(eth, "EEE, D MMMM YYYY", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mon, 1 Miyazya 2010", formatted)
+
 	formatted, err = d1.FormatCustom(env, "YYYYYY", nil)
 	assert.EqualError(t, err, "'YYYYYY' is not valid in a datetime formatting layout")
 
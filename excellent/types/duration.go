@@ -0,0 +1,288 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/utils"
+	"github.com/pkg/errors"
+)
+
+const day = 24 * time.Hour
+
+// XDuration is a length of time such as the time between two datetimes. Durations are fixed lengths of time so
+// a day is always 24 hours. Durations can be added to or subtracted from datetimes, and subtracting one datetime
+// from another gives a duration. They are rendered as ISO 8601 durations and formatted using the language of the
+// environment.
+//
+//   @(duration("P2DT3H")) -> P2DT3H
+//   @(format(duration("P2DT3H"))) -> 2 days 3 hours
+//   @(json(duration("P2DT3H"))) -> "P2DT3H"
+//   @(datetime("2017-01-15T10:00:00Z") + duration("PT90M")) -> 2017-01-15T11:30:00.000000Z
+//   @(datetime("2017-01-17T12:00:00Z") - datetime("2017-01-15T10:00:00Z")) -> P2DT2H
+//
+// @type duration
+type XDuration struct {
+	native time.Duration
+}
+
+// NewXDuration creates a new duration
+func NewXDuration(value time.Duration) XDuration {
+	return XDuration{native: value}
+}
+
+// Describe returns a representation of this type for error messages
+func (x XDuration) Describe() string { return "duration" }
+
+// Truthy determines truthiness for this type
+func (x XDuration) Truthy() bool {
+	return x.Native() != 0
+}
+
+// Render returns the canonical text representation
+func (x XDuration) Render() string { return formatISODuration(x.Native()) }
+
+// Format returns the pretty text representation
+func (x XDuration) Format(env envs.Environment) string {
+	return formatLocalizedDuration(x.Native(), env.DefaultLanguage())
+}
+
+// MarshalJSON is called when a struct containing this type is marshaled
+func (x XDuration) MarshalJSON() ([]byte, error) {
+	return jsonx.Marshal(x.Render())
+}
+
+// UnmarshalJSON is called when a struct containing this type is unmarshaled
+func (x *XDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := jsonx.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	d, err := parseISODuration(s)
+	if err != nil {
+		return err
+	}
+
+	x.native = d
+	return nil
+}
+
+// String returns the native string representation of this type
+func (x XDuration) String() string { return `XDuration(` + x.native.String() + `)` }
+
+// Native returns the native value of this type
+func (x XDuration) Native() time.Duration { return x.native }
+
+// Equals determines equality for this type
+func (x XDuration) Equals(other XDuration) bool {
+	return x.Native() == other.Native()
+}
+
+// Compare compares this duration to another
+func (x XDuration) Compare(other XDuration) int {
+	switch {
+	case x.Native() < other.Native():
+		return -1
+	case x.Native() > other.Native():
+		return 1
+	default:
+		return 0
+	}
+}
+
+// XDurationZero is the zero duration value
+var XDurationZero = NewXDuration(0)
+var _ XValue = XDurationZero
+
+// ToXDuration converts the given value to a duration or returns an error if that isn't possible
+func ToXDuration(env envs.Environment, x XValue) (XDuration, XError) {
+	if !utils.IsNil(x) {
+		switch typed := x.(type) {
+		case XError:
+			return XDurationZero, typed
+		case XDuration:
+			return typed, nil
+		case XText:
+			parsed, err := ParseDuration(typed.Native())
+			if err == nil {
+				return NewXDuration(parsed), nil
+			}
+		case *XObject:
+			if typed.hasDefault() {
+				return ToXDuration(env, typed.Default())
+			}
+		}
+	}
+
+	return XDurationZero, NewXErrorf("unable to convert %s to a duration", Describe(x))
+}
+
+// ParseDuration parses a duration which is either an ISO 8601 duration like P2DT3H, or a list of amounts and
+// units like "2 days 3 hours" or "1h30m"
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "P") || strings.HasPrefix(s, "-P") {
+		return parseISODuration(s)
+	}
+
+	return parseUnitsDuration(s)
+}
+
+var isoDurationRegex = regexp.MustCompile(`^(-)?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parses an ISO 8601 duration. Years and months aren't supported because they don't have fixed lengths.
+func parseISODuration(s string) (time.Duration, error) {
+	match := isoDurationRegex.FindStringSubmatch(s)
+	if match == nil || strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+		return 0, errors.Errorf("'%s' is not a valid ISO 8601 duration", s)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * day, day, time.Hour, time.Minute, time.Second} {
+		if match[i+2] != "" {
+			d += parseDurationAmount(match[i+2], unit)
+		}
+	}
+
+	if match[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+var durationUnitsRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(weeks|week|w|days|day|d|hours|hour|hrs|hr|h|minutes|minute|mins|min|m|seconds|second|secs|sec|s)`)
+var durationSeparatorRegex = regexp.MustCompile(`^(\s|,|and)*$`)
+
+// parses a list of amounts and units like "2 days, 3 hours and 5 minutes"
+func parseUnitsDuration(s string) (time.Duration, error) {
+	lowered := strings.ToLower(s)
+	matches := durationUnitsRegex.FindAllStringSubmatchIndex(lowered, -1)
+	if len(matches) == 0 {
+		return 0, errors.Errorf("'%s' is not a valid duration", s)
+	}
+
+	var d time.Duration
+	last := 0
+	for _, match := range matches {
+		// only whitespace and separators are allowed between the amounts
+		if !durationSeparatorRegex.MatchString(lowered[last:match[0]]) {
+			return 0, errors.Errorf("'%s' is not a valid duration", s)
+		}
+		last = match[1]
+
+		amount := lowered[match[2]:match[3]]
+		unit := lowered[match[4]:match[5]]
+
+		switch unit[0] {
+		case 'w':
+			d += parseDurationAmount(amount, 7*day)
+		case 'd':
+			d += parseDurationAmount(amount, day)
+		case 'h':
+			d += parseDurationAmount(amount, time.Hour)
+		case 'm':
+			d += parseDurationAmount(amount, time.Minute)
+		case 's':
+			d += parseDurationAmount(amount, time.Second)
+		}
+	}
+
+	if !durationSeparatorRegex.MatchString(lowered[last:]) {
+		return 0, errors.Errorf("'%s' is not a valid duration", s)
+	}
+
+	return d, nil
+}
+
+// converts an amount like "1.5" of the given unit to a duration
+func parseDurationAmount(amount string, unit time.Duration) time.Duration {
+	f, _ := strconv.ParseFloat(amount, 64)
+	return time.Duration(f * float64(unit))
+}
+
+// formats a duration as ISO 8601, e.g. P2DT3H
+func formatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	b := &strings.Builder{}
+	if d < 0 {
+		b.WriteString("-")
+		d = -d
+	}
+	b.WriteString("P")
+
+	days := d / day
+	d -= days * day
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+
+	if days > 0 {
+		fmt.Fprintf(b, "%dD", days)
+	}
+	if d > 0 || hours > 0 || minutes > 0 {
+		b.WriteString("T")
+	}
+	if hours > 0 {
+		fmt.Fprintf(b, "%dH", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(b, "%dM", minutes)
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		b.WriteString("S")
+	}
+
+	return b.String()
+}
+
+// singular and plural names of days, hours, minutes and seconds in each language we can format durations in
+var durationUnitNames = map[envs.Language][4][2]string{
+	"eng": {{"day", "days"}, {"hour", "hours"}, {"minute", "minutes"}, {"second", "seconds"}},
+	"fra": {{"jour", "jours"}, {"heure", "heures"}, {"minute", "minutes"}, {"seconde", "secondes"}},
+	"por": {{"dia", "dias"}, {"hora", "horas"}, {"minuto", "minutos"}, {"segundo", "segundos"}},
+	"spa": {{"día", "días"}, {"hora", "horas"}, {"minuto", "minutos"}, {"segundo", "segundos"}},
+}
+
+// formats a duration in the given language, e.g. "2 days 3 hours". Fractions of seconds are ignored.
+func formatLocalizedDuration(d time.Duration, lang envs.Language) string {
+	names, found := durationUnitNames[lang]
+	if !found {
+		names = durationUnitNames["eng"]
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	parts := make([]string, 0, 4)
+	for i, unit := range []time.Duration{day, time.Hour, time.Minute, time.Second} {
+		amount := d / unit
+		d -= amount * unit
+
+		if amount == 1 {
+			parts = append(parts, "1 "+names[i][0])
+		} else if amount > 1 {
+			parts = append(parts, fmt.Sprintf("%d %s", amount, names[i][1]))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "0 " + names[3][1]
+	}
+
+	return sign + strings.Join(parts, " ")
+}
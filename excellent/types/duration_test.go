@@ -0,0 +1,145 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/types"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXDuration(t *testing.T) {
+	env := envs.NewBuilder().Build()
+
+	d1 := types.NewXDuration(51*time.Hour + 30*time.Minute)
+	assert.Equal(t, `duration`, d1.Describe())
+	assert.True(t, d1.Truthy())
+	assert.False(t, types.XDurationZero.Truthy())
+	assert.Equal(t, `P2DT3H30M`, d1.Render())
+	assert.Equal(t, `2 days 3 hours 30 minutes`, d1.Format(env))
+	assert.Equal(t, `XDuration(51h30m0s)`, d1.String())
+
+	// test rendering and formatting of other durations
+	tcs := []struct {
+		duration  time.Duration
+		rendered  string
+		formatted string
+	}{
+		{0, "PT0S", "0 seconds"},
+		{time.Second, "PT1S", "1 second"},
+		{1500 * time.Millisecond, "PT1.5S", "1 second"},
+		{24 * time.Hour, "P1D", "1 day"},
+		{25*time.Hour + time.Second, "P1DT1H1S", "1 day 1 hour 1 second"},
+		{14 * 24 * time.Hour, "P14D", "14 days"},
+		{-90 * time.Minute, "-PT1H30M", "-1 hour 30 minutes"},
+	}
+	for _, tc := range tcs {
+		assert.Equal(t, tc.rendered, types.NewXDuration(tc.duration).Render(), "render mismatch for %s", tc.duration)
+		assert.Equal(t, tc.formatted, types.NewXDuration(tc.duration).Format(env), "format mismatch for %s", tc.duration)
+	}
+
+	// formatting is localized using the environment's default language
+	spa := envs.NewBuilder().WithAllowedLanguages([]envs.Language{"spa"}).Build()
+	assert.Equal(t, `2 días 3 horas 30 minutos`, d1.Format(spa))
+	kin := envs.NewBuilder().WithAllowedLanguages([]envs.Language{"kin"}).Build()
+	assert.Equal(t, `2 days 3 hours 30 minutes`, d1.Format(kin))
+
+	marshaled, err := jsonx.Marshal(d1)
+	assert.NoError(t, err)
+	assert.Equal(t, `"P2DT3H30M"`, string(marshaled))
+
+	var d2 types.XDuration
+	err = jsonx.Unmarshal([]byte(`"PT45M"`), &d2)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewXDuration(45*time.Minute), d2)
+
+	err = jsonx.Unmarshal([]byte(`"soon"`), &d2)
+	assert.EqualError(t, err, "'soon' is not a valid ISO 8601 duration")
+
+	// test equality
+	assert.True(t, d1.Equals(types.NewXDuration(51*time.Hour+30*time.Minute)))
+	assert.False(t, d1.Equals(types.NewXDuration(51*time.Hour)))
+	assert.True(t, types.Equals(d1, types.NewXDuration(51*time.Hour+30*time.Minute)))
+
+	// test comparisons
+	assert.Equal(t, 0, types.NewXDuration(51*time.Hour+30*time.Minute).Compare(d1))
+	assert.Equal(t, 1, types.NewXDuration(52*time.Hour).Compare(d1))
+	assert.Equal(t, -1, types.NewXDuration(-52*time.Hour).Compare(d1))
+}
+
+func TestParseDuration(t *testing.T) {
+	tcs := []struct {
+		input    string
+		expected time.Duration
+		err      string
+	}{
+		{"PT0S", 0, ""},
+		{"P2DT3H", 51 * time.Hour, ""},
+		{"P1W", 7 * 24 * time.Hour, ""},
+		{"PT1.5S", 1500 * time.Millisecond, ""},
+		{"-PT30M", -30 * time.Minute, ""},
+		{" P1D ", 24 * time.Hour, ""},
+		{"P", 0, "'P' is not a valid ISO 8601 duration"},
+		{"P1DT", 0, "'P1DT' is not a valid ISO 8601 duration"},
+		{"P1Y", 0, "'P1Y' is not a valid ISO 8601 duration"},
+		{"P1M", 0, "'P1M' is not a valid ISO 8601 duration"},
+		{"2 days 3 hours", 51 * time.Hour, ""},
+		{"2 Days, 3 hours and 5 minutes", 51*time.Hour + 5*time.Minute, ""},
+		{"1 week", 7 * 24 * time.Hour, ""},
+		{"1h30m", 90 * time.Minute, ""},
+		{"1.5 hrs", 90 * time.Minute, ""},
+		{"45 secs", 45 * time.Second, ""},
+		{"5 months", 0, "'5 months' is not a valid duration"},
+		{"about 5 days", 0, "'about 5 days' is not a valid duration"},
+		{"5 days ago", 0, "'5 days ago' is not a valid duration"},
+		{"", 0, "'' is not a valid duration"},
+	}
+
+	for _, tc := range tcs {
+		actual, err := types.ParseDuration(tc.input)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "error mismatch for input '%s'", tc.input)
+		} else {
+			assert.NoError(t, err, "unexpected error for input '%s'", tc.input)
+			assert.Equal(t, tc.expected, actual, "result mismatch for input '%s'", tc.input)
+		}
+	}
+}
+
+func TestToXDuration(t *testing.T) {
+	var tests = []struct {
+		value    types.XValue
+		expected types.XDuration
+		hasError bool
+	}{
+		{nil, types.XDurationZero, true},
+		{types.NewXError(errors.Errorf("Error")), types.XDurationZero, true},
+		{types.NewXNumberFromInt(123), types.XDurationZero, true},
+		{types.NewXText("PT2H"), types.NewXDuration(2 * time.Hour), false},
+		{types.NewXText("2 hours"), types.NewXDuration(2 * time.Hour), false},
+		{types.NewXText("wha?"), types.XDurationZero, true},
+		{types.NewXDuration(time.Minute), types.NewXDuration(time.Minute), false},
+		{types.NewXDateTime(time.Date(2018, 4, 9, 17, 1, 30, 0, time.UTC)), types.XDurationZero, true},
+		{types.NewXObject(map[string]types.XValue{
+			"__default__": types.NewXText("PT10M"), // should use default
+			"foo":         types.NewXNumberFromInt(234),
+		}), types.NewXDuration(10 * time.Minute), false},
+	}
+
+	env := envs.NewBuilder().Build()
+
+	for _, test := range tests {
+		result, err := types.ToXDuration(env, test.value)
+
+		if test.hasError {
+			assert.Error(t, err, "expected error for input %T{%s}", test.value, test.value)
+		} else {
+			assert.NoError(t, err, "unexpected error for input %T{%s}", test.value, test.value)
+			assert.Equal(t, test.expected.Native(), result.Native(), "result mismatch for input %T{%s}", test.value, test.value)
+		}
+	}
+}
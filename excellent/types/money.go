@@ -0,0 +1,210 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// XMoney is an amount of a currency.
+//
+//   @(money(20, "USD")) -> 20.00 USD
+//   @(format(money(1234.5, "USD"))) -> $1,234.50
+//   @(json(money(20, "USD"))) -> {"amount":20,"currency":"USD"}
+//
+// @type money
+type XMoney struct {
+	amount   decimal.Decimal
+	currency envs.Currency
+}
+
+// NewXMoney creates a new money value
+func NewXMoney(amount decimal.Decimal, currency envs.Currency) XMoney {
+	return XMoney{amount: amount, currency: currency}
+}
+
+// Describe returns a representation of this type for error messages
+func (x XMoney) Describe() string { return "money" }
+
+// Truthy determines truthiness for this type
+func (x XMoney) Truthy() bool {
+	return !x.amount.IsZero()
+}
+
+// Render returns the canonical text representation, e.g. 20.50 USD
+func (x XMoney) Render() string {
+	places := x.currency.Decimals()
+	if -x.amount.Exponent() > places {
+		places = -x.amount.Exponent()
+	}
+
+	return x.amount.StringFixed(places) + " " + string(x.currency)
+}
+
+// Format returns the pretty text representation
+func (x XMoney) Format(env envs.Environment) string {
+	return x.FormatCustom(env, env.DefaultLocale())
+}
+
+// FormatCustom formats this amount using the given locale to position the currency symbol
+func (x XMoney) FormatCustom(env envs.Environment, locale envs.Locale) string {
+	places := x.currency.Decimals()
+	amount := NewXNumber(x.amount.Round(places)).FormatCustom(env.NumberFormat(), int(places), true)
+
+	return x.currency.Place(amount, locale)
+}
+
+// MarshalJSON is called when a struct containing this type is marshaled
+func (x XMoney) MarshalJSON() ([]byte, error) {
+	return jsonx.Marshal(&moneyEnvelope{Amount: x.amount, Currency: x.currency})
+}
+
+// UnmarshalJSON is called when a struct containing this type is unmarshaled
+func (x *XMoney) UnmarshalJSON(data []byte) error {
+	e := &moneyEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return err
+	}
+
+	x.amount = e.Amount
+	x.currency = e.Currency
+	return nil
+}
+
+type moneyEnvelope struct {
+	Amount   decimal.Decimal `json:"amount"`
+	Currency envs.Currency   `json:"currency" validate:"required,currency"`
+}
+
+// String returns the native string representation of this type
+func (x XMoney) String() string {
+	return fmt.Sprintf(`XMoney(%s, %s)`, x.amount.String(), x.currency)
+}
+
+// Amount returns the amount of this money
+func (x XMoney) Amount() decimal.Decimal { return x.amount }
+
+// Currency returns the currency of this money
+func (x XMoney) Currency() envs.Currency { return x.currency }
+
+// Equals determines equality for this type
+func (x XMoney) Equals(other XMoney) bool {
+	return x.currency == other.currency && x.amount.Equal(other.amount)
+}
+
+// XMoneyZero is the zero money value
+var XMoneyZero = NewXMoney(decimal.Zero, envs.NilCurrency)
+var _ XValue = XMoneyZero
+
+// ToXMoney converts the given value to money or returns an error if that isn't possible
+func ToXMoney(env envs.Environment, x XValue) (XMoney, XError) {
+	if !utils.IsNil(x) {
+		switch typed := x.(type) {
+		case XError:
+			return XMoneyZero, typed
+		case XMoney:
+			return typed, nil
+		case XText:
+			parsed, found := FindMoney(env, typed.Native())
+			if found {
+				return parsed, nil
+			}
+		case *XObject:
+			if typed.hasDefault() {
+				return ToXMoney(env, typed.Default())
+			}
+		}
+	}
+
+	return XMoneyZero, NewXErrorf("unable to convert %s to money", Describe(x))
+}
+
+// matches the currency codes and symbols we know, longest first so that MX$ is preferred to $. Short symbols like R
+// and Rs have to match case exactly so that we don't find money in words like "r" or "rs".
+var currencySymbolPattern string
+
+func init() {
+	symbols := envs.CurrencySymbols()
+	sort.Slice(symbols, func(i, j int) bool {
+		if len(symbols[i]) != len(symbols[j]) {
+			return len(symbols[i]) > len(symbols[j])
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	long, short := make([]string, 0, len(symbols)), make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if utf8.RuneCountInString(symbol) < 3 {
+			short = append(short, regexp.QuoteMeta(symbol))
+		} else {
+			long = append(long, regexp.QuoteMeta(symbol))
+		}
+	}
+	currencySymbolPattern = `(?i:` + strings.Join(long, "|") + `)|` + strings.Join(short, "|")
+}
+
+// regexes for finding money in text, which depend on the number format
+type moneyRegexes struct {
+	money    *regexp.Regexp
+	grouping *regexp.Regexp
+}
+
+var moneyRegexesCache = make(map[envs.NumberFormat]*moneyRegexes)
+var moneyRegexesMutex sync.Mutex
+
+// gets the regexes for finding money in text with the given number format, compiling them if necessary
+func getMoneyRegexes(format *envs.NumberFormat) *moneyRegexes {
+	moneyRegexesMutex.Lock()
+	defer moneyRegexesMutex.Unlock()
+
+	if r := moneyRegexesCache[*format]; r != nil {
+		return r
+	}
+
+	grouping := `[` + regexp.QuoteMeta(format.DigitGroupingSymbol) + ` \x{00A0}]`
+	decimals := regexp.QuoteMeta(format.DecimalSymbol)
+
+	number := `\d{1,3}(?:` + grouping + `\d{3})+(?:` + decimals + `\d+)?|\d+(?:` + decimals + `\d+)?`
+	pattern := `(?:^|[^\pL\pN])(` + currencySymbolPattern + `)\s?(` + number + `)|(` + number + `)\s?(` + currencySymbolPattern + `)(?:[^\pL]|$)`
+
+	r := &moneyRegexes{money: regexp.MustCompile(pattern), grouping: regexp.MustCompile(grouping)}
+	moneyRegexesCache[*format] = r
+	return r
+}
+
+// FindMoney finds the first amount of a known currency in the given text, e.g. "$20" or "20 000 RWF". Amounts
+// can use the digit grouping and decimal symbols of the environment, or spaces for digit grouping.
+func FindMoney(env envs.Environment, s string) (XMoney, bool) {
+	format := env.NumberFormat()
+	regexes := getMoneyRegexes(format)
+
+	match := regexes.money.FindStringSubmatch(s)
+	if match == nil {
+		return XMoneyZero, false
+	}
+
+	symbol, amount := match[1], match[2]
+	if symbol == "" {
+		amount, symbol = match[3], match[4]
+	}
+
+	// remove any digit grouping and normalize the decimal symbol
+	amount = regexes.grouping.ReplaceAllString(amount, "")
+	amount = strings.Replace(amount, format.DecimalSymbol, ".", 1)
+
+	parsed, err := decimal.NewFromString(amount)
+	if err != nil {
+		return XMoneyZero, false
+	}
+
+	return NewXMoney(parsed, envs.CurrencyFromSymbol(symbol)), true
+}
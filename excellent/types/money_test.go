@@ -0,0 +1,105 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/types"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMoney(t *testing.T) {
+	env := envs.NewBuilder().Build()
+
+	m1 := types.NewXMoney(decimal.RequireFromString("1234.5"), "USD")
+	assert.Equal(t, `money`, m1.Describe())
+	assert.True(t, m1.Truthy())
+	assert.False(t, types.NewXMoney(decimal.Zero, "USD").Truthy())
+	assert.Equal(t, `1234.50 USD`, m1.Render())
+	assert.Equal(t, `$1,234.50`, m1.Format(env))
+	assert.Equal(t, `XMoney(1234.5, USD)`, m1.String())
+	assert.Equal(t, decimal.RequireFromString("1234.5"), m1.Amount())
+	assert.Equal(t, envs.Currency("USD"), m1.Currency())
+
+	// rendering uses the decimal places of the currency unless the amount is more precise
+	assert.Equal(t, `20000 RWF`, types.NewXMoney(decimal.RequireFromString("20000"), "RWF").Render())
+	assert.Equal(t, `1.125 USD`, types.NewXMoney(decimal.RequireFromString("1.125"), "USD").Render())
+
+	// formatting uses the environment's number format and the position of symbol depends on the language
+	fra := envs.NewBuilder().
+		WithAllowedLanguages([]envs.Language{"fra"}).
+		WithNumberFormat(&envs.NumberFormat{DecimalSymbol: ",", DigitGroupingSymbol: " "}).
+		Build()
+	assert.Equal(t, `1 234,50 $`, m1.Format(fra))
+	assert.Equal(t, `KSh 1,234.50`, types.NewXMoney(decimal.RequireFromString("1234.5"), "KES").Format(env))
+	assert.Equal(t, `¥1,235`, types.NewXMoney(decimal.RequireFromString("1234.5"), "JPY").Format(env))
+
+	marshaled, err := jsonx.Marshal(m1)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":1234.5,"currency":"USD"}`, string(marshaled))
+
+	var m2 types.XMoney
+	err = jsonx.Unmarshal([]byte(`{"amount":20,"currency":"RWF"}`), &m2)
+	assert.NoError(t, err)
+	assert.True(t, types.NewXMoney(decimal.NewFromInt(20), "RWF").Equals(m2))
+
+	err = jsonx.Unmarshal([]byte(`{"amount":20,"currency":"dollars"}`), &m2)
+	assert.EqualError(t, err, "field 'currency' is not a valid currency code")
+
+	assert.True(t, m1.Equals(types.NewXMoney(decimal.RequireFromString("1234.50"), "USD")))
+	assert.False(t, m1.Equals(types.NewXMoney(decimal.RequireFromString("1234.5"), "EUR")))
+	assert.True(t, types.Equals(m1, types.NewXMoney(decimal.RequireFromString("1234.5"), "USD")))
+}
+
+func TestToXMoney(t *testing.T) {
+	env := envs.NewBuilder().Build()
+	xm := func(amount string, currency envs.Currency) types.XMoney {
+		return types.NewXMoney(decimal.RequireFromString(amount), currency)
+	}
+
+	tests := []struct {
+		value    types.XValue
+		expected types.XMoney
+		hasError bool
+	}{
+		{nil, types.XMoneyZero, true},
+		{types.NewXErrorf("error"), types.XMoneyZero, true},
+		{xm("20", "USD"), xm("20", "USD"), false},
+		{types.NewXText("$20"), xm("20", "USD"), false},
+		{types.NewXText("$ 20.50"), xm("20.50", "USD"), false},
+		{types.NewXText("it's 20 000 RWF"), xm("20000", "RWF"), false},
+		{types.NewXText("it's 1,500 rwf"), xm("1500", "RWF"), false},
+		{types.NewXText("MX$100"), xm("100", "MXN"), false},
+		{types.NewXText("€5 or £4"), xm("5", "EUR"), false},
+		{types.NewXText("20"), types.XMoneyZero, true},
+		{types.NewXText("20 apples"), types.XMoneyZero, true},
+		{types.NewXText("R20"), xm("20", "ZAR"), false},
+		{types.NewXText("Rs 500"), xm("500", "PKR"), false},
+		{types.NewXText("5000 Rp"), xm("5000", "IDR"), false},
+		{types.NewXText("r 20"), types.XMoneyZero, true},      // short symbols must match case
+		{types.NewXText("2 rs left"), types.XMoneyZero, true}, // short symbols must match case
+		{types.NewXText("rp 5000"), types.XMoneyZero, true},   // short symbols must match case
+		{types.NewXNumberFromInt(20), types.XMoneyZero, true},
+		{types.NewXObject(map[string]types.XValue{"__default__": types.NewXText("5 GBP")}), xm("5", "GBP"), false},
+	}
+
+	for _, test := range tests {
+		result, err := types.ToXMoney(env, test.value)
+
+		if test.hasError {
+			assert.Error(t, err, "expected error for input %T{%s}", test.value, test.value)
+		} else {
+			assert.NoError(t, err, "unexpected error for input %T{%s}", test.value, test.value)
+			assert.True(t, test.expected.Equals(result), "result mismatch for input %T{%s}, got %s", test.value, test.value, result)
+		}
+	}
+
+	// amounts are parsed using the environment's number format
+	fra := envs.NewBuilder().WithNumberFormat(&envs.NumberFormat{DecimalSymbol: ",", DigitGroupingSymbol: "."}).Build()
+	m, err := types.ToXMoney(fra, types.NewXText("1.234,50 €"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1234.50 EUR", m.Render())
+}
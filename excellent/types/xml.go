@@ -0,0 +1,128 @@
+package types
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+)
+
+// XMLToXValue returns an X type from the given XML document. The document becomes an object with a single property
+// for its root element. Elements which only contain text become text values, and other elements become objects whose
+// properties are their child elements, keyed by local name, with repeated child elements becoming arrays. Attributes
+// become properties prefixed with an underscore, and any text in an element with children or attributes becomes
+// a `_text` property. If `maxTextLength` is greater than zero then text values are truncated to that length.
+func XMLToXValue(data []byte, maxTextLength int) XValue {
+	if len(data) == 0 {
+		return nil
+	}
+
+	root, err := parseXML(data)
+	if err != nil {
+		return NewXErrorf("invalid XML")
+	}
+
+	return NewXObject(map[string]XValue{root.name: root.toXValue(maxTextLength)})
+}
+
+// a parsed XML element
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+}
+
+func parseXML(data []byte) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root *xmlElement
+	stack := make([]*xmlElement, 0)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch typed := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: typed.Name.Local, attrs: typed.Attr}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, element)
+			} else if root == nil {
+				root = element
+			} else {
+				return nil, errors.New("document has multiple root elements")
+			}
+
+			stack = append(stack, element)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(typed)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("document has no root element")
+	}
+	return root, nil
+}
+
+func (e *xmlElement) toXValue(maxTextLength int) XValue {
+	text := truncateText(strings.TrimSpace(e.text.String()), maxTextLength)
+	attrs := make([]xml.Attr, 0, len(e.attrs))
+	for _, attr := range e.attrs {
+		// namespace declarations aren't data
+		if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	if len(attrs) == 0 && len(e.children) == 0 {
+		return NewXText(text)
+	}
+
+	properties := make(map[string]XValue, len(attrs)+len(e.children)+1)
+	for _, attr := range attrs {
+		properties["_"+attr.Name.Local] = NewXText(truncateText(attr.Value, maxTextLength))
+	}
+
+	// group child elements by name, keeping repeated elements in document order
+	byName := make(map[string][]XValue)
+	for _, child := range e.children {
+		byName[child.name] = append(byName[child.name], child.toXValue(maxTextLength))
+	}
+	for name, values := range byName {
+		if len(values) == 1 {
+			properties[name] = values[0]
+		} else {
+			properties[name] = NewXArray(values...)
+		}
+	}
+
+	if text != "" {
+		properties["_text"] = NewXText(text)
+	}
+
+	return NewXObject(properties)
+}
+
+func truncateText(s string, maxLength int) string {
+	if maxLength > 0 {
+		return utils.Truncate(s, maxLength)
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMLToXValue(t *testing.T) {
+	assert.Nil(t, types.XMLToXValue(nil, 0))
+
+	test.AssertXEqual(t, types.NewXObject(map[string]types.XValue{
+		"name": types.NewXText("Bob"),
+	}), types.XMLToXValue([]byte(`<name> Bob </name>`), 0))
+
+	test.AssertXEqual(t, types.NewXObject(map[string]types.XValue{
+		"Envelope": types.NewXObject(map[string]types.XValue{
+			"Body": types.NewXObject(map[string]types.XValue{
+				"GetBalanceResponse": types.NewXObject(map[string]types.XValue{
+					"Balance": types.NewXObject(map[string]types.XValue{
+						"_currency": types.NewXText("RWF"),
+						"_text":     types.NewXText("1250"),
+					}),
+					"Bundle":  types.NewXArray(types.NewXText("data"), types.NewXText("voice")),
+					"Expired": types.NewXText(""),
+				}),
+			}),
+		}),
+	}), types.XMLToXValue([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+	<soap:Body>
+		<GetBalanceResponse xmlns="http://example.com/billing">
+			<Balance currency="RWF">1250</Balance>
+			<Bundle>data</Bundle>
+			<!-- comments are ignored -->
+			<Bundle>voice</Bundle>
+			<Expired/>
+		</GetBalanceResponse>
+	</soap:Body>
+</soap:Envelope>`), 0))
+
+	// text values can be truncated
+	test.AssertXEqual(t, types.NewXObject(map[string]types.XValue{
+		"name": types.NewXObject(map[string]types.XValue{
+			"_title": types.NewXText("Doc"),
+			"_text":  types.NewXText("Bob"),
+		}),
+	}), types.XMLToXValue([]byte(`<name title="Doctor">Bobby</name>`), 3))
+
+	for _, invalid := range []string{`fish`, `<a><b></a>`, `<a></a><b></b>`} {
+		xerr := types.XMLToXValue([]byte(invalid), 0).(types.XError)
+		assert.Equal(t, `invalid XML`, xerr.Error(), "error mismatch for %s", invalid)
+	}
+}
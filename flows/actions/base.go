@@ -131,8 +131,8 @@ func (a *baseAction) saveResult(run flows.FlowRun, step flows.Step, name, value,
 	logEvent(events.NewRunResultChanged(result))
 }
 
-// helper to save a run result based on a webhook call and log it as an event
-func (a *baseAction) saveWebhookResult(run flows.FlowRun, step flows.Step, name string, call *flows.WebhookCall, status flows.CallStatus, logEvent flows.EventCallback) {
+// helper to save a run result based on a webhook call and its response as JSON, and log it as an event
+func (a *baseAction) saveWebhookResult(run flows.FlowRun, step flows.Step, name string, call *flows.WebhookCall, status flows.CallStatus, response json.RawMessage, logEvent flows.EventCallback) {
 	input := fmt.Sprintf("%s %s", call.Request.Method, call.Request.URL.String())
 	value := "0"
 	category := webhookStatusCategories[status]
@@ -141,16 +141,17 @@ func (a *baseAction) saveWebhookResult(run flows.FlowRun, step flows.Step, name
 	if call.Response != nil {
 		value = strconv.Itoa(call.Response.StatusCode)
 
-		if len(call.ResponseBody) < resultExtraMaxBytes && call.ValidJSON {
-			extra = call.ResponseBody
+		if len(response) < resultExtraMaxBytes {
+			extra = response
 		}
 	}
 
 	a.saveResult(run, step, name, value, category, "", input, extra, logEvent)
 }
 
-func (a *baseAction) updateWebhook(run flows.FlowRun, call *flows.WebhookCall) {
-	parsed := types.JSONToXValue(call.ResponseBody)
+// helper to update @webhook from the response of a webhook call as JSON
+func (a *baseAction) updateWebhook(run flows.FlowRun, response json.RawMessage) {
+	parsed := types.JSONToXValue(response)
 
 	switch typed := parsed.(type) {
 	case nil, types.XError:
@@ -160,6 +161,14 @@ func (a *baseAction) updateWebhook(run flows.FlowRun, call *flows.WebhookCall) {
 	}
 }
 
+// gets the response body of a webhook call if it is valid JSON
+func webhookResponse(call *flows.WebhookCall) json.RawMessage {
+	if call.ValidJSON {
+		return call.ResponseBody
+	}
+	return nil
+}
+
 // helper to apply a contact modifier
 func (a *baseAction) applyModifier(run flows.FlowRun, mod flows.Modifier, logModifier flows.ModifierCallback, logEvent flows.EventCallback) {
 	mod.Apply(run.Environment(), run.Session().Assets(), run.Contact(), logEvent)
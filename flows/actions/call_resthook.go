@@ -134,12 +134,12 @@ func (a *CallResthookAction) Execute(run flows.FlowRun, step flows.Step, logModi
 
 	asResult := a.pickResultCall(calls)
 	if asResult != nil {
-		a.updateWebhook(run, asResult)
+		a.updateWebhook(run, webhookResponse(asResult))
 	}
 
 	if a.ResultName != "" {
 		if asResult != nil {
-			a.saveWebhookResult(run, step, a.ResultName, asResult, callStatus(asResult, nil, true), logEvent)
+			a.saveWebhookResult(run, step, a.ResultName, asResult, callStatus(asResult, nil, true), webhookResponse(asResult), logEvent)
 		} else {
 			a.saveResult(run, step, a.ResultName, "no subscribers", "Failure", "", "", nil, logEvent)
 		}
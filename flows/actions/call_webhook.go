@@ -1,26 +1,51 @@
 package actions
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/types"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/utils"
 
+	"github.com/buger/jsonparser"
 	"github.com/pkg/errors"
 	"golang.org/x/net/http/httpguts"
+	"gopkg.in/go-playground/validator.v9"
 )
 
 func isValidURL(u string) bool { _, err := url.Parse(u); return err == nil }
 
 func init() {
 	registerType(TypeCallWebhook, func() flows.Action { return &CallWebhookAction{} })
+
+	utils.RegisterValidatorAlias("webhook_mode", "eq=graphql|eq=soap", func(validator.FieldError) string {
+		return "is not a valid webhook mode"
+	})
 }
 
 // TypeCallWebhook is the type for the call webhook action
 const TypeCallWebhook string = "call_webhook"
 
+// modes in which a webhook request can be made other than a plain HTTP request
+const (
+	WebhookModeGraphQL = "graphql"
+	WebhookModeSOAP    = "soap"
+)
+
+const soapEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body>
+%s
+</soap:Body>
+</soap:Envelope>`
+
 // CallWebhookAction can be used to call an external service. The body, header and url fields may be
 // templates and will be evaluated at runtime. A [event:webhook_called] event will be created based on
 // the results of the HTTP call. If this action has a `result_name`, then additionally it will create
@@ -29,6 +54,15 @@ const TypeCallWebhook string = "call_webhook"
 // accessible through `extra` on the result. The last JSON response from a webhook call in the current
 // sprint will additionally be accessible in expressions as `@webhook` regardless of size.
 //
+// If the `mode` is `graphql` then the request body is built from the `query` and `variables`, and the call is
+// considered a failure if the response contains any `errors`. Variable values are sent with the type they evaluate
+// to, e.g. `@(20 + 3)` is sent as a number but `@contact.name` is always sent as a string.
+//
+// If the `mode` is `soap` then the body is wrapped in a SOAP envelope, with any expressions in it XML escaped, and
+// the `soap_action` is sent as the `SOAPAction` header. The contents of the `Body` element of the response are
+// converted from XML so that they can be accessed in the same way as a JSON response, and the call is considered a
+// failure if that contains a `Fault`.
+//
 //   {
 //     "uuid": "8eebd020-1af5-431c-b943-aa670fc74da9",
 //     "type": "call_webhook",
@@ -45,10 +79,14 @@ type CallWebhookAction struct {
 	baseAction
 	onlineAction
 
+	Mode       string            `json:"mode,omitempty" validate:"omitempty,webhook_mode"`
 	Method     string            `json:"method" validate:"required,http_method"`
 	URL        string            `json:"url" validate:"required" engine:"evaluated"`
 	Headers    map[string]string `json:"headers,omitempty" engine:"evaluated"`
 	Body       string            `json:"body,omitempty" engine:"evaluated"`
+	Query      string            `json:"query,omitempty" engine:"evaluated"`
+	Variables  map[string]string `json:"variables,omitempty" engine:"evaluated"`
+	SOAPAction string            `json:"soap_action,omitempty"`
 	ResultName string            `json:"result_name,omitempty"`
 }
 
@@ -64,6 +102,34 @@ func NewCallWebhook(uuid flows.ActionUUID, method string, url string, headers ma
 	}
 }
 
+// NewCallGraphQLWebhook creates a new call webhook action which makes a GraphQL request
+func NewCallGraphQLWebhook(uuid flows.ActionUUID, url string, headers map[string]string, query string, variables map[string]string, resultName string) *CallWebhookAction {
+	return &CallWebhookAction{
+		baseAction: newBaseAction(TypeCallWebhook, uuid),
+		Mode:       WebhookModeGraphQL,
+		Method:     http.MethodPost,
+		URL:        url,
+		Headers:    headers,
+		Query:      query,
+		Variables:  variables,
+		ResultName: resultName,
+	}
+}
+
+// NewCallSOAPWebhook creates a new call webhook action which makes a SOAP request
+func NewCallSOAPWebhook(uuid flows.ActionUUID, url string, headers map[string]string, soapAction string, body string, resultName string) *CallWebhookAction {
+	return &CallWebhookAction{
+		baseAction: newBaseAction(TypeCallWebhook, uuid),
+		Mode:       WebhookModeSOAP,
+		Method:     http.MethodPost,
+		URL:        url,
+		Headers:    headers,
+		Body:       body,
+		SOAPAction: soapAction,
+		ResultName: resultName,
+	}
+}
+
 // Validate validates our action is valid
 func (a *CallWebhookAction) Validate() error {
 	for key := range a.Headers {
@@ -72,6 +138,23 @@ func (a *CallWebhookAction) Validate() error {
 		}
 	}
 
+	if a.Mode != "" && a.Method != http.MethodPost {
+		return errors.Errorf("%s webhooks must use the POST method", a.Mode)
+	}
+	if a.Mode == WebhookModeGraphQL {
+		if a.Query == "" {
+			return errors.New("graphql webhooks must have a query")
+		}
+		if a.Body != "" {
+			return errors.New("graphql webhooks can't have a body")
+		}
+	} else if a.Query != "" || len(a.Variables) > 0 {
+		return errors.New("only graphql webhooks can have a query or variables")
+	}
+	if a.Mode != WebhookModeSOAP && a.SOAPAction != "" {
+		return errors.New("only soap webhooks can have a SOAP action")
+	}
+
 	return nil
 }
 
@@ -93,20 +176,72 @@ func (a *CallWebhookAction) Execute(run flows.FlowRun, step flows.Step, logModif
 	}
 
 	method := strings.ToUpper(a.Method)
-	body := a.Body
+	var body string
 
-	// substitute any body variables
-	if body != "" {
-		// webhook bodies aren't truncated like other templates
-		body, err = run.EvaluateTemplateText(body, nil, false)
+	switch a.Mode {
+	case WebhookModeGraphQL:
+		body, err = a.graphQLBody(run, logEvent)
 		if err != nil {
-			logEvent(events.NewError(err))
+			return err
 		}
+	case WebhookModeSOAP:
+		body = fmt.Sprintf(soapEnvelope, a.evaluateBody(run, flows.XMLEscaping, logEvent))
+	default:
+		body = a.evaluateBody(run, nil, logEvent)
 	}
 
 	return a.call(run, step, url, method, body, logEvent)
 }
 
+// substitutes any variables in our body
+func (a *CallWebhookAction) evaluateBody(run flows.FlowRun, escaping excellent.Escaping, logEvent flows.EventCallback) string {
+	if a.Body == "" {
+		return ""
+	}
+
+	// webhook bodies aren't truncated like other templates
+	body, err := run.EvaluateTemplateText(a.Body, escaping, false)
+	if err != nil {
+		logEvent(events.NewError(err))
+	}
+	return body
+}
+
+// builds the JSON body of a GraphQL request from our query and variables
+func (a *CallWebhookAction) graphQLBody(run flows.FlowRun, logEvent flows.EventCallback) (string, error) {
+	query, err := run.EvaluateTemplateText(a.Query, nil, false)
+	if err != nil {
+		logEvent(events.NewError(err))
+	}
+
+	var variables map[string]json.RawMessage
+	if len(a.Variables) > 0 {
+		variables = make(map[string]json.RawMessage, len(a.Variables))
+
+		for key, value := range a.Variables {
+			evaluated, err := run.EvaluateTemplateValue(value)
+			if err != nil {
+				logEvent(events.NewError(err))
+			}
+
+			asJSON, xerr := types.ToXJSON(evaluated)
+			if xerr != nil {
+				logEvent(events.NewError(xerr))
+				asJSON = types.NewXText(`null`)
+			}
+
+			variables[key] = json.RawMessage(asJSON.Native())
+		}
+	}
+
+	body, err := jsonx.Marshal(&struct {
+		Query     string                     `json:"query"`
+		Variables map[string]json.RawMessage `json:"variables,omitempty"`
+	}{Query: query, Variables: variables})
+
+	return string(body), err
+}
+
 // Execute runs this action
 func (a *CallWebhookAction) call(run flows.FlowRun, step flows.Step, url, method, body string, logEvent flows.EventCallback) error {
 	// build our request
@@ -125,6 +260,18 @@ func (a *CallWebhookAction) call(run flows.FlowRun, step flows.Step, url, method
 		req.Header.Add(key, headerValue)
 	}
 
+	switch a.Mode {
+	case WebhookModeGraphQL:
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	case WebhookModeSOAP:
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		}
+		req.Header.Set("SOAPAction", `"`+a.SOAPAction+`"`)
+	}
+
 	svc, err := run.Session().Engine().Services().Webhook(run.Session())
 	if err != nil {
 		logEvent(events.NewError(err))
@@ -137,20 +284,62 @@ func (a *CallWebhookAction) call(run flows.FlowRun, step flows.Step, url, method
 		logEvent(events.NewError(err))
 	}
 	if call != nil {
-		a.updateWebhook(run, call)
+		response, reportedErrors := a.readResponse(call)
+
+		a.updateWebhook(run, response)
 
 		status := callStatus(call, err, false)
+		if status == flows.CallStatusSuccess && reportedErrors {
+			status = flows.CallStatusResponseError
+		}
 
-		logEvent(events.NewWebhookCalled(call, status, ""))
+		event := events.NewWebhookCalled(call, status, "")
+		if response != nil {
+			event.BodyIgnored = false // SOAP responses are converted from XML
+		}
+		logEvent(event)
 
 		if a.ResultName != "" {
-			a.saveWebhookResult(run, step, a.ResultName, call, status, logEvent)
+			a.saveWebhookResult(run, step, a.ResultName, call, status, response, logEvent)
 		}
 	}
 
 	return nil
 }
 
+// reads the response of a call as JSON, converting SOAP responses from XML, and returns whether the response
+// reports errors
+func (a *CallWebhookAction) readResponse(call *flows.WebhookCall) (json.RawMessage, bool) {
+	switch a.Mode {
+	case WebhookModeGraphQL:
+		if call.ValidJSON {
+			errs, dataType, _, _ := jsonparser.Get(call.ResponseBody, "errors")
+			return call.ResponseBody, dataType == jsonparser.Array && len(strings.TrimSpace(string(errs))) > 2
+		}
+	case WebhookModeSOAP:
+		doc, isObject := types.XMLToXValue(call.ResponseBody, 0).(*types.XObject)
+		if !isObject {
+			return nil, false
+		}
+
+		envelope, _ := doc.Get("Envelope")
+		if envelope, isObject := envelope.(*types.XObject); isObject {
+			body, _ := envelope.Get("Body")
+			if body, isObject := body.(*types.XObject); isObject {
+				_, hasFault := body.Get("Fault")
+
+				response, err := jsonx.Marshal(body)
+				if err != nil {
+					return nil, hasFault
+				}
+				return response, hasFault
+			}
+		}
+		return nil, false
+	}
+	return webhookResponse(call), false
+}
+
 // Results enumerates any results generated by this flow object
 func (a *CallWebhookAction) Results(include func(*flows.ResultInfo)) {
 	if a.ResultName != "" {
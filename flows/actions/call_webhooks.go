@@ -0,0 +1,254 @@
+package actions
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/events"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpguts"
+)
+
+func init() {
+	registerType(TypeCallWebhooks, func() flows.Action { return &CallWebhooksAction{} })
+}
+
+// TypeCallWebhooks is the type for the call webhooks action
+const TypeCallWebhooks string = "call_webhooks"
+
+// categories of the overall result of a call webhooks action
+const (
+	CategoryAllSucceeded = "All Succeeded"
+	CategoryPartial      = "Partial"
+	CategoryAllFailed    = "All Failed"
+)
+
+var webhooksCategories = []string{CategoryAllSucceeded, CategoryPartial, CategoryAllFailed}
+
+// default number of seconds to wait for all requests to complete
+const defaultWebhooksTimeout = 30
+
+// WebhookRequest is a single request made by a call webhooks action
+type WebhookRequest struct {
+	Method     string            `json:"method" validate:"required,http_method"`
+	URL        string            `json:"url" validate:"required" engine:"evaluated"`
+	Headers    map[string]string `json:"headers,omitempty" engine:"evaluated"`
+	Body       string            `json:"body,omitempty" engine:"evaluated"`
+	ResultName string            `json:"result_name" validate:"required"`
+}
+
+// NewWebhookRequest creates a new webhook request
+func NewWebhookRequest(method string, url string, headers map[string]string, body string, resultName string) *WebhookRequest {
+	return &WebhookRequest{Method: method, URL: url, Headers: headers, Body: body, ResultName: resultName}
+}
+
+// CallWebhooksAction can be used to call several external services at once. Each request is evaluated like
+// the request of a [action:call_webhook] action, and then all requests are made concurrently, limited by the
+// engine's maximum number of concurrent webhooks, and must all complete within `timeout` seconds (defaults to 30).
+// A [event:webhook_called] event is created for each request, in the order the requests are defined, and the
+// response of each is saved as a result with that request's `result_name`. If this action has a `result_name`,
+// then additionally it will create a result with that name whose value is the number of successful requests and
+// whose category is `All Succeeded`, `Partial` or `All Failed`, so that a router can branch on the overall outcome.
+//
+//   {
+//     "uuid": "8eebd020-1af5-431c-b943-aa670fc74da9",
+//     "type": "call_webhooks",
+//     "requests": [
+//       {
+//         "method": "GET",
+//         "url": "http://localhost:49998/?cmd=success",
+//         "result_name": "Lookup"
+//       },
+//       {
+//         "method": "POST",
+//         "url": "http://localhost:49998/?cmd=success",
+//         "body": "{\"contact\": \"@contact.uuid\"}",
+//         "result_name": "Register"
+//       }
+//     ],
+//     "timeout": 10,
+//     "result_name": "Webhooks"
+//   }
+//
+// @action call_webhooks
+type CallWebhooksAction struct {
+	baseAction
+	onlineAction
+
+	Requests   []*WebhookRequest `json:"requests" validate:"required,min=1,dive"`
+	Timeout    int               `json:"timeout,omitempty" validate:"omitempty,min=1,max=300"`
+	ResultName string            `json:"result_name,omitempty"`
+}
+
+// NewCallWebhooks creates a new call webhooks action
+func NewCallWebhooks(uuid flows.ActionUUID, requests []*WebhookRequest, timeout int, resultName string) *CallWebhooksAction {
+	return &CallWebhooksAction{
+		baseAction: newBaseAction(TypeCallWebhooks, uuid),
+		Requests:   requests,
+		Timeout:    timeout,
+		ResultName: resultName,
+	}
+}
+
+// Validate validates our action is valid
+func (a *CallWebhooksAction) Validate() error {
+	for _, r := range a.Requests {
+		for key := range r.Headers {
+			if !httpguts.ValidHeaderFieldName(key) {
+				return errors.Errorf("header '%s' is not a valid HTTP header", key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Execute runs this action
+func (a *CallWebhooksAction) Execute(run flows.FlowRun, step flows.Step, logModifier flows.ModifierCallback, logEvent flows.EventCallback) error {
+	// templates can't be evaluated concurrently so build all our requests first
+	requests := make([]*http.Request, len(a.Requests))
+	for i, r := range a.Requests {
+		requests[i] = a.buildRequest(run, r, logEvent)
+	}
+
+	svc, err := run.Session().Engine().Services().Webhook(run.Session())
+	if err != nil {
+		logEvent(events.NewError(err))
+		return nil
+	}
+
+	calls, errs := a.callAll(run.Session(), svc, requests)
+
+	succeeded := 0
+
+	for i, call := range calls {
+		if errs[i] != nil {
+			logEvent(events.NewError(errs[i]))
+		}
+		if call != nil {
+			response := webhookResponse(call)
+			a.updateWebhook(run, response)
+
+			status := callStatus(call, errs[i], false)
+			if status == flows.CallStatusSuccess {
+				succeeded++
+			}
+
+			logEvent(events.NewWebhookCalled(call, status, ""))
+
+			a.saveWebhookResult(run, step, a.Requests[i].ResultName, call, status, response, logEvent)
+		}
+	}
+
+	if a.ResultName != "" {
+		category := CategoryPartial
+		if succeeded == len(a.Requests) {
+			category = CategoryAllSucceeded
+		} else if succeeded == 0 {
+			category = CategoryAllFailed
+		}
+
+		a.saveResult(run, step, a.ResultName, strconv.Itoa(succeeded), category, "", "", nil, logEvent)
+	}
+
+	return nil
+}
+
+// evaluates the given request, returning nil if it can't be made
+func (a *CallWebhooksAction) buildRequest(run flows.FlowRun, r *WebhookRequest, logEvent flows.EventCallback) *http.Request {
+	url, err := run.EvaluateTemplate(r.URL)
+	if err != nil {
+		logEvent(events.NewError(err))
+	}
+	if url == "" {
+		logEvent(events.NewErrorf("webhook URL evaluated to empty string"))
+		return nil
+	}
+	if !isValidURL(url) {
+		logEvent(events.NewErrorf("webhook URL evaluated to an invalid URL: '%s'", url))
+		return nil
+	}
+
+	body := r.Body
+	if body != "" {
+		// webhook bodies aren't truncated like other templates
+		body, err = run.EvaluateTemplateText(body, nil, false)
+		if err != nil {
+			logEvent(events.NewError(err))
+		}
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(r.Method), url, strings.NewReader(body))
+	if err != nil {
+		logEvent(events.NewError(err))
+		return nil
+	}
+
+	for key, value := range r.Headers {
+		headerValue, err := run.EvaluateTemplate(value)
+		if err != nil {
+			logEvent(events.NewError(err))
+		}
+
+		req.Header.Add(key, headerValue)
+	}
+
+	return req
+}
+
+// makes the given requests concurrently, returning the calls and errors in the same order as the requests
+func (a *CallWebhooksAction) callAll(session flows.Session, svc flows.WebhookService, requests []*http.Request) ([]*flows.WebhookCall, []error) {
+	timeout := a.Timeout
+	if timeout == 0 {
+		timeout = defaultWebhooksTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	maxConcurrent := session.Engine().MaxConcurrentWebhooks()
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	calls := make([]*flows.WebhookCall, len(requests))
+	errs := make([]error, len(requests))
+	slots := make(chan struct{}, maxConcurrent)
+	wg := &sync.WaitGroup{}
+
+	for i, req := range requests {
+		if req == nil {
+			continue
+		}
+
+		// requests are started in order so that they're made sequentially when concurrency is limited to one
+		slots <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, req *http.Request) {
+			defer func() { <-slots; wg.Done() }()
+
+			calls[i], errs[i] = svc.Call(session, req.WithContext(ctx))
+		}(i, req)
+	}
+
+	wg.Wait()
+
+	return calls, errs
+}
+
+// Results enumerates any results generated by this flow object
+func (a *CallWebhooksAction) Results(include func(*flows.ResultInfo)) {
+	for _, r := range a.Requests {
+		include(flows.NewResultInfo(r.ResultName, webhookCategories))
+	}
+	if a.ResultName != "" {
+		include(flows.NewResultInfo(a.ResultName, webhooksCategories))
+	}
+}
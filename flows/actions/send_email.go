@@ -7,6 +7,7 @@ import (
 	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/utils"
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +21,11 @@ const TypeSendEmail string = "send_email"
 // SendEmailAction can be used to send an email to one or more recipients. The subject, body and addresses
 // can all contain expressions.
 //
+// An optional HTML body can be provided which will be sent as an alternative to the plain text body. Values of
+// expressions in the HTML body are escaped. Attachments can be message attachments (e.g. `@input.attachments.0`)
+// or plain URLs, and are fetched by the email service when sending. Carbon copy, blind carbon copy and
+// reply-to addresses are also supported.
+//
 // An [event:email_sent] event will be created if the email could be sent.
 //
 //   {
@@ -35,9 +41,14 @@ type SendEmailAction struct {
 	baseAction
 	onlineAction
 
-	Addresses []string `json:"addresses" validate:"required,min=1" engine:"evaluated"`
-	Subject   string   `json:"subject" validate:"required" engine:"localized,evaluated"`
-	Body      string   `json:"body" validate:"required" engine:"localized,evaluated"`
+	Addresses   []string `json:"addresses" validate:"required,min=1" engine:"evaluated"`
+	CC          []string `json:"cc,omitempty" engine:"evaluated"`
+	BCC         []string `json:"bcc,omitempty" engine:"evaluated"`
+	ReplyTo     string   `json:"reply_to,omitempty" engine:"evaluated"`
+	Subject     string   `json:"subject" validate:"required" engine:"localized,evaluated"`
+	Body        string   `json:"body" validate:"required" engine:"localized,evaluated"`
+	HTMLBody    string   `json:"html_body,omitempty" engine:"localized,evaluated"`
+	Attachments []string `json:"attachments,omitempty" engine:"evaluated"`
 }
 
 // NewSendEmail creates a new send email action
@@ -77,43 +88,112 @@ func (a *SendEmailAction) Execute(run flows.FlowRun, step flows.Step, logModifie
 		return nil
 	}
 
-	evaluatedAddresses := make([]string, 0)
-
-	for _, address := range a.Addresses {
-		evaluatedAddress, err := run.EvaluateTemplate(address)
+	evaluatedHTMLBody := ""
+	if a.HTMLBody != "" {
+		localizedHTMLBody := run.GetText(uuids.UUID(a.UUID()), "html_body", a.HTMLBody)
+		evaluatedHTMLBody, err = run.EvaluateTemplateText(localizedHTMLBody, flows.HTMLEscaping, false)
 		if err != nil {
 			logEvent(events.NewError(err))
 		}
-		if evaluatedAddress == "" {
-			logEvent(events.NewErrorf("email address evaluated to empty string, skipping"))
-			continue
-		}
-
-		// strip mailto prefix if this is an email URN
-		if strings.HasPrefix(evaluatedAddress, "mailto:") {
-			evaluatedAddress = evaluatedAddress[7:]
-		}
-
-		evaluatedAddresses = append(evaluatedAddresses, evaluatedAddress)
 	}
 
+	evaluatedAddresses := a.evaluateAddresses(run, a.Addresses, logEvent)
+
 	// nothing to do if there are no addresses
 	if len(evaluatedAddresses) == 0 {
 		return nil
 	}
 
+	email := &flows.Email{
+		To:          evaluatedAddresses,
+		CC:          a.evaluateAddresses(run, a.CC, logEvent),
+		BCC:         a.evaluateAddresses(run, a.BCC, logEvent),
+		Subject:     evaluatedSubject,
+		Body:        evaluatedBody,
+		HTMLBody:    evaluatedHTMLBody,
+		Attachments: a.evaluateAttachments(run, logEvent),
+	}
+
+	if a.ReplyTo != "" {
+		replyTo := a.evaluateAddresses(run, []string{a.ReplyTo}, logEvent)
+		if len(replyTo) > 0 {
+			email.ReplyTo = replyTo[0]
+		}
+	}
+
 	svc, err := run.Session().Engine().Services().Email(run.Session())
 	if err != nil {
 		logEvent(events.NewError(err))
 		return nil
 	}
 
-	err = svc.Send(run.Session(), evaluatedAddresses, evaluatedSubject, evaluatedBody)
+	err = svc.Send(run.Session(), email)
 	if err != nil {
 		logEvent(events.NewError(errors.Wrap(err, "unable to send email")))
 	} else {
-		logEvent(events.NewEmailSent(evaluatedAddresses, evaluatedSubject, evaluatedBody))
+		logEvent(events.NewEmailSent(email))
 	}
 
 	return nil
 }
+
+// evaluates the given address templates, skipping any that evaluate to empty
+func (a *SendEmailAction) evaluateAddresses(run flows.FlowRun, addresses []string, logEvent flows.EventCallback) []string {
+	var evaluatedAddresses []string
+
+	for _, address := range addresses {
+		evaluatedAddress, err := run.EvaluateTemplate(address)
+		if err != nil {
+			logEvent(events.NewError(err))
+		}
+		if evaluatedAddress == "" {
+			logEvent(events.NewErrorf("email address evaluated to empty string, skipping"))
+			continue
+		}
+
+		// strip mailto prefix if this is an email URN
+		if strings.HasPrefix(evaluatedAddress, "mailto:") {
+			evaluatedAddress = evaluatedAddress[7:]
+		}
+
+		evaluatedAddresses = append(evaluatedAddresses, evaluatedAddress)
+	}
+
+	return evaluatedAddresses
+}
+
+// evaluates the attachment templates which can be message attachments (type:url) or plain URLs
+func (a *SendEmailAction) evaluateAttachments(run flows.FlowRun, logEvent flows.EventCallback) []utils.Attachment {
+	var evaluatedAttachments []utils.Attachment
+
+	for _, attachment := range a.Attachments {
+		evaluatedAttachment, err := run.EvaluateTemplate(attachment)
+		if err != nil {
+			logEvent(events.NewError(err))
+		}
+		if evaluatedAttachment == "" {
+			logEvent(events.NewErrorf("attachment evaluated to empty string, skipping"))
+			continue
+		}
+		if len(evaluatedAttachment) > maxAttachmentLength {
+			logEvent(events.NewErrorf("evaluated attachment is longer than %d limit, skipping", maxAttachmentLength))
+			continue
+		}
+
+		// plain URLs are given a generic content type, and the actual type is determined when fetched
+		if isAttachmentURL(evaluatedAttachment) {
+			evaluatedAttachment = "application/octet-stream:" + evaluatedAttachment
+		} else if !isAttachmentURL(utils.Attachment(evaluatedAttachment).URL()) {
+			logEvent(events.NewErrorf("attachment '%s' isn't a valid attachment or URL, skipping", evaluatedAttachment))
+			continue
+		}
+
+		evaluatedAttachments = append(evaluatedAttachments, utils.Attachment(evaluatedAttachment))
+	}
+
+	return evaluatedAttachments
+}
+
+func isAttachmentURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
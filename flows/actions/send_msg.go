@@ -1,12 +1,16 @@
 package actions
 
 import (
+	"unicode/utf8"
+
 	"github.com/nyaruka/gocommon/urns"
 	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/assets"
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/flows/modifiers"
+	"github.com/nyaruka/goflow/utils"
 )
 
 func init() {
@@ -20,7 +24,15 @@ const TypeSendMsg string = "send_msg"
 // will attempt to find pairs of URNs and channels which can be used for sending. If it can't find such a pair, it will
 // create a message without a channel or URN.
 //
-// A [event:msg_created] event will be created with the evaluated text.
+// A [event:msg_created] event will be created with the evaluated text. Channels are selected for URNs without a
+// channel using the environment's channel selection strategy, in which case the event records why the channel was
+// selected. If that strategy is `sticky` then a channel selected for the contact's preferred URN also becomes their
+// preferred channel, as if it had been set by a [action:set_contact_channel] action.
+//
+// If the text is longer than the maximum message length of the channel, or the channel has an SMS encoding and the
+// text needs more than one SMS segment in that encoding, then an error event is created, unless `split_long` is set,
+// in which case the text is split on word boundaries into several messages. Attachments are sent with the first of
+// these messages and quick replies with the last.
 //
 //   {
 //     "uuid": "8eebd020-1af5-431c-b943-aa670fc74da9",
@@ -48,6 +60,7 @@ type SendMsgAction struct {
 	AllURNs    bool           `json:"all_urns,omitempty"`
 	Templating *Templating    `json:"templating,omitempty" validate:"omitempty,dive"`
 	Topic      flows.MsgTopic `json:"topic,omitempty" validate:"omitempty,msg_topic"`
+	SplitLong  bool           `json:"split_long,omitempty"`
 }
 
 // Templating represents the templating that should be used if possible
@@ -82,7 +95,8 @@ func (a *SendMsgAction) Execute(run flows.FlowRun, step flows.Step, logModifier
 
 	evaluatedText, evaluatedAttachments, evaluatedQuickReplies := a.evaluateMessage(run, nil, a.Text, a.Attachments, a.QuickReplies, logEvent)
 
-	destinations := run.Contact().ResolveDestinations(a.AllURNs)
+	strategy := run.Environment().ChannelSelection()
+	destinations := run.Contact().SelectDestinations(strategy, a.AllURNs)
 
 	sa := run.Session().Assets()
 
@@ -122,8 +136,33 @@ func (a *SendMsgAction) Execute(run flows.FlowRun, step flows.Step, logModifier
 			}
 		}
 
-		msg := flows.NewMsgOut(dest.URN.URN(), channelRef, evaluatedText, evaluatedAttachments, evaluatedQuickReplies, templating, a.Topic)
-		logEvent(events.NewMsgCreated(msg))
+		texts := []string{evaluatedText}
+		if templating == nil {
+			texts = a.splitText(dest.Channel, evaluatedText, logEvent)
+		}
+
+		for i, text := range texts {
+			var attachments []utils.Attachment
+			var quickReplies []string
+			if i == 0 {
+				attachments = evaluatedAttachments
+			}
+			if i == len(texts)-1 {
+				quickReplies = evaluatedQuickReplies
+			}
+
+			msg := flows.NewMsgOut(dest.URN.URN(), channelRef, text, attachments, quickReplies, templating, a.Topic)
+			event := events.NewMsgCreated(msg)
+			if strategy != envs.ChannelSelectionDefault {
+				event.ChannelReason = dest.Reason
+			}
+			logEvent(event)
+		}
+	}
+
+	// with sticky channel selection, a channel we selected for the preferred URN becomes the preferred channel
+	if len(destinations) > 0 && destinations[0].Reason == flows.ChannelReasonSticky {
+		a.applyModifier(run, modifiers.NewChannel(destinations[0].Channel), logModifier, logEvent)
 	}
 
 	// if we couldn't find a destination, create a msg without a URN or channel and it's up to the caller
@@ -135,3 +174,31 @@ func (a *SendMsgAction) Execute(run flows.FlowRun, step flows.Step, logModifier
 
 	return nil
 }
+
+// checks the given text against the maximum message length of the given channel and, if the channel has an SMS
+// encoding, whether it fits in a single SMS segment, splitting it if allowed
+func (a *SendMsgAction) splitText(channel *flows.Channel, text string, logEvent flows.EventCallback) []string {
+	maxLength, encoding := channel.MaxLength(), channel.Encoding()
+	length := utf8.RuneCountInString(text)
+	tooLong := maxLength > 0 && length > maxLength
+
+	segments := 0
+	if encoding != "" {
+		segments = utils.SMSSegments(text, encoding)
+	}
+
+	if !tooLong && segments <= 1 {
+		return []string{text}
+	}
+
+	if a.SplitLong {
+		return utils.SplitText(text, maxLength, encoding)
+	}
+
+	if tooLong {
+		logEvent(events.NewErrorf("message text of %d characters exceeds the maximum length of %d for channel %s", length, maxLength, channel.Name()))
+	} else {
+		logEvent(events.NewErrorf("message text of %d characters needs %d SMS segments on channel %s", length, segments, channel.Name()))
+	}
+	return []string{text}
+}
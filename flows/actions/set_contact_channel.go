@@ -2,6 +2,7 @@ package actions
 
 import (
 	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/flows/events"
 	"github.com/nyaruka/goflow/flows/modifiers"
@@ -17,7 +18,9 @@ const TypeSetContactChannel string = "set_contact_channel"
 // SetContactChannelAction can be used to change or clear the preferred channel of the current contact.
 //
 // Because channel affinity is a property of a contact's URNs, a [event:contact_urns_changed] event will be created if any
-// changes are made to the contact's URNs.
+// changes are made to the contact's URNs. Channel affinity always takes precedence over the environment's channel selection
+// strategy, so clearing the preferred channel lets that strategy select a channel for the next message. If that strategy
+// is `sticky` then clearing the preferred channel instead sets it to the channel selected for the contact's preferred URN.
 //
 //   {
 //     "uuid": "8eebd020-1af5-431c-b943-aa670fc74da9",
@@ -56,6 +59,10 @@ func (a *SetContactChannelAction) Execute(run flows.FlowRun, step flows.Step, lo
 			logEvent(events.NewDependencyError(a.Channel))
 			return nil
 		}
+	} else if run.Environment().ChannelSelection() == envs.ChannelSelectionSticky && len(contact.URNs()) > 0 {
+		// select a channel for the preferred URN as if it didn't have one
+		urn := flows.NewContactURN(contact.URNs()[0].URN(), nil)
+		channel, _ = run.Session().Assets().Channels().SelectForURN(urn, assets.ChannelRoleSend, envs.ChannelSelectionSticky)
 	}
 
 	a.applyModifier(run, modifiers.NewChannel(channel), logModifier, logEvent)
@@ -1,7 +1,10 @@
 package actions
 
 import (
+	"strings"
+
 	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/goflow/excellent/types"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/flows/events"
 	"github.com/pkg/errors"
@@ -18,7 +21,10 @@ var transferCategories = []string{CategorySuccess, CategoryFailure}
 // TypeTransferAirtime is the type for the transfer airtime action
 const TypeTransferAirtime string = "transfer_airtime"
 
-// TransferAirtimeAction attempts to make an airtime transfer to the contact.
+// TransferAirtimeAction attempts to make an airtime transfer to the contact. The product transferred can be airtime
+// (the default) or a data bundle, and is either the product closest to the given amount in any of the given
+// currencies, or the product with the given SKU. The amount can also be an expression which evaluates to money,
+// e.g. `@(money(results.amount, "USD"))`.
 //
 // An [event:airtime_transferred] event will be created if the airtime could be sent.
 //
@@ -34,8 +40,11 @@ type TransferAirtimeAction struct {
 	baseAction
 	onlineAction
 
-	Amounts    map[string]decimal.Decimal `json:"amounts" validate:"required"`
-	ResultName string                     `json:"result_name" validate:"required"`
+	ProductType flows.AirtimeProductType   `json:"product_type,omitempty" validate:"omitempty,eq=airtime|eq=data"`
+	SKU         string                     `json:"sku,omitempty" engine:"evaluated"`
+	Amounts     map[string]decimal.Decimal `json:"amounts,omitempty"`
+	Amount      string                     `json:"amount,omitempty" engine:"evaluated"`
+	ResultName  string                     `json:"result_name" validate:"required"`
 }
 
 // NewTransferAirtime creates a new airtime transfer action
@@ -47,6 +56,24 @@ func NewTransferAirtime(uuid flows.ActionUUID, amounts map[string]decimal.Decima
 	}
 }
 
+// NewTransferProduct creates a new airtime transfer action for the product with the given type and SKU
+func NewTransferProduct(uuid flows.ActionUUID, productType flows.AirtimeProductType, sku string, resultName string) *TransferAirtimeAction {
+	return &TransferAirtimeAction{
+		baseAction:  newBaseAction(TypeTransferAirtime, uuid),
+		ProductType: productType,
+		SKU:         sku,
+		ResultName:  resultName,
+	}
+}
+
+// Validate validates our action is valid
+func (a *TransferAirtimeAction) Validate() error {
+	if len(a.Amounts) == 0 && a.Amount == "" && a.SKU == "" {
+		return errors.New("must specify amounts, an amount or a SKU")
+	}
+	return nil
+}
+
 // Execute executes the transfer action
 func (a *TransferAirtimeAction) Execute(run flows.FlowRun, step flows.Step, logModifier flows.ModifierCallback, logEvent flows.EventCallback) error {
 	transfer, err := a.transfer(run, step, logEvent)
@@ -81,6 +108,37 @@ func (a *TransferAirtimeAction) transfer(run flows.FlowRun, step flows.Step, log
 		sender, _ = urns.Parse("tel:" + channel.Address())
 	}
 
+	request := &flows.AirtimeProductRequest{Type: a.ProductType, Amounts: a.Amounts}
+	if request.Type == "" {
+		request.Type = flows.AirtimeProductTypeAirtime
+	}
+
+	if a.Amount != "" {
+		amount, err := a.evaluateAmount(run)
+		if err != nil {
+			return nil, err
+		}
+
+		// an evaluated amount is added to any static amounts, taking precedence for its currency
+		request.Amounts = make(map[string]decimal.Decimal, len(a.Amounts)+1)
+		for currency, value := range a.Amounts {
+			request.Amounts[currency] = value
+		}
+		request.Amounts[string(amount.Currency())] = amount.Amount()
+	}
+
+	if a.SKU != "" {
+		sku, err := run.EvaluateTemplate(a.SKU)
+		if err != nil {
+			logEvent(events.NewError(err))
+		}
+		request.SKU = strings.TrimSpace(sku)
+
+		if request.SKU == "" && len(request.Amounts) == 0 {
+			return nil, errors.New("SKU evaluated to empty string")
+		}
+	}
+
 	svc, err := run.Session().Engine().Services().Airtime(run.Session())
 	if err != nil {
 		return nil, err
@@ -88,7 +146,7 @@ func (a *TransferAirtimeAction) transfer(run flows.FlowRun, step flows.Step, log
 
 	httpLogger := &flows.HTTPLogger{}
 
-	transfer, err := svc.Transfer(run.Session(), sender, telURNs[0].URN(), a.Amounts, httpLogger.Log)
+	transfer, err := svc.Transfer(run.Session(), sender, telURNs[0].URN(), request, httpLogger.Log)
 	if transfer != nil {
 		logEvent(events.NewAirtimeTransferred(transfer, httpLogger.Logs))
 	}
@@ -96,6 +154,24 @@ func (a *TransferAirtimeAction) transfer(run flows.FlowRun, step flows.Step, log
 	return transfer, err
 }
 
+// evaluates our amount expression as money
+func (a *TransferAirtimeAction) evaluateAmount(run flows.FlowRun) (types.XMoney, error) {
+	value, err := run.EvaluateTemplateValue(a.Amount)
+	if err != nil {
+		return types.XMoneyZero, err
+	}
+
+	amount, xerr := types.ToXMoney(run.Environment(), value)
+	if xerr != nil {
+		return types.XMoneyZero, errors.Wrap(xerr, "amount isn't valid money")
+	}
+	if !amount.Amount().IsPositive() {
+		return types.XMoneyZero, errors.New("amount must be greater than zero")
+	}
+
+	return amount, nil
+}
+
 func (a *TransferAirtimeAction) saveSuccess(run flows.FlowRun, step flows.Step, transfer *flows.AirtimeTransfer, logEvent flows.EventCallback) {
 	a.saveResult(run, step, a.ResultName, transfer.ActualAmount.String(), CategorySuccess, "", "", nil, logEvent)
 }
@@ -2,6 +2,7 @@ package flows
 
 import (
 	"fmt"
+	"hash/fnv"
 	"strings"
 
 	"github.com/nyaruka/gocommon/urns"
@@ -9,16 +10,20 @@ import (
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/excellent/types"
 	"github.com/nyaruka/goflow/utils"
+
+	"github.com/shopspring/decimal"
 )
 
 // Channel represents a means for sending and receiving input during a flow run
 type Channel struct {
 	assets.Channel
+
+	costs map[string]decimal.Decimal
 }
 
 // NewChannel creates a new channenl
 func NewChannel(asset assets.Channel) *Channel {
-	return &Channel{Channel: asset}
+	return &Channel{Channel: asset, costs: normalizeCosts(asset.Costs())}
 }
 
 // Asset returns the underlying asset
@@ -52,6 +57,9 @@ func (c *Channel) HasRole(role assets.ChannelRole) bool {
 	return false
 }
 
+// Costs returns this channel's costs of sending to numbers, keyed by number prefix without any leading +
+func (c *Channel) Costs() map[string]decimal.Decimal { return c.costs }
+
 // HasParent returns whether this channel has a parent
 func (c *Channel) HasParent() bool {
 	return c.Parent() != nil
@@ -78,6 +86,19 @@ func (c *Channel) String() string {
 	return fmt.Sprintf("%s (%s)", c.Address(), c.Name())
 }
 
+// ChannelReason describes why a channel was selected for a URN
+type ChannelReason string
+
+// the different reasons a channel can be selected
+const (
+	ChannelReasonAffinity ChannelReason = "affinity"
+	ChannelReasonDefault  ChannelReason = "default"
+	ChannelReasonHashed   ChannelReason = "hashed"
+	ChannelReasonWeighted ChannelReason = "weighted"
+	ChannelReasonCheapest ChannelReason = "cheapest"
+	ChannelReasonSticky   ChannelReason = "sticky"
+)
+
 // ChannelAssets provides access to all channel assets
 type ChannelAssets struct {
 	all    []*Channel
@@ -103,73 +124,214 @@ func (s *ChannelAssets) Get(uuid assets.ChannelUUID) *Channel {
 	return s.byUUID[uuid]
 }
 
+// All returns all the channels in this set
+func (s *ChannelAssets) All() []*Channel {
+	return s.all
+}
+
 // GetForURN returns the best channel for the given URN
 func (s *ChannelAssets) GetForURN(urn *ContactURN, role assets.ChannelRole) *Channel {
+	channel, _ := s.SelectForURN(urn, role, envs.ChannelSelectionDefault)
+	return channel
+}
+
+// SelectForURN returns the channel to use for the given URN and the reason it was selected. If the URN has a channel
+// then that is always used. Otherwise the candidate channels are those which support the URN's scheme and the given
+// role, and for tel URNs, the URN's country. If there are several candidates then the given strategy decides between
+// them, falling back to preferring channels whose prefixes best match the URN.
+func (s *ChannelAssets) SelectForURN(urn *ContactURN, role assets.ChannelRole, strategy envs.ChannelSelection) (*Channel, ChannelReason) {
 	// if caller has told us which channel to use for this URN, use that
 	if urn.Channel() != nil && urn.Channel().HasRole(role) {
-		return s.getDelegate(urn.Channel(), role)
+		return s.getDelegate(urn.Channel(), role), ChannelReasonAffinity
 	}
 
-	// tel is a special case because we do number based matching
-	if urn.URN().Scheme() == urns.TelScheme {
-		countryCode := envs.DeriveCountryFromTel(urn.URN().Path())
-		candidates := make([]*Channel, 0)
-
-		for _, ch := range s.all {
-			// skip if not tel and not sendable
-			if !ch.SupportsScheme(urns.TelScheme) || !ch.HasRole(role) {
-				continue
+	candidates := s.getCandidates(urn, role)
+	if len(candidates) == 0 {
+		return nil, ""
+	}
+
+	var channel *Channel
+	reason := ChannelReasonDefault
+
+	if len(candidates) > 1 {
+		switch strategy {
+		case envs.ChannelSelectionHashed:
+			channel, reason = hashedForURN(urn, candidates), ChannelReasonHashed
+		case envs.ChannelSelectionWeighted:
+			if channel = weightedForURN(urn, candidates); channel != nil {
+				reason = ChannelReasonWeighted
 			}
-			// skip if international and channel doesn't allow that
-			if ch.Country() != "" && countryCode != "" && countryCode != ch.Country() && !ch.AllowInternational() {
-				continue
+		case envs.ChannelSelectionCheapest:
+			if channel = cheapestForURN(urn, candidates); channel != nil {
+				reason = ChannelReasonCheapest
 			}
+		}
+	}
+
+	if strategy == envs.ChannelSelectionSticky {
+		reason = ChannelReasonSticky
+	}
 
-			candidates = append(candidates, ch)
+	if channel == nil {
+		channel = bestPrefixMatch(urn, candidates)
+	}
+
+	return s.getDelegate(channel, role), reason
+}
+
+// gets the channels which could be used for the given URN and role
+func (s *ChannelAssets) getCandidates(urn *ContactURN, role assets.ChannelRole) []*Channel {
+	scheme := urn.URN().Scheme()
+	candidates := make([]*Channel, 0)
+
+	// tel is a special case because we do number based matching
+	var countryCode envs.Country
+	if scheme == urns.TelScheme {
+		countryCode = envs.DeriveCountryFromTel(urn.URN().Path())
+	}
+
+	for _, ch := range s.all {
+		// skip if doesn't support scheme or role
+		if !ch.SupportsScheme(scheme) || !ch.HasRole(role) {
+			continue
+		}
+		// skip if international and channel doesn't allow that
+		if scheme == urns.TelScheme && ch.Country() != "" && countryCode != "" && countryCode != ch.Country() && !ch.AllowInternational() {
+			continue
 		}
 
-		var channel *Channel
-		if len(candidates) > 1 {
-			// we don't have a channel for this contact yet, let's try to pick one from the same carrier
-			// we need at least one digit to overlap to infer a channel
-			contactNumber := strings.TrimPrefix(urn.URN().Path(), "+")
-			maxOverlap := 0
-			for _, candidate := range candidates {
-				candidatePrefixes := candidate.MatchPrefixes()
-				if len(candidatePrefixes) == 0 {
-					candidatePrefixes = []string{strings.TrimPrefix(candidate.Address(), "+")}
-				}
-
-				for _, prefix := range candidatePrefixes {
-					overlap := utils.PrefixOverlap(prefix, contactNumber)
-					if overlap >= maxOverlap {
-						maxOverlap = overlap
-						channel = candidate
-					}
-				}
-			}
+		candidates = append(candidates, ch)
+	}
 
-		} else if len(candidates) == 1 {
-			channel = candidates[0]
+	return candidates
+}
+
+// gets the candidate for the given URN when spreading URNs evenly across candidates using a hash of the URN. This isn't
+// round-robin as the choice depends only on the URN, so the same URN always gets the same channel regardless of what
+// was selected for other URNs or sessions, but across many URNs the candidates are used about equally.
+func hashedForURN(urn *ContactURN, candidates []*Channel) *Channel {
+	return candidates[hashURN(urn)%uint32(len(candidates))]
+}
+
+// gets the candidate for the given URN when spreading URNs across candidates in proportion to their weights, or nil
+// if no candidate has a weight. Like hashed selection, the same URN always gets the same channel.
+func weightedForURN(urn *ContactURN, candidates []*Channel) *Channel {
+	total := 0
+	for _, ch := range candidates {
+		if ch.Weight() > 0 {
+			total += ch.Weight()
 		}
+	}
+	if total == 0 {
+		return nil
+	}
 
-		if channel != nil {
-			return s.getDelegate(channel, role)
+	n := int(hashURN(urn) % uint32(total))
+
+	for _, ch := range candidates {
+		if ch.Weight() > 0 {
+			if n < ch.Weight() {
+				return ch
+			}
+			n -= ch.Weight()
 		}
+	}
+	return nil
+}
 
+// gets a hash of the identity of the given URN
+func hashURN(urn *ContactURN) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(urn.URN().Identity()))
+	return h.Sum32()
+}
+
+// gets the candidate with the lowest cost of sending to the given URN, or nil if no candidate has a cost for it
+func cheapestForURN(urn *ContactURN, candidates []*Channel) *Channel {
+	if urn.URN().Scheme() != urns.TelScheme {
 		return nil
 	}
 
-	return s.getForSchemeAndRole(urn.URN().Scheme(), role)
+	number := strings.TrimPrefix(urn.URN().Path(), "+")
+
+	var cheapest *Channel
+	var cheapestCost decimal.Decimal
+
+	for _, ch := range candidates {
+		cost, found := costForNumber(ch, number)
+		if found && (cheapest == nil || cost.LessThan(cheapestCost)) {
+			cheapest = ch
+			cheapestCost = cost
+		}
+	}
+
+	return cheapest
 }
 
-func (s *ChannelAssets) getForSchemeAndRole(scheme string, role assets.ChannelRole) *Channel {
-	for _, ch := range s.all {
-		if ch.HasRole(role) && ch.SupportsScheme(scheme) {
-			return s.getDelegate(ch, role)
+// gets the cost of sending to the given number on the given channel, using the longest matching prefix
+func costForNumber(channel *Channel, number string) (decimal.Decimal, bool) {
+	var cost decimal.Decimal
+	longest := -1
+
+	for prefix, prefixCost := range channel.Costs() {
+		if strings.HasPrefix(number, prefix) && len(prefix) > longest {
+			cost = prefixCost
+			longest = len(prefix)
 		}
 	}
-	return nil
+
+	return cost, longest >= 0
+}
+
+// normalizes the prefixes of a cost table by removing any leading +. If a prefix appears both with and without a +, the
+// cost without takes precedence so that the result doesn't depend on map iteration order.
+func normalizeCosts(costs map[string]decimal.Decimal) map[string]decimal.Decimal {
+	normalized := make(map[string]decimal.Decimal, len(costs))
+
+	for prefix, cost := range costs {
+		if !strings.HasPrefix(prefix, "+") {
+			normalized[prefix] = cost
+		}
+	}
+	for prefix, cost := range costs {
+		trimmed := strings.TrimPrefix(prefix, "+")
+		if _, exists := normalized[trimmed]; !exists {
+			normalized[trimmed] = cost
+		}
+	}
+
+	return normalized
+}
+
+// gets the candidate whose prefixes best match the given URN, or for non-tel URNs the first candidate
+func bestPrefixMatch(urn *ContactURN, candidates []*Channel) *Channel {
+	if urn.URN().Scheme() != urns.TelScheme || len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	// we don't have a channel for this contact yet, let's try to pick one from the same carrier
+	// we need at least one digit to overlap to infer a channel
+	contactNumber := strings.TrimPrefix(urn.URN().Path(), "+")
+	maxOverlap := 0
+
+	var channel *Channel
+
+	for _, candidate := range candidates {
+		candidatePrefixes := candidate.MatchPrefixes()
+		if len(candidatePrefixes) == 0 {
+			candidatePrefixes = []string{strings.TrimPrefix(candidate.Address(), "+")}
+		}
+
+		for _, prefix := range candidatePrefixes {
+			overlap := utils.PrefixOverlap(prefix, contactNumber)
+			if overlap >= maxOverlap {
+				maxOverlap = overlap
+				channel = candidate
+			}
+		}
+	}
+
+	return channel
 }
 
 // looks for a delegate for the given channel and defaults to the channel itself
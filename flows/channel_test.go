@@ -4,15 +4,19 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/nyaruka/gocommon/jsonx"
 	"github.com/nyaruka/gocommon/urns"
 	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/assets"
+	atypes "github.com/nyaruka/goflow/assets/static/types"
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/excellent/types"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/test"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestChannel(t *testing.T) {
@@ -115,3 +119,105 @@ func TestChannelSetGetForURN(t *testing.T) {
 	assert.Equal(t, short1, all.GetForURN(flows.NewContactURN(urns.URN("tel:+250771234567"), nil), assets.ChannelRoleSend))
 	assert.Equal(t, short2, all.GetForURN(flows.NewContactURN(urns.URN("tel:+250721234567"), nil), assets.ChannelRoleSend))
 }
+
+func TestChannelSetSelectForURN(t *testing.T) {
+	readChannel := func(data string) *flows.Channel {
+		asset := &atypes.Channel{}
+		require.NoError(t, jsonx.Unmarshal([]byte(data), asset))
+		return flows.NewChannel(asset)
+	}
+
+	mtn := readChannel(`{"uuid": "7a8ff5ef-4b8d-4a6e-8bb4-ba5e0a1ad3e1", "name": "MTN", "address": "+250782222222", "schemes": ["tel"], "roles": ["send"], "weight": 3, "costs": {"250": 0.05, "25078": 0.02}}`)
+	tigo := readChannel(`{"uuid": "1ab3c2a7-32f1-45b4-9fa4-bc8c7db0f3f7", "name": "Tigo", "address": "+250723333333", "schemes": ["tel"], "roles": ["send"], "costs": {"+250": 0.03}}`)
+	twilio := readChannel(`{"uuid": "e4bd06b5-0fe1-4e6e-9cd6-2f7fd4df2b0e", "name": "Twilio", "address": "+17036975131", "schemes": ["tel"], "roles": ["send"]}`)
+	twitter := readChannel(`{"uuid": "d2d2e4fc-9b0b-4cd5-b1c5-7e4f4c2cba4d", "name": "Twitter", "address": "nyaruka", "schemes": ["twitter"], "roles": ["send"]}`)
+
+	channels := []assets.Channel{mtn.Asset(), tigo.Asset(), twilio.Asset(), twitter.Asset()}
+
+	urn := flows.NewContactURN(urns.URN("tel:+250781234567"), nil)
+
+	selectN := func(s *flows.ChannelAssets, urn *flows.ContactURN, strategy envs.ChannelSelection, n int) ([]*flows.Channel, flows.ChannelReason) {
+		selected := make([]*flows.Channel, n)
+		var reason flows.ChannelReason
+		for i := range selected {
+			selected[i], reason = s.SelectForURN(urn, assets.ChannelRoleSend, strategy)
+		}
+		return selected, reason
+	}
+
+	selectEach := func(s *flows.ChannelAssets, numbers []string, strategy envs.ChannelSelection) ([]*flows.Channel, flows.ChannelReason) {
+		selected := make([]*flows.Channel, len(numbers))
+		var reason flows.ChannelReason
+		for i, number := range numbers {
+			selected[i], reason = s.SelectForURN(flows.NewContactURN(urns.URN("tel:"+number), nil), assets.ChannelRoleSend, strategy)
+		}
+		return selected, reason
+	}
+
+	numbers := []string{"+250781234560", "+250781234561", "+250781234562", "+250781234563", "+250781234564", "+250781234565", "+250781234566", "+250781234567"}
+
+	// default strategy matches GetForURN
+	selected, reason := selectN(flows.NewChannelAssets(channels), urn, envs.ChannelSelectionDefault, 2)
+	assert.Equal(t, []*flows.Channel{mtn, mtn}, selected)
+	assert.Equal(t, flows.ChannelReasonDefault, reason)
+
+	// URN affinity takes precedence over any strategy
+	_, reason = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("tel:+250781234567"), tigo), envs.ChannelSelectionHashed, 1)
+	assert.Equal(t, flows.ChannelReasonAffinity, reason)
+
+	// hashed spreads URNs across the candidates
+	selected, reason = selectEach(flows.NewChannelAssets(channels), numbers, envs.ChannelSelectionHashed)
+	assert.Equal(t, []*flows.Channel{mtn, twilio, tigo, mtn, tigo, mtn, twilio, tigo}, selected)
+	assert.Equal(t, flows.ChannelReasonHashed, reason)
+
+	// but the same URN always gets the same channel
+	selected, _ = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("tel:+250781234561"), nil), envs.ChannelSelectionHashed, 3)
+	assert.Equal(t, []*flows.Channel{twilio, twilio, twilio}, selected)
+
+	// unless there's only one candidate
+	selected, reason = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("twitter:bob"), nil), envs.ChannelSelectionHashed, 2)
+	assert.Equal(t, []*flows.Channel{twitter, twitter}, selected)
+	assert.Equal(t, flows.ChannelReasonDefault, reason)
+
+	// weighted spreads URNs across the candidates in proportion to their weights
+	selected, reason = selectEach(flows.NewChannelAssets(channels), numbers, envs.ChannelSelectionWeighted)
+	assert.Equal(t, []*flows.Channel{mtn, mtn, mtn, twilio, mtn, mtn, twilio, tigo}, selected)
+	assert.Equal(t, flows.ChannelReasonWeighted, reason)
+
+	selected, _ = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("tel:+250781234563"), nil), envs.ChannelSelectionWeighted, 3)
+	assert.Equal(t, []*flows.Channel{twilio, twilio, twilio}, selected)
+
+	// cheapest uses the cost of the longest matching prefix
+	selected, reason = selectN(flows.NewChannelAssets(channels), urn, envs.ChannelSelectionCheapest, 1)
+	assert.Equal(t, []*flows.Channel{mtn}, selected)
+	assert.Equal(t, flows.ChannelReasonCheapest, reason)
+
+	selected, reason = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("tel:+250721234567"), nil), envs.ChannelSelectionCheapest, 1)
+	assert.Equal(t, []*flows.Channel{tigo}, selected)
+	assert.Equal(t, flows.ChannelReasonCheapest, reason)
+
+	// cost prefixes with and without a leading + are equivalent, with the latter taking precedence if both are given
+	vodacom := readChannel(`{"uuid": "4a8e3c2b-5f0d-4b7e-9a1c-6d2e8f3b7c5a", "name": "Vodacom", "address": "+250734444444", "schemes": ["tel"], "roles": ["send"], "costs": {"+250": 0.01, "250": 0.04}}`)
+	assert.Equal(t, map[string]decimal.Decimal{"250": decimal.RequireFromString("0.04")}, vodacom.Costs())
+	assert.Equal(t, map[string]decimal.Decimal{"250": decimal.RequireFromString("0.03")}, tigo.Costs())
+
+	for i := 0; i < 10; i++ {
+		selected, _ = selectN(flows.NewChannelAssets([]assets.Channel{tigo.Asset(), vodacom.Asset()}), flows.NewContactURN(urns.URN("tel:+250721234567"), nil), envs.ChannelSelectionCheapest, 1)
+		assert.Equal(t, []*flows.Channel{tigo}, selected)
+	}
+
+	// and falls back to the default if no candidate has a cost for the URN
+	selected, reason = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("tel:+12065551212"), nil), envs.ChannelSelectionCheapest, 1)
+	assert.Equal(t, []*flows.Channel{twilio}, selected)
+	assert.Equal(t, flows.ChannelReasonDefault, reason)
+
+	// sticky selects as default but records that the selection should stick
+	selected, reason = selectN(flows.NewChannelAssets(channels), urn, envs.ChannelSelectionSticky, 1)
+	assert.Equal(t, []*flows.Channel{mtn}, selected)
+	assert.Equal(t, flows.ChannelReasonSticky, reason)
+
+	// nothing selected if there are no candidates
+	selected, reason = selectN(flows.NewChannelAssets(channels), flows.NewContactURN(urns.URN("mailto:bob@nyaruka.com"), nil), envs.ChannelSelectionHashed, 1)
+	assert.Equal(t, []*flows.Channel{nil}, selected)
+	assert.Equal(t, flows.ChannelReason(""), reason)
+}
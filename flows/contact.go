@@ -360,16 +360,23 @@ func (c *Contact) Context(env envs.Environment) map[string]types.XValue {
 type Destination struct {
 	Channel *Channel
 	URN     *ContactURN
+	Reason  ChannelReason
 }
 
 // ResolveDestinations resolves possible URN/channel destinations
 func (c *Contact) ResolveDestinations(all bool) []Destination {
+	return c.SelectDestinations(envs.ChannelSelectionDefault, all)
+}
+
+// SelectDestinations resolves possible URN/channel destinations, using the given strategy to select channels for
+// URNs which don't have a channel
+func (c *Contact) SelectDestinations(strategy envs.ChannelSelection, all bool) []Destination {
 	destinations := []Destination{}
 
 	for _, u := range c.urns {
-		channel := c.assets.Channels().GetForURN(u, assets.ChannelRoleSend)
+		channel, reason := c.assets.Channels().SelectForURN(u, assets.ChannelRoleSend, strategy)
 		if channel != nil {
-			destinations = append(destinations, Destination{URN: u, Channel: channel})
+			destinations = append(destinations, Destination{URN: u, Channel: channel, Reason: reason})
 			if !all {
 				break
 			}
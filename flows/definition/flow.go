@@ -306,10 +306,19 @@ type flowEnvelope struct {
 	Revision           int             `json:"revision"`
 	ExpireAfterMinutes int             `json:"expire_after_minutes"`
 	Localization       localization    `json:"localization"`
-	Nodes              []*node         `json:"nodes"`
+	Nodes              []*node         `json:"nodes" jsonschema:"node"`
 	UI                 json.RawMessage `json:"_ui,omitempty"`
 }
 
+// SchemaEnvelopes gets empty instances of the envelopes that flow definitions are read from, keyed by name
+func SchemaEnvelopes() map[string]interface{} {
+	return map[string]interface{}{
+		"flow": &flowEnvelope{},
+		"node": &nodeEnvelope{},
+		"exit": &exitEnvelope{},
+	}
+}
+
 // ReadFlow a flow definition from the passed in byte array, migrating it to the spec version of the engine if necessary
 func ReadFlow(data json.RawMessage, migrationConfig *migrations.Config) (flows.Flow, error) {
 	var err error
@@ -28,16 +28,17 @@ import (
 
 // Flow is a flow in the legacy format
 type Flow struct {
-	BaseLanguage envs.Language `json:"base_language"`
-	FlowType     string        `json:"flow_type"`
-	RuleSets     []RuleSet     `json:"rule_sets" validate:"dive"`
-	ActionSets   []ActionSet   `json:"action_sets" validate:"dive"`
-	Entry        uuids.UUID    `json:"entry" validate:"omitempty,uuid4"`
-	Metadata     *Metadata     `json:"metadata"`
+	BaseLanguage envs.Language  `json:"base_language"`
+	FlowType     string         `json:"flow_type"`
+	RuleSets     []RuleSet      `json:"rule_sets" validate:"dive"`
+	ActionSets   []ActionSet    `json:"action_sets" validate:"dive"`
+	Entry        uuids.UUID     `json:"entry" validate:"omitempty,uuid4"`
+	Metadata     *Metadata      `json:"metadata"`
+	Version      StringOrNumber `json:"version,omitempty"`
 
 	// some flows have these set here instead of in metadata
-	UUID uuids.UUID `json:"uuid"`
-	Name string     `json:"name"`
+	UUID uuids.UUID `json:"uuid,omitempty"`
+	Name string     `json:"name,omitempty"`
 }
 
 // Metadata is the metadata section of a legacy flow
@@ -51,8 +52,8 @@ type Metadata struct {
 
 type Rule struct {
 	UUID            uuids.UUID    `json:"uuid" validate:"required,uuid4"`
-	Destination     uuids.UUID    `json:"destination" validate:"omitempty,uuid4"`
-	DestinationType string        `json:"destination_type" validate:"eq=A|eq=R"`
+	Destination     uuids.UUID    `json:"destination,omitempty" validate:"omitempty,uuid4"`
+	DestinationType string        `json:"destination_type,omitempty" validate:"eq=A|eq=R"`
 	Test            TypedEnvelope `json:"test"`
 	Category        Translations  `json:"category"`
 }
@@ -65,14 +66,14 @@ type RuleSet struct {
 	Label       string          `json:"label"`
 	Operand     string          `json:"operand"`
 	Rules       []Rule          `json:"rules"`
-	Config      json.RawMessage `json:"config"`
+	Config      json.RawMessage `json:"config,omitempty"`
 	FinishedKey string          `json:"finished_key"`
 }
 
 type ActionSet struct {
 	Y           int        `json:"y"`
 	X           int        `json:"x"`
-	Destination uuids.UUID `json:"destination" validate:"omitempty,uuid4"`
+	Destination uuids.UUID `json:"destination,omitempty" validate:"omitempty,uuid4"`
 	ExitUUID    uuids.UUID `json:"exit_uuid" validate:"required,uuid4"`
 	UUID        uuids.UUID `json:"uuid" validate:"required,uuid4"`
 	Actions     []Action   `json:"actions"`
@@ -112,6 +113,11 @@ func (l *LabelReference) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals this label reference into JSON
+func (l LabelReference) MarshalJSON() ([]byte, error) {
+	return marshalNamedReference(l.UUID, l.Name)
+}
+
 type ContactReference struct {
 	UUID uuids.UUID `json:"uuid"`
 	Name string     `json:"name"`
@@ -158,6 +164,19 @@ func (g *GroupReference) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals this group reference into JSON
+func (g GroupReference) MarshalJSON() ([]byte, error) {
+	return marshalNamedReference(g.UUID, g.Name)
+}
+
+// references without a UUID are written as just their name, which may be an expression
+func marshalNamedReference(uuid uuids.UUID, name string) ([]byte, error) {
+	if uuid == "" {
+		return jsonx.Marshal(name)
+	}
+	return jsonx.Marshal(map[string]string{"uuid": string(uuid), "name": name})
+}
+
 type VariableReference struct {
 	ID string `json:"id"`
 }
@@ -169,13 +188,13 @@ type FlowReference struct {
 
 // RulesetConfig holds the config dictionary for a legacy ruleset
 type RulesetConfig struct {
-	Flow           *FlowReference  `json:"flow"`
-	FieldDelimiter string          `json:"field_delimiter"`
-	FieldIndex     int             `json:"field_index"`
-	Webhook        string          `json:"webhook"`
-	WebhookAction  string          `json:"webhook_action"`
-	WebhookHeaders []WebhookHeader `json:"webhook_headers"`
-	Resthook       string          `json:"resthook"`
+	Flow           *FlowReference  `json:"flow,omitempty"`
+	FieldDelimiter string          `json:"field_delimiter,omitempty"`
+	FieldIndex     int             `json:"field_index,omitempty"`
+	Webhook        string          `json:"webhook,omitempty"`
+	WebhookAction  string          `json:"webhook_action,omitempty"`
+	WebhookHeaders []WebhookHeader `json:"webhook_headers,omitempty"`
+	Resthook       string          `json:"resthook,omitempty"`
 }
 
 type WebhookHeader struct {
@@ -186,43 +205,43 @@ type WebhookHeader struct {
 type Action struct {
 	Type string     `json:"type"`
 	UUID uuids.UUID `json:"uuid"`
-	Name string     `json:"name"`
+	Name string     `json:"name,omitempty"`
 
 	// message and email
-	Msg          json.RawMessage `json:"msg"`
-	Media        json.RawMessage `json:"media"`
-	QuickReplies json.RawMessage `json:"quick_replies"`
-	SendAll      bool            `json:"send_all"`
+	Msg          json.RawMessage `json:"msg,omitempty"`
+	Media        json.RawMessage `json:"media,omitempty"`
+	QuickReplies json.RawMessage `json:"quick_replies,omitempty"`
+	SendAll      bool            `json:"send_all,omitempty"`
 
 	// variable contact actions
-	Contacts  []ContactReference  `json:"contacts"`
-	Groups    []GroupReference    `json:"groups"`
-	Variables []VariableReference `json:"variables"`
+	Contacts  []ContactReference  `json:"contacts,omitempty"`
+	Groups    []GroupReference    `json:"groups,omitempty"`
+	Variables []VariableReference `json:"variables,omitempty"`
 
 	// save actions
-	Field string `json:"field"`
-	Value string `json:"value"`
-	Label string `json:"label"`
+	Field string `json:"field,omitempty"`
+	Value string `json:"value,omitempty"`
+	Label string `json:"label,omitempty"`
 
 	// set language
-	Language envs.Language `json:"lang"`
+	Language envs.Language `json:"lang,omitempty"`
 
 	// add label action
-	Labels []LabelReference `json:"labels"`
+	Labels []LabelReference `json:"labels,omitempty"`
 
 	// start/trigger flow
-	Flow FlowReference `json:"flow"`
+	Flow *FlowReference `json:"flow,omitempty"`
 
 	// channel
-	Channel uuids.UUID `json:"channel"`
+	Channel uuids.UUID `json:"channel,omitempty"`
 
 	// email
-	Emails  []string `json:"emails"`
-	Subject string   `json:"subject"`
+	Emails  []string `json:"emails,omitempty"`
+	Subject string   `json:"subject,omitempty"`
 
 	// IVR
-	Recording json.RawMessage `json:"recording"`
-	URL       string          `json:"url"`
+	Recording json.RawMessage `json:"recording,omitempty"`
+	URL       string          `json:"url,omitempty"`
 }
 
 type subflowTest struct {
@@ -344,10 +363,16 @@ func migrateAction(baseLanguage envs.Language, a Action, localization migratedLo
 	case "channel":
 		return newSetContactChannelAction(a.UUID, assets.NewChannelReference(assets.ChannelUUID(a.Channel), a.Name)), nil
 	case "flow":
+		if a.Flow == nil {
+			return nil, errors.New("flow action has no flow")
+		}
 		flowRef := assets.NewFlowReference(assets.FlowUUID(a.Flow.UUID), a.Flow.Name)
 
 		return newEnterFlowAction(a.UUID, flowRef, true), nil
 	case "trigger-flow":
+		if a.Flow == nil {
+			return nil, errors.New("trigger-flow action has no flow")
+		}
 		flowRef := assets.NewFlowReference(assets.FlowUUID(a.Flow.UUID), a.Flow.Name)
 
 		contacts := make([]*flows.ContactReference, len(a.Contacts))
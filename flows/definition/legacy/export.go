@@ -0,0 +1,936 @@
+package legacy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/definition/legacy/expressions"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// the version of the legacy format that we export to
+const exportVersion = "11.12"
+
+var flowTypeExports = map[string]string{
+	"messaging":         "M",
+	"voice":             "V",
+	"messaging_offline": "S",
+}
+
+// reverse of testTypeMappings
+var testTypeExports = map[string]string{}
+
+func init() {
+	for legacyType, newType := range testTypeMappings {
+		testTypeExports[newType] = legacyType
+	}
+}
+
+// ExportIssue is a part of a flow which couldn't be represented in the legacy format
+type ExportIssue struct {
+	NodeUUID    uuids.UUID `json:"node_uuid"`
+	ActionUUID  uuids.UUID `json:"action_uuid,omitempty"`
+	Description string     `json:"description"`
+}
+
+// String returns a human readable representation of this issue
+func (i *ExportIssue) String() string {
+	if i.ActionUUID != "" {
+		return fmt.Sprintf("node[uuid=%s] action[uuid=%s]: %s", i.NodeUUID, i.ActionUUID, i.Description)
+	}
+	return fmt.Sprintf("node[uuid=%s]: %s", i.NodeUUID, i.Description)
+}
+
+//------------------------------------------------------------------------------------------
+// New flow objects as read for export
+//------------------------------------------------------------------------------------------
+
+type exportFlow struct {
+	UUID               uuids.UUID                                           `json:"uuid"`
+	Name               string                                               `json:"name"`
+	Language           envs.Language                                        `json:"language"`
+	Type               string                                               `json:"type"`
+	Revision           int                                                  `json:"revision"`
+	ExpireAfterMinutes int                                                  `json:"expire_after_minutes"`
+	Localization       map[envs.Language]map[uuids.UUID]map[string][]string `json:"localization"`
+	Nodes              []*exportNode                                        `json:"nodes"`
+	UI                 *UI                                                  `json:"_ui"`
+}
+
+type exportNode struct {
+	UUID    uuids.UUID      `json:"uuid"`
+	Actions []*exportAction `json:"actions"`
+	Router  *exportRouter   `json:"router"`
+	Exits   []*exportExit   `json:"exits"`
+}
+
+type exportExit struct {
+	UUID            uuids.UUID `json:"uuid"`
+	DestinationUUID uuids.UUID `json:"destination_uuid"`
+}
+
+type exportRouter struct {
+	Type                string            `json:"type"`
+	Wait                *exportWait       `json:"wait"`
+	ResultName          string            `json:"result_name"`
+	Categories          []*exportCategory `json:"categories"`
+	Operand             string            `json:"operand"`
+	Cases               []*exportCase     `json:"cases"`
+	DefaultCategoryUUID uuids.UUID        `json:"default_category_uuid"`
+}
+
+type exportWait struct {
+	Type    string `json:"type"`
+	Timeout *struct {
+		Seconds      int        `json:"seconds"`
+		CategoryUUID uuids.UUID `json:"category_uuid"`
+	} `json:"timeout"`
+	Hint *struct {
+		Type         string `json:"type"`
+		Count        int    `json:"count"`
+		TerminatedBy string `json:"terminated_by"`
+	} `json:"hint"`
+}
+
+type exportCategory struct {
+	UUID     uuids.UUID `json:"uuid"`
+	Name     string     `json:"name"`
+	ExitUUID uuids.UUID `json:"exit_uuid"`
+}
+
+type exportCase struct {
+	UUID         uuids.UUID `json:"uuid"`
+	Type         string     `json:"type"`
+	Arguments    []string   `json:"arguments"`
+	CategoryUUID uuids.UUID `json:"category_uuid"`
+}
+
+type exportReference struct {
+	UUID      uuids.UUID `json:"uuid"`
+	Key       string     `json:"key"`
+	Name      string     `json:"name"`
+	NameMatch string     `json:"name_match"`
+}
+
+// union of the properties of all the action types that we can export
+type exportAction struct {
+	Type string     `json:"type"`
+	UUID uuids.UUID `json:"uuid"`
+
+	// messages
+	Text         string          `json:"text"`
+	Attachments  []string        `json:"attachments"`
+	QuickReplies []string        `json:"quick_replies"`
+	AllURNs      bool            `json:"all_urns"`
+	Templating   json.RawMessage `json:"templating"`
+	Topic        string          `json:"topic"`
+	AudioURL     string          `json:"audio_url"`
+
+	// recipients
+	URNs          []string           `json:"urns"`
+	Contacts      []*exportReference `json:"contacts"`
+	Groups        []*exportReference `json:"groups"`
+	ContactQuery  string             `json:"contact_query"`
+	LegacyVars    []string           `json:"legacy_vars"`
+	CreateContact bool               `json:"create_contact"`
+	AllGroups     bool               `json:"all_groups"`
+
+	// emails
+	Addresses []string `json:"addresses"`
+	CC        []string `json:"cc"`
+	BCC       []string `json:"bcc"`
+	ReplyTo   string   `json:"reply_to"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	HTMLBody  string   `json:"html_body"`
+
+	// contact updates
+	Name     string           `json:"name"`
+	Language envs.Language    `json:"language"`
+	Channel  *exportReference `json:"channel"`
+	Field    *exportReference `json:"field"`
+	Value    string           `json:"value"`
+	Scheme   string           `json:"scheme"`
+	Path     string           `json:"path"`
+
+	// others
+	Labels      []*exportReference         `json:"labels"`
+	Flow        *exportReference           `json:"flow"`
+	Terminal    bool                       `json:"terminal"`
+	Method      string                     `json:"method"`
+	URL         string                     `json:"url"`
+	Headers     map[string]string          `json:"headers"`
+	Resthook    string                     `json:"resthook"`
+	Amounts     map[string]decimal.Decimal `json:"amounts"`
+	SKU         string                     `json:"sku"`
+	ResultName  string                     `json:"result_name"`
+	ProductType string                     `json:"product_type"`
+}
+
+//------------------------------------------------------------------------------------------
+// Export
+//------------------------------------------------------------------------------------------
+
+type exporter struct {
+	flow   *exportFlow
+	issues []*ExportIssue
+
+	// which nodes become action sets and which become rule sets
+	nodeTypes map[uuids.UUID]string
+}
+
+// Export exports the given flow to the legacy format. Anything in the flow which can't be represented in the legacy
+// format is returned as an issue.
+func Export(flow flows.Flow) (*Flow, []*ExportIssue, error) {
+	data, err := jsonx.Marshal(flow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return exportFlowJSON(data)
+}
+
+// ExportDefinition exports the given flow definition, which should be in the current spec version, to the legacy format
+func ExportDefinition(data json.RawMessage) (json.RawMessage, []*ExportIssue, error) {
+	legacyFlow, issues, err := exportFlowJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exported, err := jsonx.Marshal(legacyFlow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return exported, issues, nil
+}
+
+func exportFlowJSON(data json.RawMessage) (*Flow, []*ExportIssue, error) {
+	f := &exportFlow{}
+	if err := jsonx.Unmarshal(data, f); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to read flow for export")
+	}
+	if f.UI == nil {
+		f.UI = NewUI()
+	}
+
+	e := &exporter{flow: f, nodeTypes: make(map[uuids.UUID]string, len(f.Nodes))}
+	return e.export(), e.issues, nil
+}
+
+func (e *exporter) addIssue(nodeUUID, actionUUID uuids.UUID, description string, args ...interface{}) {
+	e.issues = append(e.issues, &ExportIssue{NodeUUID: nodeUUID, ActionUUID: actionUUID, Description: fmt.Sprintf(description, args...)})
+}
+
+func (e *exporter) export() *Flow {
+	f := e.flow
+
+	flowType, supported := flowTypeExports[f.Type]
+	if !supported {
+		e.addIssue("", "", "flows of type '%s' can't be exported", f.Type)
+		flowType = "M"
+	}
+
+	legacyFlow := &Flow{
+		BaseLanguage: f.Language,
+		FlowType:     flowType,
+		RuleSets:     []RuleSet{},
+		ActionSets:   []ActionSet{},
+		Version:      exportVersion,
+		Metadata: &Metadata{
+			UUID:     f.UUID,
+			Name:     f.Name,
+			Revision: f.Revision,
+			Expires:  f.ExpireAfterMinutes,
+			Notes:    e.exportNotes(),
+		},
+	}
+
+	if len(f.Nodes) > 0 {
+		legacyFlow.Entry = f.Nodes[0].UUID
+	}
+
+	// first decide what each node will become so that we know the types of destinations
+	for _, node := range f.Nodes {
+		if node.Router == nil || (len(node.Actions) > 0 && routerAction(node) == nil) {
+			e.nodeTypes[node.UUID] = "A"
+		} else {
+			e.nodeTypes[node.UUID] = "R"
+		}
+	}
+
+	for _, node := range f.Nodes {
+		actionSet, ruleSet := e.exportNode(node)
+		if actionSet != nil {
+			legacyFlow.ActionSets = append(legacyFlow.ActionSets, *actionSet)
+		}
+		if ruleSet != nil {
+			legacyFlow.RuleSets = append(legacyFlow.RuleSets, *ruleSet)
+		}
+	}
+
+	return legacyFlow
+}
+
+func (e *exporter) exportNotes() []Note {
+	stickyUUIDs := make([]uuids.UUID, 0, len(e.flow.UI.Stickies))
+	for uuid := range e.flow.UI.Stickies {
+		stickyUUIDs = append(stickyUUIDs, uuid)
+	}
+	sort.Slice(stickyUUIDs, func(i, j int) bool { return stickyUUIDs[i] < stickyUUIDs[j] })
+
+	notes := make([]Note, len(stickyUUIDs))
+	for i, uuid := range stickyUUIDs {
+		sticky := e.flow.UI.Stickies[uuid]
+		notes[i] = Note{
+			X:     decimal.NewFromInt(int64(sticky.Position.Left)),
+			Y:     decimal.NewFromInt(int64(sticky.Position.Top)),
+			Title: sticky.Title,
+			Body:  sticky.Body,
+		}
+	}
+	return notes
+}
+
+// gets the action of a node which is represented by a ruleset in legacy flows, e.g. call_webhook
+func routerAction(node *exportNode) *exportAction {
+	if len(node.Actions) == 1 && node.Router != nil && node.Router.Type == "switch" {
+		action := node.Actions[0]
+		switch action.Type {
+		case "call_webhook", "call_resthook", "transfer_airtime":
+			return action
+		case "enter_flow":
+			if !action.Terminal {
+				return action
+			}
+		}
+	}
+	return nil
+}
+
+func (e *exporter) position(nodeUUID uuids.UUID) Position {
+	if nodeUI := e.flow.UI.Nodes[nodeUUID]; nodeUI != nil {
+		return nodeUI.Position
+	}
+	return Position{}
+}
+
+func (e *exporter) destination(exit *exportExit) (uuids.UUID, string) {
+	if exit == nil || exit.DestinationUUID == "" {
+		return "", ""
+	}
+	return exit.DestinationUUID, e.nodeTypes[exit.DestinationUUID]
+}
+
+// exports a node as an action set, a rule set, or an action set followed by a rule set
+func (e *exporter) exportNode(node *exportNode) (*ActionSet, *RuleSet) {
+	pos := e.position(node.UUID)
+
+	if e.nodeTypes[node.UUID] == "R" {
+		return nil, e.exportRouter(node, node.UUID, pos)
+	}
+
+	actionSet := &ActionSet{
+		UUID:    node.UUID,
+		X:       pos.Left,
+		Y:       pos.Top,
+		Actions: []Action{},
+	}
+	for _, action := range node.Actions {
+		if exported := e.exportAction(node, action); exported != nil {
+			actionSet.Actions = append(actionSet.Actions, *exported)
+		}
+	}
+
+	if node.Router == nil {
+		if len(node.Exits) > 0 {
+			actionSet.ExitUUID = node.Exits[0].UUID
+			actionSet.Destination, _ = e.destination(node.Exits[0])
+		} else {
+			actionSet.ExitUUID = uuids.New()
+		}
+		return actionSet, nil
+	}
+
+	// node has both actions and a router so becomes an action set which leads to a new rule set
+	ruleSetUUID := uuids.New()
+	e.nodeTypes[ruleSetUUID] = "R"
+	actionSet.ExitUUID = uuids.New()
+	actionSet.Destination = ruleSetUUID
+
+	return actionSet, e.exportRouter(node, ruleSetUUID, Position{Left: pos.Left, Top: pos.Top + 100})
+}
+
+// exports the router of a node (and any action it wraps) as a rule set
+func (e *exporter) exportRouter(node *exportNode, uuid uuids.UUID, pos Position) *RuleSet {
+	router := node.Router
+	ruleSet := &RuleSet{UUID: uuid, X: pos.Left, Y: pos.Top, Label: router.ResultName, Operand: "@step.value"}
+
+	if router.Type == "random" {
+		ruleSet.Type = "random"
+		ruleSet.Operand = "@(RAND())"
+		ruleSet.Rules = e.exportRandomRules(node)
+		return ruleSet
+	} else if router.Type != "switch" {
+		e.addIssue(node.UUID, "", "routers of type '%s' can't be exported", router.Type)
+		ruleSet.Type = "expression"
+		return ruleSet
+	}
+
+	if action := routerAction(node); action != nil {
+		e.exportRouterAction(node, action, ruleSet)
+	} else if router.Wait != nil {
+		e.exportWait(node, ruleSet)
+	} else {
+		e.exportSplit(node, ruleSet)
+	}
+
+	ruleSet.Rules = e.exportSwitchRules(node, ruleSet.Type)
+	return ruleSet
+}
+
+// exports an action which is represented by a rule set in legacy flows, e.g. call_webhook becomes a webhook rule set
+func (e *exporter) exportRouterAction(node *exportNode, action *exportAction, ruleSet *RuleSet) {
+	ruleSet.Label = action.ResultName
+
+	switch action.Type {
+	case "call_webhook":
+		headers := make([]WebhookHeader, 0, len(action.Headers))
+		for name, value := range action.Headers {
+			// migration adds this header to POST requests with the legacy payload
+			if name == "Content-Type" && value == "application/json" && action.Body == legacyWebhookPayload {
+				continue
+			}
+			headers = append(headers, WebhookHeader{Name: name, Value: e.exportTemplate(node.UUID, action.UUID, value, nil)})
+		}
+		sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+
+		if action.Body != "" && action.Body != legacyWebhookPayload {
+			e.addIssue(node.UUID, action.UUID, "webhook with custom body can't be exported")
+		} else if action.Method == "POST" && action.Body == "" {
+			e.addIssue(node.UUID, action.UUID, "webhook POST without body can't be exported")
+		}
+
+		ruleSet.Type = "webhook"
+		ruleSet.Config = mustMarshal(&RulesetConfig{
+			Webhook:        e.exportTemplate(node.UUID, action.UUID, action.URL, &expressions.ExportOptions{URLEncoded: true}),
+			WebhookAction:  action.Method,
+			WebhookHeaders: headers,
+		})
+
+	case "call_resthook":
+		ruleSet.Type = "resthook"
+		ruleSet.Config = mustMarshal(&RulesetConfig{Resthook: action.Resthook})
+
+	case "transfer_airtime":
+		if action.SKU != "" || (action.ProductType != "" && action.ProductType != "airtime") {
+			e.addIssue(node.UUID, action.UUID, "transfers of products other than airtime can't be exported")
+		}
+
+		// legacy config is keyed by country but only currency codes are used
+		config := make(map[string]map[string]interface{}, len(action.Amounts))
+		for currency, amount := range action.Amounts {
+			config[currency] = map[string]interface{}{"currency_code": currency, "amount": amount}
+		}
+
+		ruleSet.Type = "airtime"
+		ruleSet.Config = mustMarshal(config)
+
+	case "enter_flow":
+		ruleSet.Type = "subflow"
+		ruleSet.Label = node.Router.ResultName
+		ruleSet.Config = mustMarshal(&RulesetConfig{Flow: &FlowReference{UUID: action.Flow.UUID, Name: action.Flow.Name}})
+	}
+}
+
+// exports a switch router with a wait as a waiting rule set
+func (e *exporter) exportWait(node *exportNode, ruleSet *RuleSet) {
+	wait := node.Router.Wait
+	ruleSet.Type = "wait_message"
+
+	if wait.Hint != nil {
+		switch wait.Hint.Type {
+		case "audio":
+			if e.flow.Type == "voice" {
+				ruleSet.Type = "wait_recording"
+			} else {
+				ruleSet.Type = "wait_audio"
+			}
+		case "video":
+			ruleSet.Type = "wait_video"
+		case "image":
+			ruleSet.Type = "wait_photo"
+		case "location":
+			ruleSet.Type = "wait_gps"
+		case "digits":
+			if wait.Hint.TerminatedBy != "" {
+				ruleSet.Type = "wait_digits"
+				ruleSet.FinishedKey = wait.Hint.TerminatedBy
+			} else if wait.Hint.Count == 1 {
+				ruleSet.Type = "wait_digit"
+			} else {
+				e.addIssue(node.UUID, "", "wait for %d digits can't be exported", wait.Hint.Count)
+			}
+		default:
+			e.addIssue(node.UUID, "", "wait hint of type '%s' can't be exported", wait.Hint.Type)
+		}
+	}
+
+	if node.Router.Operand != "@input" && node.Router.Operand != "@input.text" {
+		e.addIssue(node.UUID, "", "wait with operand '%s' can't be exported", node.Router.Operand)
+	}
+}
+
+var delimitedOperandRegex = regexp.MustCompile(`^@\(field\((.+), (\d+), "(.*)"\)\)$`)
+
+// exports a switch router without a wait as a splitting rule set
+func (e *exporter) exportSplit(node *exportNode, ruleSet *RuleSet) {
+	operand := node.Router.Operand
+
+	var uiType UINodeType
+	if nodeUI := e.flow.UI.Nodes[node.UUID]; nodeUI != nil {
+		uiType = nodeUI.Type
+	}
+
+	if operand == "@contact.groups" {
+		ruleSet.Type = "group"
+		return
+	}
+
+	switch uiType {
+	case UINodeTypeSplitByContactField:
+		ruleSet.Type = "contact_field"
+	case UINodeTypeSplitByRunResult:
+		ruleSet.Type = "flow_field"
+	case UINodeTypeSplitByRunResultDelimited:
+		if match := delimitedOperandRegex.FindStringSubmatch(operand); match != nil {
+			index, _ := strconv.Atoi(match[2])
+
+			ruleSet.Type = "form_field"
+			ruleSet.Operand = e.exportTemplate(node.UUID, "", "@"+match[1], nil)
+			ruleSet.Config = mustMarshal(&RulesetConfig{FieldIndex: index, FieldDelimiter: match[3]})
+			return
+		}
+		ruleSet.Type = "expression"
+	default:
+		ruleSet.Type = "expression"
+	}
+
+	ruleSet.Operand = e.exportTemplate(node.UUID, "", operand, nil)
+
+	// operands of legacy expression rulesets default to themselves when migrated so operands which don't do that
+	// and are just contact or run result references, are exported as field rulesets
+	if ruleSet.Type == "expression" && !strings.HasPrefix(operand, "@(if(is_error(") {
+		if strings.HasPrefix(ruleSet.Operand, "@contact.") {
+			ruleSet.Type = "contact_field"
+		} else if strings.HasPrefix(ruleSet.Operand, "@flow.") {
+			ruleSet.Type = "flow_field"
+		}
+	}
+}
+
+// builds rules for a rule set, making sure the first rule for each category takes the exit UUID
+type ruleBuilder struct {
+	e     *exporter
+	node  *exportNode
+	rules []Rule
+	used  map[uuids.UUID]bool
+}
+
+func (b *ruleBuilder) add(category *exportCategory, test TypedEnvelope) {
+	var exit *exportExit
+	for _, x := range b.node.Exits {
+		if x.UUID == category.ExitUUID {
+			exit = x
+		}
+	}
+
+	ruleUUID := category.ExitUUID
+	if b.used[category.UUID] || ruleUUID == "" {
+		ruleUUID = uuids.New()
+	}
+	b.used[category.UUID] = true
+
+	destination, destinationType := b.e.destination(exit)
+
+	b.rules = append(b.rules, Rule{
+		UUID:            ruleUUID,
+		Destination:     destination,
+		DestinationType: destinationType,
+		Test:            test,
+		Category:        b.e.translations(b.node, "", category.UUID, "name", category.Name, false),
+	})
+}
+
+func (e *exporter) exportRandomRules(node *exportNode) []Rule {
+	b := &ruleBuilder{e: e, node: node, used: make(map[uuids.UUID]bool)}
+	numBuckets := decimal.NewFromInt(int64(len(node.Router.Categories)))
+
+	for i, category := range node.Router.Categories {
+		b.add(category, newTest("between", &betweenTest{
+			Min: decimal.NewFromInt(int64(i)).DivRound(numBuckets, 2).String(),
+			Max: decimal.NewFromInt(int64(i+1)).DivRound(numBuckets, 2).String(),
+		}))
+	}
+	return b.rules
+}
+
+func (e *exporter) exportSwitchRules(node *exportNode, ruleSetType string) []Rule {
+	router := node.Router
+	b := &ruleBuilder{e: e, node: node, used: make(map[uuids.UUID]bool)}
+
+	categoriesByUUID := make(map[uuids.UUID]*exportCategory, len(router.Categories))
+	for _, category := range router.Categories {
+		categoriesByUUID[category.UUID] = category
+	}
+
+	var lastTest TypedEnvelope
+	var lastCategory *exportCategory
+
+	for _, kase := range router.Cases {
+		category := categoriesByUUID[kase.CategoryUUID]
+		if category == nil {
+			continue
+		}
+
+		test, err := e.exportCase(node, kase, ruleSetType)
+		if err != nil {
+			e.addIssue(node.UUID, "", err.Error())
+			continue
+		}
+		b.add(category, test)
+		lastTest, lastCategory = test, category
+	}
+
+	if defaultCategory := categoriesByUUID[router.DefaultCategoryUUID]; defaultCategory != nil {
+		switch {
+		case lastTest.Type == "webhook_status":
+			// default is implicitly the category of the last webhook status rule
+			if lastCategory != defaultCategory {
+				e.addIssue(node.UUID, "", "default category '%s' must be the category of the last webhook status case", defaultCategory.Name)
+			}
+		case ruleSetType == "airtime":
+			b.add(defaultCategory, newTest("airtime_status", &airtimeTest{ExitStatus: "failed"}))
+		default:
+			if b.used[defaultCategory.UUID] {
+				e.addIssue(node.UUID, "", "default category '%s' can't also have cases", defaultCategory.Name)
+			}
+			b.add(defaultCategory, newTest("true", nil))
+		}
+	}
+
+	if router.Wait != nil && router.Wait.Timeout != nil {
+		if timeoutCategory := categoriesByUUID[router.Wait.Timeout.CategoryUUID]; timeoutCategory != nil {
+			if router.Wait.Timeout.Seconds%60 != 0 {
+				e.addIssue(node.UUID, "", "timeout of %d seconds can't be exported as a whole number of minutes", router.Wait.Timeout.Seconds)
+			}
+			b.add(timeoutCategory, newTest("timeout", &timeoutTest{Minutes: router.Wait.Timeout.Seconds / 60}))
+		}
+	}
+
+	for _, category := range router.Categories {
+		if !b.used[category.UUID] {
+			e.addIssue(node.UUID, "", "category '%s' has no exportable cases", category.Name)
+		}
+	}
+
+	return b.rules
+}
+
+// exports a router case as a legacy rule test
+func (e *exporter) exportCase(node *exportNode, kase *exportCase, ruleSetType string) (TypedEnvelope, error) {
+	args := kase.Arguments
+	arg := func(i int) string {
+		if i < len(args) {
+			return e.exportTemplate(node.UUID, "", args[i], nil)
+		}
+		return ""
+	}
+
+	// cases which test the results of subflows, webhooks and airtime transfers
+	switch kase.Type {
+	case "has_only_text":
+		if len(args) == 1 {
+			switch args[0] {
+			case "completed", "expired":
+				return newTest("subflow", &subflowTest{ExitType: args[0]}), nil
+			case "Success", "Failure":
+				return newTest("webhook_status", &webhookTest{Status: strings.ToLower(args[0])}), nil
+			}
+		}
+	case "has_category":
+		if ruleSetType == "airtime" && len(args) == 1 && args[0] == "Success" {
+			return newTest("airtime_status", &airtimeTest{ExitStatus: "success"}), nil
+		}
+	}
+
+	legacyType, exists := testTypeExports[kase.Type]
+	if !exists {
+		return TypedEnvelope{}, errors.Errorf("case of type '%s' can't be exported", kase.Type)
+	}
+
+	switch legacyType {
+	case "date", "has_email", "not_empty", "number", "phone", "state":
+		return newTest(legacyType, nil), nil
+	case "eq", "gt", "gte", "lt", "lte":
+		return newTest(legacyType, &numericTest{Test: StringOrNumber(arg(0))}), nil
+	case "between":
+		return newTest(legacyType, &betweenTest{Min: arg(0), Max: arg(1)}), nil
+	case "contains", "contains_any", "contains_phrase", "contains_only_phrase", "regex", "starts":
+		base := ""
+		if len(args) > 0 {
+			base = args[0]
+		}
+		return newTest(legacyType, &localizedStringTest{Test: e.translations(node, "", kase.UUID, "arguments", base, legacyType != "regex")}), nil
+	case "date_equal", "date_after", "date_before":
+		return newTest(legacyType, &stringTest{Test: arg(0)}), nil
+	case "in_group":
+		if len(args) < 2 {
+			return TypedEnvelope{}, errors.New("group case without group can't be exported")
+		}
+		return newTest(legacyType, &groupTest{Test: GroupReference{UUID: uuids.UUID(args[0]), Name: args[1]}}), nil
+	case "district":
+		return newTest(legacyType, &stringTest{Test: arg(0)}), nil
+	case "ward":
+		return newTest(legacyType, &wardTest{District: arg(0), State: arg(1)}), nil
+	}
+
+	return TypedEnvelope{}, errors.Errorf("case of type '%s' can't be exported", kase.Type)
+}
+
+// creates a new legacy rule test
+func newTest(testType string, test interface{}) TypedEnvelope {
+	var data []byte
+	if test != nil {
+		data, _ = jsonx.MarshalMerged(&typeOnly{Type: testType}, test)
+	} else {
+		data = mustMarshal(&typeOnly{Type: testType})
+	}
+	return TypedEnvelope{Type: testType, Data: data}
+}
+
+// exports the given action to a legacy action, returning nil if that's not possible
+func (e *exporter) exportAction(node *exportNode, a *exportAction) *Action {
+	exported := &Action{Type: a.Type, UUID: a.UUID}
+	tpl := func(t string) string { return e.exportTemplate(node.UUID, a.UUID, t, nil) }
+
+	switch a.Type {
+	case "send_msg", "send_broadcast":
+		exported.Msg = mustMarshal(e.translations(node, a.UUID, a.UUID, "text", a.Text, true))
+
+		if len(a.Attachments) > 1 {
+			e.addIssue(node.UUID, a.UUID, "only the first attachment can be exported")
+		}
+		if len(a.Attachments) > 0 {
+			exported.Media = mustMarshal(e.translations(node, a.UUID, a.UUID, "attachments", a.Attachments[0], true))
+		}
+		if len(a.QuickReplies) > 0 {
+			quickReplies := make([]Translations, len(a.QuickReplies))
+			for i := range a.QuickReplies {
+				quickReplies[i] = e.indexedTranslations(node, a.UUID, "quick_replies", a.QuickReplies, i)
+			}
+			exported.QuickReplies = mustMarshal(quickReplies)
+		}
+
+		if a.Type == "send_msg" {
+			if a.Templating != nil {
+				e.addIssue(node.UUID, a.UUID, "message templating can't be exported")
+			}
+			if a.Topic != "" {
+				e.addIssue(node.UUID, a.UUID, "message topics can't be exported")
+			}
+
+			exported.Type = "reply"
+			exported.SendAll = a.AllURNs
+			return exported
+		}
+
+		if len(a.URNs) > 0 {
+			e.addIssue(node.UUID, a.UUID, "broadcast URNs can't be exported")
+		}
+		exported.Type = "send"
+		e.exportRecipients(node, a, exported)
+		return exported
+
+	case "add_input_labels":
+		exported.Type = "add_label"
+		for _, label := range a.Labels {
+			exported.Labels = append(exported.Labels, LabelReference{UUID: label.UUID, Name: e.referenceName(node, a, label)})
+		}
+		return exported
+
+	case "send_email":
+		if len(a.CC) > 0 || len(a.BCC) > 0 || a.ReplyTo != "" || a.HTMLBody != "" || len(a.Attachments) > 0 {
+			e.addIssue(node.UUID, a.UUID, "only the addresses, subject and body of emails can be exported")
+		}
+
+		exported.Type = "email"
+		for _, address := range a.Addresses {
+			exported.Emails = append(exported.Emails, tpl(address))
+		}
+		exported.Subject = tpl(a.Subject)
+		exported.Msg = mustMarshal(tpl(a.Body))
+		return exported
+
+	case "set_contact_language":
+		exported.Type = "lang"
+		exported.Language = a.Language
+		return exported
+
+	case "set_contact_channel":
+		exported.Type = "channel"
+		if a.Channel != nil {
+			exported.Channel = a.Channel.UUID
+			exported.Name = a.Channel.Name
+		}
+		return exported
+
+	case "enter_flow":
+		if !a.Terminal {
+			e.addIssue(node.UUID, a.UUID, "non-terminal enter_flow actions must be followed by a router to be exported")
+			return nil
+		}
+		exported.Type = "flow"
+		exported.Flow = &FlowReference{UUID: a.Flow.UUID, Name: a.Flow.Name}
+		return exported
+
+	case "start_session":
+		if len(a.URNs) > 0 || a.ContactQuery != "" {
+			e.addIssue(node.UUID, a.UUID, "session URNs and contact queries can't be exported")
+		}
+		exported.Type = "trigger-flow"
+		exported.Flow = &FlowReference{UUID: a.Flow.UUID, Name: a.Flow.Name}
+		e.exportRecipients(node, a, exported)
+		if a.CreateContact {
+			exported.Variables = append(exported.Variables, VariableReference{ID: "@new_contact"})
+		}
+		return exported
+
+	case "add_contact_groups", "remove_contact_groups":
+		exported.Type = "add_group"
+		if a.Type == "remove_contact_groups" {
+			exported.Type = "del_group"
+			if a.AllGroups {
+				return exported
+			}
+		}
+		for _, group := range a.Groups {
+			exported.Groups = append(exported.Groups, GroupReference{UUID: group.UUID, Name: e.referenceName(node, a, group)})
+		}
+		return exported
+
+	case "set_contact_field":
+		exported.Type = "save"
+		exported.Field = a.Field.Key
+		exported.Label = a.Field.Name
+		exported.Value = tpl(a.Value)
+		return exported
+
+	case "set_contact_name":
+		exported.Type = "save"
+		exported.Field = "name"
+		exported.Label = "Contact Name"
+		exported.Value = tpl(a.Name)
+		return exported
+
+	case "add_contact_urn":
+		exported.Type = "save"
+		exported.Field = a.Scheme
+		exported.Value = tpl(a.Path)
+		return exported
+
+	case "say_msg":
+		exported.Type = "say"
+		exported.Msg = mustMarshal(e.translations(node, a.UUID, a.UUID, "text", a.Text, true))
+		if a.AudioURL != "" {
+			exported.Recording = mustMarshal(e.translations(node, a.UUID, a.UUID, "audio_url", a.AudioURL, false))
+		}
+		return exported
+
+	case "play_audio":
+		exported.Type = "play"
+		exported.URL = tpl(a.AudioURL)
+		return exported
+	}
+
+	e.addIssue(node.UUID, a.UUID, "actions of type '%s' can't be exported", a.Type)
+	return nil
+}
+
+// exports the contacts, groups and variables of a broadcast or session action
+func (e *exporter) exportRecipients(node *exportNode, a *exportAction, exported *Action) {
+	for _, contact := range a.Contacts {
+		exported.Contacts = append(exported.Contacts, ContactReference{UUID: contact.UUID, Name: contact.Name})
+	}
+	for _, group := range a.Groups {
+		exported.Groups = append(exported.Groups, GroupReference{UUID: group.UUID, Name: e.referenceName(node, a, group)})
+	}
+	for _, variable := range a.LegacyVars {
+		exported.Variables = append(exported.Variables, VariableReference{ID: e.exportTemplate(node.UUID, a.UUID, variable, nil)})
+	}
+}
+
+// gets the name of a group or label reference, which for references without UUIDs is an expression
+func (e *exporter) referenceName(node *exportNode, a *exportAction, ref *exportReference) string {
+	if ref.UUID == "" {
+		return e.exportTemplate(node.UUID, a.UUID, ref.NameMatch, nil)
+	}
+	return ref.Name
+}
+
+// builds legacy translations from a base language value and any localized values
+func (e *exporter) translations(node *exportNode, actionUUID, itemUUID uuids.UUID, property, base string, templated bool) Translations {
+	export := func(s string) string {
+		if templated {
+			return e.exportTemplate(node.UUID, actionUUID, s, nil)
+		}
+		return s
+	}
+
+	t := Translations{e.flow.Language: export(base)}
+	for lang, items := range e.flow.Localization {
+		if values := items[itemUUID][property]; len(values) > 0 {
+			t[lang] = export(values[0])
+		}
+	}
+	return t
+}
+
+// builds legacy translations for an item in a list of localized values
+func (e *exporter) indexedTranslations(node *exportNode, actionUUID uuids.UUID, property string, base []string, index int) Translations {
+	t := Translations{e.flow.Language: e.exportTemplate(node.UUID, actionUUID, base[index], nil)}
+	for lang, items := range e.flow.Localization {
+		if values := items[actionUUID][property]; index < len(values) {
+			t[lang] = e.exportTemplate(node.UUID, actionUUID, values[index], nil)
+		}
+	}
+	return t
+}
+
+// exports a template, recording an issue if it contains expressions which can't be exported
+func (e *exporter) exportTemplate(nodeUUID, actionUUID uuids.UUID, template string, options *expressions.ExportOptions) string {
+	exported, err := expressions.ExportTemplate(template, options)
+	if err != nil {
+		e.addIssue(nodeUUID, actionUUID, strings.TrimSpace(err.Error()))
+	}
+	return exported
+}
+
+// marshals values built by the exporter which are always valid JSON
+func mustMarshal(v interface{}) json.RawMessage {
+	data, err := jsonx.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,196 @@
+package legacy_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/nyaruka/goflow/flows/definition"
+	"github.com/nyaruka/goflow/flows/definition/legacy"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRoundTrips(t *testing.T) {
+	defer uuids.SetGenerator(uuids.DefaultGenerator)
+
+	legacyFlows := make([]string, 0)
+
+	var flowTests []FlowMigrationTest
+	readTestCases(t, "testdata/flows.json", &flowTests)
+	for _, tc := range flowTests {
+		legacyFlows = append(legacyFlows, string(tc.Legacy))
+	}
+
+	var actionTests []ActionMigrationTest
+	readTestCases(t, "testdata/actions.json", &actionTests)
+	for _, tc := range actionTests {
+		if tc.LegacyFlowType == "" {
+			tc.LegacyFlowType = "F"
+		}
+		legacyFlows = append(legacyFlows, fmt.Sprintf(legacyActionHolderDef, tc.LegacyFlowType, string(tc.LegacyAction)))
+	}
+
+	var testTests []TestMigrationTest
+	readTestCases(t, "testdata/tests.json", &testTests)
+	for _, tc := range testTests {
+		legacyFlows = append(legacyFlows, fmt.Sprintf(legacyTestHolderDef, string(tc.LegacyTest)))
+	}
+
+	var ruleSetTests []RuleSetMigrationTest
+	readTestCases(t, "testdata/rulesets.json", &ruleSetTests)
+	for _, tc := range ruleSetTests {
+		legacyFlows = append(legacyFlows, fmt.Sprintf(legacyRuleSetHolderDef, string(tc.LegacyRuleSet)))
+	}
+
+	allIssues := make([]string, 0)
+
+	for _, legacyFlow := range legacyFlows {
+		uuids.SetGenerator(uuids.NewSeededGenerator(123456))
+
+		migrated, err := legacy.MigrateDefinition(json.RawMessage(legacyFlow), "https://myfiles.com")
+		require.NoError(t, err)
+
+		exported, issues, err := legacy.ExportDefinition(migrated)
+		require.NoError(t, err)
+
+		for _, issue := range issues {
+			allIssues = append(allIssues, issue.String())
+		}
+
+		uuids.SetGenerator(uuids.NewSeededGenerator(123456))
+
+		remigrated, err := legacy.MigrateDefinition(exported, "https://myfiles.com")
+		require.NoError(t, err, "unable to migrate exported flow %s", string(exported))
+
+		test.AssertEqualJSON(t, normalizeGenerated(t, migrated, migrated), normalizeGenerated(t, migrated, remigrated), "round trip mismatch for %s, exported as %s", legacyFlow, string(exported))
+	}
+
+	// only invalid expressions which migration left as is, can't be exported
+	assert.Equal(t, []string{
+		"node[uuid=10e483a8-5ffb-4c4f-917b-d43ce86c1d65]: error evaluating @(legacy.expression.with.error.): syntax error at",
+	}, allIssues)
+}
+
+func TestExportIssues(t *testing.T) {
+	defer uuids.SetGenerator(uuids.DefaultGenerator)
+	uuids.SetGenerator(uuids.NewSeededGenerator(123456))
+
+	flowJSON, err := ioutil.ReadFile("testdata/export_issues.json")
+	require.NoError(t, err)
+
+	flow, err := definition.ReadFlow(flowJSON, nil)
+	require.NoError(t, err)
+
+	exported, issues, err := legacy.Export(flow)
+	require.NoError(t, err)
+
+	issueStrs := make([]string, len(issues))
+	for i := range issues {
+		issueStrs[i] = issues[i].String()
+	}
+
+	assert.Equal(t, []string{
+		"node[uuid=a58be63b-907d-4a1a-856b-0bb5579d7507] action[uuid=e97cd6d5-3354-4dbd-85bc-6c1f87849eec]: message templating can't be exported",
+		"node[uuid=a58be63b-907d-4a1a-856b-0bb5579d7507] action[uuid=7bd8b3bf-0a3c-4928-bc46-df416e77edfe]: error evaluating @(foreach(contact.urns, upper)): no legacy equivalent for context reference 'contact.urns'",
+		"node[uuid=a58be63b-907d-4a1a-856b-0bb5579d7507] action[uuid=26e5cd7a-d9d8-4d3e-b2ad-9d4c86bd2f17]: actions of type 'set_run_result' can't be exported",
+		"node[uuid=f5bb9b7a-7b5e-45c3-8f0e-61b4e95edf03]: case of type 'has_top_intent' can't be exported",
+		"node[uuid=f5bb9b7a-7b5e-45c3-8f0e-61b4e95edf03]: category 'Similar' has no exportable cases",
+	}, issueStrs)
+
+	exportedJSON, err := jsonx.MarshalPretty(exported)
+	require.NoError(t, err)
+
+	expectedJSON, err := ioutil.ReadFile("testdata/export_issues.legacy.json")
+	require.NoError(t, err)
+
+	test.AssertEqualJSON(t, expectedJSON, exportedJSON, "exported flow mismatch")
+}
+
+func readTestCases(t *testing.T, path string, cases interface{}) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, jsonx.Unmarshal(data, cases))
+}
+
+type normalizedFlow struct {
+	Nodes []struct {
+		Actions []struct {
+			UUID string `json:"uuid"`
+		} `json:"actions"`
+		Router *struct {
+			Categories []struct {
+				UUID string `json:"uuid"`
+			} `json:"categories"`
+			Cases []struct {
+				UUID string `json:"uuid"`
+			} `json:"cases"`
+		} `json:"router"`
+	} `json:"nodes"`
+}
+
+// migrating an exported flow generates new UUIDs for categories, cases etc, so we map those to the UUIDs in the
+// original migration by position, and sticky notes are compared as a list
+func normalizeGenerated(t *testing.T, original, flowJSON json.RawMessage) json.RawMessage {
+	f1, f2 := &normalizedFlow{}, &normalizedFlow{}
+	require.NoError(t, jsonx.Unmarshal(original, f1))
+	require.NoError(t, jsonx.Unmarshal(flowJSON, f2))
+	require.Equal(t, len(f1.Nodes), len(f2.Nodes), "node count mismatch in %s", string(flowJSON))
+
+	replacements := make([]string, 0)
+	replace := func(from, to string) {
+		if from != to {
+			replacements = append(replacements, from, to)
+		}
+	}
+
+	for i, n1 := range f1.Nodes {
+		n2 := f2.Nodes[i]
+		for j := 0; j < len(n1.Actions) && j < len(n2.Actions); j++ {
+			replace(n2.Actions[j].UUID, n1.Actions[j].UUID)
+		}
+		if n1.Router != nil && n2.Router != nil {
+			for j := 0; j < len(n1.Router.Categories) && j < len(n2.Router.Categories); j++ {
+				replace(n2.Router.Categories[j].UUID, n1.Router.Categories[j].UUID)
+			}
+			for j := 0; j < len(n1.Router.Cases) && j < len(n2.Router.Cases); j++ {
+				replace(n2.Router.Cases[j].UUID, n1.Router.Cases[j].UUID)
+			}
+		}
+	}
+
+	// replace in two passes via placeholders so replacements can't clobber each other
+	placeholders := make([]string, 0, len(replacements))
+	finals := make([]string, 0, len(replacements))
+	for i := 0; i < len(replacements); i += 2 {
+		placeholder := fmt.Sprintf("__uuid%d__", i)
+		placeholders = append(placeholders, replacements[i], placeholder)
+		finals = append(finals, placeholder, replacements[i+1])
+	}
+	normalized := strings.NewReplacer(placeholders...).Replace(string(flowJSON))
+	normalized = strings.NewReplacer(finals...).Replace(normalized)
+
+	generic := make(map[string]interface{})
+	require.NoError(t, jsonx.Unmarshal(json.RawMessage(normalized), &generic))
+
+	if ui, hasUI := generic["_ui"].(map[string]interface{}); hasUI {
+		stickies := make([]string, 0)
+		for _, sticky := range ui["stickies"].(map[string]interface{}) {
+			stickyJSON, _ := jsonx.Marshal(sticky)
+			stickies = append(stickies, string(stickyJSON))
+		}
+		sort.Strings(stickies)
+		ui["stickies"] = stickies
+	}
+
+	result, err := jsonx.Marshal(generic)
+	require.NoError(t, err)
+	return result
+}
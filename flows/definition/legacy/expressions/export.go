@@ -0,0 +1,587 @@
+package expressions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/goflow/excellent"
+	"github.com/nyaruka/goflow/excellent/gen"
+	"github.com/nyaruka/goflow/flows"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+	"github.com/pkg/errors"
+)
+
+// ExportOptions are options for how expressions are exported
+type ExportOptions struct {
+	URLEncoded bool // expressions were URL encoded by migration so url_encode calls should be removed
+}
+
+var defaultExportOptions = &ExportOptions{}
+
+// ExportTemplate will take a new template and translate it to the legacy syntax. Any expressions which can't be
+// translated are left as they are and returned as errors.
+func ExportTemplate(template string, options *ExportOptions) (string, error) {
+	if options == nil {
+		options = defaultExportOptions
+	}
+
+	buf := &strings.Builder{}
+	scanner := excellent.NewXScanner(strings.NewReader(template), flows.RunContextTopLevels)
+	scanner.SetUnescapeBody(false)
+	errors := excellent.NewTemplateErrors()
+
+	for tokenType, token := scanner.Scan(); tokenType != excellent.EOF; tokenType, token = scanner.Scan() {
+		switch tokenType {
+		case excellent.BODY:
+			buf.WriteString(token)
+		case excellent.IDENTIFIER, excellent.EXPRESSION:
+			exported, err := exportExpression(token, options)
+			if err != nil {
+				repr := "@" + token
+				if tokenType == excellent.EXPRESSION {
+					repr = "@(" + token + ")"
+				}
+				errors.Add(repr, err.Error())
+				buf.WriteString(repr)
+			} else {
+				buf.WriteString(wrapLegacyExpression(exported))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return buf.String(), errors
+	}
+	return buf.String(), nil
+}
+
+// exports a new expression to a legacy expression
+func exportExpression(expression string, options *ExportOptions) (string, error) {
+	visitor := &exportVisitor{options: options}
+	output, err := excellent.VisitExpression(expression, visitor)
+	if err != nil {
+		return "", err
+	}
+	if visitor.err != nil {
+		return "", visitor.err
+	}
+
+	return visitor.render(output), visitor.err
+}
+
+var legacyIdentifierRegex = regexp.MustCompile(`^[a-z][\w.]*$`)
+
+// takes a legacy expression and wraps it for inclusion in a template, e.g. contact.name -> @contact.name
+func wrapLegacyExpression(expression string) string {
+	if legacyIdentifierRegex.MatchString(expression) {
+		for _, topLevel := range ContextTopLevels {
+			if expression == topLevel || strings.HasPrefix(expression, topLevel+".") {
+				return "@" + expression
+			}
+		}
+	}
+	return "@(" + expression + ")"
+}
+
+type exportMapping struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// mappings of new context paths to legacy context paths, the reverse of the mappings used for migration
+var exportMappings = []exportMapping{
+	{regexp.MustCompile(`^((?:parent|child)\.)?contact$`), `${1}contact`},
+	{regexp.MustCompile(`^((?:parent|child)\.)?contact\.(uuid|id|name|first_name|created_on|language|groups)$`), `${1}contact.$2`},
+	{regexp.MustCompile(`^((?:parent|child)\.)?(?:contact\.)?fields\.(\w+)$`), `${1}contact.$2`},
+	{regexp.MustCompile(`^((?:parent|child)\.)?(?:contact\.)?urns\.(\w+)$`), `${1}contact.$2.urn`},
+	{regexp.MustCompile(`^contact\.channel\.address$`), `channel`},
+	{regexp.MustCompile(`^contact\.channel\.name$`), `channel.name`},
+
+	{regexp.MustCompile(`^results$`), `flow`},
+	{regexp.MustCompile(`^results\.(\w+)$`), `flow.$1`},
+	{regexp.MustCompile(`^results\.(\w+)\.value$`), `flow.$1.value`},
+	{regexp.MustCompile(`^results\.(\w+)\.category(?:_localized)?$`), `flow.$1.category`},
+	{regexp.MustCompile(`^results\.(\w+)\.input$`), `flow.$1.text`},
+	{regexp.MustCompile(`^results\.(\w+)\.created_on$`), `flow.$1.time`},
+
+	{regexp.MustCompile(`^(parent|child)\.results$`), `$1`},
+	{regexp.MustCompile(`^(parent|child)\.results\.(\w+)$`), `$1.$2`},
+	{regexp.MustCompile(`^(parent|child)\.results\.(\w+)\.value$`), `$1.$2.value`},
+	{regexp.MustCompile(`^(parent|child)\.results\.(\w+)\.category(?:_localized)?$`), `$1.$2.category`},
+	{regexp.MustCompile(`^(parent|child)\.results\.(\w+)\.input$`), `$1.$2.text`},
+	{regexp.MustCompile(`^(parent|child)\.results\.(\w+)\.created_on$`), `$1.$2.time`},
+
+	{regexp.MustCompile(`^input$`), `step.value`},
+	{regexp.MustCompile(`^input\.text$`), `step.text`},
+	{regexp.MustCompile(`^input\.created_on$`), `step.time`},
+
+	{regexp.MustCompile(`^legacy_extra$`), `extra`},
+	{regexp.MustCompile(`^legacy_extra\.([\w.]+)$`), `extra.$1`},
+}
+
+// ExportContextReference exports a context reference in a new expression, returning an error if it has no legacy
+// equivalent
+func ExportContextReference(path string) (string, error) {
+	path = strings.ToLower(path)
+
+	for _, mapping := range exportMappings {
+		if mapping.pattern.MatchString(path) {
+			return mapping.pattern.ReplaceAllString(path, mapping.replace), nil
+		}
+	}
+
+	return "", errors.Errorf("no legacy equivalent for context reference '%s'", path)
+}
+
+// a context path in a new expression, e.g. results.foo.value, which hasn't been exported yet
+type contextPath string
+
+// a function call in a new expression which hasn't been exported yet, so that we can recognize calls which were
+// generated by migration and convert them back to what they were migrated from
+type functionCall struct {
+	name   string
+	params []interface{}
+}
+
+// functions which have the same name and parameters in both syntaxes
+var exportAsIs = map[string]bool{
+	"abs": true, "and": true, "char": true, "clean": true, "code": true, "epoch": true, "format_location": true,
+	"if": true, "lower": true, "max": true, "min": true, "mod": true, "now": true, "or": true, "percent": true,
+	"rand": true, "remove_first_word": true, "round": true, "today": true, "upper": true,
+}
+
+// functions which were simple renames during migration
+var exportRenames = map[string]string{
+	"date":            "datevalue",
+	"date_from_parts": "date",
+	"format_datetime": "format_date",
+	"format_number":   "fixed",
+	"mean":            "average",
+	"rand_between":    "randbetween",
+	"read_chars":      "read_digits",
+	"regex_match":     "regex_group",
+	"repeat":          "rept",
+	"replace":         "substitute",
+	"round_down":      "rounddown",
+	"round_up":        "roundup",
+	"text_length":     "len",
+	"time":            "timevalue",
+	"time_from_parts": "time",
+	"title":           "proper",
+}
+
+// visitor which exports each part of an expression, recording the first error encountered
+type exportVisitor struct {
+	gen.BaseExcellent2Visitor
+	options *ExportOptions
+	err     error
+}
+
+func (v *exportVisitor) fail(err error) string {
+	if v.err == nil {
+		v.err = err
+	}
+	return ""
+}
+
+// renders a visited value as a legacy expression
+func (v *exportVisitor) render(value interface{}) string {
+	switch typed := value.(type) {
+	case contextPath:
+		exported, err := ExportContextReference(string(typed))
+		if err != nil {
+			return v.fail(err)
+		}
+		return exported
+	case *functionCall:
+		return v.exportCall(typed)
+	case *urnPartLookup:
+		return "contact." + typed.scheme + "." + typed.part
+	case string:
+		return typed
+	}
+	return ""
+}
+
+// renders the given function call, converting calls generated by migration back to their legacy equivalents
+func (v *exportVisitor) exportCall(call *functionCall) string {
+	params := call.params
+
+	// if(is_error(x), "...", x) was generated to make expressions default to themselves
+	if call.name == "if" && len(params) == 3 {
+		if inner, isCall := params[0].(*functionCall); isCall && inner.name == "is_error" && len(inner.params) == 1 {
+			if value := v.render(params[2]); v.render(inner.params[0]) == value {
+				return value
+			}
+		}
+	}
+
+	rendered := make([]string, len(params))
+	for i := range params {
+		rendered[i] = v.render(params[i])
+	}
+
+	// if this call has a path as its only parameter, get that path
+	pathParam := func(i int) string {
+		if len(params) > i {
+			if p, isPath := params[i].(contextPath); isPath {
+				return strings.ToLower(string(p))
+			}
+		}
+		return ""
+	}
+
+	switch call.name {
+	case "join":
+		if len(params) == 2 && pathParam(0) == "contact.groups" && rendered[1] == `","` {
+			return "contact.groups"
+		}
+	case "format_urn":
+		if len(params) == 1 && strings.HasPrefix(pathParam(0), "urns.") {
+			scheme := strings.TrimPrefix(pathParam(0), "urns.")
+			return "contact." + scheme + ".display"
+		}
+	case "default":
+		if len(params) == 2 && rendered[1] == `""` {
+			if lookup, isLookup := params[0].(*urnPartLookup); isLookup && lookup.part == "path" {
+				if lookup.scheme == "tel" {
+					return "contact.tel_e164"
+				}
+				return "contact." + lookup.scheme
+			}
+		}
+	case "url_encode":
+		if v.options.URLEncoded && len(params) == 1 {
+			return rendered[0]
+		}
+	case "legacy_add":
+		if len(params) == 2 {
+			if strings.HasPrefix(rendered[1], "-") {
+				return fmt.Sprintf("%s - %s", rendered[0], rendered[1][1:])
+			}
+			return fmt.Sprintf("%s + %s", rendered[0], rendered[1])
+		}
+	case "datetime_add":
+		if len(params) == 3 {
+			if rendered[2] == `"D"` {
+				if rendered[0] == "NOW()" && rendered[1] == "1" {
+					return "date.tomorrow"
+				} else if rendered[0] == "NOW()" && rendered[1] == "-1" {
+					return "date.yesterday"
+				}
+				// relative date tests used @(date.today + n)
+				date := rendered[0]
+				if date == "TODAY()" {
+					date = "date.today"
+				}
+				if strings.HasPrefix(rendered[1], "-") {
+					return fmt.Sprintf("%s - %s", date, rendered[1][1:])
+				}
+				return fmt.Sprintf("%s + %s", date, rendered[1])
+			}
+			if rendered[2] == `"M"` {
+				return renderLegacyCall("edate", rendered[:2])
+			}
+		}
+	case "datetime_diff":
+		if len(params) == 3 && rendered[2] == `"D"` {
+			return renderLegacyCall("days", []string{rendered[1], rendered[0]})
+		}
+		return renderLegacyCall("datedif", rendered)
+	case "format_date":
+		// dates like @date.today were wrapped in format_date
+		if len(params) == 1 {
+			if rendered[0] == "TODAY()" {
+				return "date.today"
+			}
+			if inner, isCall := params[0].(*functionCall); isCall && inner.name == "datetime_add" {
+				return rendered[0]
+			}
+		}
+		if len(params) == 2 {
+			switch rendered[1] {
+			case `"D"`:
+				return renderLegacyCall("day", rendered[:1])
+			case `"M"`:
+				return renderLegacyCall("month", rendered[:1])
+			case `"YYYY"`:
+				return renderLegacyCall("year", rendered[:1])
+			}
+		}
+	case "format_datetime":
+		if len(params) == 2 {
+			switch rendered[1] {
+			case `"tt"`:
+				return renderLegacyCall("hour", rendered[:1])
+			case `"m"`:
+				return renderLegacyCall("minute", rendered[:1])
+			case `"s"`:
+				return renderLegacyCall("second", rendered[:1])
+			}
+		}
+	case "field":
+		if len(params) == 3 {
+			return renderLegacyCall("field", []string{rendered[0], incremented(rendered[1]), rendered[2]})
+		}
+	case "text_slice":
+		if len(params) == 3 && rendered[1] == "0" {
+			return renderLegacyCall("left", []string{rendered[0], rendered[2]})
+		}
+		if len(params) == 2 && strings.HasPrefix(rendered[1], "-") {
+			return renderLegacyCall("right", []string{rendered[0], rendered[1][1:]})
+		}
+	case "word":
+		if len(params) >= 2 {
+			return renderLegacyCall("word", append([]string{rendered[0], incremented(rendered[1])}, exportBySpaces(rendered[2:])...))
+		}
+	case "word_slice":
+		if len(params) >= 2 {
+			exported := []string{rendered[0], incremented(rendered[1])}
+			if len(params) >= 3 {
+				exported = append(exported, incremented(rendered[2]))
+			}
+			if len(params) >= 4 {
+				exported = append(exported, exportBySpaces(rendered[3:])...)
+			}
+			return renderLegacyCall("word_slice", exported)
+		}
+	case "word_count":
+		if len(params) >= 1 {
+			return renderLegacyCall("word_count", append([]string{rendered[0]}, exportBySpaces(rendered[1:])...))
+		}
+	case "round_down":
+		if len(params) == 1 {
+			return renderLegacyCall("int", rendered)
+		}
+	}
+
+	if exportAsIs[call.name] {
+		return renderLegacyCall(call.name, rendered)
+	}
+	if renamed, exists := exportRenames[call.name]; exists {
+		return renderLegacyCall(renamed, rendered)
+	}
+
+	return v.fail(errors.Errorf("no legacy equivalent for function '%s'", call.name))
+}
+
+// exports the by_spaces parameter of word functions
+func exportBySpaces(params []string) []string {
+	if len(params) == 0 {
+		return nil
+	}
+	if params[0] == `" \t"` {
+		return []string{"TRUE"}
+	}
+	return []string{"FALSE"}
+}
+
+// increments a 0-based index to a 1-based index
+func incremented(param string) string {
+	if asInt, err := strconv.Atoi(param); err == nil {
+		return strconv.Itoa(asInt + 1)
+	}
+	if strings.HasSuffix(param, " - 1") {
+		return strings.TrimSuffix(param, " - 1")
+	}
+	return param + " + 1"
+}
+
+func renderLegacyCall(funcName string, params []string) string {
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(funcName), strings.Join(params, ", "))
+}
+
+// a lookup of a part of a URN, e.g. urn_parts(urns.tel).path
+type urnPartLookup struct {
+	scheme string
+	part   string
+}
+
+// Visit the top level parse tree
+func (v *exportVisitor) Visit(tree antlr.ParseTree) interface{} {
+	return tree.Accept(v)
+}
+
+// VisitParse handles our top level parser
+func (v *exportVisitor) VisitParse(ctx *gen.ParseContext) interface{} {
+	return v.Visit(ctx.Expression())
+}
+
+// VisitTextLiteral deals with string literals such as "asdf"
+func (v *exportVisitor) VisitTextLiteral(ctx *gen.TextLiteralContext) interface{} {
+	return ExportStringLiteral(ctx.GetText())
+}
+
+// VisitNumberLiteral deals with numbers like 123 or 1.5
+func (v *exportVisitor) VisitNumberLiteral(ctx *gen.NumberLiteralContext) interface{} {
+	return ctx.GetText()
+}
+
+// VisitContextReference deals with references to variables in the context such as "foo"
+func (v *exportVisitor) VisitContextReference(ctx *gen.ContextReferenceContext) interface{} {
+	return contextPath(ctx.NAME().GetText())
+}
+
+// VisitDotLookup deals with lookups like foo.bar
+func (v *exportVisitor) VisitDotLookup(ctx *gen.DotLookupContext) interface{} {
+	container := v.Visit(ctx.Atom())
+
+	var property string
+	if ctx.NAME() != nil {
+		property = ctx.NAME().GetText()
+	} else {
+		property = ctx.INTEGER().GetText()
+	}
+
+	switch typed := container.(type) {
+	case contextPath:
+		return contextPath(string(typed) + "." + property)
+	case *functionCall:
+		// urn_parts(urns.tel).path etc
+		if typed.name == "urn_parts" && len(typed.params) == 1 {
+			if p, isPath := typed.params[0].(contextPath); isPath && strings.HasPrefix(strings.ToLower(string(p)), "urns.") {
+				scheme := strings.TrimPrefix(strings.ToLower(string(p)), "urns.")
+				return &urnPartLookup{scheme: scheme, part: strings.ToLower(property)}
+			}
+		}
+	}
+
+	return v.fail(errors.New("no legacy equivalent for property lookups on values"))
+}
+
+// VisitArrayLookup deals with lookups such as foo[5] or foo["key with spaces"]
+func (v *exportVisitor) VisitArrayLookup(ctx *gen.ArrayLookupContext) interface{} {
+	container := v.Visit(ctx.Atom())
+	key := v.Visit(ctx.Expression())
+
+	// lookups like results["1"] can be written as dot lookups in legacy expressions
+	if p, isPath := container.(contextPath); isPath {
+		if k, isString := key.(string); isString && len(k) > 2 && k[0] == '"' {
+			name := k[1 : len(k)-1]
+			if regexp.MustCompile(`^\w+$`).MatchString(name) {
+				return contextPath(string(p) + "." + name)
+			}
+		}
+	}
+
+	return v.fail(errors.New("no legacy equivalent for array lookups"))
+}
+
+// VisitFunctionCall deals with function calls like TITLE(foo.bar)
+func (v *exportVisitor) VisitFunctionCall(ctx *gen.FunctionCallContext) interface{} {
+	function, isPath := v.Visit(ctx.Atom()).(contextPath)
+	if !isPath {
+		return v.fail(errors.New("no legacy equivalent for calling function values"))
+	}
+
+	var params []interface{}
+	if ctx.Parameters() != nil {
+		params, _ = v.Visit(ctx.Parameters()).([]interface{})
+	}
+
+	return &functionCall{name: strings.ToLower(string(function)), params: params}
+}
+
+// VisitFunctionParameters deals with the parameters to a function call
+func (v *exportVisitor) VisitFunctionParameters(ctx *gen.FunctionParametersContext) interface{} {
+	params := make([]interface{}, len(ctx.AllExpression()))
+	for i, exp := range ctx.AllExpression() {
+		params[i] = v.Visit(exp)
+	}
+	return params
+}
+
+// VisitTrue deals with the `true` reserved word
+func (v *exportVisitor) VisitTrue(ctx *gen.TrueContext) interface{} {
+	return "TRUE"
+}
+
+// VisitFalse deals with the `false` reserved word
+func (v *exportVisitor) VisitFalse(ctx *gen.FalseContext) interface{} {
+	return "FALSE"
+}
+
+// VisitNull deals with the `null` reserved word
+func (v *exportVisitor) VisitNull(ctx *gen.NullContext) interface{} {
+	return "NULL"
+}
+
+// VisitAtomReference deals with visiting a single atom in our expression
+func (v *exportVisitor) VisitAtomReference(ctx *gen.AtomReferenceContext) interface{} {
+	return v.Visit(ctx.Atom())
+}
+
+// VisitParentheses deals with expressions in parentheses such as (1+2)
+func (v *exportVisitor) VisitParentheses(ctx *gen.ParenthesesContext) interface{} {
+	return fmt.Sprintf("(%s)", v.render(v.Visit(ctx.Expression())))
+}
+
+// VisitNegation deals with negations such as -5
+func (v *exportVisitor) VisitNegation(ctx *gen.NegationContext) interface{} {
+	return fmt.Sprintf("-%s", v.render(v.Visit(ctx.Expression())))
+}
+
+// VisitExponent deals with exponenets such as 5^5
+func (v *exportVisitor) VisitExponent(ctx *gen.ExponentContext) interface{} {
+	base := v.render(v.Visit(ctx.Expression(0)))
+	exponent := v.render(v.Visit(ctx.Expression(1)))
+
+	// e ^ x was migrated from EXP(x)
+	if base == "2.718281828459045" {
+		return renderLegacyCall("exp", []string{exponent})
+	}
+	return fmt.Sprintf("%s ^ %s", base, exponent)
+}
+
+// VisitConcatenation deals with string concatenations like "foo" & "bar"
+func (v *exportVisitor) VisitConcatenation(ctx *gen.ConcatenationContext) interface{} {
+	return fmt.Sprintf("%s & %s", v.render(v.Visit(ctx.Expression(0))), v.render(v.Visit(ctx.Expression(1))))
+}
+
+// VisitAdditionOrSubtraction deals with addition and subtraction like 5+5 and 5-3
+func (v *exportVisitor) VisitAdditionOrSubtraction(ctx *gen.AdditionOrSubtractionContext) interface{} {
+	left := v.Visit(ctx.Expression(0))
+	right := v.render(v.Visit(ctx.Expression(1)))
+	op := ctx.GetOp().GetText()
+
+	// WEEKDAY(x) was migrated to weekday(x) + 1
+	if call, isCall := left.(*functionCall); isCall && call.name == "weekday" && len(call.params) == 1 && op == "+" && right == "1" {
+		return renderLegacyCall("weekday", []string{v.render(call.params[0])})
+	}
+
+	return fmt.Sprintf("%s %s %s", v.render(left), op, right)
+}
+
+// VisitMultiplicationOrDivision deals with division and multiplication such as 5*5 or 5/2
+func (v *exportVisitor) VisitMultiplicationOrDivision(ctx *gen.MultiplicationOrDivisionContext) interface{} {
+	return fmt.Sprintf("%s %s %s", v.render(v.Visit(ctx.Expression(0))), ctx.GetOp().GetText(), v.render(v.Visit(ctx.Expression(1))))
+}
+
+// VisitEquality deals with equality or inequality tests 5 = 5 and 5 != 5
+func (v *exportVisitor) VisitEquality(ctx *gen.EqualityContext) interface{} {
+	op := "="
+	if ctx.GetOp().GetText() == "!=" {
+		op = "<>"
+	}
+	return fmt.Sprintf("%s %s %s", v.render(v.Visit(ctx.Expression(0))), op, v.render(v.Visit(ctx.Expression(1))))
+}
+
+// VisitComparison deals with visiting a comparison between two values, such as 5<3 or 3>5
+func (v *exportVisitor) VisitComparison(ctx *gen.ComparisonContext) interface{} {
+	return fmt.Sprintf("%s %s %s", v.render(v.Visit(ctx.Expression(0))), ctx.GetOp().GetText(), v.render(v.Visit(ctx.Expression(1))))
+}
+
+// ExportStringLiteral exports a string literal (legacy expressions use Excel "" escaping)
+func ExportStringLiteral(s string) string {
+	// strip surrounding quotes
+	s = s[1 : len(s)-1]
+
+	// replace any escaped quotes
+	s = strings.Replace(s, `\"`, `""`, -1)
+
+	// re-quote
+	return `"` + s + `"`
+}
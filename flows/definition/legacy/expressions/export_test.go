@@ -0,0 +1,80 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/flows/definition/legacy/expressions"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportTemplate(t *testing.T) {
+	exportTests := []struct {
+		template   string
+		urlEncoded bool
+		exported   string
+		hasError   bool
+	}{
+		{template: `Hi there`, exported: `Hi there`},
+		{template: `Hi @contact.name`, exported: `Hi @contact.name`},
+		{template: `Hi @contact.fields.gender`, exported: `Hi @contact.gender`},
+		{template: `Hi @fields.gender`, exported: `Hi @contact.gender`},
+		{template: `@(join(contact.groups, ","))`, exported: `@contact.groups`},
+		{template: `@(format_urn(urns.tel))`, exported: `@contact.tel.display`},
+		{template: `@(default(urn_parts(urns.twitter).path, ""))`, exported: `@contact.twitter`},
+		{template: `@(default(urn_parts(urns.tel).path, ""))`, exported: `@contact.tel_e164`},
+		{template: `@(urn_parts(urns.tel).scheme)`, exported: `@contact.tel.scheme`},
+		{template: `@urns.mailto`, exported: `@contact.mailto.urn`},
+		{template: `@results.age.value @results.age.category_localized`, exported: `@flow.age.value @flow.age.category`},
+		{template: `@(results["1abc"])`, exported: `@flow.1abc`},
+		{template: `@child.results.age @parent.results.age.input`, exported: `@child.age @parent.age.text`},
+		{template: `@input @input.text @input.created_on`, exported: `@step.value @step.text @step.time`},
+		{template: `@contact.channel.address`, exported: `@channel`},
+		{template: `@legacy_extra.foo.bar`, exported: `@extra.foo.bar`},
+		{template: `@(format_date(today()))`, exported: `@date.today`},
+		{template: `@(format_date(datetime_add(now(), 1, "D")))`, exported: `@date.tomorrow`},
+		{template: `@(datetime_add(contact.created_on, -3, "D"))`, exported: `@(contact.created_on - 3)`},
+		{template: `@(legacy_add(fields.age, 3))`, exported: `@(contact.age + 3)`},
+		{template: `@(if(is_error(fields.age), "@contact.age", fields.age))`, exported: `@contact.age`},
+		{template: `@(url_encode(contact.name))`, urlEncoded: true, exported: `@contact.name`},
+		{template: `@(word(input, 0, " \t"))`, exported: `@(WORD(step.value, 1, TRUE))`},
+		{template: `@(word_slice(input, 1, 3))`, exported: `@(WORD_SLICE(step.value, 2, 4))`},
+		{template: `@(field(input, fields.index - 1, ","))`, exported: `@(FIELD(step.value, contact.index, ","))`},
+		{template: `@(text_slice(contact.name, 0, 3)) @(text_slice(contact.name, -3))`, exported: `@(LEFT(contact.name, 3)) @(RIGHT(contact.name, 3))`},
+		{template: `@(format_date(contact.created_on, "YYYY"))`, exported: `@(YEAR(contact.created_on))`},
+		{template: `@(datetime_diff(contact.created_on, now(), "D"))`, exported: `@(DAYS(NOW(), contact.created_on))`},
+		{template: `@(weekday(now()) + 1)`, exported: `@(WEEKDAY(NOW()))`},
+		{template: `@(title(contact.name) != "Bob")`, exported: `@(PROPER(contact.name) <> "Bob")`},
+		{template: `@(upper("say \"hi\"") & true)`, exported: `@(UPPER("say ""hi""") & TRUE)`},
+
+		// things which can't be exported are left as they are
+		{template: `@(foreach(input.attachments, upper))`, exported: `@(foreach(input.attachments, upper))`, hasError: true},
+		{template: `Hi @webhook.json`, exported: `Hi @webhook.json`, hasError: true},
+		{template: `@(url_encode(contact.name))`, exported: `@(url_encode(contact.name))`, hasError: true},
+	}
+
+	for _, tc := range exportTests {
+		exported, err := expressions.ExportTemplate(tc.template, &expressions.ExportOptions{URLEncoded: tc.urlEncoded})
+
+		if tc.hasError {
+			assert.Error(t, err, "expected error exporting template '%s'", tc.template)
+		} else {
+			assert.NoError(t, err, "unexpected error exporting template '%s'", tc.template)
+		}
+		assert.Equal(t, tc.exported, exported, "export mismatch for template '%s'", tc.template)
+	}
+}
+
+func TestExportTemplateRoundTrip(t *testing.T) {
+	// any migrated template which we can export, should migrate back to the same template
+	for _, tc := range tests {
+		exported, err := expressions.ExportTemplate(tc.new, nil)
+		if err != nil {
+			continue
+		}
+
+		migrated, err := expressions.MigrateTemplate(exported, &expressions.MigrateOptions{DefaultToSelf: tc.defaultToSelf})
+		assert.NoError(t, err, "unexpected error re-migrating '%s' exported from '%s'", exported, tc.new)
+		assert.Equal(t, tc.new, migrated, "round trip mismatch for '%s' (exported as '%s')", tc.old, exported)
+	}
+}
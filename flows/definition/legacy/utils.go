@@ -115,6 +115,11 @@ func (e *TypedEnvelope) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals this typed envelope as its original JSON
+func (e TypedEnvelope) MarshalJSON() ([]byte, error) {
+	return e.Data, nil
+}
+
 // URLJoin joins two URL parts with /
 func URLJoin(base, relative string) string {
 
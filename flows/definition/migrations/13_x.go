@@ -1,13 +1,15 @@
 package migrations
 
 import (
+	"fmt"
+
 	"github.com/nyaruka/gocommon/uuids"
 
 	"github.com/Masterminds/semver"
 )
 
 func init() {
-	registerMigration(semver.MustParse("13.1.0"), Migrate13_1)
+	registerMigration(semver.MustParse("13.1.0"), Migrate13_1, Downgrade13_1)
 }
 
 // Migrate13_1 adds UUID to send_msg templating
@@ -24,3 +26,31 @@ func Migrate13_1(f Flow) (Flow, error) {
 	}
 	return f, nil
 }
+
+// Downgrade13_1 removes UUID from send_msg templating, and with it any localized template variables
+func Downgrade13_1(f Flow) (Flow, []string, error) {
+	losses := make([]string, 0)
+	localization, _ := f["localization"].(map[string]interface{})
+
+	for _, node := range f.Nodes() {
+		for _, action := range node.Actions() {
+			if action.Type() == "send_msg" {
+				templating, _ := action["templating"].(map[string]interface{})
+				if templating != nil {
+					uuid, _ := templating["uuid"].(string)
+					delete(templating, "uuid")
+
+					for _, lang := range objectProperties(localization) {
+						items, _ := localization[lang].(map[string]interface{})
+						if _, exists := items[uuid]; exists && uuid != "" {
+							delete(items, uuid)
+							losses = append(losses, fmt.Sprintf("localized template variables in language '%s' for send_msg action '%s'", lang, action["uuid"]))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return f, losses, nil
+}
@@ -1,6 +1,7 @@
 package migrations
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -17,11 +18,20 @@ import (
 // MigrationFunc is a function that can migrate a flow definition from one version to another
 type MigrationFunc func(Flow) (Flow, error)
 
+// DowngradeFunc is a function that can migrate a flow definition back to the previous version, returning
+// descriptions of anything that can't be represented in that version
+type DowngradeFunc func(Flow) (Flow, []string, error)
+
+// the oldest version we can migrate to, which is what legacy definitions are migrated to
+var minimumVersion = semver.MustParse("13.0.0")
+
 var registered = map[*semver.Version]MigrationFunc{}
+var registeredDowngrades = map[*semver.Version]DowngradeFunc{}
 
-// registers a new type of action
-func registerMigration(version *semver.Version, fn MigrationFunc) {
+// registers a new migration to the given version, and the downgrade which reverses it
+func registerMigration(version *semver.Version, fn MigrationFunc, downgrade DowngradeFunc) {
 	registered[version] = fn
+	registeredDowngrades[version] = downgrade
 }
 
 // Registered gets all registered migrations
@@ -29,6 +39,21 @@ func Registered() map[*semver.Version]MigrationFunc {
 	return registered
 }
 
+// RegisteredDowngrades gets all registered downgrades by the version they downgrade from
+func RegisteredDowngrades() map[*semver.Version]DowngradeFunc {
+	return registeredDowngrades
+}
+
+// Loss is something in a flow definition which couldn't be represented when it was downgraded
+type Loss struct {
+	Version     *semver.Version `json:"version"`
+	Description string          `json:"description"`
+}
+
+func (l *Loss) String() string {
+	return fmt.Sprintf("%s: %s", l.Version, l.Description)
+}
+
 // Header13 is the set of fields common to all 13+ flow spec versions
 type Header13 struct {
 	UUID        assets.FlowUUID `json:"uuid" validate:"required,uuid4"`
@@ -46,8 +71,20 @@ func MigrateToLatest(data []byte, config *Config) ([]byte, error) {
 	return MigrateToVersion(data, nil, config)
 }
 
-// MigrateToVersion migrates the given flow definition to the given version
+// MigrateToVersion migrates the given flow definition to the given version, which can be older than the
+// version of the definition, in which case anything that couldn't be represented in that version is dropped
 func MigrateToVersion(data []byte, to *semver.Version, config *Config) ([]byte, error) {
+	migrated, _, err := MigrateToVersionWithLosses(data, to, config)
+	return migrated, err
+}
+
+// MigrateToVersionWithLosses migrates the given flow definition to the given version, and if that requires
+// downgrading it, also returns anything that couldn't be represented in that version
+func MigrateToVersionWithLosses(data []byte, to *semver.Version, config *Config) ([]byte, []*Loss, error) {
+	if to != nil && to.LessThan(minimumVersion) {
+		return nil, nil, errors.Errorf("can't migrate to versions older than %s", minimumVersion)
+	}
+
 	// try to read new style header (uuid, name, spec_version)
 	header := &Header13{}
 	err := utils.UnmarshalAndValidate(data, header)
@@ -56,14 +93,14 @@ func MigrateToVersion(data []byte, to *semver.Version, config *Config) ([]byte,
 		// could this be a legacy definition?
 		if legacy.IsPossibleDefinition(data) {
 			if config == nil {
-				return nil, errors.New("unable to migrate what appears to be a legacy definition without a migration config")
+				return nil, nil, errors.New("unable to migrate what appears to be a legacy definition without a migration config")
 			}
 
 			// try to migrate it forwards to 13.0.0
 			var err error
 			data, err = legacy.MigrateDefinition(data, config.BaseMediaURL)
 			if err != nil {
-				return nil, errors.Wrap(err, "error migrating what appears to be a legacy definition")
+				return nil, nil, errors.Wrap(err, "error migrating what appears to be a legacy definition")
 			}
 		}
 
@@ -72,10 +109,15 @@ func MigrateToVersion(data []byte, to *semver.Version, config *Config) ([]byte,
 	}
 
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to read flow header")
+		return nil, nil, errors.Wrap(err, "unable to read flow header")
+	}
+
+	if to != nil && to.LessThan(header.SpecVersion) {
+		return downgrade(data, header.SpecVersion, to)
 	}
 
-	return migrate(data, header.SpecVersion, to)
+	migrated, err := migrate(data, header.SpecVersion, to)
+	return migrated, nil, err
 }
 
 func migrate(data []byte, from *semver.Version, to *semver.Version) ([]byte, error) {
@@ -113,6 +155,60 @@ func migrate(data []byte, from *semver.Version, to *semver.Version) ([]byte, err
 	return jsonx.Marshal(migrated)
 }
 
+func downgrade(data []byte, from *semver.Version, to *semver.Version) ([]byte, []*Loss, error) {
+	// get all versions we need to downgrade from
+	versions := make([]*semver.Version, 0)
+	for v := range registeredDowngrades {
+		if v.GreaterThan(to) && v.Compare(from) <= 0 {
+			versions = append(versions, v)
+		}
+	}
+
+	// sorted by latest first
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].GreaterThan(versions[j]) })
+
+	migrated, err := readFlow(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	losses := make([]*Loss, 0)
+
+	for _, version := range versions {
+		previous := previousVersion(version)
+
+		var descriptions []string
+		migrated, descriptions, err = registeredDowngrades[version](migrated)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to migrate to version %s", previous.String())
+		}
+
+		for _, d := range descriptions {
+			losses = append(losses, &Loss{Version: previous, Description: d})
+		}
+
+		migrated["spec_version"] = previous.String()
+	}
+
+	// even if there was nothing to downgrade, the flow is now at the version we were asked for
+	migrated["spec_version"] = to.String()
+
+	// finally marshal back to JSON
+	marshaled, err := jsonx.Marshal(migrated)
+	return marshaled, losses, err
+}
+
+// gets the registered version before the given version, or the minimum version if there isn't one
+func previousVersion(version *semver.Version) *semver.Version {
+	previous := minimumVersion
+	for v := range registered {
+		if v.LessThan(version) && v.GreaterThan(previous) {
+			previous = v
+		}
+	}
+	return previous
+}
+
 // Clone clones the given flow definition by replacing all UUIDs using the provided mapping and
 // generating new random UUIDs if they aren't in the mapping
 func Clone(data []byte, depMapping map[uuids.UUID]uuids.UUID) ([]byte, error) {
@@ -61,6 +61,105 @@ func TestMigrateToVersion(t *testing.T) {
 	}
 }
 
+func TestDowngrades(t *testing.T) {
+	// get all versions in order, latest first
+	versions := make([]*semver.Version, 0, len(migrations.RegisteredDowngrades()))
+	for v := range migrations.RegisteredDowngrades() {
+		versions = append(versions, v)
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].GreaterThan(versions[j]) })
+
+	for i, version := range versions {
+		testsJSON, err := ioutil.ReadFile(fmt.Sprintf("testdata/downgrades/%s.json", version.String()))
+		require.NoError(t, err)
+
+		tests := []struct {
+			Description string          `json:"description"`
+			Original    json.RawMessage `json:"original"`
+			Downgraded  json.RawMessage `json:"downgraded"`
+			Losses      []string        `json:"losses"`
+		}{}
+
+		err = jsonx.Unmarshal(testsJSON, &tests)
+		require.NoError(t, err, "unable to read tests for version %s", version)
+
+		// downgrade to the next registered version or the oldest supported version
+		to := semver.MustParse("13.0.0")
+		if i < len(versions)-1 {
+			to = versions[i+1]
+		}
+
+		for _, tc := range tests {
+			testName := fmt.Sprintf("version %s with '%s'", version, tc.Description)
+
+			actual, losses, err := migrations.MigrateToVersionWithLosses(tc.Original, to, nil)
+			assert.NoError(t, err, "unexpected error in %s", testName)
+
+			test.AssertEqualJSON(t, tc.Downgraded, actual, "downgrade mismatch in %s", testName)
+
+			actualLosses := make([]string, len(losses))
+			for i := range losses {
+				actualLosses[i] = losses[i].String()
+			}
+			assert.Equal(t, tc.Losses, actualLosses, "losses mismatch in %s", testName)
+		}
+	}
+}
+
+func TestMigrateToOlderVersion(t *testing.T) {
+	flowJSON := []byte(`{
+		"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+		"name": "Empty Flow",
+		"spec_version": "13.1.0",
+		"language": "eng",
+		"type": "messaging",
+		"nodes": []
+	}`)
+
+	migrated, err := migrations.MigrateToVersion(flowJSON, semver.MustParse("13.0.0"), nil)
+	require.NoError(t, err)
+	test.AssertEqualJSON(t, []byte(`{
+		"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+		"name": "Empty Flow",
+		"spec_version": "13.0.0",
+		"language": "eng",
+		"type": "messaging",
+		"nodes": []
+	}`), migrated, "downgrade mismatch")
+
+	// a flow can be migrated back up to the same definition
+	migrated, err = migrations.MigrateToVersion(migrated, semver.MustParse("13.1.0"), nil)
+	require.NoError(t, err)
+	test.AssertEqualJSON(t, flowJSON, migrated, "migration mismatch")
+
+	// the version is always updated even if there's nothing to downgrade in between
+	migrated, err = migrations.MigrateToVersion([]byte(`{
+		"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+		"name": "Empty Flow",
+		"spec_version": "13.1.2",
+		"language": "eng",
+		"type": "messaging",
+		"nodes": []
+	}`), semver.MustParse("13.1.0"), nil)
+	require.NoError(t, err)
+	test.AssertEqualJSON(t, flowJSON, migrated, "downgrade mismatch")
+
+	// or if we're downgrading to a version that isn't registered
+	migrated, err = migrations.MigrateToVersion(flowJSON, semver.MustParse("13.0.5"), nil)
+	require.NoError(t, err)
+	test.AssertEqualJSON(t, []byte(`{
+		"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+		"name": "Empty Flow",
+		"spec_version": "13.0.5",
+		"language": "eng",
+		"type": "messaging",
+		"nodes": []
+	}`), migrated, "downgrade mismatch")
+
+	_, err = migrations.MigrateToVersion(flowJSON, semver.MustParse("12.0.0"), nil)
+	assert.EqualError(t, err, "can't migrate to versions older than 13.0.0")
+}
+
 func TestMigrateToLatest(t *testing.T) {
 	defer uuids.SetGenerator(uuids.DefaultGenerator)
 
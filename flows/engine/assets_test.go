@@ -79,7 +79,7 @@ func TestSessionAssetsWithSourceErrors(t *testing.T) {
 	_, err = sa.Flows().Get(assets.FlowUUID("ddba5842-252f-4a20-b901-08696fc773e2"))
 	assert.EqualError(t, err, "unable to load flow assets")
 
-	for _, errType := range []string{"channels", "classifiers", "fields", "globals", "groups", "labels", "locations", "resthooks", "templates", "users"} {
+	for _, errType := range []string{"channels", "classifiers", "exchange_rates", "fields", "globals", "groups", "labels", "locations", "resthooks", "templates", "users"} {
 		source.currentErrType = errType
 		_, err = engine.NewSessionAssets(env, source, nil)
 		assert.EqualError(t, err, fmt.Sprintf("unable to load %s assets", errType), "error mismatch for type %s", errType)
@@ -106,6 +106,10 @@ func (s *testSource) Classifiers() ([]assets.Classifier, error) {
 	return nil, s.err("classifiers")
 }
 
+func (s *testSource) ExchangeRates() ([]assets.ExchangeRate, error) {
+	return nil, s.err("exchange_rates")
+}
+
 func (s *testSource) Fields() ([]assets.Field, error) {
 	return nil, s.err("fields")
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/nyaruka/gocommon/uuids"
 	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/excellent"
 	"github.com/nyaruka/goflow/flows"
 )
 
@@ -13,6 +14,9 @@ type engine struct {
 	services          *services
 	maxStepsPerSprint int
 	maxTemplateChars  int
+	traceTemplates    bool
+	templateCache     *excellent.TemplateCache
+	maxWebhooks       int
 }
 
 // NewSession creates a new session
@@ -37,9 +41,13 @@ func (e *engine) ReadSession(sa flows.SessionAssets, data json.RawMessage, missi
 	return readSession(e, sa, data, missing)
 }
 
-func (e *engine) Services() flows.Services { return e.services }
-func (e *engine) MaxStepsPerSprint() int   { return e.maxStepsPerSprint }
-func (e *engine) MaxTemplateChars() int    { return e.maxTemplateChars }
+func (e *engine) Services() flows.Services   { return e.services }
+func (e *engine) MaxStepsPerSprint() int     { return e.maxStepsPerSprint }
+func (e *engine) MaxTemplateChars() int      { return e.maxTemplateChars }
+func (e *engine) TraceTemplates() bool       { return e.traceTemplates }
+func (e *engine) MaxConcurrentWebhooks() int { return e.maxWebhooks }
+
+func (e *engine) TemplateCache() *excellent.TemplateCache { return e.templateCache }
 
 var _ flows.Engine = (*engine)(nil)
 
@@ -59,6 +67,8 @@ func NewBuilder() *Builder {
 			services:          newEmptyServices(),
 			maxStepsPerSprint: 100,
 			maxTemplateChars:  10000,
+			templateCache:     excellent.NewTemplateCache(10000),
+			maxWebhooks:       5,
 		},
 	}
 }
@@ -105,5 +115,23 @@ func (b *Builder) WithMaxTemplateChars(max int) *Builder {
 	return b
 }
 
+// WithTraceTemplates sets whether template evaluation errors should include traces of the evaluation
+func (b *Builder) WithTraceTemplates(trace bool) *Builder {
+	b.eng.traceTemplates = trace
+	return b
+}
+
+// WithTemplateCacheSize sets the maximum number of compiled templates kept in the engine's template cache
+func (b *Builder) WithTemplateCacheSize(size int) *Builder {
+	b.eng.templateCache = excellent.NewTemplateCache(size)
+	return b
+}
+
+// WithMaxConcurrentWebhooks sets the maximum number of webhook calls a single action can make concurrently
+func (b *Builder) WithMaxConcurrentWebhooks(max int) *Builder {
+	b.eng.maxWebhooks = max
+	return b
+}
+
 // Build returns the final engine
 func (b *Builder) Build() flows.Engine { return b.eng }
@@ -13,9 +13,11 @@ import (
 
 func TestBuilder(t *testing.T) {
 	// create engine with no services
-	eng := engine.NewBuilder().WithMaxStepsPerSprint(123).Build()
+	eng := engine.NewBuilder().WithMaxStepsPerSprint(123).WithTraceTemplates(true).WithMaxConcurrentWebhooks(3).Build()
 
 	assert.Equal(t, 123, eng.MaxStepsPerSprint())
+	assert.True(t, eng.TraceTemplates())
+	assert.Equal(t, 3, eng.MaxConcurrentWebhooks())
 
 	_, err := eng.Services().Email(nil)
 	assert.EqualError(t, err, "no email service factory configured")
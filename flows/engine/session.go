@@ -240,7 +240,7 @@ func (s *session) tryToResume(sprint flows.Sprint, waitingRun flows.FlowRun, res
 	}
 
 	// try to end our wait which will return and log an error if it can't be ended with this resume
-	if err := node.Router().Wait().End(resume); err != nil {
+	if err := node.Router().Wait().End(s.wait, resume); err != nil {
 		sprint.LogEvent(events.NewError(err))
 		return nil
 	}
@@ -442,7 +442,11 @@ func (s *session) visitNode(sprint flows.Sprint, run flows.FlowRun, node flows.N
 	if wait != nil {
 
 		// waits have the option to skip themselves
-		activatedWait := wait.Begin(run, logEvent)
+		activatedWait, err := wait.Begin(run, logEvent)
+		if err != nil {
+			failure(sprint, run, step, errors.Wrapf(err, "unable to begin wait on node[uuid=%s]", node.UUID()))
+			return step, noDestination, nil
+		}
 		if activatedWait != nil {
 			// mark ouselves as waiting and hand back to
 			run.SetStatus(flows.RunStatusWaiting)
@@ -535,14 +539,21 @@ type sessionEnvelope struct {
 	UUID        flows.SessionUUID   `json:"uuid"` // TODO validate:"required"`
 	Type        flows.FlowType      `json:"type"` // TODO validate:"required"`
 	Environment json.RawMessage     `json:"environment"`
-	Trigger     json.RawMessage     `json:"trigger" validate:"required"`
+	Trigger     json.RawMessage     `json:"trigger" validate:"required" jsonschema:"trigger"`
 	Contact     *json.RawMessage    `json:"contact,omitempty"`
-	Runs        []json.RawMessage   `json:"runs"`
+	Runs        []json.RawMessage   `json:"runs" jsonschema:"run"`
 	Status      flows.SessionStatus `json:"status" validate:"required"`
 	Wait        json.RawMessage     `json:"wait,omitempty"`
 	Input       json.RawMessage     `json:"input,omitempty" validate:"omitempty"`
 }
 
+// SchemaEnvelopes gets empty instances of the envelopes that sessions are read from, keyed by name
+func SchemaEnvelopes() map[string]interface{} {
+	return map[string]interface{}{
+		"session": &sessionEnvelope{},
+	}
+}
+
 // ReadSession decodes a session from the passed in JSON
 func readSession(eng flows.Engine, sessionAssets flows.SessionAssets, data json.RawMessage, missing assets.MissingCallback) (flows.Session, error) {
 	e := &sessionEnvelope{}
@@ -305,6 +305,45 @@ func TestWaitTimeout(t *testing.T) {
 	require.Equal(t, "", result.Input)
 }
 
+func TestInvalidTimerWait(t *testing.T) {
+	assetsJSON := []byte(`{
+		"flows": [
+			{
+				"uuid": "76f0a02f-3b75-4b86-9064-e9195e1b3a02",
+				"name": "Timer",
+				"spec_version": "13.0",
+				"language": "eng",
+				"type": "messaging",
+				"nodes": [
+					{
+						"uuid": "a58be63b-907d-4a1a-856b-0bb5579d7507",
+						"router": {
+							"type": "switch",
+							"wait": {"type": "timer", "delay": "@(1 + 2)"},
+							"categories": [
+								{"uuid": "97b9451c-2856-475b-af38-32af68100897", "name": "Done", "exit_uuid": "4bb4b9a9-bd0a-4a1d-b2de-f8e2e9a3e40b"}
+							],
+							"operand": "@input.text",
+							"default_category_uuid": "97b9451c-2856-475b-af38-32af68100897"
+						},
+						"exits": [{"uuid": "4bb4b9a9-bd0a-4a1d-b2de-f8e2e9a3e40b"}]
+					}
+				]
+			}
+		]
+	}`)
+
+	// a timer wait whose delay can't be evaluated fails the run rather than continuing immediately
+	session, sprint, err := test.CreateSession(assetsJSON, assets.FlowUUID("76f0a02f-3b75-4b86-9064-e9195e1b3a02"))
+	require.NoError(t, err)
+
+	assert.Equal(t, flows.SessionStatusFailed, session.Status())
+	assert.Equal(t, flows.RunStatusFailed, session.Runs()[0].Status())
+	require.Equal(t, 1, len(sprint.Events()))
+	assert.Equal(t, "failure", sprint.Events()[0].Type())
+	assert.Equal(t, "unable to begin wait on node[uuid=a58be63b-907d-4a1a-856b-0bb5579d7507]: delay evaluated to 3 which isn't a duration or datetime", sprint.Events()[0].(*events.FailureEvent).Text)
+}
+
 func TestCurrentContext(t *testing.T) {
 	assetsJSON, err := ioutil.ReadFile("../../test/testdata/runner/subflow_loop_with_wait.json")
 	require.NoError(t, err)
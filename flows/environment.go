@@ -11,25 +11,34 @@ import (
 type environment struct {
 	envs.Environment
 
-	locationResolver envs.LocationResolver
+	locationResolver  envs.LocationResolver
+	currencyConverter envs.CurrencyConverter
 }
 
 // NewEnvironment creates a new environment
-func NewEnvironment(base envs.Environment, la *LocationAssets) envs.Environment {
+func NewEnvironment(base envs.Environment, la *LocationAssets, ra *ExchangeRateAssets) envs.Environment {
 	var locationResolver envs.LocationResolver
+	var currencyConverter envs.CurrencyConverter
 
 	hierarchies := la.Hierarchies()
 	if len(hierarchies) > 0 {
 		locationResolver = &assetLocationResolver{hierarchies[0]}
 	}
+	if ra != nil {
+		currencyConverter = ra
+	}
 
-	return &environment{base, locationResolver}
+	return &environment{base, locationResolver, currencyConverter}
 }
 
 func (e *environment) LocationResolver() envs.LocationResolver {
 	return e.locationResolver
 }
 
+func (e *environment) CurrencyConverter() envs.CurrencyConverter {
+	return e.currencyConverter
+}
+
 type assetLocationResolver struct {
 	locations assets.LocationHierarchy
 }
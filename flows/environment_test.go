@@ -72,7 +72,7 @@ func TestEnvironment(t *testing.T) {
 	sa, err := engine.NewSessionAssets(env, source, nil)
 	require.NoError(t, err)
 
-	fenv := flows.NewEnvironment(env, sa.Locations())
+	fenv := flows.NewEnvironment(env, sa.Locations(), sa.ExchangeRates())
 	assert.Equal(t, envs.Country("RW"), fenv.DefaultCountry())
 	require.NotNil(t, fenv.LocationResolver())
 
@@ -14,13 +14,16 @@ func init() {
 // TypeAirtimeTransferred is the type of our airtime transferred event
 const TypeAirtimeTransferred string = "airtime_transferred"
 
-// AirtimeTransferredEvent events are created when airtime has been transferred to the contact.
+// AirtimeTransferredEvent events are created when airtime or a data bundle has been transferred to the contact.
 //
 //   {
 //     "type": "airtime_transferred",
 //     "created_on": "2006-01-02T15:04:05Z",
 //     "sender": "tel:4748",
 //     "recipient": "tel:+1242563637",
+//     "product_type": "airtime",
+//     "sku": "6035",
+//     "product_name": "100 RWF",
 //     "currency": "RWF",
 //     "desired_amount": 120,
 //     "actual_amount": 100,
@@ -40,12 +43,15 @@ const TypeAirtimeTransferred string = "airtime_transferred"
 type AirtimeTransferredEvent struct {
 	baseEvent
 
-	Sender        urns.URN         `json:"sender"`
-	Recipient     urns.URN         `json:"recipient"`
-	Currency      string           `json:"currency"`
-	DesiredAmount decimal.Decimal  `json:"desired_amount"`
-	ActualAmount  decimal.Decimal  `json:"actual_amount"`
-	HTTPLogs      []*flows.HTTPLog `json:"http_logs"`
+	Sender        urns.URN                 `json:"sender"`
+	Recipient     urns.URN                 `json:"recipient"`
+	ProductType   flows.AirtimeProductType `json:"product_type,omitempty"`
+	SKU           string                   `json:"sku,omitempty"`
+	ProductName   string                   `json:"product_name,omitempty"`
+	Currency      string                   `json:"currency"`
+	DesiredAmount decimal.Decimal          `json:"desired_amount"`
+	ActualAmount  decimal.Decimal          `json:"actual_amount"`
+	HTTPLogs      []*flows.HTTPLog         `json:"http_logs"`
 }
 
 // NewAirtimeTransferred creates a new airtime transferred event
@@ -54,6 +60,9 @@ func NewAirtimeTransferred(t *flows.AirtimeTransfer, httpLogs []*flows.HTTPLog)
 		baseEvent:     newBaseEvent(TypeAirtimeTransferred),
 		Sender:        t.Sender,
 		Recipient:     t.Recipient,
+		ProductType:   t.ProductType,
+		SKU:           t.SKU,
+		ProductName:   t.ProductName,
 		Currency:      t.Currency,
 		DesiredAmount: t.DesiredAmount,
 		ActualAmount:  t.ActualAmount,
@@ -18,6 +18,11 @@ func registerType(name string, initFunc func() flows.Event) {
 	registeredTypes[name] = initFunc
 }
 
+// RegisteredTypes gets the registered types of event
+func RegisteredTypes() map[string](func() flows.Event) {
+	return registeredTypes
+}
+
 // base of all event types
 type baseEvent struct {
 	Type_      string         `json:"type" validate:"required"`
@@ -22,6 +22,7 @@ import (
 	"github.com/nyaruka/goflow/flows/routers/waits/hints"
 	"github.com/nyaruka/goflow/services/webhooks"
 	"github.com/nyaruka/goflow/test"
+	"github.com/nyaruka/goflow/utils"
 	"github.com/shopspring/decimal"
 
 	"github.com/stretchr/testify/assert"
@@ -89,6 +90,34 @@ func TestEventMarshaling(t *testing.T) {
 				"type": "airtime_transferred"
 			}`,
 		},
+		{
+			events.NewAirtimeTransferred(
+				&flows.AirtimeTransfer{
+					Sender:        urns.URN("tel:+593979099111"),
+					Recipient:     urns.URN("tel:+593979099222"),
+					ProductType:   flows.AirtimeProductTypeData,
+					SKU:           "CLARO-1GB",
+					ProductName:   "1GB",
+					Currency:      "USD",
+					DesiredAmount: decimal.RequireFromString("5"),
+					ActualAmount:  decimal.RequireFromString("5"),
+				},
+				nil,
+			),
+			`{
+				"actual_amount": 5,
+				"created_on": "2018-10-18T14:20:30.000123456Z",
+				"currency": "USD",
+				"desired_amount": 5,
+				"http_logs": null,
+				"product_name": "1GB",
+				"product_type": "data",
+				"recipient": "tel:+593979099222",
+				"sender": "tel:+593979099111",
+				"sku": "CLARO-1GB",
+				"type": "airtime_transferred"
+			}`,
+		},
 		{
 			events.NewBroadcastCreated(
 				map[envs.Language]*events.BroadcastTranslation{
@@ -350,7 +379,7 @@ func TestEventMarshaling(t *testing.T) {
 			}`,
 		},
 		{
-			events.NewEmailSent([]string{"bob@nyaruka.com", "jim@nyaruka.com"}, "Update", "Flows are great!"),
+			events.NewEmailSent(&flows.Email{To: []string{"bob@nyaruka.com", "jim@nyaruka.com"}, Subject: "Update", Body: "Flows are great!"}),
 			`{
 				"created_on": "2018-10-18T14:20:30.000123456Z",
 				"type": "email_sent",
@@ -359,6 +388,30 @@ func TestEventMarshaling(t *testing.T) {
 				"body": "Flows are great!"
 			}`,
 		},
+		{
+			events.NewEmailSent(&flows.Email{
+				To:          []string{"bob@nyaruka.com"},
+				CC:          []string{"jim@nyaruka.com"},
+				BCC:         []string{"ann@nyaruka.com"},
+				ReplyTo:     "support@nyaruka.com",
+				Subject:     "Update",
+				Body:        "Flows are great!",
+				HTMLBody:    "<p>Flows are <b>great!</b></p>",
+				Attachments: []utils.Attachment{"application/pdf:http://s3.amazon.com/bucket/update.pdf"},
+			}),
+			`{
+				"created_on": "2018-10-18T14:20:30.000123456Z",
+				"type": "email_sent",
+				"to": ["bob@nyaruka.com"],
+				"cc": ["jim@nyaruka.com"],
+				"bcc": ["ann@nyaruka.com"],
+				"reply_to": "support@nyaruka.com",
+				"subject": "Update",
+				"body": "Flows are great!",
+				"html_body": "<p>Flows are <b>great!</b></p>",
+				"attachments": ["application/pdf:http://s3.amazon.com/bucket/update.pdf"]
+			}`,
+		},
 		{
 			events.NewEnvironmentRefreshed(session.Environment()),
 			`{
@@ -448,6 +501,40 @@ func TestEventMarshaling(t *testing.T) {
 				"type": "wait_timed_out"
 			}`,
 		},
+		{
+			events.NewTimerWait(time.Date(2018, 10, 20, 14, 20, 30, 0, time.UTC), false),
+			`{
+				"type": "timer_wait",
+				"created_on": "2018-10-18T14:20:30.000123456Z",
+				"fires_on": "2018-10-20T14:20:30Z"
+			}`,
+		},
+		{
+			events.NewExternalWait("payment_completed", "ORD-1234", nil),
+			`{
+				"type": "external_wait",
+				"created_on": "2018-10-18T14:20:30.000123456Z",
+				"event": "payment_completed",
+				"key": "ORD-1234"
+			}`,
+		},
+		{
+			events.NewExternalEventReceived("payment_completed", "ORD-1234", []byte(`{"status":"paid"}`)),
+			`{
+				"type": "external_event_received",
+				"created_on": "2018-10-18T14:20:30.000123456Z",
+				"event": "payment_completed",
+				"key": "ORD-1234",
+				"payload": {"status": "paid"}
+			}`,
+		},
+		{
+			events.NewTimerFired(),
+			`{
+				"created_on": "2018-10-18T14:20:30.000123456Z",
+				"type": "timer_fired"
+			}`,
+		},
 		{
 			events.NewDialEnded(flows.NewDial(flows.DialStatusBusy, 0)),
 			`{
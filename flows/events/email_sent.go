@@ -2,6 +2,7 @@ package events
 
 import (
 	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
 )
 
 func init() {
@@ -11,31 +12,45 @@ func init() {
 // TypeEmailSent is our type for the email event
 const TypeEmailSent string = "email_sent"
 
-// EmailSentEvent events are created when an action has sent an email.
+// EmailSentEvent events are created when an action has sent an email. The CC, BCC, reply-to, HTML body and
+// attachments are only included when they were set.
 //
 //   {
 //     "type": "email_sent",
 //     "created_on": "2006-01-02T15:04:05Z",
 //     "to": ["foo@bar.com"],
+//     "cc": ["boss@bar.com"],
 //     "subject": "Your activation token",
-//     "body": "Your activation token is AAFFKKEE"
+//     "body": "Your activation token is AAFFKKEE",
+//     "html_body": "Your activation token is <b>AAFFKKEE</b>",
+//     "attachments": ["application/pdf:http://s3.amazon.com/bucket/terms.pdf"]
 //   }
 //
 // @event email_sent
 type EmailSentEvent struct {
 	baseEvent
 
-	To      []string `json:"to" validate:"required,min=1"`
-	Subject string   `json:"subject" validate:"required"`
-	Body    string   `json:"body"`
+	To          []string           `json:"to" validate:"required,min=1"`
+	CC          []string           `json:"cc,omitempty"`
+	BCC         []string           `json:"bcc,omitempty"`
+	ReplyTo     string             `json:"reply_to,omitempty"`
+	Subject     string             `json:"subject" validate:"required"`
+	Body        string             `json:"body"`
+	HTMLBody    string             `json:"html_body,omitempty"`
+	Attachments []utils.Attachment `json:"attachments,omitempty"`
 }
 
-// NewEmailSent returns a new email event with the passed in subject, body and emails
-func NewEmailSent(to []string, subject string, body string) *EmailSentEvent {
+// NewEmailSent returns a new email event for the passed in email
+func NewEmailSent(email *flows.Email) *EmailSentEvent {
 	return &EmailSentEvent{
-		baseEvent: newBaseEvent(TypeEmailSent),
-		To:        to,
-		Subject:   subject,
-		Body:      body,
+		baseEvent:   newBaseEvent(TypeEmailSent),
+		To:          email.To,
+		CC:          email.CC,
+		BCC:         email.BCC,
+		ReplyTo:     email.ReplyTo,
+		Subject:     email.Subject,
+		Body:        email.Body,
+		HTMLBody:    email.HTMLBody,
+		Attachments: email.Attachments,
 	}
 }
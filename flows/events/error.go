@@ -4,7 +4,10 @@ import (
 	"fmt"
 
 	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/excellent"
 	"github.com/nyaruka/goflow/flows"
+
+	"github.com/pkg/errors"
 )
 
 func init() {
@@ -14,7 +17,8 @@ func init() {
 // TypeError is the type of our error events
 const TypeError string = "error"
 
-// ErrorEvent events are created when an error occurs during flow execution.
+// ErrorEvent events are created when an error occurs during flow execution. If the engine is configured to trace
+// templates, errors from evaluating templates will include a `trace` of that evaluation.
 //
 //   {
 //     "type": "error",
@@ -26,12 +30,19 @@ const TypeError string = "error"
 type ErrorEvent struct {
 	baseEvent
 
-	Text string `json:"text" validate:"required"`
+	Text  string           `json:"text" validate:"required"`
+	Trace *excellent.Trace `json:"trace,omitempty"`
 }
 
 // NewError returns a new error event for the passed in error
 func NewError(err error) *ErrorEvent {
-	return NewErrorf(err.Error())
+	event := NewErrorf(err.Error())
+
+	if traced, isTraced := errors.Cause(err).(*excellent.TracedError); isTraced {
+		event.Trace = traced.Trace
+	}
+
+	return event
 }
 
 // NewErrorf returns a new error event for the passed in format string and args
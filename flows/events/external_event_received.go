@@ -0,0 +1,45 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/nyaruka/goflow/flows"
+)
+
+func init() {
+	registerType(TypeExternalEventReceived, func() flows.Event { return &ExternalEventReceivedEvent{} })
+}
+
+// TypeExternalEventReceived is the type of our external event received event
+const TypeExternalEventReceived string = "external_event_received"
+
+// ExternalEventReceivedEvent events are created when a session is resumed by an event from an external system.
+//
+//   {
+//     "type": "external_event_received",
+//     "created_on": "2019-01-02T15:04:05Z",
+//     "event": "payment_completed",
+//     "key": "ORD-1234",
+//     "payload": {"status": "paid", "amount": 25}
+//   }
+//
+// @event external_event_received
+type ExternalEventReceivedEvent struct {
+	baseEvent
+
+	Event   string          `json:"event" validate:"required"`
+	Key     string          `json:"key,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewExternalEventReceived returns a new external event received event
+func NewExternalEventReceived(event, key string, payload json.RawMessage) *ExternalEventReceivedEvent {
+	return &ExternalEventReceivedEvent{
+		baseEvent: newBaseEvent(TypeExternalEventReceived),
+		Event:     event,
+		Key:       key,
+		Payload:   payload,
+	}
+}
+
+var _ flows.Event = (*ExternalEventReceivedEvent)(nil)
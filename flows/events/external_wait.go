@@ -0,0 +1,45 @@
+package events
+
+import (
+	"github.com/nyaruka/goflow/flows"
+)
+
+func init() {
+	registerType(TypeExternalWait, func() flows.Event { return &ExternalWaitEvent{} })
+}
+
+// TypeExternalWait is the type of our external wait event
+const TypeExternalWait string = "external_wait"
+
+// ExternalWaitEvent events are created when a flow pauses waiting for an event from an external system. The
+// caller should resume the flow with an external_event resume when it receives an event with the same name and
+// key. If a timeout is set, then the caller should resume the flow after the number of seconds in the timeout.
+//
+//   {
+//     "type": "external_wait",
+//     "created_on": "2019-01-02T15:04:05Z",
+//     "event": "payment_completed",
+//     "key": "ORD-1234",
+//     "timeout_seconds": 3600
+//   }
+//
+// @event external_wait
+type ExternalWaitEvent struct {
+	baseEvent
+
+	Event          string `json:"event" validate:"required"`
+	Key            string `json:"key,omitempty"`
+	TimeoutSeconds *int   `json:"timeout_seconds,omitempty"`
+}
+
+// NewExternalWait returns a new external wait event
+func NewExternalWait(event, key string, timeoutSeconds *int) *ExternalWaitEvent {
+	return &ExternalWaitEvent{
+		baseEvent:      newBaseEvent(TypeExternalWait),
+		Event:          event,
+		Key:            key,
+		TimeoutSeconds: timeoutSeconds,
+	}
+}
+
+var _ flows.Event = (*ExternalWaitEvent)(nil)
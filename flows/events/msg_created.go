@@ -11,7 +11,8 @@ func init() {
 // TypeMsgCreated is a constant for incoming messages
 const TypeMsgCreated string = "msg_created"
 
-// MsgCreatedEvent events are created when an action wants to send a reply to the current contact.
+// MsgCreatedEvent events are created when an action wants to send a reply to the current contact. If the environment
+// has a channel selection strategy, then `channel_reason` records why the message's channel was selected.
 //
 //   {
 //     "type": "msg_created",
@@ -29,7 +30,8 @@ const TypeMsgCreated string = "msg_created"
 type MsgCreatedEvent struct {
 	baseEvent
 
-	Msg *flows.MsgOut `json:"msg" validate:"required,dive"`
+	Msg           *flows.MsgOut       `json:"msg" validate:"required,dive"`
+	ChannelReason flows.ChannelReason `json:"channel_reason,omitempty"`
 }
 
 // NewMsgCreated creates a new outgoing msg event to a single contact
@@ -0,0 +1,31 @@
+package events
+
+import (
+	"github.com/nyaruka/goflow/flows"
+)
+
+func init() {
+	registerType(TypeTimerFired, func() flows.Event { return &TimerFiredEvent{} })
+}
+
+// TypeTimerFired is the type of our timer fired events
+const TypeTimerFired string = "timer_fired"
+
+// TimerFiredEvent events are created when a session is resumed because the timer of a timer wait has fired.
+//
+//   {
+//     "type": "timer_fired",
+//     "created_on": "2006-01-02T15:04:05Z"
+//   }
+//
+// @event timer_fired
+type TimerFiredEvent struct {
+	baseEvent
+}
+
+// NewTimerFired creates a new timer fired event
+func NewTimerFired() *TimerFiredEvent {
+	return &TimerFiredEvent{baseEvent: newBaseEvent(TypeTimerFired)}
+}
+
+var _ flows.Event = (*TimerFiredEvent)(nil)
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"time"
+
+	"github.com/nyaruka/goflow/flows"
+)
+
+func init() {
+	registerType(TypeTimerWait, func() flows.Event { return &TimerWaitEvent{} })
+}
+
+// TypeTimerWait is the type of our timer wait event
+const TypeTimerWait string = "timer_wait"
+
+// TimerWaitEvent events are created when a flow pauses until a specific time. The caller should resume the
+// flow with a timer_fired resume at that time. Incoming messages should be queued until then unless the wait
+// allows them to break it.
+//
+//   {
+//     "type": "timer_wait",
+//     "created_on": "2019-01-02T15:04:05Z",
+//     "fires_on": "2019-01-04T15:04:05Z",
+//     "break_on_msg": true
+//   }
+//
+// @event timer_wait
+type TimerWaitEvent struct {
+	baseEvent
+
+	FiresOn    time.Time `json:"fires_on" validate:"required"`
+	BreakOnMsg bool      `json:"break_on_msg,omitempty"`
+}
+
+// NewTimerWait returns a new timer wait event with the passed in fire time
+func NewTimerWait(firesOn time.Time, breakOnMsg bool) *TimerWaitEvent {
+	return &TimerWaitEvent{
+		baseEvent:  newBaseEvent(TypeTimerWait),
+		FiresOn:    firesOn,
+		BreakOnMsg: breakOnMsg,
+	}
+}
+
+var _ flows.Event = (*TimerWaitEvent)(nil)
@@ -0,0 +1,80 @@
+package flows
+
+import (
+	"sort"
+
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/envs"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRateAssets provides access to all exchange rate assets and can convert amounts between currencies using them
+type ExchangeRateAssets struct {
+	rates map[envs.Currency]map[envs.Currency]decimal.Decimal
+}
+
+var _ envs.CurrencyConverter = (*ExchangeRateAssets)(nil)
+
+// NewExchangeRateAssets creates a new set of exchange rate assets
+func NewExchangeRateAssets(rates []assets.ExchangeRate) *ExchangeRateAssets {
+	s := &ExchangeRateAssets{rates: make(map[envs.Currency]map[envs.Currency]decimal.Decimal, len(rates))}
+
+	for _, rate := range rates {
+		if rate.Rate().IsZero() {
+			continue
+		}
+
+		s.add(rate.From(), rate.To(), rate.Rate())
+
+		// only use the inverse of a rate if we haven't been given an explicit rate for that direction
+		if _, exists := s.rates[rate.To()][rate.From()]; !exists {
+			s.add(rate.To(), rate.From(), decimal.NewFromInt(1).Div(rate.Rate()))
+		}
+	}
+	return s
+}
+
+func (s *ExchangeRateAssets) add(from, to envs.Currency, rate decimal.Decimal) {
+	if s.rates[from] == nil {
+		s.rates[from] = make(map[envs.Currency]decimal.Decimal)
+	}
+	s.rates[from][to] = rate
+}
+
+// Rate returns the rate for converting from one currency to another. If there's no rate between the two currencies
+// but they both have rates with a third currency, then those rates are combined.
+func (s *ExchangeRateAssets) Rate(from, to envs.Currency) (decimal.Decimal, bool) {
+	if from == to {
+		return decimal.NewFromInt(1), true
+	}
+	if rate, exists := s.rates[from][to]; exists {
+		return rate, true
+	}
+
+	// iterate over possible intermediate currencies in a deterministic order
+	vias := make([]string, 0, len(s.rates[from]))
+	for via := range s.rates[from] {
+		vias = append(vias, string(via))
+	}
+	sort.Strings(vias)
+
+	for _, via := range vias {
+		if rate2, exists := s.rates[envs.Currency(via)][to]; exists {
+			return s.rates[from][envs.Currency(via)].Mul(rate2), true
+		}
+	}
+
+	return decimal.Zero, false
+}
+
+// Convert converts the given amount from one currency to another
+func (s *ExchangeRateAssets) Convert(amount decimal.Decimal, from, to envs.Currency) (decimal.Decimal, error) {
+	rate, found := s.Rate(from, to)
+	if !found {
+		return decimal.Zero, errors.Errorf("no exchange rate available from %s to %s", from, to)
+	}
+
+	return amount.Mul(rate), nil
+}
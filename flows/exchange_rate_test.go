@@ -0,0 +1,52 @@
+package flows_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/static/types"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRateAssets(t *testing.T) {
+	rates := flows.NewExchangeRateAssets([]assets.ExchangeRate{
+		types.NewExchangeRate("USD", "RWF", decimal.RequireFromString("1000")),
+		types.NewExchangeRate("USD", "EUR", decimal.RequireFromString("0.8")),
+		types.NewExchangeRate("EUR", "USD", decimal.RequireFromString("1.2")),
+		types.NewExchangeRate("USD", "KES", decimal.Zero),
+	})
+
+	tcs := []struct {
+		from  envs.Currency
+		to    envs.Currency
+		rate  string
+		found bool
+	}{
+		{"USD", "USD", "1", true},
+		{"USD", "RWF", "1000", true},
+		{"RWF", "USD", "0.001", true}, // inverse of given rate
+		{"USD", "EUR", "0.8", true},
+		{"EUR", "USD", "1.2", true},    // explicit rate takes precedence over inverse
+		{"EUR", "RWF", "1200", true},   // via USD
+		{"RWF", "EUR", "0.0008", true}, // via USD
+		{"USD", "KES", "0", false},     // zero rates are ignored
+		{"USD", "GBP", "0", false},
+	}
+
+	for _, tc := range tcs {
+		rate, found := rates.Rate(tc.from, tc.to)
+		assert.Equal(t, tc.found, found, "found mismatch for %s > %s", tc.from, tc.to)
+		assert.True(t, decimal.RequireFromString(tc.rate).Equal(rate), "rate mismatch for %s > %s, got %s", tc.from, tc.to, rate)
+	}
+
+	converted, err := rates.Convert(decimal.RequireFromString("2.5"), "USD", "RWF")
+	assert.NoError(t, err)
+	assert.Equal(t, "2500", converted.String())
+
+	_, err = rates.Convert(decimal.RequireFromString("2.5"), "USD", "GBP")
+	assert.EqualError(t, err, "no exchange rate available from USD to GBP")
+}
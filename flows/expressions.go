@@ -1,7 +1,10 @@
 package flows
 
 import (
+	"encoding/xml"
+	"html"
 	"strconv"
+	"strings"
 
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/excellent/types"
@@ -53,3 +56,15 @@ var RunContextTopLevels = []string{
 func ContactQueryEscaping(s string) string {
 	return strconv.Quote(s)
 }
+
+// HTMLEscaping is the escaping function used for expressions in HTML content
+func HTMLEscaping(s string) string {
+	return html.EscapeString(s)
+}
+
+// XMLEscaping is the escaping function used for expressions in XML content
+func XMLEscaping(s string) string {
+	escaped := &strings.Builder{}
+	xml.EscapeText(escaped, []byte(s))
+	return escaped.String()
+}
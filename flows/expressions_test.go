@@ -14,3 +14,10 @@ func TestContactQueryEscaping(t *testing.T) {
 	assert.Equal(t, `"\"\" OR (id = 1)"`, flows.ContactQueryEscaping(`"" OR (id = 1)`))
 	assert.Equal(t, `"\\\"foo"`, flows.ContactQueryEscaping(`\"foo`))
 }
+
+func TestXMLEscaping(t *testing.T) {
+	assert.Equal(t, ``, flows.XMLEscaping(``))
+	assert.Equal(t, `bobby tables`, flows.XMLEscaping(`bobby tables`))
+	assert.Equal(t, `&lt;/Name&gt;&lt;Admin&gt;true&lt;/Admin&gt;`, flows.XMLEscaping(`</Name><Admin>true</Admin>`))
+	assert.Equal(t, `Tom &amp; Jerry&#39;s`, flows.XMLEscaping(`Tom & Jerry's`))
+}
@@ -0,0 +1,89 @@
+package issues
+
+import (
+	"fmt"
+
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/actions"
+	"github.com/nyaruka/goflow/flows/inspect"
+	"github.com/nyaruka/goflow/utils"
+)
+
+func init() {
+	registerType(TypeLongMessage, LongMessageCheck)
+}
+
+// TypeLongMessage is our type for a message which needs multiple SMS segments
+const TypeLongMessage string = "long_message"
+
+// LongMessage is a message whose text needs multiple SMS segments
+type LongMessage struct {
+	baseIssue
+
+	Segments int `json:"segments"`
+}
+
+func newLongMessage(nodeUUID flows.NodeUUID, actionUUID flows.ActionUUID, language envs.Language, segments int) *LongMessage {
+	return &LongMessage{
+		baseIssue: newBaseIssue(
+			TypeLongMessage,
+			nodeUUID,
+			actionUUID,
+			language,
+			fmt.Sprintf("message text will be sent as %d SMS segments", segments),
+		),
+		Segments: segments,
+	}
+}
+
+// LongMessageCheck checks for message texts, including their translations, which need more than one SMS segment.
+// Expressions can't be evaluated so they are counted as written. The check only applies if some channel declares its
+// encoding, and segments are counted in UCS-2 if any channel uses that encoding, since that's the most that a message
+// could need, and otherwise in GSM-7.
+func LongMessageCheck(sa flows.SessionAssets, flow flows.Flow, tpls []flows.ExtractedTemplate, refs []flows.ExtractedReference, report func(flows.Issue)) {
+	if sa == nil {
+		return
+	}
+
+	var encoding utils.SMSEncoding
+	for _, ch := range sa.Channels().All() {
+		if ch.Encoding() == utils.SMSEncodingUCS2 {
+			encoding = utils.SMSEncodingUCS2
+		} else if ch.Encoding() != "" && encoding == "" {
+			encoding = utils.SMSEncodingGSM7
+		}
+	}
+	if encoding == "" {
+		return
+	}
+
+	checkText := func(n flows.Node, a flows.Action, l envs.Language, t string) {
+		if segments := utils.SMSSegments(t, encoding); segments > 1 {
+			report(newLongMessage(n.UUID(), a.UUID(), l, segments))
+		}
+	}
+
+	for _, node := range flow.Nodes() {
+		for _, action := range node.Actions() {
+			var text string
+			var localizationUUID uuids.UUID
+
+			switch typed := action.(type) {
+			case *actions.SendMsgAction:
+				text, localizationUUID = typed.Text, typed.LocalizationUUID()
+			case *actions.SendBroadcastAction:
+				text, localizationUUID = typed.Text, typed.LocalizationUUID()
+			default:
+				continue
+			}
+
+			checkText(node, action, "", text)
+
+			inspect.Translations(flow.Localization(), localizationUUID, "text", func(l envs.Language, t string) {
+				checkText(node, action, l, t)
+			})
+		}
+	}
+}
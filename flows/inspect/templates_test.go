@@ -81,7 +81,12 @@ func TestTemplatePaths(t *testing.T) {
 		"$.nodes[*].actions[@.type=\"call_classifier\"].input",
 		"$.nodes[*].actions[@.type=\"call_webhook\"].body",
 		"$.nodes[*].actions[@.type=\"call_webhook\"].headers[*]",
+		"$.nodes[*].actions[@.type=\"call_webhook\"].query",
 		"$.nodes[*].actions[@.type=\"call_webhook\"].url",
+		"$.nodes[*].actions[@.type=\"call_webhook\"].variables[*]",
+		"$.nodes[*].actions[@.type=\"call_webhooks\"].requests[*].body",
+		"$.nodes[*].actions[@.type=\"call_webhooks\"].requests[*].headers[*]",
+		"$.nodes[*].actions[@.type=\"call_webhooks\"].requests[*].url",
 		"$.nodes[*].actions[@.type=\"open_ticket\"].body",
 		"$.nodes[*].actions[@.type=\"open_ticket\"].subject",
 		"$.nodes[*].actions[@.type=\"play_audio\"].audio_url",
@@ -94,7 +99,12 @@ func TestTemplatePaths(t *testing.T) {
 		"$.nodes[*].actions[@.type=\"send_broadcast\"].quick_replies[*]",
 		"$.nodes[*].actions[@.type=\"send_broadcast\"].text",
 		"$.nodes[*].actions[@.type=\"send_email\"].addresses[*]",
+		"$.nodes[*].actions[@.type=\"send_email\"].attachments[*]",
+		"$.nodes[*].actions[@.type=\"send_email\"].bcc[*]",
 		"$.nodes[*].actions[@.type=\"send_email\"].body",
+		"$.nodes[*].actions[@.type=\"send_email\"].cc[*]",
+		"$.nodes[*].actions[@.type=\"send_email\"].html_body",
+		"$.nodes[*].actions[@.type=\"send_email\"].reply_to",
 		"$.nodes[*].actions[@.type=\"send_email\"].subject",
 		"$.nodes[*].actions[@.type=\"send_msg\"].attachments[*]",
 		"$.nodes[*].actions[@.type=\"send_msg\"].quick_replies[*]",
@@ -108,6 +118,8 @@ func TestTemplatePaths(t *testing.T) {
 		"$.nodes[*].actions[@.type=\"start_session\"].contact_query",
 		"$.nodes[*].actions[@.type=\"start_session\"].groups[*].name_match",
 		"$.nodes[*].actions[@.type=\"start_session\"].legacy_vars[*]",
+		"$.nodes[*].actions[@.type=\"transfer_airtime\"].amount",
+		"$.nodes[*].actions[@.type=\"transfer_airtime\"].sku",
 	}, paths)
 }
 
@@ -102,6 +102,7 @@ type SessionAssets interface {
 
 	Channels() *ChannelAssets
 	Classifiers() *ClassifierAssets
+	ExchangeRates() *ExchangeRateAssets
 	Fields() *FieldAssets
 	Flows() FlowAssets
 	Globals() *GlobalAssets
@@ -215,8 +216,10 @@ type Wait interface {
 
 	Timeout() Timeout
 
-	Begin(FlowRun, EventCallback) ActivatedWait
-	End(Resume) error
+	Begin(FlowRun, EventCallback) (ActivatedWait, error)
+	End(ActivatedWait, Resume) error
+
+	EnumerateTemplates(Localization, func(envs.Language, string))
 }
 
 // ActivatedWait is a wait once it has been activated in a session
@@ -327,6 +330,9 @@ type Engine interface {
 	Services() Services
 	MaxStepsPerSprint() int
 	MaxTemplateChars() int
+	TraceTemplates() bool
+	TemplateCache() *excellent.TemplateCache
+	MaxConcurrentWebhooks() int
 }
 
 // Sprint is an interaction with the engine - i.e. a start or resume of a session
@@ -20,9 +20,13 @@ type readFunc func(flows.SessionAssets, json.RawMessage, assets.MissingCallback)
 // RegisteredTypes is the registered modifier types
 var RegisteredTypes = map[string]readFunc{}
 
-// egisters a new type of modifier
-func registerType(name string, f readFunc) {
+// RegisteredEnvelopes is empty instances of the envelopes that each registered modifier type is read from
+var RegisteredEnvelopes = map[string]interface{}{}
+
+// registers a new type of modifier and the envelope it is read from
+func registerType(name string, f readFunc, envelope interface{}) {
 	RegisteredTypes[name] = f
+	RegisteredEnvelopes[name] = envelope
 }
 
 // base of all modifier types
@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeChannel, readChannelModifier)
+	registerType(TypeChannel, readChannelModifier, &channelModifierEnvelope{})
 }
 
 // TypeChannel is the type of our channel modifier
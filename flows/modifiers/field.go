@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	registerType(TypeField, readFieldModifier)
+	registerType(TypeField, readFieldModifier, &fieldModifierEnvelope{})
 }
 
 // TypeField is the type of our field modifier
@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeGroups, readGroupsModifier)
+	registerType(TypeGroups, readGroupsModifier, &groupsModifierEnvelope{})
 }
 
 // TypeGroups is the type of our groups modifier
@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	registerType(TypeLanguage, readLanguageModifier)
+	registerType(TypeLanguage, readLanguageModifier, &LanguageModifier{})
 }
 
 // TypeLanguage is the type of our language modifier
@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	registerType(TypeName, readNameModifier)
+	registerType(TypeName, readNameModifier, &NameModifier{})
 }
 
 // TypeName is the type of our name modifier
@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	registerType(TypeStatus, readStatusModifier)
+	registerType(TypeStatus, readStatusModifier, &StatusModifier{})
 }
 
 // TypeStatus is the type of our status modifier
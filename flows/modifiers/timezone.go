@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	registerType(TypeTimezone, readTimezoneModifier)
+	registerType(TypeTimezone, readTimezoneModifier, &timezoneModifierEnvelope{})
 }
 
 // TypeTimezone is the type of our timezone modifier
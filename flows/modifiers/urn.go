@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeURN, readURNModifier)
+	registerType(TypeURN, readURNModifier, &URNModifier{})
 }
 
 // TypeURN is the type of our URN modifier
@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeURNs, readURNsModifier)
+	registerType(TypeURNs, readURNsModifier, &URNsModifier{})
 }
 
 // TypeURNs is the type of our URNs modifier
@@ -0,0 +1,235 @@
+package offline
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/flows/triggers"
+
+	"github.com/buger/jsonparser"
+	"github.com/pkg/errors"
+)
+
+// ErrDuplicateSprint is returned when appending a sprint which has already been synced
+var ErrDuplicateSprint = errors.New("sprint has already been synced")
+
+// Log is the append-only log of synced sprints for a single session
+type Log struct {
+	sessionUUID flows.SessionUUID
+	sprints     []*Sprint
+}
+
+// NewLog creates a new empty log for the given session
+func NewLog(sessionUUID flows.SessionUUID) *Log {
+	return &Log{sessionUUID: sessionUUID, sprints: make([]*Sprint, 0)}
+}
+
+// SessionUUID returns the UUID of the session this log is for
+func (l *Log) SessionUUID() flows.SessionUUID { return l.sessionUUID }
+
+// Sprints returns the sprints in this log
+func (l *Log) Sprints() []*Sprint { return l.sprints }
+
+// Next returns the sequence number of the next sprint expected by this log
+func (l *Log) Next() int { return len(l.sprints) + 1 }
+
+// Append appends the given sprint to this log. If the sprint has already been synced then ErrDuplicateSprint is
+// returned, and if it would leave a gap in the log or conflicts with an already synced sprint, another error.
+func (l *Log) Append(sprint *Sprint) error {
+	if sprint.SessionUUID != l.sessionUUID {
+		return errors.Errorf("sprint is for session %s, not %s", sprint.SessionUUID, l.sessionUUID)
+	}
+
+	if sprint.Sequence < l.Next() {
+		if sprint.Equals(l.sprints[sprint.Sequence-1]) {
+			return ErrDuplicateSprint
+		}
+		return errors.Errorf("sprint %d conflicts with previously synced sprint", sprint.Sequence)
+	}
+	if sprint.Sequence > l.Next() {
+		return errors.Errorf("expected sprint %d but got sprint %d", l.Next(), sprint.Sequence)
+	}
+
+	l.sprints = append(l.sprints, sprint)
+	return nil
+}
+
+// Compose replays all the sprints in this log to compose the current state of the session, returning an error
+// if any sprint isn't consistent with its replay
+func (l *Log) Compose(eng flows.Engine, sa flows.SessionAssets) (flows.Session, error) {
+	if len(l.sprints) == 0 {
+		return nil, errors.New("can't compose session from empty log")
+	}
+
+	var session flows.Session
+	var err error
+
+	for _, sprint := range l.sprints {
+		if session, err = Replay(eng, sa, session, sprint); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// Replay replays the given sprint's trigger or resume on the given session, which should be nil for the first
+// sprint, and returns the new session state. An error is returned if the replayed sprint has different events or
+// modifiers to the given sprint, ignoring times and the UUIDs of things created during the sprint which will always
+// differ.
+func Replay(eng flows.Engine, sa flows.SessionAssets, session flows.Session, sprint *Sprint) (flows.Session, error) {
+	var replayed flows.Sprint
+	var err error
+
+	if sprint.Sequence == 1 {
+		if session != nil {
+			return nil, errors.New("can't replay first sprint on an existing session")
+		}
+
+		trigger, err := triggers.ReadTrigger(sa, sprint.Trigger, assets.IgnoreMissing)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read trigger of sprint %d", sprint.Sequence)
+		}
+
+		if session, replayed, err = eng.NewSession(sa, trigger); err != nil {
+			return nil, errors.Wrapf(err, "unable to replay sprint %d", sprint.Sequence)
+		}
+
+		// replayed session will have a new UUID so give it the UUID of the synced session
+		if session, err = readSession(eng, sa, session, sprint.SessionUUID); err != nil {
+			return nil, err
+		}
+	} else {
+		if session == nil {
+			return nil, errors.Errorf("can't replay sprint %d without an existing session", sprint.Sequence)
+		}
+
+		resume, err := resumes.ReadResume(sa, sprint.Resume, assets.IgnoreMissing)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read resume of sprint %d", sprint.Sequence)
+		}
+
+		// always replay on a copy of the session read from its serialized state
+		if session, err = readSession(eng, sa, session, session.UUID()); err != nil {
+			return nil, err
+		}
+		if replayed, err = session.Resume(resume); err != nil {
+			return nil, errors.Wrapf(err, "unable to replay sprint %d", sprint.Sequence)
+		}
+	}
+
+	if err = checkConsistent(sprint, replayed); err != nil {
+		return nil, errors.Wrapf(err, "sprint %d isn't consistent with its replay", sprint.Sequence)
+	}
+
+	return session, nil
+}
+
+func readSession(eng flows.Engine, sa flows.SessionAssets, session flows.Session, uuid flows.SessionUUID) (flows.Session, error) {
+	data, err := jsonx.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = jsonparser.Set(data, []byte(`"`+string(uuid)+`"`), "uuid"); err != nil {
+		return nil, err
+	}
+	return eng.ReadSession(sa, data, assets.IgnoreMissing)
+}
+
+// checks that a synced sprint has the same events and modifiers as its replay, ignoring the times and UUIDs
+// which will always be different
+func checkConsistent(synced *Sprint, replayed flows.Sprint) error {
+	if len(synced.Events) != len(replayed.Events()) {
+		return errors.Errorf("expected %d events but replay produced %d", len(synced.Events), len(replayed.Events()))
+	}
+	if len(synced.Modifiers) != len(replayed.Modifiers()) {
+		return errors.Errorf("expected %d modifiers but replay produced %d", len(synced.Modifiers), len(replayed.Modifiers()))
+	}
+
+	for i, event := range replayed.Events() {
+		if err := checkEquivalent(synced.Events[i], event); err != nil {
+			return errors.Wrapf(err, "event %d differs", i)
+		}
+	}
+	for i, modifier := range replayed.Modifiers() {
+		if err := checkEquivalent(synced.Modifiers[i], modifier); err != nil {
+			return errors.Wrapf(err, "modifier %d differs", i)
+		}
+	}
+	return nil
+}
+
+// UUID fields which will differ between a sprint and its replay, at any depth, because they are generated as the
+// sprint runs. Fields ending in _on are times and will also differ.
+var volatileFields = map[string]bool{
+	"step_uuid":       true,
+	"run_uuid":        true,
+	"parent_run_uuid": true,
+	"session_uuid":    true,
+	"input_uuid":      true,
+}
+
+// matches ISO formatted times in text, e.g. a message which includes @(now())
+var isoTimeRegex = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+
+func checkEquivalent(synced json.RawMessage, replayed interface{}) error {
+	replayedJSON, err := jsonx.Marshal(replayed)
+	if err != nil {
+		return err
+	}
+
+	n1, err := normalize(synced)
+	if err != nil {
+		return err
+	}
+	n2, err := normalize(replayedJSON)
+	if err != nil {
+		return err
+	}
+
+	if n1 != n2 {
+		return errors.Errorf("expected %s but replay produced %s", n1, n2)
+	}
+	return nil
+}
+
+// normalizes JSON by removing times and generated UUIDs, and sorting object keys. Objects with a name are references
+// to assets or contacts so their UUIDs are kept, but the UUIDs of other objects, like the event or modifier itself or
+// a message it creates, are generated and so removed.
+func normalize(data json.RawMessage) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+
+	normalized, err := jsonx.Marshal(stripVolatile(v))
+	return string(normalized), err
+}
+
+func stripVolatile(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		if _, hasName := typed["name"]; !hasName {
+			delete(typed, "uuid")
+		}
+
+		for k, item := range typed {
+			if volatileFields[k] || strings.HasSuffix(k, "_on") {
+				delete(typed, k)
+			} else {
+				typed[k] = stripVolatile(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = stripVolatile(item)
+		}
+	case string:
+		return isoTimeRegex.ReplaceAllString(typed, "<time>")
+	}
+	return v
+}
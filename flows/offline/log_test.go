@@ -0,0 +1,221 @@
+package offline_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/static"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/engine"
+	"github.com/nyaruka/goflow/flows/offline"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/flows/triggers"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runs a session "offline" and returns its sprints as they would be uploaded
+func runOffline(t *testing.T, sa flows.SessionAssets, answers ...string) (flows.Session, []*offline.Sprint) {
+	env := envs.NewBuilder().Build()
+	eng := engine.NewBuilder().Build()
+	contact := flows.NewEmptyContact(sa, "Bob", envs.Language("eng"), nil)
+	flow := assets.NewFlowReference("7c3db26f-e12a-48af-9673-e2feefdf8516", "Two Questions")
+
+	session, sprint, err := eng.NewSession(sa, triggers.NewBuilder(env, flow, contact).Manual().Build())
+	require.NoError(t, err)
+
+	first, err := offline.NewStartSprint(session, sprint)
+	require.NoError(t, err)
+
+	sprints := []*offline.Sprint{first}
+
+	for i, answer := range answers {
+		msg := flows.NewMsgIn(flows.MsgUUID("8e6f0213-a122-4c50-a430-442085754c16"), urns.NilURN, nil, answer, nil)
+		resume := resumes.NewMsg(nil, nil, msg)
+
+		sprint, err := session.Resume(resume)
+		require.NoError(t, err)
+
+		s, err := offline.NewResumeSprint(session, i+2, resume, sprint)
+		require.NoError(t, err)
+
+		// sprints go over the wire as JSON
+		data, err := jsonx.Marshal(s)
+		require.NoError(t, err)
+		s, err = offline.ReadSprint(data)
+		require.NoError(t, err)
+
+		sprints = append(sprints, s)
+	}
+
+	return session, sprints
+}
+
+func TestLog(t *testing.T) {
+	sa, err := test.LoadSessionAssets(envs.NewBuilder().Build(), "../../test/testdata/runner/two_questions_offline.json")
+	require.NoError(t, err)
+
+	session, sprints := runOffline(t, sa, "red", "coke", "here you go")
+	require.Len(t, sprints, 4)
+
+	log := offline.NewLog(session.UUID())
+	assert.Equal(t, session.UUID(), log.SessionUUID())
+	assert.Equal(t, 1, log.Next())
+
+	_, err = log.Compose(engine.NewBuilder().Build(), sa)
+	assert.EqualError(t, err, "can't compose session from empty log")
+
+	// can't skip a sprint
+	assert.EqualError(t, log.Append(sprints[1]), "expected sprint 1 but got sprint 2")
+
+	assert.NoError(t, log.Append(sprints[0]))
+	assert.NoError(t, log.Append(sprints[1]))
+
+	// uploading the same sprint again is detected as a duplicate
+	assert.Equal(t, offline.ErrDuplicateSprint, log.Append(sprints[1]))
+
+	// but a different sprint with the same sequence number is a conflict
+	_, otherSprints := runOffline(t, sa, "blue")
+	otherSprints[1].SessionUUID = session.UUID()
+	assert.EqualError(t, log.Append(otherSprints[1]), "sprint 2 conflicts with previously synced sprint")
+
+	// sprints from other sessions are rejected
+	_, otherSprints = runOffline(t, sa)
+	assert.EqualError(t, log.Append(otherSprints[0]), "sprint is for session "+string(otherSprints[0].SessionUUID)+", not "+string(session.UUID()))
+
+	assert.NoError(t, log.Append(sprints[2]))
+	assert.NoError(t, log.Append(sprints[3]))
+	assert.Equal(t, 5, log.Next())
+	assert.Len(t, log.Sprints(), 4)
+
+	// compose the session on the "server" and check it matches the session on the "device"
+	composed, err := log.Compose(engine.NewBuilder().Build(), sa)
+	require.NoError(t, err)
+
+	assert.Equal(t, session.UUID(), composed.UUID())
+	assert.Equal(t, session.Status(), composed.Status())
+	assert.Equal(t, flows.SessionStatusCompleted, composed.Status())
+
+	results := composed.Runs()[0].Results()
+	assert.Equal(t, "Red", results.Get("favorite_color").Category)
+	assert.Equal(t, "coke", results.Get("soda").Value)
+}
+
+func TestReplay(t *testing.T) {
+	sa, err := test.LoadSessionAssets(envs.NewBuilder().Build(), "../../test/testdata/runner/two_questions_offline.json")
+	require.NoError(t, err)
+
+	eng := engine.NewBuilder().Build()
+
+	session, sprints := runOffline(t, sa, "red")
+
+	// replay sprints one at a time as a server would as they are uploaded
+	replayed, err := offline.Replay(eng, sa, nil, sprints[0])
+	require.NoError(t, err)
+	assert.Equal(t, session.UUID(), replayed.UUID())
+	assert.Equal(t, flows.SessionStatusWaiting, replayed.Status())
+
+	_, err = offline.Replay(eng, sa, replayed, sprints[0])
+	assert.EqualError(t, err, "can't replay first sprint on an existing session")
+
+	_, err = offline.Replay(eng, sa, nil, sprints[1])
+	assert.EqualError(t, err, "can't replay sprint 2 without an existing session")
+
+	// tamper with the events of the sprint so that they are no longer consistent with its input
+	tampered := *sprints[1]
+	tampered.Events = tampered.Events[1:]
+
+	_, err = offline.Replay(eng, sa, replayed, &tampered)
+	assert.EqualError(t, err, "sprint 2 isn't consistent with its replay: expected 3 events but replay produced 4")
+
+	tampered = *sprints[1]
+	tampered.Events = append(tampered.Events[:0:0], tampered.Events...)
+	tampered.Events[1] = []byte(`{"type":"run_result_changed","created_on":"2020-01-01T12:00:00Z","name":"Favorite Color","value":"blue","category":"Blue"}`)
+
+	_, err = offline.Replay(eng, sa, replayed, &tampered)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "sprint 2 isn't consistent with its replay: event 1 differs")
+
+	replayed, err = offline.Replay(eng, sa, replayed, sprints[1])
+	require.NoError(t, err)
+	assert.Equal(t, session.UUID(), replayed.UUID())
+	assert.Equal(t, flows.SessionStatusWaiting, replayed.Status())
+	assert.Equal(t, "Red", replayed.Runs()[0].Results().Get("favorite_color").Category)
+}
+
+func TestReplayWithTimesAndReferences(t *testing.T) {
+	// each call to now returns a later time so times can't be the same in a sprint and its replay
+	defer dates.SetNowSource(dates.DefaultNowSource)
+	dates.SetNowSource(dates.NewSequentialNowSource(time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)))
+
+	source, err := static.NewSource([]byte(`{
+		"flows": [
+			{
+				"uuid": "a3c4f5b6-1d2e-4f8a-9b0c-7d6e5f4a3b2c",
+				"name": "Time Check",
+				"spec_version": "13.0",
+				"language": "eng",
+				"type": "messaging_offline",
+				"nodes": [
+					{
+						"uuid": "5d8c2e4f-6a1b-4c3d-8e9f-0a1b2c3d4e5f",
+						"actions": [
+							{
+								"uuid": "0c2d4e6f-8a1b-4c3d-9e5f-7a9b1c3d5e7f",
+								"type": "add_contact_groups",
+								"groups": [{"uuid": "b7cf0d83-f1c9-411c-96fd-c511a4cfa86d", "name": "Testers"}]
+							},
+							{
+								"uuid": "e4f6a8b0-2c4d-4e6f-8a0b-2c4d6e8f0a1b",
+								"type": "send_msg",
+								"text": "It's @(now())"
+							}
+						],
+						"exits": [{"uuid": "f1e2d3c4-b5a6-4978-8695-a4b3c2d1e0f9"}]
+					}
+				]
+			}
+		],
+		"groups": [
+			{"uuid": "b7cf0d83-f1c9-411c-96fd-c511a4cfa86d", "name": "Testers"},
+			{"uuid": "4f1f98fc-27a7-4a69-bbdb-24744ba739a9", "name": "Males"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	env := envs.NewBuilder().Build()
+	sa, err := engine.NewSessionAssets(env, source, nil)
+	require.NoError(t, err)
+
+	eng := engine.NewBuilder().Build()
+	contact := flows.NewEmptyContact(sa, "Bob", envs.Language("eng"), nil)
+	flow := assets.NewFlowReference("a3c4f5b6-1d2e-4f8a-9b0c-7d6e5f4a3b2c", "Time Check")
+
+	session, sprint, err := eng.NewSession(sa, triggers.NewBuilder(env, flow, contact).Manual().Build())
+	require.NoError(t, err)
+
+	synced, err := offline.NewStartSprint(session, sprint)
+	require.NoError(t, err)
+
+	// output which depends on the time is still consistent with its replay
+	replayed, err := offline.Replay(eng, sa, nil, synced)
+	require.NoError(t, err)
+	assert.Equal(t, session.UUID(), replayed.UUID())
+
+	// but a sprint which references a different group isn't
+	tampered := *synced
+	tampered.Events = append(tampered.Events[:0:0], tampered.Events...)
+	tampered.Events[0] = []byte(strings.Replace(string(tampered.Events[0]), "b7cf0d83-f1c9-411c-96fd-c511a4cfa86d", "4f1f98fc-27a7-4a69-bbdb-24744ba739a9", 1))
+
+	_, err = offline.Replay(eng, sa, nil, &tampered)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "sprint 1 isn't consistent with its replay: event 0 differs")
+}
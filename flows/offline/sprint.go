@@ -0,0 +1,110 @@
+// Package offline is an append-only format for syncing sessions which were run offline, e.g. on a mobile device.
+package offline
+
+import (
+	"encoding/json"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+)
+
+// Sprint is a single sprint of a session which was run offline, serialized for syncing. The first sprint of a
+// session has a sequence number of 1 and includes the trigger which started the session. Subsequent sprints
+// include the resume which resumed the session.
+//
+//   {
+//     "session_uuid": "2a8e7db4-5f59-4a23-8b8c-6c5e61b9d4d1",
+//     "sequence": 2,
+//     "resume": {
+//       "type": "msg",
+//       "resumed_on": "2020-10-20T15:04:05Z",
+//       "msg": {...}
+//     },
+//     "events": [...]
+//   }
+type Sprint struct {
+	SessionUUID flows.SessionUUID `json:"session_uuid" validate:"required,uuid4"`
+	Sequence    int               `json:"sequence" validate:"min=1"`
+	Trigger     json.RawMessage   `json:"trigger,omitempty"`
+	Resume      json.RawMessage   `json:"resume,omitempty"`
+	Modifiers   []json.RawMessage `json:"modifiers,omitempty"`
+	Events      []json.RawMessage `json:"events"`
+}
+
+// NewStartSprint creates the first sprint of the given session from the sprint returned when it was started
+func NewStartSprint(session flows.Session, sprint flows.Sprint) (*Sprint, error) {
+	trigger, err := jsonx.Marshal(session.Trigger())
+	if err != nil {
+		return nil, err
+	}
+
+	return newSprint(session, 1, trigger, nil, sprint)
+}
+
+// NewResumeSprint creates a subsequent sprint of the given session from the resume and the sprint it produced
+func NewResumeSprint(session flows.Session, sequence int, resume flows.Resume, sprint flows.Sprint) (*Sprint, error) {
+	if sequence < 2 {
+		return nil, errors.Errorf("resume sprints must have a sequence number greater than 1")
+	}
+
+	resumeJSON, err := jsonx.Marshal(resume)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSprint(session, sequence, nil, resumeJSON, sprint)
+}
+
+func newSprint(session flows.Session, sequence int, trigger, resume json.RawMessage, sprint flows.Sprint) (*Sprint, error) {
+	s := &Sprint{
+		SessionUUID: session.UUID(),
+		Sequence:    sequence,
+		Trigger:     trigger,
+		Resume:      resume,
+		Events:      make([]json.RawMessage, len(sprint.Events())),
+	}
+	var err error
+
+	if len(sprint.Modifiers()) > 0 {
+		s.Modifiers = make([]json.RawMessage, len(sprint.Modifiers()))
+		for i, modifier := range sprint.Modifiers() {
+			if s.Modifiers[i], err = jsonx.Marshal(modifier); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i, event := range sprint.Events() {
+		if s.Events[i], err = jsonx.Marshal(event); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// ReadSprint reads a sprint from the given JSON
+func ReadSprint(data []byte) (*Sprint, error) {
+	s := &Sprint{}
+	if err := utils.UnmarshalAndValidate(data, s); err != nil {
+		return nil, errors.Wrap(err, "unable to read sprint")
+	}
+
+	if s.Sequence == 1 && (s.Trigger == nil || s.Resume != nil) {
+		return nil, errors.New("unable to read sprint: first sprint must have a trigger and no resume")
+	}
+	if s.Sequence > 1 && (s.Resume == nil || s.Trigger != nil) {
+		return nil, errors.New("unable to read sprint: subsequent sprints must have a resume and no trigger")
+	}
+
+	return s, nil
+}
+
+// Equals returns whether this sprint is identical to the other sprint
+func (s *Sprint) Equals(other *Sprint) bool {
+	d1, _ := jsonx.Marshal(s)
+	d2, _ := jsonx.Marshal(other)
+	return string(d1) == string(d2)
+}
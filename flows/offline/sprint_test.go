@@ -0,0 +1,36 @@
+package offline_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/flows/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSprint(t *testing.T) {
+	_, err := offline.ReadSprint([]byte(`{`))
+	assert.Error(t, err)
+
+	_, err = offline.ReadSprint([]byte(`{"session_uuid": "2a8e7db4-5f59-4a23-8b8c-6c5e61b9d4d1", "sequence": 0, "events": []}`))
+	assert.EqualError(t, err, "unable to read sprint: field 'sequence' must have a minimum of 1 items")
+
+	_, err = offline.ReadSprint([]byte(`{"session_uuid": "2a8e7db4-5f59-4a23-8b8c-6c5e61b9d4d1", "sequence": 1, "events": []}`))
+	assert.EqualError(t, err, "unable to read sprint: first sprint must have a trigger and no resume")
+
+	_, err = offline.ReadSprint([]byte(`{"session_uuid": "2a8e7db4-5f59-4a23-8b8c-6c5e61b9d4d1", "sequence": 2, "trigger": {}, "events": []}`))
+	assert.EqualError(t, err, "unable to read sprint: subsequent sprints must have a resume and no trigger")
+
+	s1, err := offline.ReadSprint([]byte(`{"session_uuid": "2a8e7db4-5f59-4a23-8b8c-6c5e61b9d4d1", "sequence": 2, "resume": {"type": "wait_timeout"}, "events": [{"type": "run_expired"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, s1.Sequence)
+	assert.Equal(t, 1, len(s1.Events))
+
+	s2, err := offline.ReadSprint([]byte(`{"sequence":2,"session_uuid":"2a8e7db4-5f59-4a23-8b8c-6c5e61b9d4d1","resume":{"type":"wait_timeout"},"events":[{"type":"run_expired"}]}`))
+	require.NoError(t, err)
+	assert.True(t, s1.Equals(s2))
+
+	s2.Sequence = 3
+	assert.False(t, s1.Equals(s2))
+}
@@ -20,10 +20,12 @@ import (
 type ReadFunc func(flows.SessionAssets, json.RawMessage, assets.MissingCallback) (flows.Resume, error)
 
 var registeredTypes = map[string]ReadFunc{}
+var registeredEnvelopes = map[string]interface{}{}
 
-// registers a new type of resume
-func registerType(name string, f ReadFunc) {
+// registers a new type of resume and the envelope it is read from
+func registerType(name string, f ReadFunc, envelope interface{}) {
 	registeredTypes[name] = f
+	registeredEnvelopes[name] = envelope
 }
 
 // RegisteredTypes gets the registered types of resumes
@@ -31,6 +33,11 @@ func RegisteredTypes() map[string]ReadFunc {
 	return registeredTypes
 }
 
+// RegisteredEnvelopes gets empty instances of the envelopes that each registered type of resume is read from
+func RegisteredEnvelopes() map[string]interface{} {
+	return registeredEnvelopes
+}
+
 // base of all resume types
 type baseResume struct {
 	type_       string
@@ -79,14 +86,16 @@ func (r *baseResume) Apply(run flows.FlowRun, logEvent flows.EventCallback) {
 
 // Context is the schema of trigger objects in the context, across all types
 type Context struct {
-	type_ string
-	dial  types.XValue
+	type_   string
+	dial    types.XValue
+	payload types.XValue
 }
 
 func (c *Context) asMap() map[string]types.XValue {
 	return map[string]types.XValue{
-		"type": types.NewXText(c.type_),
-		"dial": c.dial,
+		"type":    types.NewXText(c.type_),
+		"dial":    c.dial,
+		"payload": c.payload,
 	}
 }
 
@@ -97,6 +106,7 @@ func (r *baseResume) context() *Context {
 // Context returns the properties available in expressions
 //
 //   type:text -> the type of resume that resumed this session
+//   payload:any -> the payload of the external event that resumed this session
 //
 // @context resume
 func (r *baseResume) Context(env envs.Environment) map[string]types.XValue {
@@ -172,8 +172,9 @@ func TestResumeContext(t *testing.T) {
 	)
 
 	assert.Equal(t, map[string]types.XValue{
-		"type": types.NewXText("msg"),
-		"dial": nil,
+		"type":    types.NewXText("msg"),
+		"dial":    nil,
+		"payload": nil,
 	}, resume.Context(env))
 
 	resume = resumes.NewDial(env, nil, flows.NewDial(flows.DialStatusNoAnswer, 5))
@@ -181,4 +182,14 @@ func TestResumeContext(t *testing.T) {
 
 	assert.Equal(t, types.NewXText("dial"), context["type"])
 	assert.NotNil(t, context["dial"])
+
+	resume = resumes.NewExternalEvent(env, nil, "payment_completed", "ORD-1234", []byte(`{"status": "paid", "amount": 25}`))
+	context = resume.Context(env)
+
+	assert.Equal(t, types.NewXText("external_event"), context["type"])
+	assert.Nil(t, context["dial"])
+	test.AssertXEqual(t, types.NewXObject(map[string]types.XValue{
+		"status": types.NewXText("paid"),
+		"amount": types.RequireXNumberFromString("25"),
+	}), context["payload"])
 }
@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	registerType(TypeDial, readDialResume)
+	registerType(TypeDial, readDialResume, &dialResumeEnvelope{})
 }
 
 // TypeDial is the type for dial resumes
@@ -0,0 +1,117 @@
+package resumes
+
+import (
+	"encoding/json"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/utils"
+)
+
+func init() {
+	registerType(TypeExternalEvent, readExternalEventResume, &externalEventResumeEnvelope{})
+}
+
+// TypeExternalEvent is the type for resuming a session with an event from an external system
+const TypeExternalEvent string = "external_event"
+
+// ExternalEventResume is used when a session waiting on an external wait is resumed by an event from an external
+// system. The payload is available in expressions as @resume.payload.
+//
+//   {
+//     "type": "external_event",
+//     "resumed_on": "2021-01-20T12:18:30Z",
+//     "event": "payment_completed",
+//     "key": "ORD-1234",
+//     "payload": {"status": "paid", "amount": 25}
+//   }
+//
+// @resume external_event
+type ExternalEventResume struct {
+	baseResume
+
+	event   string
+	key     string
+	payload json.RawMessage
+}
+
+// NewExternalEvent creates a new external event resume
+func NewExternalEvent(env envs.Environment, contact *flows.Contact, event, key string, payload json.RawMessage) *ExternalEventResume {
+	return &ExternalEventResume{
+		baseResume: newBaseResume(TypeExternalEvent, env, contact),
+		event:      event,
+		key:        key,
+		payload:    payload,
+	}
+}
+
+// Event returns the name of the external event
+func (r *ExternalEventResume) Event() string { return r.event }
+
+// Key returns the correlation key of the external event
+func (r *ExternalEventResume) Key() string { return r.key }
+
+// Payload returns the JSON payload of the external event
+func (r *ExternalEventResume) Payload() json.RawMessage { return r.payload }
+
+// Apply applies our state changes and saves any events to the run
+func (r *ExternalEventResume) Apply(run flows.FlowRun, logEvent flows.EventCallback) {
+	// clear the last input
+	run.Session().SetInput(nil)
+	logEvent(events.NewExternalEventReceived(r.event, r.key, r.payload))
+
+	r.baseResume.Apply(run, logEvent)
+}
+
+// Context for external event resumes additionally exposes the payload
+func (r *ExternalEventResume) Context(env envs.Environment) map[string]types.XValue {
+	c := r.context()
+	if r.payload != nil {
+		c.payload = types.JSONToXValue(r.payload)
+	}
+	return c.asMap()
+}
+
+var _ flows.Resume = (*ExternalEventResume)(nil)
+
+//------------------------------------------------------------------------------------------
+// JSON Encoding / Decoding
+//------------------------------------------------------------------------------------------
+
+type externalEventResumeEnvelope struct {
+	baseResumeEnvelope
+
+	Event   string          `json:"event" validate:"required"`
+	Key     string          `json:"key,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func readExternalEventResume(sessionAssets flows.SessionAssets, data json.RawMessage, missing assets.MissingCallback) (flows.Resume, error) {
+	e := &externalEventResumeEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return nil, err
+	}
+
+	r := &ExternalEventResume{event: e.Event, key: e.Key, payload: e.Payload}
+
+	if err := r.unmarshal(sessionAssets, &e.baseResumeEnvelope, missing); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// MarshalJSON marshals this resume into JSON
+func (r *ExternalEventResume) MarshalJSON() ([]byte, error) {
+	e := &externalEventResumeEnvelope{Event: r.event, Key: r.key, Payload: r.payload}
+
+	if err := r.marshal(&e.baseResumeEnvelope); err != nil {
+		return nil, err
+	}
+
+	return jsonx.Marshal(e)
+}
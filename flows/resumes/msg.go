@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	registerType(TypeMsg, readMsgResume)
+	registerType(TypeMsg, readMsgResume, &msgResumeEnvelope{})
 }
 
 // TypeMsg is the type for resuming a session with a message
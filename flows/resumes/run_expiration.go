@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeRunExpiration, readRunExpirationResume)
+	registerType(TypeRunExpiration, readRunExpirationResume, &baseResumeEnvelope{})
 }
 
 // TypeRunExpiration is the type for resuming a session when a run has expired
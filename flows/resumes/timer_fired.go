@@ -0,0 +1,87 @@
+package resumes
+
+import (
+	"encoding/json"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/utils"
+)
+
+func init() {
+	registerType(TypeTimerFired, readTimerFiredResume, &baseResumeEnvelope{})
+}
+
+// TypeTimerFired is the type for resuming a session when the timer of a timer wait has fired
+const TypeTimerFired string = "timer_fired"
+
+// TimerFiredResume is used when a session is resumed because the timer of a timer wait has fired
+//
+//   {
+//     "type": "timer_fired",
+//     "contact": {
+//       "uuid": "9f7ede93-4b16-4692-80ad-b7dc54a1cd81",
+//       "name": "Bob",
+//       "created_on": "2018-01-01T12:00:00.000000Z",
+//       "language": "fra",
+//       "fields": {"gender": {"text": "Male"}},
+//       "groups": []
+//     },
+//     "resumed_on": "2000-01-01T00:00:00.000000000-00:00"
+//   }
+//
+// @resume timer_fired
+type TimerFiredResume struct {
+	baseResume
+}
+
+// NewTimerFired creates a new timer fired resume with the passed in values
+func NewTimerFired(env envs.Environment, contact *flows.Contact) *TimerFiredResume {
+	return &TimerFiredResume{
+		baseResume: newBaseResume(TypeTimerFired, env, contact),
+	}
+}
+
+// Apply applies our state changes and saves any events to the run
+func (r *TimerFiredResume) Apply(run flows.FlowRun, logEvent flows.EventCallback) {
+	// clear the last input
+	run.Session().SetInput(nil)
+	logEvent(events.NewTimerFired())
+
+	r.baseResume.Apply(run, logEvent)
+}
+
+var _ flows.Resume = (*TimerFiredResume)(nil)
+
+//------------------------------------------------------------------------------------------
+// JSON Encoding / Decoding
+//------------------------------------------------------------------------------------------
+
+func readTimerFiredResume(sessionAssets flows.SessionAssets, data json.RawMessage, missing assets.MissingCallback) (flows.Resume, error) {
+	e := &baseResumeEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return nil, err
+	}
+
+	r := &TimerFiredResume{}
+
+	if err := r.unmarshal(sessionAssets, e, missing); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// MarshalJSON marshals this resume into JSON
+func (r *TimerFiredResume) MarshalJSON() ([]byte, error) {
+	e := &baseResumeEnvelope{}
+
+	if err := r.marshal(e); err != nil {
+		return nil, err
+	}
+
+	return jsonx.Marshal(e)
+}
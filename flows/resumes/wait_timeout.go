@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeWaitTimeout, readWaitTimeoutResume)
+	registerType(TypeWaitTimeout, readWaitTimeoutResume, &baseResumeEnvelope{})
 }
 
 // TypeWaitTimeout is the type for resuming a session when a wait has timed out
@@ -21,10 +21,12 @@ import (
 type readFunc func(json.RawMessage) (flows.Router, error)
 
 var registeredTypes = map[string]readFunc{}
+var registeredEnvelopes = map[string]interface{}{}
 
-// registers a new type of router
-func registerType(name string, f readFunc) {
+// registers a new type of router and the envelope it is read from
+func registerType(name string, f readFunc, envelope interface{}) {
 	registeredTypes[name] = f
+	registeredEnvelopes[name] = envelope
 }
 
 // RegisteredTypes gets the registered types of router
@@ -36,6 +38,11 @@ func RegisteredTypes() []string {
 	return typeNames
 }
 
+// RegisteredEnvelopes gets empty instances of the envelopes that each registered type of router is read from
+func RegisteredEnvelopes() map[string]interface{} {
+	return registeredEnvelopes
+}
+
 // baseRouter is the base class for all router types
 type baseRouter struct {
 	type_      string
@@ -68,6 +75,9 @@ func (r *baseRouter) ResultName() string { return r.resultName }
 
 // EnumerateTemplates enumerates all expressions on this object and its children
 func (r *baseRouter) EnumerateTemplates(localization flows.Localization, include func(envs.Language, string)) {
+	if r.wait != nil {
+		r.wait.EnumerateTemplates(localization, include)
+	}
 }
 
 // EnumerateDependencies enumerates all dependencies on this object
@@ -199,9 +209,9 @@ func (r *baseRouter) routeToCategory(run flows.FlowRun, step flows.Step, categor
 
 type baseRouterEnvelope struct {
 	Type       string            `json:"type"                  validate:"required"`
-	Wait       json.RawMessage   `json:"wait,omitempty"`
+	Wait       json.RawMessage   `json:"wait,omitempty"                                 jsonschema:"wait"`
 	ResultName string            `json:"result_name,omitempty"`
-	Categories []json.RawMessage `json:"categories,omitempty"  validate:"required,min=1"`
+	Categories []json.RawMessage `json:"categories,omitempty"  validate:"required,min=1" jsonschema:"category"`
 }
 
 // ReadRouter reads a router from the given JSON
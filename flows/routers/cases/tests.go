@@ -60,6 +60,12 @@ var XTESTS = map[string]types.XFunction{
 	"has_date_eq": functions.TextAndDateFunction(HasDateEQ),
 	"has_date_gt": functions.TextAndDateFunction(HasDateGT),
 
+	"has_duration":    functions.OneTextFunction(HasDuration),
+	"has_duration_lt": functions.TextAndDurationFunction(HasDurationLT),
+	"has_duration_gt": functions.TextAndDurationFunction(HasDurationGT),
+
+	"has_money": functions.OneTextFunction(HasMoney),
+
 	"has_time":  functions.OneTextFunction(HasTime),
 	"has_phone": functions.InitialTextFunction(0, 1, HasPhone),
 	"has_email": functions.OneTextFunction(HasEmail),
@@ -496,6 +502,62 @@ func HasTime(env envs.Environment, text types.XText) types.XValue {
 	return FalseResult
 }
 
+// HasDuration tests whether `text` contains a duration
+//
+//   @(has_duration("it took 2 days 3 hours")) -> true
+//   @(has_duration("it took 2 days 3 hours").match) -> P2DT3H
+//   @(has_duration("P1DT12H").match) -> P1DT12H
+//   @(has_duration("it took a while")) -> false
+//
+// @test has_duration(text)
+func HasDuration(env envs.Environment, text types.XText) types.XValue {
+	return testDuration(env, text, types.XDurationZero, isDurationTest)
+}
+
+// HasDurationLT tests whether `text` contains a duration shorter than `max`
+//
+//   @(has_duration_lt("about 40 minutes", "PT1H")) -> true
+//   @(has_duration_lt("about 40 minutes", "PT1H").match) -> PT40M
+//   @(has_duration_lt("about 2 hours", "PT1H")) -> false
+//   @(has_duration_lt(datetime("2017-01-15T12:00:00Z") - datetime("2017-01-15T10:00:00Z"), "PT1H")) -> false
+//   @(has_duration_lt("about 40 minutes", "soon")) -> ERROR
+//
+// @test has_duration_lt(text, max)
+func HasDurationLT(env envs.Environment, text types.XText, duration types.XDuration) types.XValue {
+	return testDuration(env, text, duration, isDurationLTTest)
+}
+
+// HasDurationGT tests whether `text` contains a duration longer than `min`
+//
+//   @(has_duration_gt("it's been 2 weeks", "P7D")) -> true
+//   @(has_duration_gt("it's been 2 weeks", "P7D").match) -> P14D
+//   @(has_duration_gt("it's been 5 days", "P7D")) -> false
+//   @(has_duration_gt(datetime("2017-01-15T12:00:00Z") - datetime("2017-01-15T10:00:00Z"), "PT1H")) -> true
+//   @(has_duration_gt("it's been 5 days", "soon")) -> ERROR
+//
+// @test has_duration_gt(text, min)
+func HasDurationGT(env envs.Environment, text types.XText, duration types.XDuration) types.XValue {
+	return testDuration(env, text, duration, isDurationGTTest)
+}
+
+// HasMoney tests whether `text` contains an amount of money, i.e. a number with a currency code or symbol
+//
+//   @(has_money("it costs $20")) -> true
+//   @(has_money("it costs $20").match) -> 20.00 USD
+//   @(has_money("I sent 20 000 RWF").match) -> 20000 RWF
+//   @(has_money("about 15.5 EUR").match) -> 15.50 EUR
+//   @(has_money("it costs 20")) -> false
+//
+// @test has_money(text)
+func HasMoney(env envs.Environment, text types.XText) types.XValue {
+	money, found := types.FindMoney(env, text.Native())
+	if found {
+		return NewTrueResult(money)
+	}
+
+	return FalseResult
+}
+
 var emailAddressRE = regexp.MustCompile(`([\pL\pN][-_+$~.\pL\pN]*)@([\pL\pN][-_\pL\pN]*)(\.[\pL\pN][-_\pL\pN]*)+`)
 
 // HasEmail tests whether an email is contained in `text`
@@ -928,6 +990,39 @@ func isDateGTTest(value dates.Date, test dates.Date) bool {
 	return value.Compare(test) > 0
 }
 
+//------------------------------------------------------------------------------------------
+// Duration Test helpers
+//------------------------------------------------------------------------------------------
+
+// matches either an ISO 8601 duration or a sequence of amounts and units
+var durationAmountPattern = `\d+(\.\d+)?\s*(weeks?|days?|hours?|hrs?|minutes?|mins?|seconds?|secs?|[wdhms])`
+var durationRegex = regexp.MustCompile(`(?i)-?\bP(\d+[WD])*(T(\d+[HM]|\d+(\.\d+)?S)+)?\b|\b` + durationAmountPattern + `([\s,]*(and\s+)?` + durationAmountPattern + `)*\b`)
+
+type durationTest func(value types.XDuration, test types.XDuration) bool
+
+func testDuration(env envs.Environment, str types.XText, testDuration types.XDuration, testFunc durationTest) types.XValue {
+	for _, candidate := range durationRegex.FindAllString(str.Native(), -1) {
+		value, xerr := types.ToXDuration(env, types.NewXText(candidate))
+		if xerr == nil && testFunc(value, testDuration) {
+			return NewTrueResult(value)
+		}
+	}
+
+	return FalseResult
+}
+
+func isDurationTest(value types.XDuration, test types.XDuration) bool {
+	return true
+}
+
+func isDurationLTTest(value types.XDuration, test types.XDuration) bool {
+	return value.Compare(test) < 0
+}
+
+func isDurationGTTest(value types.XDuration, test types.XDuration) bool {
+	return value.Compare(test) > 0
+}
+
 //------------------------------------------------------------------------------------------
 // Result Test helpers
 //------------------------------------------------------------------------------------------
@@ -14,6 +14,7 @@ import (
 	"github.com/nyaruka/goflow/flows/routers/cases"
 	"github.com/nyaruka/goflow/test"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,7 +24,11 @@ var xn = types.RequireXNumberFromString
 var xi = types.NewXNumberFromInt
 var xd = types.NewXDateTime
 var xt = types.NewXTime
+var xdu = types.NewXDuration
 var xa = types.NewXArray
+var xm = func(amount string, currency envs.Currency) types.XMoney {
+	return types.NewXMoney(decimal.RequireFromString(amount), currency)
+}
 var xj = func(s string) types.XValue { return types.JSONToXValue([]byte(s)) }
 var result = cases.NewTrueResult
 var resultWithExtra = cases.NewTrueResultWithExtra
@@ -246,6 +251,27 @@ var testTests = []struct {
 	{"has_date_gt", []types.XValue{xs("too"), xs("many"), xs("args")}, ERROR},
 	{"has_date_gt", []types.XValue{}, ERROR},
 
+	{"has_duration", []types.XValue{xs("it took 3 days and 2 hours")}, result(xdu(74 * time.Hour))},
+	{"has_duration", []types.XValue{xs("about 1h30m")}, result(xdu(90 * time.Minute))},
+	{"has_duration", []types.XValue{xs("-PT15M")}, result(xdu(-15 * time.Minute))},
+	{"has_duration", []types.XValue{xs("only 3 apples")}, falseResult},
+	{"has_duration", []types.XValue{}, ERROR},
+	{"has_duration_lt", []types.XValue{xs("wait 5 mins or 2 hours"), xs("PT1H")}, result(xdu(5 * time.Minute))},
+	{"has_duration_lt", []types.XValue{xs("wait 2 hours"), xs("PT1H")}, falseResult},
+	{"has_duration_lt", []types.XValue{xs("wait 2 hours"), xs("whenever")}, ERROR},
+	{"has_duration_gt", []types.XValue{xs("wait 5 mins or 2 hours"), xs("PT1H")}, result(xdu(2 * time.Hour))},
+	{"has_duration_gt", []types.XValue{xdu(50 * time.Hour), xs("P2D")}, result(xdu(50 * time.Hour))},
+	{"has_duration_gt", []types.XValue{xs("wait 5 mins"), xdu(time.Hour)}, falseResult},
+	{"has_duration_gt", []types.XValue{xs("wait 5 mins")}, ERROR},
+
+	{"has_money", []types.XValue{xs("it costs $20")}, result(xm("20", "USD"))},
+	{"has_money", []types.XValue{xs("it costs 20 000 RWF")}, result(xm("20000", "RWF"))},
+	{"has_money", []types.XValue{xs("about ksh1,500.50 each")}, result(xm("1500.50", "KES"))},
+	{"has_money", []types.XValue{xs("15.5€")}, result(xm("15.5", "EUR"))},
+	{"has_money", []types.XValue{xs("it costs 20")}, falseResult},
+	{"has_money", []types.XValue{xs("the USD is strong")}, falseResult},
+	{"has_money", []types.XValue{}, ERROR},
+
 	{"has_time", []types.XValue{xs("last time was 10:30")}, result(xt(dates.NewTimeOfDay(10, 30, 0, 0)))},
 	{"has_time", []types.XValue{xs("this isn't a valid time 59:77")}, falseResult},
 	{"has_time", []types.XValue{xs("no time at all")}, falseResult},
@@ -468,7 +494,7 @@ func TestTests(t *testing.T) {
 	locations, err := envs.ReadLocationHierarchy([]byte(locationHierarchyJSON))
 	require.NoError(t, err)
 
-	env = flows.NewEnvironment(env, flows.NewLocationAssets([]assets.LocationHierarchy{locations}))
+	env = flows.NewEnvironment(env, flows.NewLocationAssets([]assets.LocationHierarchy{locations}), nil)
 
 	for _, tc := range testTests {
 		testID := fmt.Sprintf("%s(%#v)", tc.name, tc.args)
@@ -487,6 +513,37 @@ func TestTests(t *testing.T) {
 	}
 }
 
+func TestDateTestsWithCalendar(t *testing.T) {
+	dates.SetNowSource(dates.NewFixedNowSource(time.Date(2018, 4, 11, 13, 24, 30, 123456000, time.UTC)))
+	defer dates.SetNowSource(dates.DefaultNowSource)
+
+	env := envs.NewBuilder().
+		WithDateFormat(envs.DateFormatDayMonthYear).
+		WithCalendar(envs.CalendarBikramSambat).
+		WithTimezone(kgl).
+		Build()
+
+	calendarTests := []struct {
+		name     string
+		args     []types.XValue
+		expected types.XValue
+	}{
+		{"has_date", []types.XValue{xs("born on 3/6/2072")}, result(xd(time.Date(2015, 9, 20, 15, 24, 30, 123456000, kgl)))},
+		{"has_date", []types.XValue{xs("born on 32/6/2072")}, falseResult},
+		{"has_date_lt", []types.XValue{xs("born on 3/6/2072"), xs("1/1/2073")}, result(xd(time.Date(2015, 9, 20, 15, 24, 30, 123456000, kgl)))},
+		{"has_date_lt", []types.XValue{xs("born on 3/6/2072"), xs("1/1/2072")}, falseResult},
+		{"has_date_eq", []types.XValue{xs("born on 3/6/72"), xs("2015-09-20")}, result(xd(time.Date(2015, 9, 20, 15, 24, 30, 123456000, kgl)))},
+		{"has_date_gt", []types.XValue{xs("born on 3/6/2072"), xs("1/1/2073")}, falseResult},
+	}
+
+	for _, tc := range calendarTests {
+		testID := fmt.Sprintf("%s(%#v)", tc.name, tc.args)
+
+		result := cases.XTESTS[tc.name](env, tc.args...)
+		test.AssertXEqual(t, tc.expected, result, "result mismatch for %s", testID)
+	}
+}
+
 func TestEvaluateTemplate(t *testing.T) {
 	vars := types.NewXObject(map[string]types.XValue{
 		"int1":   types.NewXNumberFromInt(1),
@@ -39,6 +39,14 @@ type categoryEnvelope struct {
 	ExitUUID flows.ExitUUID     `json:"exit_uuid,omitempty" validate:"required,uuid4"`
 }
 
+// SchemaEnvelopes gets empty instances of the envelopes that router components are read from, keyed by name
+func SchemaEnvelopes() map[string]interface{} {
+	return map[string]interface{}{
+		"category": &categoryEnvelope{},
+		"case":     &Case{},
+	}
+}
+
 // ReadCategory unmarshals a router category from the given JSON
 func ReadCategory(data []byte) (flows.Category, error) {
 	e := &categoryEnvelope{}
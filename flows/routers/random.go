@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeRandom, readRandomRouter)
+	registerType(TypeRandom, readRandomRouter, &baseRouterEnvelope{})
 }
 
 // TypeRandom is the type for a random router
@@ -19,7 +19,7 @@ import (
 )
 
 func init() {
-	registerType(TypeSwitch, readSwitchRouter)
+	registerType(TypeSwitch, readSwitchRouter, &switchRouterEnvelope{})
 }
 
 // TypeSwitch is the constant for our switch router
@@ -217,6 +217,8 @@ func (r *SwitchRouter) EnumerateTemplates(localization flows.Localization, inclu
 	include(envs.NilLanguage, r.operand)
 
 	inspect.Templates(r.cases, localization, include)
+
+	r.baseRouter.EnumerateTemplates(localization, include)
 }
 
 // EnumerateDependencies enumerates all dependencies on this object and its children
@@ -239,7 +241,7 @@ type switchRouterEnvelope struct {
 	baseRouterEnvelope
 
 	Operand             string             `json:"operand"               validate:"required"`
-	Cases               []*Case            `json:"cases"`
+	Cases               []*Case            `json:"cases"                                     jsonschema:"case"`
 	DefaultCategoryUUID flows.CategoryUUID `json:"default_category_uuid" validate:"omitempty,uuid4"`
 }
 
@@ -3,6 +3,7 @@ package waits
 import (
 	"encoding/json"
 
+	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/flows"
 	"github.com/nyaruka/goflow/utils"
 
@@ -14,11 +15,18 @@ type readActivatedFunc func(data json.RawMessage) (flows.ActivatedWait, error)
 
 var registeredTypes = map[string]readFunc{}
 var registeredActivatedTypes = map[string]readActivatedFunc{}
+var registeredEnvelopes = map[string]interface{}{}
 
-// RegisterType registers a new type of wait
-func registerType(name string, f1 readFunc, f2 readActivatedFunc) {
+// RegisterType registers a new type of wait and the envelope it is read from
+func registerType(name string, f1 readFunc, f2 readActivatedFunc, envelope interface{}) {
 	registeredTypes[name] = f1
 	registeredActivatedTypes[name] = f2
+	registeredEnvelopes[name] = envelope
+}
+
+// RegisteredEnvelopes gets empty instances of the envelopes that each registered type of wait is read from
+func RegisteredEnvelopes() map[string]interface{} {
+	return registeredEnvelopes
 }
 
 type Timeout struct {
@@ -51,6 +59,10 @@ func (w *baseWait) Type() string { return w.type_ }
 // Timeout returns the timeout of this wait or nil if no timeout is set
 func (w *baseWait) Timeout() flows.Timeout { return w.timeout }
 
+// EnumerateTemplates enumerates all expressions on this object
+func (w *baseWait) EnumerateTemplates(localization flows.Localization, include func(envs.Language, string)) {
+}
+
 func (w *baseWait) resumeTypeError(r flows.Resume) error {
 	return errors.Errorf("can't end a wait of type '%s' with a resume of type '%s'", w.type_, r.Type())
 }
@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeDial, readDialWait, readActivatedDialWait)
+	registerType(TypeDial, readDialWait, readActivatedDialWait, &dialWaitEnvelope{})
 }
 
 // TypeDial is the type of our dial wait
@@ -39,7 +39,7 @@ func (w *DialWait) AllowedFlowTypes() []flows.FlowType {
 }
 
 // Begin beings waiting at this wait
-func (w *DialWait) Begin(run flows.FlowRun, log flows.EventCallback) flows.ActivatedWait {
+func (w *DialWait) Begin(run flows.FlowRun, log flows.EventCallback) (flows.ActivatedWait, error) {
 	phone, err := run.EvaluateTemplate(w.phone)
 	if err != nil {
 		log(events.NewError(err))
@@ -48,16 +48,16 @@ func (w *DialWait) Begin(run flows.FlowRun, log flows.EventCallback) flows.Activ
 	urn, err := urns.NewTelURNForCountry(phone, string(run.Environment().DefaultCountry()))
 	if err != nil {
 		log(events.NewError(err))
-		return nil
+		return nil, nil
 	}
 
 	log(events.NewDialWait(urn))
 
-	return NewActivatedDialWait(urn)
+	return NewActivatedDialWait(urn), nil
 }
 
 // End ends this wait or returns an error
-func (w *DialWait) End(resume flows.Resume) error {
+func (w *DialWait) End(activated flows.ActivatedWait, resume flows.Resume) error {
 	if resume.Type() == resumes.TypeDial {
 		return nil
 	}
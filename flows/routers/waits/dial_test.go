@@ -34,7 +34,8 @@ func TestDialWait(t *testing.T) {
 
 	// try activating the wait
 	log := test.NewEventLog()
-	activated := wait.Begin(run, log.Log)
+	activated, err := wait.Begin(run, log.Log)
+	require.NoError(t, err)
 
 	assert.Equal(t, "dial", activated.Type())
 	assert.Equal(t, 1, len(log.Events))
@@ -46,18 +47,19 @@ func TestDialWait(t *testing.T) {
 	assert.Equal(t, `{"type":"dial","urn":"tel:+593979123456"}`, string(marshaled))
 
 	// try to end with incorrect resume type
-	err = wait.End(resumes.NewWaitTimeout(nil, nil))
+	err = wait.End(activated, resumes.NewWaitTimeout(nil, nil))
 	assert.EqualError(t, err, "can't end a wait of type 'dial' with a resume of type 'wait_timeout'")
 
 	// try to end with dial resume type
-	err = wait.End(resumes.NewDial(nil, nil, flows.NewDial(flows.DialStatusAnswered, 5)))
+	err = wait.End(activated, resumes.NewDial(nil, nil, flows.NewDial(flows.DialStatusAnswered, 5)))
 	assert.NoError(t, err)
 
 	// try when wait has expression error but still generates valid tel URN
 	wait, err = waits.ReadWait([]byte(`{"type": "dial", "phone": "+593979123456@(1 / 0)"}`))
 
 	log = test.NewEventLog()
-	activated = wait.Begin(run, log.Log)
+	activated, err = wait.Begin(run, log.Log)
+	require.NoError(t, err)
 
 	assert.Equal(t, "dial", activated.Type())
 	assert.Equal(t, urns.URN("tel:+593979123456"), activated.(*waits.ActivatedDialWait).URN())
@@ -69,7 +71,8 @@ func TestDialWait(t *testing.T) {
 	wait, err = waits.ReadWait([]byte(`{"type": "dial", "phone": "@(\"\")"}`))
 
 	log = test.NewEventLog()
-	activated = wait.Begin(run, log.Log)
+	activated, err = wait.Begin(run, log.Log)
+	require.NoError(t, err)
 
 	assert.Nil(t, activated)
 	assert.Equal(t, 1, len(log.Events))
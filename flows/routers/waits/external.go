@@ -0,0 +1,190 @@
+package waits
+
+import (
+	"encoding/json"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registerType(TypeExternal, readExternalWait, readActivatedExternalWait, &externalWaitEnvelope{})
+}
+
+// TypeExternal is the type of our external wait
+const TypeExternal string = "external"
+
+// ExternalWait is a wait which waits for a named event from an external system (e.g. a payment being completed),
+// optionally with a correlation key evaluated from an expression, which callers can use to find the session to
+// resume with an external_event resume.
+type ExternalWait struct {
+	baseWait
+
+	event string
+	key   string
+}
+
+// NewExternalWait creates a new external wait
+func NewExternalWait(event, key string, timeout *Timeout) *ExternalWait {
+	return &ExternalWait{
+		baseWait: newBaseWait(TypeExternal, timeout),
+		event:    event,
+		key:      key,
+	}
+}
+
+// Event returns the name of the external event this wait is waiting for
+func (w *ExternalWait) Event() string { return w.event }
+
+// Key returns the correlation key expression of this wait
+func (w *ExternalWait) Key() string { return w.key }
+
+// AllowedFlowTypes returns the flow types which this wait is allowed to occur in
+func (w *ExternalWait) AllowedFlowTypes() []flows.FlowType {
+	return []flows.FlowType{flows.FlowTypeMessaging, flows.FlowTypeVoice}
+}
+
+// Begin beings waiting at this wait
+func (w *ExternalWait) Begin(run flows.FlowRun, log flows.EventCallback) (flows.ActivatedWait, error) {
+	var timeoutSeconds *int
+	if w.timeout != nil {
+		seconds := w.timeout.Seconds()
+		timeoutSeconds = &seconds
+	}
+
+	var key string
+	if w.key != "" {
+		var err error
+		key, err = run.EvaluateTemplate(w.key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log(events.NewExternalWait(w.event, key, timeoutSeconds))
+
+	return NewActivatedExternalWait(w.event, key, timeoutSeconds), nil
+}
+
+// EnumerateTemplates enumerates all expressions on this object
+func (w *ExternalWait) EnumerateTemplates(localization flows.Localization, include func(envs.Language, string)) {
+	include(envs.NilLanguage, w.key)
+}
+
+// End ends this wait or returns an error
+func (w *ExternalWait) End(activated flows.ActivatedWait, resume flows.Resume) error {
+	switch resume.Type() {
+	case resumes.TypeExternalEvent:
+		typed := resume.(*resumes.ExternalEventResume)
+		if typed.Event() != w.event {
+			return errors.Errorf("can't end with external event '%s' as wait is for '%s'", typed.Event(), w.event)
+		}
+		if key := activated.(*ActivatedExternalWait).Key(); typed.Key() != key {
+			return errors.Errorf("can't end with external event key '%s' as wait is for key '%s'", typed.Key(), key)
+		}
+		return nil
+	case resumes.TypeRunExpiration:
+		return nil
+	case resumes.TypeWaitTimeout:
+		if w.timeout == nil {
+			return errors.Errorf("can't end with timeout as wait doesn't have a timeout")
+		}
+		return nil
+	}
+	return w.resumeTypeError(resume)
+}
+
+var _ flows.Wait = (*ExternalWait)(nil)
+
+// ActivatedExternalWait is an external wait once it has been activated with an evaluated key
+type ActivatedExternalWait struct {
+	baseActivatedWait
+
+	event string
+	key   string
+}
+
+// NewActivatedExternalWait creates a new activated external wait
+func NewActivatedExternalWait(event, key string, timeoutSeconds *int) *ActivatedExternalWait {
+	return &ActivatedExternalWait{
+		baseActivatedWait: baseActivatedWait{type_: TypeExternal, timeoutSeconds: timeoutSeconds},
+		event:             event,
+		key:               key,
+	}
+}
+
+// Event returns the name of the external event this wait is waiting for
+func (w *ActivatedExternalWait) Event() string { return w.event }
+
+// Key returns the evaluated correlation key of this wait
+func (w *ActivatedExternalWait) Key() string { return w.key }
+
+var _ flows.ActivatedWait = (*ActivatedExternalWait)(nil)
+
+//------------------------------------------------------------------------------------------
+// JSON Encoding / Decoding
+//------------------------------------------------------------------------------------------
+
+type externalWaitEnvelope struct {
+	baseWaitEnvelope
+
+	Event string `json:"event" validate:"required"`
+	Key   string `json:"key,omitempty"`
+}
+
+func readExternalWait(data json.RawMessage) (flows.Wait, error) {
+	e := &externalWaitEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return nil, err
+	}
+
+	w := &ExternalWait{event: e.Event, key: e.Key}
+
+	return w, w.unmarshal(&e.baseWaitEnvelope)
+}
+
+// MarshalJSON marshals this wait into JSON
+func (w *ExternalWait) MarshalJSON() ([]byte, error) {
+	e := &externalWaitEnvelope{Event: w.event, Key: w.key}
+
+	if err := w.marshal(&e.baseWaitEnvelope); err != nil {
+		return nil, err
+	}
+
+	return jsonx.Marshal(e)
+}
+
+type activatedExternalWaitEnvelope struct {
+	baseActivatedWaitEnvelope
+
+	Event string `json:"event" validate:"required"`
+	Key   string `json:"key,omitempty"`
+}
+
+func readActivatedExternalWait(data json.RawMessage) (flows.ActivatedWait, error) {
+	e := &activatedExternalWaitEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return nil, err
+	}
+
+	w := &ActivatedExternalWait{event: e.Event, key: e.Key}
+
+	return w, w.unmarshal(&e.baseActivatedWaitEnvelope)
+}
+
+// MarshalJSON marshals this wait into JSON
+func (w *ActivatedExternalWait) MarshalJSON() ([]byte, error) {
+	e := &activatedExternalWaitEnvelope{Event: w.event, Key: w.key}
+
+	if err := w.marshal(&e.baseActivatedWaitEnvelope); err != nil {
+		return nil, err
+	}
+
+	return jsonx.Marshal(e)
+}
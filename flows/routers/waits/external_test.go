@@ -0,0 +1,99 @@
+package waits_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/flows/routers/waits"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalWait(t *testing.T) {
+	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
+	require.NoError(t, err)
+	run := session.Runs()[0]
+
+	// event field required
+	_, err = waits.ReadWait([]byte(`{"type": "external"}`))
+	assert.EqualError(t, err, "field 'event' is required")
+
+	wait, err := waits.ReadWait([]byte(`{"type": "external", "event": "payment_completed", "key": "ORD-@contact.id", "timeout": {"seconds": 3600, "category_uuid": "c82e161f-fa2d-4e7d-a338-c27f6c349445"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, waits.TypeExternal, wait.Type())
+	assert.Equal(t, "payment_completed", wait.(*waits.ExternalWait).Event())
+	assert.Equal(t, "ORD-@contact.id", wait.(*waits.ExternalWait).Key())
+	assert.Equal(t, 3600, wait.Timeout().Seconds())
+
+	// test marshalling definition wait
+	marshaled, err := jsonx.Marshal(wait)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"external","timeout":{"seconds":3600,"category_uuid":"c82e161f-fa2d-4e7d-a338-c27f6c349445"},"event":"payment_completed","key":"ORD-@contact.id"}`, string(marshaled))
+
+	// try activating the wait
+	log := test.NewEventLog()
+	activated, err := wait.Begin(run, log.Log)
+	require.NoError(t, err)
+
+	assert.Equal(t, "external", activated.Type())
+	assert.Equal(t, 3600, *activated.TimeoutSeconds())
+	assert.Equal(t, "payment_completed", activated.(*waits.ActivatedExternalWait).Event())
+	assert.Equal(t, "ORD-1234567", activated.(*waits.ActivatedExternalWait).Key())
+	assert.Equal(t, 1, len(log.Events))
+	assert.Equal(t, "external_wait", log.Events[0].Type())
+
+	// test marshalling activated wait
+	marshaled, err = jsonx.Marshal(activated)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"external","timeout_seconds":3600,"event":"payment_completed","key":"ORD-1234567"}`, string(marshaled))
+
+	// and reading it back
+	activated, err = waits.ReadActivatedWait(marshaled)
+	require.NoError(t, err)
+	assert.Equal(t, "ORD-1234567", activated.(*waits.ActivatedExternalWait).Key())
+
+	// try to end with incorrect resume type
+	err = wait.End(activated, resumes.NewTimerFired(nil, nil))
+	assert.EqualError(t, err, "can't end a wait of type 'external' with a resume of type 'timer_fired'")
+
+	// or external event with different name
+	err = wait.End(activated, resumes.NewExternalEvent(nil, nil, "document_signed", "ORD-1234567", nil))
+	assert.EqualError(t, err, "can't end with external event 'document_signed' as wait is for 'payment_completed'")
+
+	// or external event with different key
+	err = wait.End(activated, resumes.NewExternalEvent(nil, nil, "payment_completed", "ORD-7654321", nil))
+	assert.EqualError(t, err, "can't end with external event key 'ORD-7654321' as wait is for key 'ORD-1234567'")
+
+	// try to end with valid resumes
+	assert.NoError(t, wait.End(activated, resumes.NewExternalEvent(nil, nil, "payment_completed", "ORD-1234567", []byte(`{"status": "paid"}`))))
+	assert.NoError(t, wait.End(activated, resumes.NewWaitTimeout(nil, nil)))
+	assert.NoError(t, wait.End(activated, resumes.NewRunExpiration(nil, nil)))
+
+	// key is optional, and timeouts can only be used if wait has one
+	wait = waits.NewExternalWait("document_signed", "", nil)
+
+	log = test.NewEventLog()
+	activated, err = wait.Begin(run, log.Log)
+	require.NoError(t, err)
+
+	marshaled, err = jsonx.Marshal(activated)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"external","event":"document_signed"}`, string(marshaled))
+
+	err = wait.End(activated, resumes.NewWaitTimeout(nil, nil))
+	assert.EqualError(t, err, "can't end with timeout as wait doesn't have a timeout")
+
+	// key expression errors prevent the wait from being activated
+	wait = waits.NewExternalWait("document_signed", "@(1 / 0)", nil)
+
+	log = test.NewEventLog()
+	activated, err = wait.Begin(run, log.Log)
+	assert.EqualError(t, err, "error evaluating @(1 / 0): division by zero")
+
+	assert.Nil(t, activated)
+	assert.Equal(t, 0, len(log.Events))
+}
@@ -15,7 +15,7 @@ import (
 )
 
 func init() {
-	registerType(TypeMsg, readMsgWait, readActivatedMsgWait)
+	registerType(TypeMsg, readMsgWait, readActivatedMsgWait, &msgWaitEnvelope{})
 }
 
 // TypeMsg is the type of our message wait
@@ -49,7 +49,7 @@ func (w *MsgWait) AllowedFlowTypes() []flows.FlowType {
 }
 
 // Begin beings waiting at this wait
-func (w *MsgWait) Begin(run flows.FlowRun, log flows.EventCallback) flows.ActivatedWait {
+func (w *MsgWait) Begin(run flows.FlowRun, log flows.EventCallback) (flows.ActivatedWait, error) {
 	var timeoutSeconds *int
 
 	if w.timeout != nil {
@@ -61,16 +61,16 @@ func (w *MsgWait) Begin(run flows.FlowRun, log flows.EventCallback) flows.Activa
 	triggerHasMsg := run.Session().Trigger().Type() == triggers.TypeMsg
 
 	if triggerHasMsg && len(run.Session().Runs()) == 1 && len(run.Path()) == 1 {
-		return nil
+		return nil, nil
 	}
 
 	log(events.NewMsgWait(timeoutSeconds, w.hint))
 
-	return NewActivatedMsgWait(timeoutSeconds, w.hint)
+	return NewActivatedMsgWait(timeoutSeconds, w.hint), nil
 }
 
 // End ends this wait or returns an error
-func (w *MsgWait) End(resume flows.Resume) error {
+func (w *MsgWait) End(activated flows.ActivatedWait, resume flows.Resume) error {
 	switch resume.Type() {
 	case resumes.TypeMsg, resumes.TypeRunExpiration:
 		return nil
@@ -110,7 +110,7 @@ var _ flows.ActivatedWait = (*ActivatedMsgWait)(nil)
 type msgWaitEnvelope struct {
 	baseWaitEnvelope
 
-	Hint json.RawMessage `json:"hint,omitempty"`
+	Hint json.RawMessage `json:"hint,omitempty" jsonschema:"hint"`
 }
 
 func readMsgWait(data json.RawMessage) (flows.Wait, error) {
@@ -78,7 +78,8 @@ func TestMsgWait(t *testing.T) {
 
 	// try activating the wait
 	log := test.NewEventLog()
-	activated := wait.Begin(run, log.Log)
+	activated, err := wait.Begin(run, log.Log)
+	require.NoError(t, err)
 
 	assert.Equal(t, "msg", activated.Type())
 	assert.Equal(t, 1, len(log.Events))
@@ -90,11 +91,11 @@ func TestMsgWait(t *testing.T) {
 	assert.Equal(t, `{"type":"msg","timeout_seconds":5,"hint":{"type":"image"}}`, string(marshaled))
 
 	// try to end with incorrect resume type
-	err = wait.End(resumes.NewDial(nil, nil, flows.NewDial(flows.DialStatusBusy, 0)))
+	err = wait.End(activated, resumes.NewDial(nil, nil, flows.NewDial(flows.DialStatusBusy, 0)))
 	assert.EqualError(t, err, "can't end a wait of type 'msg' with a resume of type 'dial'")
 
 	// try to end with timeout resume type
-	err = wait.End(resumes.NewWaitTimeout(nil, nil))
+	err = wait.End(activated, resumes.NewWaitTimeout(nil, nil))
 	assert.NoError(t, err)
 }
 
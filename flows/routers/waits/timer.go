@@ -0,0 +1,197 @@
+package waits
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/excellent/types"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registerType(TypeTimer, readTimerWait, readActivatedTimerWait, &timerWaitEnvelope{})
+}
+
+// TypeTimer is the type of our timer wait
+const TypeTimer string = "timer"
+
+// TimerWait is a wait which pauses the flow until a time computed from its delay expression, which can evaluate
+// to a duration (e.g. "2 days") relative to when the wait begins, or an absolute datetime. It requires no input
+// and is ended by a timer_fired resume. Incoming messages should be queued by the caller until then, unless the
+// wait allows them to break it, in which case the wait can also be ended by a msg resume.
+type TimerWait struct {
+	baseWait
+
+	delay      string
+	breakOnMsg bool
+}
+
+// NewTimerWait creates a new timer wait
+func NewTimerWait(delay string, breakOnMsg bool) *TimerWait {
+	return &TimerWait{
+		baseWait:   newBaseWait(TypeTimer, nil),
+		delay:      delay,
+		breakOnMsg: breakOnMsg,
+	}
+}
+
+// Delay returns the delay expression of this wait
+func (w *TimerWait) Delay() string { return w.delay }
+
+// BreakOnMsg returns whether incoming messages can end this wait
+func (w *TimerWait) BreakOnMsg() bool { return w.breakOnMsg }
+
+// AllowedFlowTypes returns the flow types which this wait is allowed to occur in
+func (w *TimerWait) AllowedFlowTypes() []flows.FlowType {
+	return []flows.FlowType{flows.FlowTypeMessaging, flows.FlowTypeMessagingOffline}
+}
+
+// Begin beings waiting at this wait. If the delay can't be evaluated then an error is returned rather than skipping
+// the wait, as continuing immediately could be worse than not continuing at all.
+func (w *TimerWait) Begin(run flows.FlowRun, log flows.EventCallback) (flows.ActivatedWait, error) {
+	firesOn, err := w.evaluateFiresOn(run)
+	if err != nil {
+		return nil, err
+	}
+
+	log(events.NewTimerWait(firesOn, w.breakOnMsg))
+
+	return NewActivatedTimerWait(firesOn, w.breakOnMsg), nil
+}
+
+func (w *TimerWait) evaluateFiresOn(run flows.FlowRun) (time.Time, error) {
+	value, err := run.EvaluateTemplateValue(w.delay)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	env := run.Environment()
+
+	if duration, xerr := types.ToXDuration(env, value); xerr == nil {
+		return dates.Now().Add(duration.Native()), nil
+	}
+	if datetime, xerr := types.ToXDateTime(env, value); xerr == nil {
+		return datetime.Native(), nil
+	}
+
+	return time.Time{}, errors.Errorf("delay evaluated to %s which isn't a duration or datetime", types.Describe(value))
+}
+
+// EnumerateTemplates enumerates all expressions on this object
+func (w *TimerWait) EnumerateTemplates(localization flows.Localization, include func(envs.Language, string)) {
+	include(envs.NilLanguage, w.delay)
+}
+
+// End ends this wait or returns an error
+func (w *TimerWait) End(activated flows.ActivatedWait, resume flows.Resume) error {
+	switch resume.Type() {
+	case resumes.TypeTimerFired, resumes.TypeRunExpiration:
+		return nil
+	case resumes.TypeMsg:
+		if !w.breakOnMsg {
+			return errors.Errorf("can't end with msg as wait doesn't allow messages to break it")
+		}
+		return nil
+	}
+	return w.resumeTypeError(resume)
+}
+
+var _ flows.Wait = (*TimerWait)(nil)
+
+// ActivatedTimerWait is a timer wait once it has been activated with a fire time
+type ActivatedTimerWait struct {
+	baseActivatedWait
+
+	firesOn    time.Time
+	breakOnMsg bool
+}
+
+// NewActivatedTimerWait creates a new activated timer wait
+func NewActivatedTimerWait(firesOn time.Time, breakOnMsg bool) *ActivatedTimerWait {
+	return &ActivatedTimerWait{
+		baseActivatedWait: baseActivatedWait{type_: TypeTimer},
+		firesOn:           firesOn,
+		breakOnMsg:        breakOnMsg,
+	}
+}
+
+// FiresOn returns when the caller should resume the session with a timer_fired resume
+func (w *ActivatedTimerWait) FiresOn() time.Time { return w.firesOn }
+
+// BreakOnMsg returns whether incoming messages can end this wait
+func (w *ActivatedTimerWait) BreakOnMsg() bool { return w.breakOnMsg }
+
+var _ flows.ActivatedWait = (*ActivatedTimerWait)(nil)
+
+//------------------------------------------------------------------------------------------
+// JSON Encoding / Decoding
+//------------------------------------------------------------------------------------------
+
+type timerWaitEnvelope struct {
+	baseWaitEnvelope
+
+	Delay      string `json:"delay" validate:"required"`
+	BreakOnMsg bool   `json:"break_on_msg,omitempty"`
+}
+
+func readTimerWait(data json.RawMessage) (flows.Wait, error) {
+	e := &timerWaitEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return nil, err
+	}
+	if e.Timeout != nil {
+		return nil, errors.New("timer waits can't have a timeout")
+	}
+
+	w := &TimerWait{delay: e.Delay, breakOnMsg: e.BreakOnMsg}
+
+	return w, w.unmarshal(&e.baseWaitEnvelope)
+}
+
+// MarshalJSON marshals this wait into JSON
+func (w *TimerWait) MarshalJSON() ([]byte, error) {
+	e := &timerWaitEnvelope{Delay: w.delay, BreakOnMsg: w.breakOnMsg}
+
+	if err := w.marshal(&e.baseWaitEnvelope); err != nil {
+		return nil, err
+	}
+
+	return jsonx.Marshal(e)
+}
+
+type activatedTimerWaitEnvelope struct {
+	baseActivatedWaitEnvelope
+
+	FiresOn    time.Time `json:"fires_on" validate:"required"`
+	BreakOnMsg bool      `json:"break_on_msg,omitempty"`
+}
+
+func readActivatedTimerWait(data json.RawMessage) (flows.ActivatedWait, error) {
+	e := &activatedTimerWaitEnvelope{}
+	if err := utils.UnmarshalAndValidate(data, e); err != nil {
+		return nil, err
+	}
+
+	w := &ActivatedTimerWait{firesOn: e.FiresOn, breakOnMsg: e.BreakOnMsg}
+
+	return w, w.unmarshal(&e.baseActivatedWaitEnvelope)
+}
+
+// MarshalJSON marshals this wait into JSON
+func (w *ActivatedTimerWait) MarshalJSON() ([]byte, error) {
+	e := &activatedTimerWaitEnvelope{FiresOn: w.firesOn, BreakOnMsg: w.breakOnMsg}
+
+	if err := w.marshal(&e.baseActivatedWaitEnvelope); err != nil {
+		return nil, err
+	}
+
+	return jsonx.Marshal(e)
+}
@@ -0,0 +1,110 @@
+package waits_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/resumes"
+	"github.com/nyaruka/goflow/flows/routers/waits"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerWait(t *testing.T) {
+	dates.SetNowSource(dates.NewFixedNowSource(time.Date(2018, 4, 11, 13, 24, 30, 0, time.UTC)))
+	defer dates.SetNowSource(dates.DefaultNowSource)
+
+	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
+	require.NoError(t, err)
+	run := session.Runs()[0]
+
+	// delay field required
+	_, err = waits.ReadWait([]byte(`{"type": "timer"}`))
+	assert.EqualError(t, err, "field 'delay' is required")
+
+	// and can't have a timeout
+	_, err = waits.ReadWait([]byte(`{"type": "timer", "delay": "2 days", "timeout": {"seconds": 60, "category_uuid": "c82e161f-fa2d-4e7d-a338-c27f6c349445"}}`))
+	assert.EqualError(t, err, "timer waits can't have a timeout")
+
+	wait, err := waits.ReadWait([]byte(`{"type": "timer", "delay": "@(\"2 days\")"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, waits.TypeTimer, wait.Type())
+	assert.Equal(t, `@("2 days")`, wait.(*waits.TimerWait).Delay())
+	assert.False(t, wait.(*waits.TimerWait).BreakOnMsg())
+	assert.Nil(t, wait.Timeout())
+
+	// test marshalling definition wait
+	marshaled, err := jsonx.Marshal(wait)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"timer","delay":"@(\"2 days\")"}`, string(marshaled))
+
+	// try activating the wait
+	log := test.NewEventLog()
+	activated, err := wait.Begin(run, log.Log)
+	require.NoError(t, err)
+
+	assert.Equal(t, "timer", activated.Type())
+	assert.Nil(t, activated.TimeoutSeconds())
+	assert.Equal(t, time.Date(2018, 4, 13, 13, 24, 30, 0, time.UTC), activated.(*waits.ActivatedTimerWait).FiresOn())
+	assert.Equal(t, 1, len(log.Events))
+	assert.Equal(t, "timer_wait", log.Events[0].Type())
+
+	// test marshalling activated wait
+	marshaled, err = jsonx.Marshal(activated)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"timer","fires_on":"2018-04-13T13:24:30Z"}`, string(marshaled))
+
+	// and reading it back
+	activated, err = waits.ReadActivatedWait(marshaled)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 4, 13, 13, 24, 30, 0, time.UTC), activated.(*waits.ActivatedTimerWait).FiresOn())
+
+	msg := flows.NewMsgIn(flows.MsgUUID("8e6f0213-a122-4c50-a430-442085754c16"), urns.NilURN, nil, "Hi", nil)
+
+	// try to end with incorrect resume types
+	err = wait.End(activated, resumes.NewWaitTimeout(nil, nil))
+	assert.EqualError(t, err, "can't end a wait of type 'timer' with a resume of type 'wait_timeout'")
+	err = wait.End(activated, resumes.NewMsg(nil, nil, msg))
+	assert.EqualError(t, err, "can't end with msg as wait doesn't allow messages to break it")
+
+	// try to end with timer fired and run expiration resumes
+	assert.NoError(t, wait.End(activated, resumes.NewTimerFired(nil, nil)))
+	assert.NoError(t, wait.End(activated, resumes.NewRunExpiration(nil, nil)))
+
+	// delay can also be a datetime and wait can allow messages to break it
+	wait, err = waits.ReadWait([]byte(`{"type": "timer", "delay": "@(datetime(\"2018-05-01T12:00:00Z\"))", "break_on_msg": true}`))
+	require.NoError(t, err)
+
+	marshaled, err = jsonx.Marshal(wait)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"timer","delay":"@(datetime(\"2018-05-01T12:00:00Z\"))","break_on_msg":true}`, string(marshaled))
+
+	log = test.NewEventLog()
+	activated, err = wait.Begin(run, log.Log)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC), activated.(*waits.ActivatedTimerWait).FiresOn().UTC())
+	assert.True(t, activated.(*waits.ActivatedTimerWait).BreakOnMsg())
+	assert.NoError(t, wait.End(activated, resumes.NewMsg(nil, nil, msg)))
+
+	marshaled, err = jsonx.Marshal(activated)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"timer","fires_on":"2018-05-01T12:00:00Z","break_on_msg":true}`, string(marshaled))
+
+	// if delay isn't a duration or datetime, wait isn't activated and an error is returned
+	wait = waits.NewTimerWait("@(1 + 2)", false)
+
+	log = test.NewEventLog()
+	activated, err = wait.Begin(run, log.Log)
+
+	assert.EqualError(t, err, "delay evaluated to 3 which isn't a duration or datetime")
+	assert.Nil(t, activated)
+	assert.Equal(t, 0, len(log.Events))
+}
@@ -17,7 +17,7 @@ type runEnvironment struct {
 // creates a run environment based on the given run
 func newRunEnvironment(base envs.Environment, run *flowRun) envs.Environment {
 	return &runEnvironment{
-		flows.NewEnvironment(base, run.Session().Assets().Locations()),
+		flows.NewEnvironment(base, run.Session().Assets().Locations(), run.Session().Assets().ExchangeRates()),
 		run,
 	}
 }
@@ -2,6 +2,7 @@ package runs
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/nyaruka/gocommon/dates"
@@ -341,14 +342,28 @@ func (r *flowRun) nodeContext(env envs.Environment) map[string]types.XValue {
 func (r *flowRun) EvaluateTemplateValue(template string) (types.XValue, error) {
 	context := types.NewXObject(r.RootContext(r.Environment()))
 
-	return excellent.EvaluateTemplateValue(r.Environment(), context, template)
+	return r.compileTemplate(strings.TrimSpace(template)).EvaluateValue(r.Environment(), context)
 }
 
 // EvaluateTemplateText evaluates the given template as text in the context of this run
 func (r *flowRun) EvaluateTemplateText(template string, escaping excellent.Escaping, truncate bool) (string, error) {
 	context := types.NewXObject(r.RootContext(r.Environment()))
 
-	value, err := excellent.EvaluateTemplate(r.Environment(), context, template, escaping)
+	compiled := r.compileTemplate(template)
+
+	var value string
+	var err error
+
+	if r.Session().Engine().TraceTemplates() {
+		var trace *excellent.Trace
+		value, trace, err = compiled.EvaluateWithTrace(r.Environment(), context, escaping)
+		if err != nil {
+			err = excellent.NewTracedError(err, trace)
+		}
+	} else {
+		value, err = compiled.Evaluate(r.Environment(), context, escaping)
+	}
+
 	if truncate {
 		value = utils.TruncateEllipsis(value, r.Session().Engine().MaxTemplateChars())
 	}
@@ -360,6 +375,11 @@ func (r *flowRun) EvaluateTemplate(template string) (string, error) {
 	return r.EvaluateTemplateText(template, nil, true)
 }
 
+// gets the compiled version of the given template from the engine's cache
+func (r *flowRun) compileTemplate(template string) *excellent.Template {
+	return r.Session().Engine().TemplateCache().Get(template, flows.RunContextTopLevels)
+}
+
 // get the ordered list of languages to be used for localization in this run
 func (r *flowRun) getLanguages() []envs.Language {
 	languages := make([]envs.Language, 0, 3)
@@ -436,8 +456,8 @@ var _ flows.RunSummary = (*flowRun)(nil)
 type runEnvelope struct {
 	UUID       flows.RunUUID         `json:"uuid" validate:"required,uuid4"`
 	Flow       *assets.FlowReference `json:"flow" validate:"required,dive"`
-	Path       []*step               `json:"path" validate:"dive"`
-	Events     []json.RawMessage     `json:"events,omitempty"`
+	Path       []*step               `json:"path" validate:"dive" jsonschema:"step"`
+	Events     []json.RawMessage     `json:"events,omitempty" jsonschema:"event"`
 	Results    flows.Results         `json:"results,omitempty" validate:"omitempty,dive"`
 	Status     flows.RunStatus       `json:"status" validate:"required"`
 	ParentUUID flows.RunUUID         `json:"parent_uuid,omitempty" validate:"omitempty,uuid4"`
@@ -448,6 +468,14 @@ type runEnvelope struct {
 	ExitedOn   *time.Time `json:"exited_on"`
 }
 
+// SchemaEnvelopes gets empty instances of the envelopes that runs are read from, keyed by name
+func SchemaEnvelopes() map[string]interface{} {
+	return map[string]interface{}{
+		"run":  &runEnvelope{},
+		"step": &stepEnvelope{},
+	}
+}
+
 // ReadRun decodes a run from the passed in JSON. Parent run UUID is returned separately as the
 // run in question might be loaded yet from the session.
 func ReadRun(session flows.Session, data json.RawMessage, missing assets.MissingCallback) (flows.FlowRun, error) {
@@ -12,6 +12,8 @@ import (
 	"github.com/nyaruka/goflow/envs"
 	"github.com/nyaruka/goflow/excellent/types"
 	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/engine"
+	"github.com/nyaruka/goflow/flows/events"
 	"github.com/nyaruka/goflow/flows/runs"
 	"github.com/nyaruka/goflow/flows/triggers"
 	"github.com/nyaruka/goflow/test"
@@ -268,6 +270,46 @@ func TestMissingRelatedRunContext(t *testing.T) {
 	assert.Equal(t, types.NewXErrorf("null doesn't support lookups"), val)
 }
 
+func TestTraceTemplates(t *testing.T) {
+	sa, err := test.CreateSessionAssets([]byte(sessionAssets), "")
+	require.NoError(t, err)
+
+	trigger, err := triggers.ReadTrigger(sa, []byte(sessionTrigger), assets.IgnoreMissing)
+	require.NoError(t, err)
+
+	// by default errors don't carry traces
+	session, _, err := test.NewEngine().NewSession(sa, trigger)
+	require.NoError(t, err)
+
+	_, err = session.Runs()[0].EvaluateTemplate(`@(upper(contact.nickname))`)
+	assert.EqualError(t, err, "error evaluating @(upper(contact.nickname)): error calling UPPER: object has no property 'nickname'")
+	assert.Nil(t, events.NewError(err).Trace)
+
+	// but if the engine is configured to trace templates, they do and those are included in error events
+	session, _, err = engine.NewBuilder().WithTraceTemplates(true).Build().NewSession(sa, trigger)
+	require.NoError(t, err)
+
+	_, err = session.Runs()[0].EvaluateTemplate(`@(upper(contact.nickname))`)
+	assert.EqualError(t, err, "error evaluating @(upper(contact.nickname)): error calling UPPER: object has no property 'nickname'")
+
+	event := events.NewError(err)
+	require.NotNil(t, event.Trace)
+	assert.Equal(t, `template @(upper(contact.nickname)) → error "error evaluating @(upper(contact.nickname)): error calling UPPER: object has no property 'nickname'"
+└── expression upper(contact.nickname) → error "error calling UPPER: object has no property 'nickname'"
+    └── function upper(contact.nickname) → error "error calling UPPER: object has no property 'nickname'"
+        └── lookup contact.nickname → error "object has no property 'nickname'"
+            └── lookup contact → object "Ryan Lewis"
+`, event.Trace.String())
+
+	// and traces survive a round trip through JSON
+	eventJSON, err := jsonx.Marshal(event)
+	require.NoError(t, err)
+
+	event2, err := events.ReadEvent(eventJSON)
+	require.NoError(t, err)
+	assert.Equal(t, event.Trace, event2.(*events.ErrorEvent).Trace)
+}
+
 func TestSaveResult(t *testing.T) {
 	sa, err := test.CreateSessionAssets([]byte(sessionAssets), "")
 	require.NoError(t, err)
@@ -21,9 +21,22 @@ type Services interface {
 	Airtime(Session) (AirtimeService, error)
 }
 
+// Email is an email to be sent by an email service. Attachments are in the same format as message attachments
+// and are fetched by the service when sending.
+type Email struct {
+	To          []string
+	CC          []string
+	BCC         []string
+	ReplyTo     string
+	Subject     string
+	Body        string
+	HTMLBody    string
+	Attachments []utils.Attachment
+}
+
 // EmailService provides email functionality to the engine
 type EmailService interface {
-	Send(session Session, addresses []string, subject, body string) error
+	Send(session Session, email *Email) error
 }
 
 // CallStatus represents the status of a call to an external service
@@ -92,11 +105,32 @@ const (
 	AirtimeTransferStatusFailed  AirtimeTransferStatus = "failed"
 )
 
+// AirtimeProductType is a type of product which can be transferred
+type AirtimeProductType string
+
+// possible values for airtime product types
+const (
+	AirtimeProductTypeAirtime AirtimeProductType = "airtime"
+	AirtimeProductTypeData    AirtimeProductType = "data"
+)
+
+// AirtimeProductRequest describes the product to be transferred. If a SKU is given then that exact product is
+// transferred, otherwise the product of the given type closest to but not exceeding the desired amount in any
+// of the given currencies is transferred.
+type AirtimeProductRequest struct {
+	Type    AirtimeProductType
+	SKU     string
+	Amounts map[string]decimal.Decimal
+}
+
 // AirtimeTransfer is the result of an attempted airtime transfer
 type AirtimeTransfer struct {
 	UUID          uuids.UUID
 	Sender        urns.URN
 	Recipient     urns.URN
+	ProductType   AirtimeProductType
+	SKU           string
+	ProductName   string
 	Currency      string
 	DesiredAmount decimal.Decimal
 	ActualAmount  decimal.Decimal
@@ -104,8 +138,8 @@ type AirtimeTransfer struct {
 
 // AirtimeService provides airtime functionality to the engine
 type AirtimeService interface {
-	// Transfer transfers airtime to the given URN
-	Transfer(session Session, sender urns.URN, recipient urns.URN, amounts map[string]decimal.Decimal, logHTTP HTTPLogCallback) (*AirtimeTransfer, error)
+	// Transfer transfers airtime or a data bundle to the given URN
+	Transfer(session Session, sender urns.URN, recipient urns.URN, product *AirtimeProductRequest, logHTTP HTTPLogCallback) (*AirtimeTransfer, error)
 }
 
 // HTTPLog describes an HTTP request/response
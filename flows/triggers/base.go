@@ -19,10 +19,12 @@ import (
 type ReadFunc func(flows.SessionAssets, json.RawMessage, assets.MissingCallback) (flows.Trigger, error)
 
 var registeredTypes = map[string]ReadFunc{}
+var registeredEnvelopes = map[string]interface{}{}
 
-// registers a new type of trigger
-func registerType(name string, f ReadFunc) {
+// registers a new type of trigger and the envelope it is read from
+func registerType(name string, f ReadFunc, envelope interface{}) {
 	registeredTypes[name] = f
+	registeredEnvelopes[name] = envelope
 }
 
 // RegisteredTypes gets the registered types of trigger
@@ -30,6 +32,11 @@ func RegisteredTypes() map[string]ReadFunc {
 	return registeredTypes
 }
 
+// RegisteredEnvelopes gets empty instances of the envelopes that each registered type of trigger is read from
+func RegisteredEnvelopes() map[string]interface{} {
+	return registeredEnvelopes
+}
+
 // base of all trigger types
 type baseTrigger struct {
 	type_       string
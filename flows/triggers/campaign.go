@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	registerType(TypeCampaign, readCampaignTrigger)
+	registerType(TypeCampaign, readCampaignTrigger, &campaignTriggerEnvelope{})
 }
 
 // TypeCampaign is the type for sessions triggered by campaign events
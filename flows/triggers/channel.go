@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeChannel, readChannelTrigger)
+	registerType(TypeChannel, readChannelTrigger, &channelTriggerEnvelope{})
 }
 
 // TypeChannel is the type for sessions triggered by channel events
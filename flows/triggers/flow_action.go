@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	registerType(TypeFlowAction, readFlowActionTrigger)
+	registerType(TypeFlowAction, readFlowActionTrigger, &flowActionTriggerEnvelope{})
 }
 
 // TypeFlowAction is a constant for sessions triggered by flow actions in other sessions
@@ -0,0 +1,164 @@
+package triggers
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// KeywordTrigger is a definition of a flow which should be triggered when an incoming message matches one of its
+// keywords. It can be restricted to messages received on a particular channel, and to contacts who are in at least
+// one of the include groups and not in any of the exclude groups.
+type KeywordTrigger struct {
+	Flow          *assets.FlowReference    `json:"flow" validate:"required"`
+	Keywords      []string                 `json:"keywords" validate:"required,min=1"`
+	MatchType     KeywordMatchType         `json:"match_type" validate:"required,eq=first_word|eq=only_word"`
+	Channel       *assets.ChannelReference `json:"channel,omitempty"`
+	IncludeGroups []*assets.GroupReference `json:"include_groups,omitempty"`
+	ExcludeGroups []*assets.GroupReference `json:"exclude_groups,omitempty"`
+}
+
+// NewKeywordTrigger creates a new keyword trigger definition
+func NewKeywordTrigger(flow *assets.FlowReference, keywords []string, matchType KeywordMatchType, channel *assets.ChannelReference, includeGroups []*assets.GroupReference, excludeGroups []*assets.GroupReference) *KeywordTrigger {
+	return &KeywordTrigger{
+		Flow:          flow,
+		Keywords:      keywords,
+		MatchType:     matchType,
+		Channel:       channel,
+		IncludeGroups: includeGroups,
+		ExcludeGroups: excludeGroups,
+	}
+}
+
+// checks whether this trigger's channel and group filters allow the given message and contact
+func (t *KeywordTrigger) allows(msg *flows.MsgIn, contact *flows.Contact) bool {
+	if t.Channel != nil && (msg.Channel() == nil || msg.Channel().UUID != t.Channel.UUID) {
+		return false
+	}
+
+	if len(t.IncludeGroups) > 0 {
+		if contact == nil || !inAnyGroup(contact, t.IncludeGroups) {
+			return false
+		}
+	}
+
+	if len(t.ExcludeGroups) > 0 && contact != nil && inAnyGroup(contact, t.ExcludeGroups) {
+		return false
+	}
+
+	return true
+}
+
+// the specificity of a trigger is used to decide between triggers which match the same keyword, with channel
+// filters taking precedence over group filters
+func (t *KeywordTrigger) specificity() int {
+	s := 0
+	if t.Channel != nil {
+		s += 2
+	}
+	if len(t.IncludeGroups) > 0 {
+		s++
+	}
+	return s
+}
+
+func inAnyGroup(contact *flows.Contact, groups []*assets.GroupReference) bool {
+	for _, g := range groups {
+		if contact.Groups().FindByUUID(g.UUID) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// a keyword of a trigger, as it was defined on that trigger
+type keywordEntry struct {
+	trigger *KeywordTrigger
+	keyword string
+}
+
+// KeywordMatcher finds which of a set of keyword triggers an incoming message matches. Triggers are indexed by their
+// normalized keywords so the cost of matching a message doesn't depend on the number of keywords.
+type KeywordMatcher struct {
+	index map[string][]keywordEntry
+}
+
+// NewKeywordMatcher creates a new matcher for the given trigger definitions. Where several triggers match a message
+// equally well, the one which appears first takes precedence. Messages are matched by their first word so an error
+// is returned if any keyword isn't a single word.
+func NewKeywordMatcher(triggers []*KeywordTrigger) (*KeywordMatcher, error) {
+	m := &KeywordMatcher{index: make(map[string][]keywordEntry)}
+
+	for _, t := range triggers {
+		for _, keyword := range t.Keywords {
+			normalized := NormalizeKeyword(keyword)
+			if normalized == "" {
+				continue
+			}
+			if len(utils.TokenizeString(normalized)) > 1 {
+				return nil, errors.Errorf("keyword '%s' isn't a single word", keyword)
+			}
+
+			m.index[normalized] = append(m.index[normalized], keywordEntry{trigger: t, keyword: keyword})
+		}
+	}
+
+	return m, nil
+}
+
+// Match returns the trigger which the given message matches and a description of how it matched, or nil if the
+// message doesn't match any trigger. The contact is used for group filters and may be nil, in which case triggers
+// with include groups never match.
+func (m *KeywordMatcher) Match(msg *flows.MsgIn, contact *flows.Contact) (*KeywordTrigger, *KeywordMatch) {
+	words := utils.TokenizeString(msg.Text())
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	onlyWord := len(words) == 1
+
+	candidates := m.index[NormalizeKeyword(words[0])]
+	var best *keywordEntry
+
+	for i := range candidates {
+		entry := &candidates[i]
+
+		if entry.trigger.MatchType == KeywordMatchTypeOnlyWord && !onlyWord {
+			continue
+		}
+		if !entry.trigger.allows(msg, contact) {
+			continue
+		}
+		if best == nil || entry.trigger.specificity() > best.trigger.specificity() {
+			best = entry
+		}
+	}
+
+	if best == nil {
+		return nil, nil
+	}
+
+	return best.trigger, NewKeywordMatch(best.trigger.MatchType, best.keyword)
+}
+
+// NormalizeKeyword normalizes the given keyword for matching by trimming it, lowercasing it and removing diacritics
+func NormalizeKeyword(keyword string) string {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+
+	// transformers aren't safe for concurrent use so we create a new one each time
+	diacriticRemover := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	normalized, _, err := transform.String(diacriticRemover, keyword)
+	if err != nil {
+		return keyword
+	}
+	return normalized
+}
@@ -0,0 +1,152 @@
+package triggers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/static"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/flows/engine"
+	"github.com/nyaruka/goflow/flows/triggers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeKeyword(t *testing.T) {
+	assert.Equal(t, "", triggers.NormalizeKeyword(""))
+	assert.Equal(t, "join", triggers.NormalizeKeyword(" JOIN "))
+	assert.Equal(t, "cafe", triggers.NormalizeKeyword("Café"))
+	assert.Equal(t, "senor", triggers.NormalizeKeyword("SEÑOR"))
+	assert.Equal(t, "مرحبا", triggers.NormalizeKeyword("مرحبا"))
+}
+
+func TestKeywordMatcher(t *testing.T) {
+	env := envs.NewBuilder().Build()
+
+	source, err := static.NewSource([]byte(`{
+		"groups": [
+			{"uuid": "b7cf0d83-f1c9-411c-96fd-c511a4cfa86d", "name": "Testers"},
+			{"uuid": "4f1f98fc-27a7-4a69-bbdb-24744ba739a9", "name": "Males"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	sa, err := engine.NewSessionAssets(env, source, nil)
+	require.NoError(t, err)
+
+	testers := assets.NewGroupReference("b7cf0d83-f1c9-411c-96fd-c511a4cfa86d", "Testers")
+	males := assets.NewGroupReference("4f1f98fc-27a7-4a69-bbdb-24744ba739a9", "Males")
+	nexmo := assets.NewChannelReference("3a05eaf5-cb1b-4246-bef1-f277419c83a7", "Nexmo")
+	facebook := assets.NewChannelReference("8cd472c4-bb85-459a-8c9a-c04708af799e", "Facebook")
+
+	registration := assets.NewFlowReference("7c37d7e5-6468-4b31-8109-ced2ef8b5ddc", "Registration")
+	registrationNexmo := assets.NewFlowReference("2d3e8ecb-7e3e-4e5c-9a2e-8a1d1f0f4d52", "Registration (Nexmo)")
+	registrationTesters := assets.NewFlowReference("5b1c0a1e-8f0e-4f5e-9a8e-0d3e3e3a4b1c", "Registration (Testers)")
+	stop := assets.NewFlowReference("a1b4c5d6-7e8f-4a0b-9c1d-2e3f4a5b6c7d", "Stop")
+	survey := assets.NewFlowReference("f8e7d6c5-b4a3-4291-8e7f-6d5c4b3a2918", "Survey")
+
+	matcher, err := triggers.NewKeywordMatcher([]*triggers.KeywordTrigger{
+		triggers.NewKeywordTrigger(registration, []string{"join", "Unirse"}, triggers.KeywordMatchTypeFirstWord, nil, nil, nil),
+		triggers.NewKeywordTrigger(registrationNexmo, []string{"join"}, triggers.KeywordMatchTypeFirstWord, nexmo, nil, nil),
+		triggers.NewKeywordTrigger(registrationTesters, []string{"JOIN"}, triggers.KeywordMatchTypeFirstWord, nil, []*assets.GroupReference{testers}, nil),
+		triggers.NewKeywordTrigger(stop, []string{"stop"}, triggers.KeywordMatchTypeOnlyWord, nil, nil, nil),
+		triggers.NewKeywordTrigger(survey, []string{"Encuesta"}, triggers.KeywordMatchTypeFirstWord, nil, nil, []*assets.GroupReference{males}),
+	})
+	require.NoError(t, err)
+
+	bob := flows.NewEmptyContact(sa, "Bob", envs.NilLanguage, nil)
+
+	tester := flows.NewEmptyContact(sa, "Tim", envs.NilLanguage, nil)
+	tester.Groups().Add(sa.Groups().Get(testers.UUID))
+
+	male := flows.NewEmptyContact(sa, "Jim", envs.NilLanguage, nil)
+	male.Groups().Add(sa.Groups().Get(males.UUID))
+
+	tcs := []struct {
+		text          string
+		channel       *assets.ChannelReference
+		contact       *flows.Contact
+		expectedFlow  *assets.FlowReference
+		expectedMatch *triggers.KeywordMatch
+	}{
+		{"", nil, bob, nil, nil},
+		{"  ", nil, bob, nil, nil},
+		{"hello", nil, bob, nil, nil},
+		{"join", nil, bob, registration, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "join")},
+		{"Join the group", facebook, bob, registration, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "join")},
+		{"  JOIN!", nil, nil, registration, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "join")},
+		{"unírse", nil, bob, registration, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "Unirse")},
+		{"rejoin", nil, bob, nil, nil},
+
+		// channel filters take precedence over group filters
+		{"join", nexmo, bob, registrationNexmo, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "join")},
+		{"join", nexmo, tester, registrationNexmo, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "join")},
+		{"join", facebook, tester, registrationTesters, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "JOIN")},
+
+		// only word matches require the message to be a single word
+		{"stop", nil, bob, stop, triggers.NewKeywordMatch(triggers.KeywordMatchTypeOnlyWord, "stop")},
+		{"Stop.", nil, bob, stop, triggers.NewKeywordMatch(triggers.KeywordMatchTypeOnlyWord, "stop")},
+		{"stop it", nil, bob, nil, nil},
+
+		// exclude groups
+		{"encuesta", nil, bob, survey, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "Encuesta")},
+		{"encuesta", nil, nil, survey, triggers.NewKeywordMatch(triggers.KeywordMatchTypeFirstWord, "Encuesta")},
+		{"encuesta", nil, male, nil, nil},
+	}
+
+	for _, tc := range tcs {
+		msg := flows.NewMsgIn(flows.MsgUUID("2d611e17-fb22-457f-b802-b8f7ec5cda5b"), urns.URN("tel:+12065551212"), tc.channel, tc.text, nil)
+
+		trigger, match := matcher.Match(msg, tc.contact)
+
+		if tc.expectedFlow == nil {
+			assert.Nil(t, trigger, "unexpected trigger for text '%s'", tc.text)
+		} else if assert.NotNil(t, trigger, "expected trigger for text '%s'", tc.text) {
+			assert.Equal(t, tc.expectedFlow, trigger.Flow, "flow mismatch for text '%s'", tc.text)
+		}
+		assert.Equal(t, tc.expectedMatch, match, "match mismatch for text '%s'", tc.text)
+	}
+}
+
+func TestKeywordMatcherWithInvalidKeywords(t *testing.T) {
+	flow := assets.NewFlowReference("7c37d7e5-6468-4b31-8109-ced2ef8b5ddc", "Registration")
+
+	// messages are only matched by their first word so keywords with several words could never match
+	_, err := triggers.NewKeywordMatcher([]*triggers.KeywordTrigger{
+		triggers.NewKeywordTrigger(flow, []string{"join", "sign up"}, triggers.KeywordMatchTypeFirstWord, nil, nil, nil),
+	})
+	assert.EqualError(t, err, "keyword 'sign up' isn't a single word")
+
+	_, err = triggers.NewKeywordMatcher([]*triggers.KeywordTrigger{
+		triggers.NewKeywordTrigger(flow, []string{"sign-up"}, triggers.KeywordMatchTypeFirstWord, nil, nil, nil),
+	})
+	assert.EqualError(t, err, "keyword 'sign-up' isn't a single word")
+
+	// but surrounding whitespace is ignored
+	_, err = triggers.NewKeywordMatcher([]*triggers.KeywordTrigger{
+		triggers.NewKeywordTrigger(flow, []string{" join ", ""}, triggers.KeywordMatchTypeFirstWord, nil, nil, nil),
+	})
+	assert.NoError(t, err)
+}
+
+func BenchmarkKeywordMatcher(b *testing.B) {
+	flow := assets.NewFlowReference("7c37d7e5-6468-4b31-8109-ced2ef8b5ddc", "Registration")
+
+	defs := make([]*triggers.KeywordTrigger, 5000)
+	for i := range defs {
+		defs[i] = triggers.NewKeywordTrigger(flow, []string{fmt.Sprintf("keyword%d", i)}, triggers.KeywordMatchTypeFirstWord, nil, nil, nil)
+	}
+
+	matcher, _ := triggers.NewKeywordMatcher(defs)
+	msg := flows.NewMsgIn(flows.MsgUUID("2d611e17-fb22-457f-b802-b8f7ec5cda5b"), urns.URN("tel:+12065551212"), nil, "Keyword4999 please", nil)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		matcher.Match(msg, nil)
+	}
+}
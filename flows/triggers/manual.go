@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	registerType(TypeManual, readManualTrigger)
+	registerType(TypeManual, readManualTrigger, &manualTriggerEnvelope{})
 }
 
 // TypeManual is the type for manually triggered sessions
@@ -14,7 +14,7 @@ import (
 )
 
 func init() {
-	registerType(TypeMsg, readMsgTrigger)
+	registerType(TypeMsg, readMsgTrigger, &msgTriggerEnvelope{})
 }
 
 // TypeMsg is the type for message triggered sessions
@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	registerType(TypeTicket, readTicketTrigger)
+	registerType(TypeTicket, readTicketTrigger, &ticketTriggerEnvelope{})
 }
 
 // TypeTicket is the type for sessions triggered by ticket events
@@ -11,6 +11,7 @@ require (
 	github.com/nyaruka/gocommon v1.9.1
 	github.com/nyaruka/phonenumbers v1.0.58
 	github.com/olivere/elastic/v7 v7.0.22
+	github.com/peterh/liner v1.2.2
 	github.com/pkg/errors v0.9.1
 	github.com/sergi/go-diff v1.1.0
 	github.com/shopspring/decimal v1.2.0
@@ -18,12 +18,15 @@ import (
 	"github.com/nyaruka/goflow/flows/definition"
 	"github.com/nyaruka/goflow/flows/definition/migrations"
 	"github.com/nyaruka/goflow/flows/engine"
+	"github.com/nyaruka/goflow/flows/events"
+	"github.com/nyaruka/goflow/flows/offline"
 	"github.com/nyaruka/goflow/flows/resumes"
 	"github.com/nyaruka/goflow/flows/routers/waits"
 	"github.com/nyaruka/goflow/flows/triggers"
 	"github.com/nyaruka/goflow/utils"
 
 	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
 )
 
 // CurrentSpecVersion returns the current flow spec version
@@ -98,18 +101,144 @@ func NewSessionAssets(environment *Environment, source *AssetsSource) (*SessionA
 	return &SessionAssets{target: s}, nil
 }
 
+// GetFlow gets the flow with the given UUID
+func (sa *SessionAssets) GetFlow(uuid string) (*Flow, error) {
+	flow, err := sa.target.Flows().Get(assets.FlowUUID(uuid))
+	if err != nil {
+		return nil, err
+	}
+	return &Flow{target: flow, sa: sa.target}, nil
+}
+
+// MigrateFlow migrates the given flow definition to the current spec version
+func MigrateFlow(definition string) (string, error) {
+	migrated, err := migrations.MigrateToLatest([]byte(definition), &migrations.Config{BaseMediaURL: ""})
+	if err != nil {
+		return "", err
+	}
+	return string(migrated), nil
+}
+
 // Contact represents a person who is interacting with a flow
 type Contact struct {
 	target *flows.Contact
+	sa     flows.SessionAssets
 }
 
 // NewEmptyContact creates a new contact
 func NewEmptyContact(sa *SessionAssets) *Contact {
 	return &Contact{
 		target: flows.NewEmptyContact(sa.target, "", envs.NilLanguage, nil),
+		sa:     sa.target,
 	}
 }
 
+// NewContact creates a new contact with the given name, language and timezone, any of which can be empty
+func NewContact(sa *SessionAssets, name string, language string, timezone string) (*Contact, error) {
+	var tz *time.Location
+	if timezone != "" {
+		var err error
+		tz, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Contact{
+		target: flows.NewEmptyContact(sa.target, name, envs.Language(language), tz),
+		sa:     sa.target,
+	}, nil
+}
+
+// ReadContact reads a contact from JSON
+func ReadContact(sa *SessionAssets, data string) (*Contact, error) {
+	c, err := flows.ReadContact(sa.target, json.RawMessage(data), assets.IgnoreMissing)
+	if err != nil {
+		return nil, err
+	}
+	return &Contact{target: c, sa: sa.target}, nil
+}
+
+// UUID returns the UUID of this contact
+func (c *Contact) UUID() string {
+	return string(c.target.UUID())
+}
+
+// Name returns the name of this contact
+func (c *Contact) Name() string {
+	return c.target.Name()
+}
+
+// URNs returns the URNs of this contact
+func (c *Contact) URNs() *StringSlice {
+	contactURNs := NewStringSlice(len(c.target.URNs()))
+	for _, urn := range c.target.URNs() {
+		contactURNs.Add(string(urn.URN()))
+	}
+	return contactURNs
+}
+
+// AddURN adds the given URN to this contact if it doesn't already have it
+func (c *Contact) AddURN(urn string) error {
+	parsed, err := urns.Parse(urn)
+	if err != nil {
+		return err
+	}
+	c.target.AddURN(parsed, nil)
+	return nil
+}
+
+// FieldValue returns the text value of the field with the given key, or an empty string if it isn't set
+func (c *Contact) FieldValue(key string) (string, error) {
+	field := c.sa.Fields().Get(key)
+	if field == nil {
+		return "", errors.Errorf("no such field with key '%s'", key)
+	}
+	value := c.target.Fields().Get(field)
+	if value == nil {
+		return "", nil
+	}
+	return value.Text.Native(), nil
+}
+
+// SetFieldValue parses and sets the value of the field with the given key, clearing it if the value is empty
+func (c *Contact) SetFieldValue(environment *Environment, key string, value string) error {
+	field := c.sa.Fields().Get(key)
+	if field == nil {
+		return errors.Errorf("no such field with key '%s'", key)
+	}
+	c.target.Fields().Set(field, c.target.Fields().Parse(environment.target, c.sa.Fields(), field, value))
+	return nil
+}
+
+// Groups returns the UUIDs of the groups this contact belongs to
+func (c *Contact) Groups() *StringSlice {
+	groups := NewStringSlice(c.target.Groups().Count())
+	for _, group := range c.target.Groups().All() {
+		groups.Add(string(group.UUID()))
+	}
+	return groups
+}
+
+// AddGroup adds this contact to the group with the given UUID
+func (c *Contact) AddGroup(uuid string) error {
+	group := c.sa.Groups().Get(assets.GroupUUID(uuid))
+	if group == nil {
+		return errors.Errorf("no such group with UUID '%s'", uuid)
+	}
+	c.target.Groups().Add(group)
+	return nil
+}
+
+// ToJSON serializes this contact as JSON
+func (c *Contact) ToJSON() (string, error) {
+	data, err := jsonx.Marshal(c.target)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // MsgIn is an incoming message
 type MsgIn struct {
 	target *flows.MsgIn
@@ -166,6 +295,25 @@ func NewManualTrigger(environment *Environment, contact *Contact, flow *FlowRefe
 	}
 }
 
+// NewMsgTrigger creates a new message trigger, with an optional keyword match if keyword isn't empty
+func NewMsgTrigger(environment *Environment, contact *Contact, flow *FlowReference, msg *MsgIn, matchType string, keyword string) *Trigger {
+	flowRef := assets.NewFlowReference(assets.FlowUUID(flow.uuid), flow.name)
+	builder := triggers.NewBuilder(environment.target, flowRef, contact.target).Msg(msg.target)
+	if keyword != "" {
+		builder = builder.WithMatch(triggers.NewKeywordMatch(triggers.KeywordMatchType(matchType), keyword))
+	}
+	return &Trigger{target: builder.Build()}
+}
+
+// NewCampaignTrigger creates a new campaign trigger
+func NewCampaignTrigger(environment *Environment, contact *Contact, flow *FlowReference, campaignUUID string, campaignName string, eventUUID string) *Trigger {
+	flowRef := assets.NewFlowReference(assets.FlowUUID(flow.uuid), flow.name)
+	campaign := triggers.NewCampaignReference(triggers.CampaignUUID(campaignUUID), campaignName)
+	return &Trigger{
+		target: triggers.NewBuilder(environment.target, flowRef, contact.target).Campaign(campaign, triggers.CampaignEventUUID(eventUUID)).Build(),
+	}
+}
+
 // Resume represents something which can resume a session
 type Resume struct {
 	target flows.Resume
@@ -173,6 +321,33 @@ type Resume struct {
 
 // NewMsgResume creates a new message resume
 func NewMsgResume(environment *Environment, contact *Contact, msg *MsgIn) *Resume {
+	e, c := resumeEnvironmentAndContact(environment, contact)
+
+	return &Resume{
+		target: resumes.NewMsg(e, c, msg.target),
+	}
+}
+
+// NewWaitTimeoutResume creates a new resume for when a wait has timed out
+func NewWaitTimeoutResume(environment *Environment, contact *Contact) *Resume {
+	e, c := resumeEnvironmentAndContact(environment, contact)
+
+	return &Resume{
+		target: resumes.NewWaitTimeout(e, c),
+	}
+}
+
+// NewRunExpirationResume creates a new resume for when a run has expired
+func NewRunExpirationResume(environment *Environment, contact *Contact) *Resume {
+	e, c := resumeEnvironmentAndContact(environment, contact)
+
+	return &Resume{
+		target: resumes.NewRunExpiration(e, c),
+	}
+}
+
+// resumes can optionally change the environment and contact
+func resumeEnvironmentAndContact(environment *Environment, contact *Contact) (envs.Environment, *flows.Contact) {
 	var e envs.Environment
 	if environment != nil {
 		e = environment.target
@@ -181,13 +356,11 @@ func NewMsgResume(environment *Environment, contact *Contact, msg *MsgIn) *Resum
 	if contact != nil {
 		c = contact.target
 	}
-
-	return &Resume{
-		target: resumes.NewMsg(e, c, msg.target),
-	}
+	return e, c
 }
 
 type Event struct {
+	target  flows.Event
 	type_   string
 	payload string
 }
@@ -200,6 +373,48 @@ func (e *Event) Payload() string {
 	return e.payload
 }
 
+// MsgCreated returns the message of this event if it's a msg_created event, otherwise nil
+func (e *Event) MsgCreated() *MsgOut {
+	asMsgCreated, isMsgCreated := e.target.(*events.MsgCreatedEvent)
+	if isMsgCreated {
+		return &MsgOut{target: asMsgCreated.Msg}
+	}
+	return nil
+}
+
+// MsgOut is an outgoing message
+type MsgOut struct {
+	target *flows.MsgOut
+}
+
+func (m *MsgOut) UUID() string {
+	return string(m.target.UUID())
+}
+
+func (m *MsgOut) URN() string {
+	return string(m.target.URN())
+}
+
+func (m *MsgOut) Text() string {
+	return m.target.Text()
+}
+
+func (m *MsgOut) Attachments() *StringSlice {
+	attachments := NewStringSlice(len(m.target.Attachments()))
+	for _, attachment := range m.target.Attachments() {
+		attachments.Add(string(attachment))
+	}
+	return attachments
+}
+
+func (m *MsgOut) QuickReplies() *StringSlice {
+	quickReplies := NewStringSlice(len(m.target.QuickReplies()))
+	for _, quickReply := range m.target.QuickReplies() {
+		quickReplies.Add(quickReply)
+	}
+	return quickReplies
+}
+
 type Modifier struct {
 	type_   string
 	payload string
@@ -233,7 +448,7 @@ func (s *Sprint) Events() *EventSlice {
 	events := NewEventSlice(len(s.target.Events()))
 	for _, event := range s.target.Events() {
 		marshaled, _ := jsonx.Marshal(event)
-		events.Add(&Event{type_: event.Type(), payload: string(marshaled)})
+		events.Add(&Event{target: event, type_: event.Type(), payload: string(marshaled)})
 	}
 	return events
 }
@@ -270,6 +485,25 @@ func (s *Session) GetWait() *Wait {
 	return nil
 }
 
+// EvaluateTemplate evaluates the given template against the waiting run of this session, or the most
+// recently modified run if no run is waiting
+func (s *Session) EvaluateTemplate(template string) (string, error) {
+	var run flows.FlowRun
+	for _, r := range s.target.Runs() {
+		if r.Status() == flows.RunStatusWaiting {
+			run = r
+			break
+		}
+		if run == nil || r.ModifiedOn().After(run.ModifiedOn()) {
+			run = r
+		}
+	}
+	if run == nil {
+		return "", errors.New("session has no runs to evaluate against")
+	}
+	return run.EvaluateTemplate(template)
+}
+
 // ToJSON serializes this session as JSON
 func (s *Session) ToJSON() (string, error) {
 	data, err := jsonx.Marshal(s.target)
@@ -279,6 +513,151 @@ func (s *Session) ToJSON() (string, error) {
 	return string(data), nil
 }
 
+// Flow is a flow definition
+type Flow struct {
+	target flows.Flow
+	sa     flows.SessionAssets
+}
+
+func (f *Flow) UUID() string {
+	return string(f.target.UUID())
+}
+
+func (f *Flow) Name() string {
+	return f.target.Name()
+}
+
+func (f *Flow) Type() string {
+	return string(f.target.Type())
+}
+
+func (f *Flow) Revision() int {
+	return f.target.Revision()
+}
+
+// Inspect inspects this flow for dependencies, issues, results etc
+func (f *Flow) Inspect() *Inspection {
+	return &Inspection{target: f.target.Inspect(f.sa)}
+}
+
+// Inspection is the result of inspecting a flow
+type Inspection struct {
+	target *flows.Inspection
+}
+
+// Dependencies returns the assets this flow depends on
+func (i *Inspection) Dependencies() *DependencySlice {
+	deps := NewDependencySlice(len(i.target.Dependencies))
+	for _, dep := range i.target.Dependencies {
+		deps.Add(&Dependency{target: dep})
+	}
+	return deps
+}
+
+// Issues returns the problems found in this flow
+func (i *Inspection) Issues() *IssueSlice {
+	issues := NewIssueSlice(len(i.target.Issues))
+	for _, issue := range i.target.Issues {
+		issues.Add(&Issue{target: issue})
+	}
+	return issues
+}
+
+// Results returns the results this flow might generate
+func (i *Inspection) Results() *ResultSpecSlice {
+	results := NewResultSpecSlice(len(i.target.Results))
+	for _, result := range i.target.Results {
+		results.Add(&ResultSpec{target: result})
+	}
+	return results
+}
+
+// WaitingExits returns the UUIDs of exits which lead out of waiting nodes
+func (i *Inspection) WaitingExits() *StringSlice {
+	exits := NewStringSlice(len(i.target.WaitingExits))
+	for _, exit := range i.target.WaitingExits {
+		exits.Add(string(exit))
+	}
+	return exits
+}
+
+// ParentRefs returns the keys of parent results referenced by this flow
+func (i *Inspection) ParentRefs() *StringSlice {
+	return newStringSliceFrom(i.target.ParentRefs)
+}
+
+// ToJSON serializes this inspection as JSON
+func (i *Inspection) ToJSON() (string, error) {
+	data, err := jsonx.Marshal(i.target)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Dependency is an asset that a flow depends on
+type Dependency struct {
+	target flows.Dependency
+}
+
+func (d *Dependency) Type() string {
+	return d.target.Type()
+}
+
+func (d *Dependency) Identity() string {
+	return d.target.Reference().Identity()
+}
+
+func (d *Dependency) Missing() bool {
+	return d.target.Missing()
+}
+
+// Issue is a problem found during flow inspection
+type Issue struct {
+	target flows.Issue
+}
+
+func (i *Issue) Type() string {
+	return i.target.Type()
+}
+
+func (i *Issue) NodeUUID() string {
+	return string(i.target.NodeUUID())
+}
+
+func (i *Issue) ActionUUID() string {
+	return string(i.target.ActionUUID())
+}
+
+func (i *Issue) Language() string {
+	return string(i.target.Language())
+}
+
+func (i *Issue) Description() string {
+	return i.target.Description()
+}
+
+// ResultSpec is a result that a flow might generate
+type ResultSpec struct {
+	target *flows.ResultSpec
+}
+
+func (r *ResultSpec) Key() string {
+	return r.target.Key
+}
+
+func (r *ResultSpec) Name() string {
+	return r.target.Name
+}
+
+func (r *ResultSpec) Categories() *StringSlice {
+	return newStringSliceFrom(r.target.Categories)
+}
+
+func (r *ResultSpec) NodeUUIDs() *StringSlice {
+	return newStringSliceFrom(r.target.NodeUUIDs)
+}
+
 type Hint struct {
 	target flows.Hint
 }
@@ -348,3 +727,30 @@ func (ss *SessionAndSprint) Session() *Session {
 func (ss *SessionAndSprint) Sprint() *Sprint {
 	return ss.sprint
 }
+
+// ToSyncJSON serializes the sprint which started this session for syncing
+func (ss *SessionAndSprint) ToSyncJSON() (string, error) {
+	s, err := offline.NewStartSprint(ss.session.target, ss.sprint.target)
+	if err != nil {
+		return "", err
+	}
+	return marshalSyncSprint(s)
+}
+
+// ResumeToSyncJSON serializes a sprint produced by resuming this session for syncing, with sequence being the
+// number of sprints of this session so far including this one
+func (s *Session) ResumeToSyncJSON(sequence int, resume *Resume, sprint *Sprint) (string, error) {
+	synced, err := offline.NewResumeSprint(s.target, sequence, resume.target, sprint.target)
+	if err != nil {
+		return "", err
+	}
+	return marshalSyncSprint(synced)
+}
+
+func marshalSyncSprint(s *offline.Sprint) (string, error) {
+	data, err := jsonx.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -62,6 +62,17 @@ func TestMobileBindings(t *testing.T) {
 	assert.Equal(t, "msg_created", events.Get(0).Type())
 	assert.Equal(t, "msg_wait", events.Get(1).Type())
 
+	msgOut := events.Get(0).MsgCreated()
+	require.NotNil(t, msgOut)
+	assert.Equal(t, "Hi ! What is your favorite color? (red/blue)", msgOut.Text())
+	assert.Equal(t, 0, msgOut.Attachments().Length())
+	assert.Equal(t, 0, msgOut.QuickReplies().Length())
+	assert.Nil(t, events.Get(1).MsgCreated())
+
+	syncJSON, err := ss.ToSyncJSON()
+	require.NoError(t, err)
+	assert.Contains(t, syncJSON, `"sequence":1`)
+
 	modifiers := sprint.Modifiers()
 	assert.Equal(t, 0, modifiers.Length())
 
@@ -89,6 +100,18 @@ func TestMobileBindings(t *testing.T) {
 	assert.Equal(t, "msg_created", events.Get(2).Type())
 	assert.Equal(t, "msg_wait", events.Get(3).Type())
 
+	syncJSON, err = session.ResumeToSyncJSON(2, resume, sprint)
+	require.NoError(t, err)
+	assert.Contains(t, syncJSON, `"sequence":2`)
+
+	_, err = session.ResumeToSyncJSON(1, resume, sprint)
+	assert.EqualError(t, err, "resume sprints must have a sequence number greater than 1")
+
+	// evaluate an expression against the session
+	evaluated, err := session.EvaluateTemplate("@results.favorite_color.category @(upper(input.text))")
+	assert.NoError(t, err)
+	assert.Equal(t, "Other HI THERE", evaluated)
+
 	// convert session to JSON
 	marshaled, err := session.ToJSON()
 	require.NoError(t, err)
@@ -101,3 +124,134 @@ func TestMobileBindings(t *testing.T) {
 
 	assert.Equal(t, "waiting", session2.Status())
 }
+
+func TestMobileTriggersAndResumes(t *testing.T) {
+	environment, sa := loadTestAssets(t)
+	eng := mobile.NewEngine()
+	flow := mobile.NewFlowReference("7c3db26f-e12a-48af-9673-e2feefdf8516", "Two Questions")
+
+	contact, err := mobile.NewContact(sa, "Bob", "eng", "Africa/Kigali")
+	require.NoError(t, err)
+
+	// start with a msg trigger with a keyword match
+	msg := mobile.NewMsgIn("8e6f0213-a122-4c50-a430-442085754c16", "survey", nil)
+	ss, err := eng.NewSession(sa, mobile.NewMsgTrigger(environment, contact, flow, msg, "first_word", "survey"))
+	require.NoError(t, err)
+	assert.Equal(t, "waiting", ss.Session().Status())
+
+	evaluated, err := ss.Session().EvaluateTemplate("@trigger.type @trigger.keyword @contact.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "msg survey Bob", evaluated)
+
+	// wait doesn't have a timeout so trying to time it out is an error
+	sprint, err := ss.Session().Resume(mobile.NewWaitTimeoutResume(nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "error", sprint.Events().Get(0).Type())
+
+	// start with a campaign trigger and let it expire
+	ss, err = eng.NewSession(sa, mobile.NewCampaignTrigger(environment, contact, flow, "58e9b092-fe42-4173-876c-ff45a14a24fe", "Reminders", "e68f4c70-9db1-44c8-8498-602d6857235e"))
+	require.NoError(t, err)
+
+	evaluated, err = ss.Session().EvaluateTemplate("@trigger.type @contact.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "campaign Bob", evaluated)
+
+	sprint, err = ss.Session().Resume(mobile.NewRunExpirationResume(nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "completed", ss.Session().Status())
+	assert.Equal(t, "run_expired", sprint.Events().Get(0).Type())
+}
+
+func TestMobileContacts(t *testing.T) {
+	environment, sa := loadTestAssets(t)
+
+	_, err := mobile.NewContact(sa, "Bob", "eng", "Cuba/Havana")
+	assert.Error(t, err)
+
+	contact, err := mobile.NewContact(sa, "Bob", "eng", "Africa/Kigali")
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", contact.Name())
+
+	assert.NoError(t, contact.AddURN("tel:+250781234567"))
+	assert.NoError(t, contact.AddURN("tel:+250781234567"))
+	assert.Error(t, contact.AddURN("xyz"))
+	assert.Equal(t, 1, contact.URNs().Length())
+	assert.Equal(t, "tel:+250781234567", contact.URNs().Get(0))
+
+	assert.NoError(t, contact.SetFieldValue(environment, "gender", "Male"))
+	assert.EqualError(t, contact.SetFieldValue(environment, "age", "23"), "no such field with key 'age'")
+
+	value, err := contact.FieldValue("gender")
+	assert.NoError(t, err)
+	assert.Equal(t, "Male", value)
+
+	assert.EqualError(t, contact.AddGroup("b7cf0d83-f1c9-411c-96fd-c511a4cfa86d"), "no such group with UUID 'b7cf0d83-f1c9-411c-96fd-c511a4cfa86d'")
+	assert.Equal(t, 0, contact.Groups().Length())
+
+	// contacts can be round-tripped through JSON
+	marshaled, err := contact.ToJSON()
+	require.NoError(t, err)
+
+	contact2, err := mobile.ReadContact(sa, marshaled)
+	require.NoError(t, err)
+	assert.Equal(t, contact.UUID(), contact2.UUID())
+	assert.Equal(t, 1, contact2.URNs().Length())
+
+	value, err = contact2.FieldValue("gender")
+	assert.NoError(t, err)
+	assert.Equal(t, "Male", value)
+}
+
+func TestMobileFlows(t *testing.T) {
+	_, sa := loadTestAssets(t)
+
+	_, err := sa.GetFlow("0c3f5cd0-9a3c-45e0-8c40-d9d8dfba6a6f")
+	assert.Error(t, err)
+
+	flow, err := sa.GetFlow("7c3db26f-e12a-48af-9673-e2feefdf8516")
+	require.NoError(t, err)
+	assert.Equal(t, "7c3db26f-e12a-48af-9673-e2feefdf8516", flow.UUID())
+	assert.Equal(t, "Two Questions", flow.Name())
+	assert.Equal(t, "messaging_offline", flow.Type())
+
+	info := flow.Inspect()
+	assert.Equal(t, 0, info.Dependencies().Length())
+	assert.Equal(t, 0, info.Issues().Length())
+	assert.Equal(t, 0, info.ParentRefs().Length())
+	assert.Equal(t, 7, info.WaitingExits().Length())
+
+	results := info.Results()
+	assert.Equal(t, 2, results.Length())
+	assert.Equal(t, "favorite_color", results.Get(0).Key())
+	assert.Equal(t, "Favorite Color", results.Get(0).Name())
+	assert.Equal(t, 3, results.Get(0).Categories().Length())
+	assert.Equal(t, 1, results.Get(0).NodeUUIDs().Length())
+
+	marshaled, err := info.ToJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"dependencies":[],"`, marshaled[:20])
+
+	// migrate a legacy flow definition
+	_, err = mobile.MigrateFlow("{")
+	assert.Error(t, err)
+
+	migrated, err := mobile.MigrateFlow(`{"uuid": "8ca44c09-791d-453a-9799-a70dd3303306", "name": "Empty", "spec_version": "13.0.0", "language": "eng", "type": "messaging", "nodes": []}`)
+	require.NoError(t, err)
+	assert.Contains(t, migrated, `"spec_version":"`+mobile.CurrentSpecVersion()+`"`)
+}
+
+func loadTestAssets(t *testing.T) (*mobile.Environment, *mobile.SessionAssets) {
+	assetsJSON, err := ioutil.ReadFile("../test/testdata/runner/two_questions_offline.json")
+	require.NoError(t, err)
+
+	source, err := mobile.NewAssetsSource(string(assetsJSON))
+	require.NoError(t, err)
+
+	environment, err := mobile.NewEnvironment("DD-MM-YYYY", "tt:mm", "Africa/Kigali", mobile.NewStringSlice(0), "RW", "none")
+	require.NoError(t, err)
+
+	sa, err := mobile.NewSessionAssets(environment, source)
+	require.NoError(t, err)
+
+	return environment, sa
+}
@@ -27,6 +27,14 @@ func (l *StringSlice) Get(index int) string {
 	return l.items[index]
 }
 
+func newStringSliceFrom(items []string) *StringSlice {
+	s := NewStringSlice(len(items))
+	for _, item := range items {
+		s.Add(item)
+	}
+	return s
+}
+
 // EventSlice wraps a slice of events
 type EventSlice struct {
 	items []*Event
@@ -76,3 +84,78 @@ func (l *ModifierSlice) Length() int {
 func (l *ModifierSlice) Get(index int) *Modifier {
 	return l.items[index]
 }
+
+// DependencySlice wraps a slice of dependencies
+type DependencySlice struct {
+	items []*Dependency
+}
+
+// NewDependencySlice creates a new slice of dependencies
+func NewDependencySlice(capacity int) *DependencySlice {
+	return &DependencySlice{items: make([]*Dependency, 0, capacity)}
+}
+
+// Add adds a dependency to this slice
+func (l *DependencySlice) Add(item *Dependency) {
+	l.items = append(l.items, item)
+}
+
+// Length gets the length of this slice
+func (l *DependencySlice) Length() int {
+	return len(l.items)
+}
+
+// Get returns the dependency at the given index
+func (l *DependencySlice) Get(index int) *Dependency {
+	return l.items[index]
+}
+
+// IssueSlice wraps a slice of issues
+type IssueSlice struct {
+	items []*Issue
+}
+
+// NewIssueSlice creates a new slice of issues
+func NewIssueSlice(capacity int) *IssueSlice {
+	return &IssueSlice{items: make([]*Issue, 0, capacity)}
+}
+
+// Add adds an issue to this slice
+func (l *IssueSlice) Add(item *Issue) {
+	l.items = append(l.items, item)
+}
+
+// Length gets the length of this slice
+func (l *IssueSlice) Length() int {
+	return len(l.items)
+}
+
+// Get returns the issue at the given index
+func (l *IssueSlice) Get(index int) *Issue {
+	return l.items[index]
+}
+
+// ResultSpecSlice wraps a slice of result specs
+type ResultSpecSlice struct {
+	items []*ResultSpec
+}
+
+// NewResultSpecSlice creates a new slice of result specs
+func NewResultSpecSlice(capacity int) *ResultSpecSlice {
+	return &ResultSpecSlice{items: make([]*ResultSpec, 0, capacity)}
+}
+
+// Add adds a result spec to this slice
+func (l *ResultSpecSlice) Add(item *ResultSpec) {
+	l.items = append(l.items, item)
+}
+
+// Length gets the length of this slice
+func (l *ResultSpecSlice) Length() int {
+	return len(l.items)
+}
+
+// Get returns the result spec at the given index
+func (l *ResultSpecSlice) Get(index int) *ResultSpec {
+	return l.items[index]
+}
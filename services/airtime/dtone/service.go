@@ -2,6 +2,7 @@ package dtone
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/nyaruka/gocommon/httpx"
 	"github.com/nyaruka/gocommon/urns"
@@ -26,11 +27,16 @@ func NewService(httpClient *http.Client, httpRetries *httpx.RetryConfig, key, se
 	}
 }
 
-func (s *service) Transfer(session flows.Session, sender urns.URN, recipient urns.URN, amounts map[string]decimal.Decimal, logHTTP flows.HTTPLogCallback) (*flows.AirtimeTransfer, error) {
+func (s *service) Transfer(session flows.Session, sender urns.URN, recipient urns.URN, request *flows.AirtimeProductRequest, logHTTP flows.HTTPLogCallback) (*flows.AirtimeTransfer, error) {
+	if request.Type != "" && request.Type != flows.AirtimeProductTypeAirtime {
+		return nil, errors.Errorf("product type '%s' not supported by DTOne service", request.Type)
+	}
+
 	transfer := &flows.AirtimeTransfer{
 		UUID:          uuids.New(),
 		Sender:        sender,
 		Recipient:     recipient,
+		ProductType:   flows.AirtimeProductTypeAirtime,
 		DesiredAmount: decimal.Zero,
 		ActualAmount:  decimal.Zero,
 	}
@@ -64,34 +70,33 @@ func (s *service) Transfer(session flows.Session, sender urns.URN, recipient urn
 		return transfer, errors.Wrap(err, "product fetch failed")
 	}
 
-	// closest product for each currency we have a desired amount for
-	closestProducts := make(map[string]*Product, len(amounts))
-
-	for currency, desiredAmount := range amounts {
-		for _, product := range products {
-			if product.Destination.Unit == currency {
-				closest := closestProducts[currency]
-				prodAmount := product.Destination.Amount
+	var product *Product
 
-				if (closest == nil || prodAmount.GreaterThan(closest.Destination.Amount)) && prodAmount.LessThanOrEqual(desiredAmount) {
-					closestProducts[currency] = product
-				}
+	if request.SKU != "" {
+		// SKUs for this service are product IDs
+		for _, p := range products {
+			if strconv.Itoa(p.ID) == request.SKU {
+				product = p
+				break
 			}
 		}
-	}
-	if len(closestProducts) == 0 {
-		return transfer, errors.Errorf("unable to find a suitable product for operator '%s'", operator.Name)
-	}
-
-	// it's possible we have more than one supported currency/product.. use any
-	var product *Product
-	for i := range closestProducts {
-		product = closestProducts[i]
-		break
+		if product == nil {
+			return transfer, errors.Errorf("unable to find product with SKU '%s' for operator '%s'", request.SKU, operator.Name)
+		}
+	} else {
+		product = closestProduct(products, request.Amounts)
+		if product == nil {
+			return transfer, errors.Errorf("unable to find a suitable product for operator '%s'", operator.Name)
+		}
 	}
 
+	transfer.SKU = strconv.Itoa(product.ID)
+	transfer.ProductName = product.Name
 	transfer.Currency = product.Destination.Unit
-	transfer.DesiredAmount = amounts[transfer.Currency]
+	transfer.DesiredAmount = product.Destination.Amount
+	if desired, ok := request.Amounts[transfer.Currency]; ok {
+		transfer.DesiredAmount = desired
+	}
 
 	// request synchronous confirmed transaction for this product
 	tx, trace, err := s.client.TransactionSync(string(transfer.UUID), product.ID, recipient.Path())
@@ -110,3 +115,27 @@ func (s *service) Transfer(session flows.Session, sender urns.URN, recipient urn
 
 	return transfer, nil
 }
+
+// finds the closest product for each currency we have a desired amount for
+func closestProduct(products []*Product, amounts map[string]decimal.Decimal) *Product {
+	closestProducts := make(map[string]*Product, len(amounts))
+
+	for currency, desiredAmount := range amounts {
+		for _, product := range products {
+			if product.Destination.Unit == currency {
+				closest := closestProducts[currency]
+				prodAmount := product.Destination.Amount
+
+				if (closest == nil || prodAmount.GreaterThan(closest.Destination.Amount)) && prodAmount.LessThanOrEqual(desiredAmount) {
+					closestProducts[currency] = product
+				}
+			}
+		}
+	}
+
+	// it's possible we have more than one supported currency/product.. use any
+	for i := range closestProducts {
+		return closestProducts[i]
+	}
+	return nil
+}
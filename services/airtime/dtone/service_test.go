@@ -58,9 +58,12 @@ func TestServiceWithSuccessfulTranfer(t *testing.T) {
 		session,
 		urns.URN("tel:+593979000000"),
 		urns.URN("tel:+593979123456"),
-		map[string]decimal.Decimal{
-			"USD": decimal.RequireFromString("3.5"),
-			"RWF": decimal.RequireFromString("5000"),
+		&flows.AirtimeProductRequest{
+			Type: flows.AirtimeProductTypeAirtime,
+			Amounts: map[string]decimal.Decimal{
+				"USD": decimal.RequireFromString("3.5"),
+				"RWF": decimal.RequireFromString("5000"),
+			},
 		},
 		httpLogger.Log,
 	)
@@ -69,6 +72,9 @@ func TestServiceWithSuccessfulTranfer(t *testing.T) {
 		UUID:          uuids.UUID("1ae96956-4b34-433e-8d1a-f05fe6923d6d"),
 		Sender:        urns.URN("tel:+593979000000"),
 		Recipient:     urns.URN("tel:+593979123456"),
+		ProductType:   flows.AirtimeProductTypeAirtime,
+		SKU:           "6035",
+		ProductName:   "3 USD",
 		Currency:      "USD",
 		DesiredAmount: decimal.RequireFromString("3.5"),
 		ActualAmount:  decimal.RequireFromString("3"), // closest product
@@ -79,6 +85,78 @@ func TestServiceWithSuccessfulTranfer(t *testing.T) {
 	assert.False(t, mocks.HasUnused())
 }
 
+func TestServiceWithSKU(t *testing.T) {
+	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
+	require.NoError(t, err)
+
+	defer uuids.SetGenerator(uuids.DefaultGenerator)
+	defer httpx.SetRequestor(httpx.DefaultRequestor)
+
+	mocks := httpx.NewMockRequestor(map[string][]httpx.MockResponse{
+		"https://dvs-api.dtone.com/v1/lookup/mobile-number/+593979123456": {
+			httpx.NewMockResponse(200, nil, lookupNumberResponse),
+			httpx.NewMockResponse(200, nil, lookupNumberResponse),
+		},
+		"https://dvs-api.dtone.com/v1/products?type=FIXED_VALUE_RECHARGE&operator_id=1596&per_page=100": {
+			httpx.NewMockResponse(200, nil, productsResponse),
+			httpx.NewMockResponse(200, nil, productsResponse),
+		},
+		"https://dvs-api.dtone.com/v1/sync/transactions": {
+			httpx.NewMockResponse(200, nil, transactionConfirmedResponse),
+		},
+	})
+
+	uuids.SetGenerator(uuids.NewSeededGenerator(12345))
+	httpx.SetRequestor(mocks)
+
+	svc := dtone.NewService(http.DefaultClient, nil, "key123", "sesame")
+
+	httpLogger := &flows.HTTPLogger{}
+
+	transfer, err := svc.Transfer(
+		session,
+		urns.URN("tel:+593979000000"),
+		urns.URN("tel:+593979123456"),
+		&flows.AirtimeProductRequest{Type: flows.AirtimeProductTypeAirtime, SKU: "6036"},
+		httpLogger.Log,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &flows.AirtimeTransfer{
+		UUID:          uuids.UUID("1ae96956-4b34-433e-8d1a-f05fe6923d6d"),
+		Sender:        urns.URN("tel:+593979000000"),
+		Recipient:     urns.URN("tel:+593979123456"),
+		ProductType:   flows.AirtimeProductTypeAirtime,
+		SKU:           "6036",
+		ProductName:   "6 USD",
+		Currency:      "USD",
+		DesiredAmount: decimal.RequireFromString("6"),
+		ActualAmount:  decimal.RequireFromString("6"),
+	}, transfer)
+
+	// try with a SKU which doesn't match any product
+	_, err = svc.Transfer(
+		session,
+		urns.URN("tel:+593979000000"),
+		urns.URN("tel:+593979123456"),
+		&flows.AirtimeProductRequest{Type: flows.AirtimeProductTypeAirtime, SKU: "1234"},
+		httpLogger.Log,
+	)
+	assert.EqualError(t, err, "unable to find product with SKU '1234' for operator 'Claro Ecuador'")
+
+	// try to transfer a data bundle
+	_, err = svc.Transfer(
+		session,
+		urns.URN("tel:+593979000000"),
+		urns.URN("tel:+593979123456"),
+		&flows.AirtimeProductRequest{Type: flows.AirtimeProductTypeData, SKU: "6036"},
+		httpLogger.Log,
+	)
+	assert.EqualError(t, err, "product type 'data' not supported by DTOne service")
+
+	assert.Equal(t, 5, len(httpLogger.Logs))
+	assert.False(t, mocks.HasUnused())
+}
+
 func TestServiceFailedTransfers(t *testing.T) {
 	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
 	require.NoError(t, err)
@@ -117,10 +195,13 @@ func TestServiceFailedTransfers(t *testing.T) {
 	svc := dtone.NewService(http.DefaultClient, nil, "key123", "sesame")
 
 	httpLogger := &flows.HTTPLogger{}
-	amounts := map[string]decimal.Decimal{"USD": decimal.RequireFromString("3.5")}
+	request := &flows.AirtimeProductRequest{
+		Type:    flows.AirtimeProductTypeAirtime,
+		Amounts: map[string]decimal.Decimal{"USD": decimal.RequireFromString("3.5")},
+	}
 
 	// try when phone number lookup gives a connection error
-	transfer, err := svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err := svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "number lookup failed: unable to connect to server")
 	assert.Equal(t, urns.URN("tel:+593979000000"), transfer.Sender)
 	assert.Equal(t, urns.URN("tel:+593979123456"), transfer.Recipient)
@@ -128,32 +209,32 @@ func TestServiceFailedTransfers(t *testing.T) {
 	assert.Equal(t, decimal.Zero, transfer.ActualAmount)
 
 	// try when phone number lookup fails
-	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "number lookup failed: Credit party mobile number is invalid")
 	assert.NotNil(t, transfer)
 
 	// try when phone number lookup returns no matches
-	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "unable to find operator for number +593979123456")
 	assert.NotNil(t, transfer)
 
 	// try when product fetch fails
-	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "product fetch failed: Product is not available in your account")
 	assert.NotNil(t, transfer)
 
 	// try when we can't find any suitable products
-	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "unable to find a suitable product for operator 'Claro Ecuador'")
 	assert.NotNil(t, transfer)
 
 	// try when transaction request errors
-	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "transaction creation failed: Something went wrong")
 	assert.NotNil(t, transfer)
 
 	// try when transaction is rejected
-	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), amounts, httpLogger.Log)
+	transfer, err = svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), request, httpLogger.Log)
 	assert.EqualError(t, err, "transaction to send product 6035 on operator 1596 ended with status REJECTED-OPERATOR-CURRENTLY-UNAVAILABLE")
 	assert.NotNil(t, transfer)
 }
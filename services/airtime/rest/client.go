@@ -0,0 +1,221 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/gocommon/httpx"
+	"github.com/nyaruka/gocommon/jsonx"
+
+	"github.com/shopspring/decimal"
+)
+
+// default paths of the generic contract which can be overridden in the config. Placeholders like {msisdn} are
+// replaced with URL escaped values.
+const (
+	DefaultLookupPath   = "/operators/lookup?msisdn={msisdn}"
+	DefaultProductsPath = "/products?operator_id={operator_id}&type={type}"
+	DefaultTransferPath = "/transfers"
+	DefaultStatusPath   = "/transfers/{id}"
+)
+
+// TransferStatus is the status of a transfer
+type TransferStatus string
+
+// possible transfer statuses
+const (
+	TransferStatusPending TransferStatus = "pending"
+	TransferStatusSuccess TransferStatus = "success"
+	TransferStatusFailed  TransferStatus = "failed"
+)
+
+// Config is the configuration of a generic REST airtime API. Headers are sent with every request and so can be
+// used for authentication. Paths, and the interval and maximum number of checks of the status of a pending transfer,
+// use the defaults if left unset.
+type Config struct {
+	BaseURL      string
+	Headers      map[string]string
+	LookupPath   string
+	ProductsPath string
+	TransferPath string
+	StatusPath   string
+	PollInterval time.Duration
+	MaxPolls     int
+}
+
+// Client is a client for a generic REST airtime API which implements the following contract:
+//
+//   GET  /operators/lookup?msisdn={msisdn}                  -> {"id": "mtn-rw", "name": "MTN Rwanda", "country": "RW"}
+//   GET  /products?operator_id={operator_id}&type={type}     -> {"products": [{"sku": "MTN-500", "name": "500 RWF", "type": "airtime", "currency": "RWF", "amount": 500}]}
+//   POST /transfers {"external_id": "...", "sku": "MTN-500", "msisdn": "+250788123123"} -> {"id": "T123", "status": "pending"}
+//   GET  /transfers/{id}                                    -> {"id": "T123", "status": "success"}
+//
+// Errors should be returned with a non-2XX status code and a body like {"error": "message"}.
+type Client struct {
+	httpClient  *http.Client
+	httpRetries *httpx.RetryConfig
+	config      *Config
+}
+
+// NewClient creates a new generic REST airtime client
+func NewClient(httpClient *http.Client, httpRetries *httpx.RetryConfig, config *Config) *Client {
+	return &Client{httpClient: httpClient, httpRetries: httpRetries, config: config}
+}
+
+// error response contains the error when a request fails
+type errorResponse struct {
+	Message string `json:"error"`
+}
+
+func (e *errorResponse) Error() string {
+	return e.Message
+}
+
+// Operator is a mobile operator
+type Operator struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Country string `json:"country"`
+}
+
+// LookupOperator looks up the operator of the given mobile number
+func (c *Client) LookupOperator(msisdn string) (*Operator, *httpx.Trace, error) {
+	response := &Operator{}
+
+	path := expandPath(orDefault(c.config.LookupPath, DefaultLookupPath), map[string]string{"msisdn": msisdn})
+
+	trace, err := c.request("GET", path, nil, response)
+	if err != nil {
+		return nil, trace, err
+	}
+
+	return response, trace, nil
+}
+
+// Product is a product which can be transferred
+type Product struct {
+	SKU      string          `json:"sku"`
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// Products fetches the products of the given type for the given operator
+func (c *Client) Products(operatorID, productType string) ([]*Product, *httpx.Trace, error) {
+	response := &struct {
+		Products []*Product `json:"products"`
+	}{}
+
+	path := expandPath(orDefault(c.config.ProductsPath, DefaultProductsPath), map[string]string{"operator_id": operatorID, "type": productType})
+
+	trace, err := c.request("GET", path, nil, response)
+	if err != nil {
+		return nil, trace, err
+	}
+
+	return response.Products, trace, nil
+}
+
+// Transfer is a product transfer to a mobile number
+type Transfer struct {
+	ID      string         `json:"id"`
+	Status  TransferStatus `json:"status"`
+	Message string         `json:"message,omitempty"`
+}
+
+// CreateTransfer creates a new transfer of the given product to the given mobile number
+func (c *Client) CreateTransfer(externalID, sku, msisdn string) (*Transfer, *httpx.Trace, error) {
+	response := &Transfer{}
+
+	payload := &struct {
+		ExternalID string `json:"external_id"`
+		SKU        string `json:"sku"`
+		MSISDN     string `json:"msisdn"`
+	}{
+		ExternalID: externalID,
+		SKU:        sku,
+		MSISDN:     msisdn,
+	}
+
+	trace, err := c.request("POST", orDefault(c.config.TransferPath, DefaultTransferPath), payload, response)
+	if err != nil {
+		return nil, trace, err
+	}
+
+	return response, trace, nil
+}
+
+// TransferStatus fetches the current status of the transfer with the given ID
+func (c *Client) TransferStatus(id string) (*Transfer, *httpx.Trace, error) {
+	response := &Transfer{}
+
+	path := expandPath(orDefault(c.config.StatusPath, DefaultStatusPath), map[string]string{"id": id})
+
+	trace, err := c.request("GET", path, nil, response)
+	if err != nil {
+		return nil, trace, err
+	}
+
+	return response, trace, nil
+}
+
+func (c *Client) request(method, path string, payload interface{}, response interface{}) (*httpx.Trace, error) {
+	url := strings.TrimRight(c.config.BaseURL, "/") + path
+	headers := make(map[string]string, len(c.config.Headers)+1)
+	for k, v := range c.config.Headers {
+		headers[k] = v
+	}
+	var body io.Reader
+
+	if payload != nil {
+		data, err := jsonx.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+		headers["Content-Type"] = "application/json"
+	}
+
+	req, err := httpx.NewRequest(method, url, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	trace, err := httpx.DoTrace(c.httpClient, req, c.httpRetries, nil, -1)
+	if err != nil {
+		return trace, err
+	}
+
+	if trace.Response.StatusCode < 200 || trace.Response.StatusCode >= 300 {
+		response := &errorResponse{}
+		jsonx.Unmarshal(trace.ResponseBody, response)
+		if response.Message == "" {
+			response.Message = fmt.Sprintf("request failed with status %d", trace.Response.StatusCode)
+		}
+		return trace, response
+	}
+
+	return trace, jsonx.Unmarshal(trace.ResponseBody, response)
+}
+
+// replaces placeholders like {msisdn} in the given path with URL escaped values
+func expandPath(path string, values map[string]string) string {
+	replacements := make([]string, 0, len(values)*2)
+	for k, v := range values {
+		replacements = append(replacements, "{"+k+"}", url.QueryEscape(v))
+	}
+	return strings.NewReplacer(replacements...).Replace(path)
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
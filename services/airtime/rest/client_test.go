@@ -0,0 +1,121 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/httpx"
+	"github.com/nyaruka/goflow/services/airtime/rest"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	lookupResponse   = `{"id": "claro-ec", "name": "Claro Ecuador", "country": "EC"}`
+	productsResponse = `{"products": [
+		{"sku": "CLARO-3", "name": "3 USD", "type": "airtime", "currency": "USD", "amount": 3},
+		{"sku": "CLARO-6", "name": "6 USD", "type": "airtime", "currency": "USD", "amount": 6}
+	]}`
+	dataProductsResponse = `{"products": [
+		{"sku": "CLARO-1GB", "name": "1GB", "type": "data", "currency": "USD", "amount": 5},
+		{"sku": "CLARO-5GB", "name": "5GB", "type": "data", "currency": "USD", "amount": 15}
+	]}`
+	transferPendingResponse = `{"id": "T123", "status": "pending"}`
+	transferSuccessResponse = `{"id": "T123", "status": "success"}`
+	transferFailedResponse  = `{"id": "T123", "status": "failed", "message": "Operator unavailable"}`
+)
+
+var config = &rest.Config{
+	BaseURL:      "https://airtime.example.com/api/",
+	Headers:      map[string]string{"Authorization": "Token sesame"},
+	PollInterval: time.Millisecond,
+}
+
+func TestClient(t *testing.T) {
+	defer httpx.SetRequestor(httpx.DefaultRequestor)
+
+	mocks := httpx.NewMockRequestor(map[string][]httpx.MockResponse{
+		"https://airtime.example.com/api/operators/lookup?msisdn=%2B593979123456": {
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(404, nil, `{"error": "No operator found"}`),
+			httpx.NewMockResponse(500, nil, `Oops`),
+		},
+		"https://airtime.example.com/api/products?operator_id=claro-ec&type=airtime": {
+			httpx.NewMockResponse(200, nil, productsResponse),
+		},
+		"https://airtime.example.com/api/transfers": {
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+		},
+		"https://airtime.example.com/api/transfers/T123": {
+			httpx.NewMockResponse(200, nil, transferSuccessResponse),
+		},
+	})
+	httpx.SetRequestor(mocks)
+
+	cl := rest.NewClient(http.DefaultClient, nil, config)
+
+	operator, trace, err := cl.LookupOperator("+593979123456")
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.Operator{ID: "claro-ec", Name: "Claro Ecuador", Country: "EC"}, operator)
+	test.AssertSnapshot(t, "lookup_operator", string(trace.RequestTrace))
+
+	_, _, err = cl.LookupOperator("+593979123456")
+	assert.EqualError(t, err, "No operator found")
+
+	_, _, err = cl.LookupOperator("+593979123456")
+	assert.EqualError(t, err, "request failed with status 500")
+
+	products, trace, err := cl.Products("claro-ec", "airtime")
+	assert.NoError(t, err)
+	assert.Equal(t, []*rest.Product{
+		{SKU: "CLARO-3", Name: "3 USD", Type: "airtime", Currency: "USD", Amount: decimal.RequireFromString("3")},
+		{SKU: "CLARO-6", Name: "6 USD", Type: "airtime", Currency: "USD", Amount: decimal.RequireFromString("6")},
+	}, products)
+	test.AssertSnapshot(t, "products", string(trace.RequestTrace))
+
+	transfer, trace, err := cl.CreateTransfer("EX12345", "CLARO-3", "+593979123456")
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.Transfer{ID: "T123", Status: rest.TransferStatusPending}, transfer)
+	test.AssertSnapshot(t, "create_transfer", string(trace.RequestTrace))
+
+	transfer, trace, err = cl.TransferStatus("T123")
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.Transfer{ID: "T123", Status: rest.TransferStatusSuccess}, transfer)
+	test.AssertSnapshot(t, "transfer_status", string(trace.RequestTrace))
+
+	assert.False(t, mocks.HasUnused())
+}
+
+func TestClientWithCustomPaths(t *testing.T) {
+	defer httpx.SetRequestor(httpx.DefaultRequestor)
+
+	mocks := httpx.NewMockRequestor(map[string][]httpx.MockResponse{
+		"https://airtime.example.com/v2/lookup/%2B593979123456": {
+			httpx.NewMockResponse(200, nil, lookupResponse),
+		},
+		"https://airtime.example.com/v2/operators/claro-ec/data-products": {
+			httpx.NewMockResponse(200, nil, dataProductsResponse),
+		},
+	})
+	httpx.SetRequestor(mocks)
+
+	cl := rest.NewClient(http.DefaultClient, nil, &rest.Config{
+		BaseURL:      "https://airtime.example.com/v2",
+		LookupPath:   "/lookup/{msisdn}",
+		ProductsPath: "/operators/{operator_id}/{type}-products",
+	})
+
+	operator, _, err := cl.LookupOperator("+593979123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "claro-ec", operator.ID)
+
+	products, _, err := cl.Products("claro-ec", "data")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(products))
+	assert.Equal(t, "CLARO-1GB", products[0].SKU)
+
+	assert.False(t, mocks.HasUnused())
+}
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/nyaruka/gocommon/httpx"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// defaults for checking the status of a pending transfer which are used if they're not set in the config
+const (
+	DefaultMaxPolls     = 3
+	DefaultPollInterval = time.Second
+)
+
+type service struct {
+	client   *Client
+	config   *Config
+	redactor utils.Redactor
+}
+
+// NewService creates a new airtime service for a generic REST API. Header values in the config (e.g. authorization
+// tokens) are redacted from HTTP logs.
+func NewService(httpClient *http.Client, httpRetries *httpx.RetryConfig, config *Config) flows.AirtimeService {
+	secrets := make([]string, 0, len(config.Headers))
+	for _, v := range config.Headers {
+		secrets = append(secrets, v)
+	}
+
+	return &service{
+		client:   NewClient(httpClient, httpRetries, config),
+		config:   config,
+		redactor: utils.NewRedactor(flows.RedactionMask, secrets...),
+	}
+}
+
+func (s *service) Transfer(session flows.Session, sender urns.URN, recipient urns.URN, request *flows.AirtimeProductRequest, logHTTP flows.HTTPLogCallback) (*flows.AirtimeTransfer, error) {
+	productType := request.Type
+	if productType == "" {
+		productType = flows.AirtimeProductTypeAirtime
+	}
+
+	transfer := &flows.AirtimeTransfer{
+		UUID:          uuids.New(),
+		Sender:        sender,
+		Recipient:     recipient,
+		ProductType:   productType,
+		DesiredAmount: decimal.Zero,
+		ActualAmount:  decimal.Zero,
+	}
+
+	operator, trace, err := s.client.LookupOperator(recipient.Path())
+	if trace != nil {
+		logHTTP(flows.NewHTTPLog(trace, flows.HTTPStatusFromCode, s.redactor))
+	}
+	if err != nil {
+		return transfer, errors.Wrap(err, "operator lookup failed")
+	}
+
+	products, trace, err := s.client.Products(operator.ID, string(productType))
+	if trace != nil {
+		logHTTP(flows.NewHTTPLog(trace, flows.HTTPStatusFromCode, s.redactor))
+	}
+	if err != nil {
+		return transfer, errors.Wrap(err, "product fetch failed")
+	}
+
+	var product *Product
+
+	if request.SKU != "" {
+		for _, p := range products {
+			if p.SKU == request.SKU {
+				product = p
+				break
+			}
+		}
+		if product == nil {
+			return transfer, errors.Errorf("unable to find %s product with SKU '%s' for operator '%s'", productType, request.SKU, operator.Name)
+		}
+	} else {
+		product = closestProduct(products, request.Amounts)
+		if product == nil {
+			return transfer, errors.Errorf("unable to find a suitable %s product for operator '%s'", productType, operator.Name)
+		}
+	}
+
+	transfer.SKU = product.SKU
+	transfer.ProductName = product.Name
+	transfer.Currency = product.Currency
+	transfer.DesiredAmount = product.Amount
+	if desired, ok := request.Amounts[transfer.Currency]; ok {
+		transfer.DesiredAmount = desired
+	}
+
+	tx, trace, err := s.client.CreateTransfer(string(transfer.UUID), product.SKU, recipient.Path())
+	if trace != nil {
+		logHTTP(flows.NewHTTPLog(trace, flows.HTTPStatusFromCode, s.redactor))
+	}
+	if err != nil {
+		return transfer, errors.Wrap(err, "transfer creation failed")
+	}
+
+	// poll the status of the transfer until it's no longer pending
+	maxPolls := s.config.MaxPolls
+	if maxPolls <= 0 {
+		maxPolls = DefaultMaxPolls
+	}
+	pollInterval := s.config.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	for polls := 0; tx.Status == TransferStatusPending && polls < maxPolls; polls++ {
+		time.Sleep(pollInterval)
+
+		tx, trace, err = s.client.TransferStatus(tx.ID)
+		if trace != nil {
+			logHTTP(flows.NewHTTPLog(trace, flows.HTTPStatusFromCode, s.redactor))
+		}
+		if err != nil {
+			return transfer, errors.Wrap(err, "transfer status check failed")
+		}
+	}
+
+	if tx.Status == TransferStatusPending {
+		return transfer, errors.Errorf("transfer %s still pending after %d status checks", tx.ID, maxPolls)
+	}
+	if tx.Status != TransferStatusSuccess {
+		return transfer, errors.Errorf("transfer %s of product %s ended with status %s", tx.ID, product.SKU, tx.Status)
+	}
+
+	transfer.ActualAmount = product.Amount
+
+	return transfer, nil
+}
+
+// finds the product closest to but not exceeding the desired amount for any of the given currencies
+func closestProduct(products []*Product, amounts map[string]decimal.Decimal) *Product {
+	var closest *Product
+
+	for _, product := range products {
+		desiredAmount, hasAmount := amounts[product.Currency]
+		if !hasAmount || product.Amount.GreaterThan(desiredAmount) {
+			continue
+		}
+
+		if closest == nil || (closest.Currency == product.Currency && product.Amount.GreaterThan(closest.Amount)) {
+			closest = product
+		}
+	}
+
+	return closest
+}
@@ -0,0 +1,189 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nyaruka/gocommon/httpx"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/services/airtime/rest"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceWithSuccessfulTransfers(t *testing.T) {
+	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
+	require.NoError(t, err)
+
+	defer uuids.SetGenerator(uuids.DefaultGenerator)
+	defer httpx.SetRequestor(httpx.DefaultRequestor)
+
+	mocks := httpx.NewMockRequestor(map[string][]httpx.MockResponse{
+		"https://airtime.example.com/api/operators/lookup?msisdn=%2B593979123456": {
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+		},
+		"https://airtime.example.com/api/products?operator_id=claro-ec&type=airtime": {
+			httpx.NewMockResponse(200, nil, productsResponse),
+		},
+		"https://airtime.example.com/api/products?operator_id=claro-ec&type=data": {
+			httpx.NewMockResponse(200, nil, dataProductsResponse),
+		},
+		"https://airtime.example.com/api/transfers": {
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+			httpx.NewMockResponse(200, nil, transferSuccessResponse),
+		},
+		"https://airtime.example.com/api/transfers/T123": {
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+			httpx.NewMockResponse(200, nil, transferSuccessResponse),
+		},
+	})
+
+	uuids.SetGenerator(uuids.NewSeededGenerator(12345))
+	httpx.SetRequestor(mocks)
+
+	svc := rest.NewService(http.DefaultClient, nil, config)
+
+	httpLogger := &flows.HTTPLogger{}
+
+	// transfer airtime by amount, which requires polling for the transfer to complete
+	transfer, err := svc.Transfer(
+		session,
+		urns.URN("tel:+593979000000"),
+		urns.URN("tel:+593979123456"),
+		&flows.AirtimeProductRequest{
+			Type:    flows.AirtimeProductTypeAirtime,
+			Amounts: map[string]decimal.Decimal{"USD": decimal.RequireFromString("3.5"), "RWF": decimal.RequireFromString("5000")},
+		},
+		httpLogger.Log,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &flows.AirtimeTransfer{
+		UUID:          uuids.UUID("1ae96956-4b34-433e-8d1a-f05fe6923d6d"),
+		Sender:        urns.URN("tel:+593979000000"),
+		Recipient:     urns.URN("tel:+593979123456"),
+		ProductType:   flows.AirtimeProductTypeAirtime,
+		SKU:           "CLARO-3",
+		ProductName:   "3 USD",
+		Currency:      "USD",
+		DesiredAmount: decimal.RequireFromString("3.5"),
+		ActualAmount:  decimal.RequireFromString("3"),
+	}, transfer)
+
+	assert.Equal(t, 5, len(httpLogger.Logs))
+	assert.Equal(t, "GET /api/operators/lookup?msisdn=%2B593979123456 HTTP/1.1\r\nHost: airtime.example.com\r\nUser-Agent: Go-http-client/1.1\r\nAuthorization: ****************\r\nAccept-Encoding: gzip\r\n\r\n", httpLogger.Logs[0].Request)
+
+	// transfer a data bundle by SKU
+	transfer, err = svc.Transfer(
+		session,
+		urns.URN("tel:+593979000000"),
+		urns.URN("tel:+593979123456"),
+		&flows.AirtimeProductRequest{Type: flows.AirtimeProductTypeData, SKU: "CLARO-5GB"},
+		httpLogger.Log,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &flows.AirtimeTransfer{
+		UUID:          uuids.UUID("e7187099-7d38-4f60-955c-325957214c42"),
+		Sender:        urns.URN("tel:+593979000000"),
+		Recipient:     urns.URN("tel:+593979123456"),
+		ProductType:   flows.AirtimeProductTypeData,
+		SKU:           "CLARO-5GB",
+		ProductName:   "5GB",
+		Currency:      "USD",
+		DesiredAmount: decimal.RequireFromString("15"),
+		ActualAmount:  decimal.RequireFromString("15"),
+	}, transfer)
+
+	assert.Equal(t, 8, len(httpLogger.Logs))
+	assert.False(t, mocks.HasUnused())
+}
+
+func TestServiceFailedTransfers(t *testing.T) {
+	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
+	require.NoError(t, err)
+
+	defer httpx.SetRequestor(httpx.DefaultRequestor)
+
+	mocks := httpx.NewMockRequestor(map[string][]httpx.MockResponse{
+		"https://airtime.example.com/api/operators/lookup?msisdn=%2B593979123456": {
+			httpx.MockConnectionError,
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+			httpx.NewMockResponse(200, nil, lookupResponse),
+		},
+		"https://airtime.example.com/api/products?operator_id=claro-ec&type=airtime": {
+			httpx.NewMockResponse(400, nil, `{"error": "Invalid operator"}`),
+			httpx.NewMockResponse(200, nil, productsResponse),
+			httpx.NewMockResponse(200, nil, productsResponse),
+			httpx.NewMockResponse(200, nil, productsResponse),
+			httpx.NewMockResponse(200, nil, productsResponse),
+			httpx.NewMockResponse(200, nil, productsResponse),
+			httpx.NewMockResponse(200, nil, productsResponse),
+		},
+		"https://airtime.example.com/api/transfers": {
+			httpx.NewMockResponse(400, nil, `{"error": "Insufficient balance"}`),
+			httpx.NewMockResponse(200, nil, transferFailedResponse),
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+		},
+		"https://airtime.example.com/api/transfers/T123": {
+			httpx.NewMockResponse(500, nil, `{"error": "Server error"}`),
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+			httpx.NewMockResponse(200, nil, transferPendingResponse),
+		},
+	})
+	httpx.SetRequestor(mocks)
+
+	svc := rest.NewService(http.DefaultClient, nil, config)
+
+	httpLogger := &flows.HTTPLogger{}
+	request := &flows.AirtimeProductRequest{
+		Type:    flows.AirtimeProductTypeAirtime,
+		Amounts: map[string]decimal.Decimal{"USD": decimal.RequireFromString("3.5")},
+	}
+
+	transferWith := func(r *flows.AirtimeProductRequest) (*flows.AirtimeTransfer, error) {
+		return svc.Transfer(session, urns.URN("tel:+593979000000"), urns.URN("tel:+593979123456"), r, httpLogger.Log)
+	}
+
+	transfer, err := transferWith(request)
+	assert.EqualError(t, err, "operator lookup failed: unable to connect to server")
+	assert.Equal(t, decimal.Zero, transfer.DesiredAmount)
+	assert.Equal(t, decimal.Zero, transfer.ActualAmount)
+
+	_, err = transferWith(request)
+	assert.EqualError(t, err, "product fetch failed: Invalid operator")
+
+	_, err = transferWith(&flows.AirtimeProductRequest{Amounts: map[string]decimal.Decimal{"USD": decimal.RequireFromString("2")}})
+	assert.EqualError(t, err, "unable to find a suitable airtime product for operator 'Claro Ecuador'")
+
+	_, err = transferWith(&flows.AirtimeProductRequest{SKU: "CLARO-10"})
+	assert.EqualError(t, err, "unable to find airtime product with SKU 'CLARO-10' for operator 'Claro Ecuador'")
+
+	_, err = transferWith(request)
+	assert.EqualError(t, err, "transfer creation failed: Insufficient balance")
+
+	transfer, err = transferWith(request)
+	assert.EqualError(t, err, "transfer T123 of product CLARO-3 ended with status failed")
+	assert.Equal(t, "CLARO-3", transfer.SKU)
+	assert.Equal(t, decimal.Zero, transfer.ActualAmount)
+
+	_, err = transferWith(request)
+	assert.EqualError(t, err, "transfer status check failed: Server error")
+
+	_, err = transferWith(request)
+	assert.EqualError(t, err, "transfer T123 still pending after 3 status checks")
+
+	assert.False(t, mocks.HasUnused())
+}
@@ -0,0 +1,387 @@
+package rules
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Training is the training data for a rules based classifier, e.g.
+//
+//   {
+//     "min_confidence": 0.25,
+//     "intents": [
+//       {
+//         "name": "book_flight",
+//         "keywords": ["flight", "fly"],
+//         "patterns": ["\\bplane\\b"],
+//         "examples": ["I want to book a flight", "get me on a plane to Quito"]
+//       }
+//     ],
+//     "entities": [
+//       {
+//         "name": "destination",
+//         "patterns": ["\\bto ([A-Z]\\w+)"],
+//         "gazetteer": [{"value": "Quito", "synonyms": ["uio"]}]
+//       }
+//     ]
+//   }
+type Training struct {
+	MinConfidence float64        `json:"min_confidence,omitempty" validate:"min=0,max=1"`
+	Intents       []*IntentRules `json:"intents" validate:"dive"`
+	Entities      []*EntityRules `json:"entities,omitempty" validate:"dive"`
+}
+
+// DefaultMinConfidence is the confidence below which intents are omitted if training doesn't specify a value
+const DefaultMinConfidence = 0.2
+
+// IntentRules are the rules used to score an intent
+type IntentRules struct {
+	Name     string   `json:"name" validate:"required"`
+	Keywords []string `json:"keywords,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// EntityRules are the rules used to extract an entity
+type EntityRules struct {
+	Name      string           `json:"name" validate:"required"`
+	Patterns  []string         `json:"patterns,omitempty"`
+	Gazetteer []*GazetteerItem `json:"gazetteer,omitempty" validate:"dive"`
+}
+
+// GazetteerItem is a known entity value and its synonyms
+type GazetteerItem struct {
+	Value    string   `json:"value" validate:"required"`
+	Synonyms []string `json:"synonyms,omitempty"`
+}
+
+// ReadTraining reads training data from the given JSON
+func ReadTraining(data json.RawMessage) (*Training, error) {
+	t := &Training{}
+	if err := utils.UnmarshalAndValidate(data, t); err != nil {
+		return nil, errors.Wrap(err, "unable to read classifier training")
+	}
+	return t, nil
+}
+
+type intentModel struct {
+	name     string
+	keywords [][]string
+	patterns []*regexp.Regexp
+	examples []vector
+}
+
+type gazetteerPhrase struct {
+	value  string
+	tokens []string
+}
+
+type entityModel struct {
+	name     string
+	patterns []*regexp.Regexp
+	phrases  []gazetteerPhrase
+}
+
+// Model is a compiled rules based classifier which is safe for concurrent use
+type Model struct {
+	minConfidence float64
+	intents       []*intentModel
+	entities      []*entityModel
+	idf           map[string]float64
+	numDocs       int
+}
+
+// NewModel compiles a model from the given training data
+func NewModel(training *Training) (*Model, error) {
+	m := &Model{
+		minConfidence: training.MinConfidence,
+		intents:       make([]*intentModel, len(training.Intents)),
+		entities:      make([]*entityModel, len(training.Entities)),
+	}
+	if m.minConfidence == 0 {
+		m.minConfidence = DefaultMinConfidence
+	}
+
+	// the document frequencies for TF-IDF are calculated over all examples of all intents
+	docFreqs := make(map[string]int)
+	tokenizedExamples := make([][][]string, len(training.Intents))
+
+	for i, intent := range training.Intents {
+		tokenizedExamples[i] = make([][]string, len(intent.Examples))
+
+		for j, example := range intent.Examples {
+			tokens := tokenize(example)
+			tokenizedExamples[i][j] = tokens
+
+			for t := range termFrequencies(tokens) {
+				docFreqs[t]++
+			}
+			m.numDocs++
+		}
+	}
+
+	m.idf = make(map[string]float64, len(docFreqs))
+	for t, df := range docFreqs {
+		m.idf[t] = m.inverseDocFreq(df)
+	}
+
+	for i, intent := range training.Intents {
+		patterns, err := compilePatterns(intent.Patterns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern for intent '%s'", intent.Name)
+		}
+
+		im := &intentModel{
+			name:     intent.Name,
+			keywords: make([][]string, 0, len(intent.Keywords)),
+			patterns: patterns,
+			examples: make([]vector, 0, len(intent.Examples)),
+		}
+		for _, keyword := range intent.Keywords {
+			if tokens := tokenize(keyword); len(tokens) > 0 {
+				im.keywords = append(im.keywords, tokens)
+			}
+		}
+		for _, tokens := range tokenizedExamples[i] {
+			if len(tokens) > 0 {
+				im.examples = append(im.examples, m.vectorize(tokens))
+			}
+		}
+
+		m.intents[i] = im
+	}
+
+	for i, entity := range training.Entities {
+		patterns, err := compilePatterns(entity.Patterns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern for entity '%s'", entity.Name)
+		}
+
+		em := &entityModel{name: entity.Name, patterns: patterns}
+
+		for _, item := range entity.Gazetteer {
+			for _, phrase := range append([]string{item.Value}, item.Synonyms...) {
+				if tokens := tokenize(phrase); len(tokens) > 0 {
+					em.phrases = append(em.phrases, gazetteerPhrase{value: item.Value, tokens: tokens})
+				}
+			}
+		}
+
+		m.entities[i] = em
+	}
+
+	return m, nil
+}
+
+// Classify classifies the given input. Intents are scored in the range 0-1 by taking the highest of:
+//
+//   * 1 if any of the intent's patterns match the input
+//   * 1 - 0.5^N where N is the number of the intent's keywords found in the input
+//   * the highest cosine similarity between the input and any of the intent's examples
+//
+// Intents which score less than the minimum confidence are omitted, and the remaining intents are ordered by descending confidence.
+func (m *Model) Classify(input string) *flows.Classification {
+	tokens := tokenize(input)
+	inputVector := m.vectorize(tokens)
+
+	type scoredIntent struct {
+		name  string
+		score float64
+	}
+
+	scored := make([]scoredIntent, 0, len(m.intents))
+
+	for _, intent := range m.intents {
+		score := 0.0
+
+		for _, pattern := range intent.patterns {
+			if pattern.MatchString(input) {
+				score = 1
+				break
+			}
+		}
+
+		if score < 1 && len(intent.keywords) > 0 {
+			matched := 0
+			for _, keyword := range intent.keywords {
+				if indexOfPhrase(tokens, keyword) >= 0 {
+					matched++
+				}
+			}
+			score = math.Max(score, 1-math.Pow(0.5, float64(matched)))
+		}
+
+		for _, example := range intent.examples {
+			score = math.Max(score, inputVector.cosine(example))
+		}
+
+		// round to 4 decimal places for stable output
+		score = math.Round(score*10000) / 10000
+
+		if score > 0 && score >= m.minConfidence {
+			scored = append(scored, scoredIntent{name: intent.name, score: score})
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+
+	classification := &flows.Classification{
+		Intents:  make([]flows.ExtractedIntent, len(scored)),
+		Entities: make(map[string][]flows.ExtractedEntity),
+	}
+	for i, s := range scored {
+		classification.Intents[i] = flows.ExtractedIntent{Name: s.name, Confidence: decimal.NewFromFloat(s.score)}
+	}
+
+	for _, entity := range m.entities {
+		if values := entity.extract(input, tokens); len(values) > 0 {
+			extracted := make([]flows.ExtractedEntity, len(values))
+			for i, v := range values {
+				extracted[i] = flows.ExtractedEntity{Value: v, Confidence: decimal.New(1, 0)}
+			}
+			classification.Entities[entity.name] = extracted
+		}
+	}
+
+	return classification
+}
+
+// extracts the distinct values of this entity from the given input, in order of pattern and then gazetteer matches
+func (e *entityModel) extract(input string, tokens []string) []string {
+	values := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			values = append(values, v)
+			seen[v] = true
+		}
+	}
+
+	for _, pattern := range e.patterns {
+		for _, match := range pattern.FindAllStringSubmatch(input, -1) {
+			// if pattern has a capture group, the value is the first group, otherwise it's the whole match
+			if len(match) > 1 {
+				add(match[1])
+			} else {
+				add(match[0])
+			}
+		}
+	}
+
+	for _, phrase := range e.phrases {
+		if indexOfPhrase(tokens, phrase.tokens) >= 0 {
+			add(phrase.value)
+		}
+	}
+
+	return values
+}
+
+func (m *Model) inverseDocFreq(docFreq int) float64 {
+	return math.Log(float64(1+m.numDocs)/float64(1+docFreq)) + 1
+}
+
+// creates a normalized TF-IDF vector from the given tokens
+func (m *Model) vectorize(tokens []string) vector {
+	v := make(vector)
+	for t, tf := range termFrequencies(tokens) {
+		idf, known := m.idf[t]
+		if !known {
+			idf = m.inverseDocFreq(0)
+		}
+		v[t] = float64(tf) * idf
+	}
+	return v.normalize()
+}
+
+// a sparse term vector
+type vector map[string]float64
+
+func (v vector) normalize() vector {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum > 0 {
+		norm := math.Sqrt(sum)
+		for t := range v {
+			v[t] /= norm
+		}
+	}
+	return v
+}
+
+// calculates the cosine similarity of two normalized vectors
+func (v vector) cosine(other vector) float64 {
+	if len(other) < len(v) {
+		v, other = other, v
+	}
+	dot := 0.0
+	for t, x := range v {
+		dot += x * other[t]
+	}
+	return dot
+}
+
+func termFrequencies(tokens []string) map[string]int {
+	freqs := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		freqs[t]++
+	}
+	return freqs
+}
+
+// finds the index of the given phrase in the given tokens, or -1 if not found
+func indexOfPhrase(tokens []string, phrase []string) int {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		match := true
+		for j := range phrase {
+			if tokens[i+j] != phrase[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		r, err := regexp.Compile(`(?i)` + p)
+		if err != nil {
+			return nil, err
+		}
+		compiled[i] = r
+	}
+	return compiled, nil
+}
+
+// tokenizes the given text after lowercasing it and removing diacritics
+func tokenize(text string) []string {
+	// transformers aren't safe for concurrent use so we create a new one each time
+	diacriticRemover := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	normalized, _, err := transform.String(diacriticRemover, strings.ToLower(text))
+	if err != nil {
+		normalized = strings.ToLower(text)
+	}
+	return utils.TokenizeString(normalized)
+}
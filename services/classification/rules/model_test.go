@@ -0,0 +1,157 @@
+package rules_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/services/classification/rules"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTraining(t *testing.T) {
+	_, err := rules.ReadTraining([]byte(`{"intents": [{"keywords": ["yes"]}]}`))
+	assert.EqualError(t, err, "unable to read classifier training: field 'intents[0].name' is required")
+
+	_, err = rules.ReadTraining([]byte(`[]`))
+	assert.Error(t, err)
+
+	training, err := rules.ReadTraining([]byte(`{"intents": [{"name": "yes", "keywords": ["yes"]}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", training.Intents[0].Name)
+	assert.Equal(t, []string{"yes"}, training.Intents[0].Keywords)
+
+	_, err = rules.NewModel(&rules.Training{Intents: []*rules.IntentRules{{Name: "yes", Patterns: []string{`(`}}}})
+	assert.EqualError(t, err, "invalid pattern for intent 'yes': error parsing regexp: missing closing ): `(?i)(`")
+
+	_, err = rules.NewModel(&rules.Training{Entities: []*rules.EntityRules{{Name: "number", Patterns: []string{`[`}}}})
+	assert.EqualError(t, err, "invalid pattern for entity 'number': error parsing regexp: missing closing ]: `[`")
+}
+
+func TestModel(t *testing.T) {
+	training, err := rules.ReadTraining([]byte(`{
+		"intents": [
+			{
+				"name": "book_flight",
+				"keywords": ["flight", "fly", "air ticket"],
+				"patterns": ["\\bplane\\b"],
+				"examples": ["I want to book a flight", "get me a flight to Quito"]
+			},
+			{
+				"name": "book_hotel",
+				"keywords": ["hotel", "room"],
+				"examples": ["I need a hotel room", "book me a room for two nights"]
+			},
+			{
+				"name": "greeting",
+				"keywords": ["hello", "hi", "olá"]
+			}
+		],
+		"entities": [
+			{
+				"name": "nights",
+				"patterns": ["(\\d+) nights?"]
+			},
+			{
+				"name": "destination",
+				"gazetteer": [
+					{"value": "Quito", "synonyms": ["uio"]},
+					{"value": "Bogotá", "synonyms": ["bog"]}
+				]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	model, err := rules.NewModel(training)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		input    string
+		intents  []flows.ExtractedIntent
+		entities map[string][]flows.ExtractedEntity
+	}{
+		{
+			input:    "",
+			intents:  []flows.ExtractedIntent{},
+			entities: map[string][]flows.ExtractedEntity{},
+		},
+		{
+			input:    "nothing to see here",
+			intents:  []flows.ExtractedIntent{},
+			entities: map[string][]flows.ExtractedEntity{},
+		},
+		{
+			// matches by pattern
+			input: "I'm taking a PLANE to uio",
+			intents: []flows.ExtractedIntent{
+				{Name: "book_flight", Confidence: decimal.RequireFromString("1")},
+			},
+			entities: map[string][]flows.ExtractedEntity{
+				"destination": {{Value: "Quito", Confidence: decimal.RequireFromString("1")}},
+			},
+		},
+		{
+			// matches by keywords and examples
+			input: "Hotel room in Bogota for 3 nights",
+			intents: []flows.ExtractedIntent{
+				{Name: "book_hotel", Confidence: decimal.RequireFromString("0.75")},
+			},
+			entities: map[string][]flows.ExtractedEntity{
+				"nights":      {{Value: "3", Confidence: decimal.RequireFromString("1")}},
+				"destination": {{Value: "Bogotá", Confidence: decimal.RequireFromString("1")}},
+			},
+		},
+		{
+			// multi-word keywords and accents ignored
+			input: "Ola, I need an air ticket",
+			intents: []flows.ExtractedIntent{
+				{Name: "book_flight", Confidence: decimal.RequireFromString("0.5")},
+				{Name: "greeting", Confidence: decimal.RequireFromString("0.5")},
+				{Name: "book_hotel", Confidence: decimal.RequireFromString("0.2877")},
+			},
+			entities: map[string][]flows.ExtractedEntity{},
+		},
+		{
+			// exact example match
+			input: "i want to book a flight",
+			intents: []flows.ExtractedIntent{
+				{Name: "book_flight", Confidence: decimal.RequireFromString("1")},
+				{Name: "book_hotel", Confidence: decimal.RequireFromString("0.2459")},
+			},
+			entities: map[string][]flows.ExtractedEntity{},
+		},
+	}
+
+	for _, tc := range tcs {
+		classification := model.Classify(tc.input)
+
+		assert.Equal(t, tc.intents, classification.Intents, "intents mismatch for input '%s'", tc.input)
+		assert.Equal(t, tc.entities, classification.Entities, "entities mismatch for input '%s'", tc.input)
+	}
+}
+
+func TestModelConcurrentClassify(t *testing.T) {
+	training, err := rules.ReadTraining([]byte(`{"intents": [{"name": "greeting", "keywords": ["olá", "hello"]}]}`))
+	require.NoError(t, err)
+
+	model, err := rules.NewModel(training)
+	require.NoError(t, err)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				classification := model.Classify("Olá, tudo bem?")
+				assert.Equal(t, "greeting", classification.Intents[0].Name)
+			}
+		}()
+	}
+	wg.Wait()
+}
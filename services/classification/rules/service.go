@@ -0,0 +1,43 @@
+package rules
+
+import (
+	"encoding/json"
+
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/flows"
+
+	"github.com/pkg/errors"
+)
+
+// a classification service implementation which evaluates rules from the classifier's own training data
+// in-process, and so doesn't require any external API
+type service struct {
+	classifier *flows.Classifier
+	model      *Model
+}
+
+// NewService creates a new classification service from the training data of the given classifier
+func NewService(classifier *flows.Classifier) (flows.ClassificationService, error) {
+	var data json.RawMessage
+	if trained, ok := classifier.Asset().(assets.TrainedClassifier); ok {
+		data = trained.Training()
+	}
+
+	training, err := ReadTraining(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read training for classifier '%s'", classifier.Name())
+	}
+
+	model, err := NewModel(training)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to build model for classifier '%s'", classifier.Name())
+	}
+
+	return &service{classifier: classifier, model: model}, nil
+}
+
+func (s *service) Classify(session flows.Session, input string, logHTTP flows.HTTPLogCallback) (*flows.Classification, error) {
+	return s.model.Classify(input), nil
+}
+
+var _ flows.ClassificationService = (*service)(nil)
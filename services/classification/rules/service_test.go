@@ -0,0 +1,75 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/nyaruka/goflow/assets"
+	"github.com/nyaruka/goflow/assets/static/types"
+	"github.com/nyaruka/goflow/envs"
+	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/services/classification/rules"
+	"github.com/nyaruka/goflow/test"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a classifier asset which doesn't provide training data
+type untrainedClassifier struct{}
+
+func (c *untrainedClassifier) UUID() assets.ClassifierUUID {
+	return "37657cf7-5eab-4286-9cb0-bbf270587bad"
+}
+func (c *untrainedClassifier) Name() string      { return "Booking" }
+func (c *untrainedClassifier) Type() string      { return "rules" }
+func (c *untrainedClassifier) Intents() []string { return []string{"book_flight"} }
+
+func TestService(t *testing.T) {
+	session, _, err := test.CreateTestSession("", envs.RedactionPolicyNone)
+	require.NoError(t, err)
+
+	// classifier with no training
+	_, err = rules.NewService(test.NewClassifier("Booking", "rules", []string{"book_flight", "book_hotel"}))
+	assert.EqualError(t, err, "unable to read training for classifier 'Booking': unable to read classifier training: unexpected end of JSON input")
+
+	// classifier asset which doesn't provide training
+	_, err = rules.NewService(flows.NewClassifier(&untrainedClassifier{}))
+	assert.EqualError(t, err, "unable to read training for classifier 'Booking': unable to read classifier training: unexpected end of JSON input")
+
+	// classifier with invalid training
+	_, err = rules.NewService(flows.NewClassifier(types.NewTrainedClassifier(
+		assets.ClassifierUUID(uuids.New()), "Booking", "rules", []string{"book_flight"},
+		[]byte(`{"intents": [{"name": "book_flight", "patterns": ["("]}]}`),
+	)))
+	assert.EqualError(t, err, "unable to build model for classifier 'Booking': invalid pattern for intent 'book_flight': error parsing regexp: missing closing ): `(?i)(`")
+
+	svc, err := rules.NewService(flows.NewClassifier(types.NewTrainedClassifier(
+		assets.ClassifierUUID(uuids.New()), "Booking", "rules", []string{"book_flight", "book_hotel"},
+		[]byte(`{
+			"intents": [
+				{"name": "book_flight", "keywords": ["flight"], "examples": ["book me a flight"]},
+				{"name": "book_hotel", "keywords": ["hotel"], "examples": ["book me a hotel"]}
+			],
+			"entities": [
+				{"name": "destination", "gazetteer": [{"value": "Quito", "synonyms": ["uio"]}]}
+			]
+		}`),
+	)))
+	require.NoError(t, err)
+
+	httpLogger := &flows.HTTPLogger{}
+
+	classification, err := svc.Classify(session, "book flight to Quito", httpLogger.Log)
+	assert.NoError(t, err)
+	assert.Equal(t, []flows.ExtractedIntent{
+		{Name: "book_flight", Confidence: decimal.RequireFromString(`0.5`)},
+	}, classification.Intents)
+	assert.Equal(t, map[string][]flows.ExtractedEntity{
+		"destination": {{Value: "Quito", Confidence: decimal.RequireFromString(`1`)}},
+	}, classification.Entities)
+
+	// no HTTP calls are made
+	assert.Equal(t, 0, len(httpLogger.Logs))
+}
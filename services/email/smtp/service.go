@@ -1,34 +1,171 @@
 package smtp
 
 import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
 	"strings"
+	"sync"
 
+	"github.com/nyaruka/gocommon/httpx"
 	"github.com/nyaruka/goflow/flows"
+	"github.com/nyaruka/goflow/utils"
 	"github.com/nyaruka/goflow/utils/smtpx"
+
+	"github.com/pkg/errors"
 )
 
-type service struct {
-	client  *smtpx.Client
-	retries *smtpx.RetryConfig
+// ServiceFactory creates SMTP email services. Services which use the same SMTP URL share a client, so that connections
+// can be reused across sessions, and the factory should be closed when no longer needed to close those connections.
+type ServiceFactory struct {
+	retries            *smtpx.RetryConfig
+	httpClient         *http.Client
+	httpRetries        *httpx.RetryConfig
+	maxAttachmentBytes int
+
+	clientsMutex sync.Mutex
+	clients      map[string]*smtpx.Client
+}
+
+// NewServiceFactory creates a new SMTP email service factory
+func NewServiceFactory(retries *smtpx.RetryConfig, httpClient *http.Client, httpRetries *httpx.RetryConfig, maxAttachmentBytes int) *ServiceFactory {
+	return &ServiceFactory{
+		retries:            retries,
+		httpClient:         httpClient,
+		httpRetries:        httpRetries,
+		maxAttachmentBytes: maxAttachmentBytes,
+		clients:            make(map[string]*smtpx.Client),
+	}
+}
+
+// Service gets an email service which sends using the given SMTP URL
+func (f *ServiceFactory) Service(smtpURL string) (flows.EmailService, error) {
+	client, err := f.client(smtpURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewService(client, f.retries, f.httpClient, f.httpRetries, f.maxAttachmentBytes), nil
 }
 
-// NewService creates a new SMTP email service
-func NewService(smtpURL string, retries *smtpx.RetryConfig) (flows.EmailService, error) {
+// Close closes the clients of all services created by this factory
+func (f *ServiceFactory) Close() error {
+	f.clientsMutex.Lock()
+	defer f.clientsMutex.Unlock()
+
+	for _, c := range f.clients {
+		c.Close()
+	}
+	f.clients = make(map[string]*smtpx.Client)
+	return nil
+}
+
+// gets the client for the given SMTP URL, creating it if this is the first time it's been requested
+func (f *ServiceFactory) client(smtpURL string) (*smtpx.Client, error) {
+	f.clientsMutex.Lock()
+	defer f.clientsMutex.Unlock()
+
+	if c, ok := f.clients[smtpURL]; ok {
+		return c, nil
+	}
+
 	c, err := smtpx.NewClientFromURL(smtpURL)
 	if err != nil {
 		return nil, err
 	}
 
-	return &service{client: c, retries: retries}, nil
+	f.clients[smtpURL] = c
+	return c, nil
 }
 
-func (s *service) Send(session flows.Session, addresses []string, subject, body string) error {
+type service struct {
+	client             *smtpx.Client
+	retries            *smtpx.RetryConfig
+	httpClient         *http.Client
+	httpRetries        *httpx.RetryConfig
+	maxAttachmentBytes int
+}
+
+// NewService creates a new SMTP email service which sends using the given client. Attachments are fetched using the
+// given HTTP client and their combined size can't exceed maxAttachmentBytes.
+func NewService(client *smtpx.Client, retries *smtpx.RetryConfig, httpClient *http.Client, httpRetries *httpx.RetryConfig, maxAttachmentBytes int) flows.EmailService {
+	return &service{
+		client:             client,
+		retries:            retries,
+		httpClient:         httpClient,
+		httpRetries:        httpRetries,
+		maxAttachmentBytes: maxAttachmentBytes,
+	}
+}
+
+func (s *service) Send(session flows.Session, email *flows.Email) error {
 	// sending blank emails is a good way to get flagged as a spammer so use placeholder if body is empty
+	body := email.Body
 	if strings.TrimSpace(body) == "" {
 		body = "(empty body)"
 	}
 
-	m := smtpx.NewMessage(addresses, subject, body, "")
+	m := smtpx.NewMessage(email.To, email.Subject, body, email.HTMLBody)
+	m.AddCC(email.CC...)
+	m.AddBCC(email.BCC...)
+	m.SetReplyTo(email.ReplyTo)
+
+	remainingBytes := s.maxAttachmentBytes
+
+	for _, attachment := range email.Attachments {
+		if remainingBytes <= 0 {
+			return errors.Errorf("attachments exceed %d bytes limit", s.maxAttachmentBytes)
+		}
+
+		filename, contentType, data, err := s.fetchAttachment(attachment, remainingBytes)
+		if err != nil {
+			return err
+		}
+
+		m.Attach(filename, contentType, data)
+		remainingBytes -= len(data)
+	}
 
 	return smtpx.Send(s.client, m, s.retries)
 }
+
+// fetches the content of the given attachment, reading at most maxBytes
+func (s *service) fetchAttachment(attachment utils.Attachment, maxBytes int) (string, string, []byte, error) {
+	attachmentURL := attachment.URL()
+
+	request, err := httpx.NewRequest("GET", attachmentURL, nil, nil)
+	if err != nil {
+		return "", "", nil, errors.Wrapf(err, "unable to fetch attachment %s", attachmentURL)
+	}
+
+	trace, err := httpx.DoTrace(s.httpClient, request, s.httpRetries, nil, maxBytes)
+	if err != nil {
+		// if we got a response but couldn't read the body, it was too big
+		if trace != nil && trace.Response != nil {
+			return "", "", nil, errors.Errorf("attachments exceed %d bytes limit", s.maxAttachmentBytes)
+		}
+		return "", "", nil, errors.Wrapf(err, "unable to fetch attachment %s", attachmentURL)
+	}
+	if trace.Response.StatusCode/100 != 2 {
+		return "", "", nil, errors.Errorf("unable to fetch attachment %s: status code %d", attachmentURL, trace.Response.StatusCode)
+	}
+
+	// use the attachment's content type if it's a specific media type, otherwise whatever the server told us
+	contentType := attachment.ContentType()
+	if !strings.Contains(contentType, "/") || contentType == "application/octet-stream" {
+		contentType, _, _ = mime.ParseMediaType(trace.Response.Header.Get("Content-Type"))
+	}
+
+	return attachmentFilename(attachmentURL), contentType, trace.ResponseBody, nil
+}
+
+// gets a filename for an attachment from the last segment of its URL path
+func attachmentFilename(attachmentURL string) string {
+	if u, err := url.Parse(attachmentURL); err == nil {
+		if name := path.Base(u.Path); name != "." && name != "/" {
+			return name
+		}
+	}
+	return "attachment"
+}
@@ -42,7 +42,7 @@ func newEmailService() *emailService {
 	return &emailService{}
 }
 
-func (s *emailService) Send(session flows.Session, addresses []string, subject, body string) error {
+func (s *emailService) Send(session flows.Session, email *flows.Email) error {
 	return nil
 }
 
@@ -134,7 +134,7 @@ func newAirtimeService(currency string) *airtimeService {
 	return &airtimeService{fixedCurrency: currency}
 }
 
-func (s *airtimeService) Transfer(session flows.Session, sender urns.URN, recipient urns.URN, amounts map[string]decimal.Decimal, logHTTP flows.HTTPLogCallback) (*flows.AirtimeTransfer, error) {
+func (s *airtimeService) Transfer(session flows.Session, sender urns.URN, recipient urns.URN, request *flows.AirtimeProductRequest, logHTTP flows.HTTPLogCallback) (*flows.AirtimeTransfer, error) {
 	logHTTP(&flows.HTTPLog{
 		URL:       "http://send.airtime.com",
 		Request:   "GET / HTTP/1.1\r\nHost: send.airtime.com\r\nUser-Agent: Go-http-client/1.1\r\nAccept-Encoding: gzip\r\n\r\n",
@@ -144,7 +144,7 @@ func (s *airtimeService) Transfer(session flows.Session, sender urns.URN, recipi
 		ElapsedMS: 0,
 	})
 
-	amount, hasAmount := amounts[s.fixedCurrency]
+	amount, hasAmount := request.Amounts[s.fixedCurrency]
 	if !hasAmount {
 		return nil, errors.Errorf("no amount configured for transfers in %s", s.fixedCurrency)
 	}
@@ -152,6 +152,8 @@ func (s *airtimeService) Transfer(session flows.Session, sender urns.URN, recipi
 	transfer := &flows.AirtimeTransfer{
 		Sender:        sender,
 		Recipient:     recipient,
+		ProductType:   request.Type,
+		SKU:           request.SKU,
 		Currency:      s.fixedCurrency,
 		DesiredAmount: amount,
 		ActualAmount:  amount,
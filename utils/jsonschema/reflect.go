@@ -0,0 +1,293 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Reflector builds schema definitions from Go types using their `json` and `validate` struct tags. Fields which are
+// read as raw JSON can be given a `jsonschema` tag which names the definition they should reference.
+type Reflector struct {
+	definitions map[string]*Schema
+}
+
+// NewReflector creates a new reflector
+func NewReflector() *Reflector {
+	return &Reflector{definitions: make(map[string]*Schema)}
+}
+
+// Definitions returns all the definitions created by this reflector
+func (r *Reflector) Definitions() map[string]*Schema {
+	return r.definitions
+}
+
+// Define adds a definition with the given name, reflected from the type of the given value
+func (r *Reflector) Define(name string, v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	s := r.reflectType(t, false)
+	r.definitions[name] = s
+	return s
+}
+
+// DefineUnion adds a definition with the given name which allows any of the given definitions
+func (r *Reflector) DefineUnion(name string, members []string) *Schema {
+	s := &Schema{OneOf: make([]*Schema, len(members))}
+	for i, member := range members {
+		s.OneOf[i] = Ref(member)
+	}
+	r.definitions[name] = s
+	return s
+}
+
+// reflects a type, with named struct types being added as separate definitions if asRef is true
+func (r *Reflector) reflectType(t reflect.Type, asRef bool) *Schema {
+	switch {
+	case t == rawMessageType:
+		return &Schema{}
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// types with custom marshaling can't be described by reflection
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: Types{"string"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return r.reflectType(t.Elem(), asRef)
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: Types{"string"}} // encoded as base64
+		}
+		return &Schema{Type: Types{"array"}, Items: r.reflectType(t.Elem(), true)}
+	case reflect.Map:
+		return &Schema{Type: Types{"object"}, AdditionalProperties: r.reflectType(t.Elem(), true)}
+	case reflect.Struct:
+		if asRef && t.Name() != "" {
+			name := path.Base(t.PkgPath()) + "." + t.Name()
+			if _, exists := r.definitions[name]; !exists {
+				r.definitions[name] = nil // placeholder in case type is recursive
+				r.definitions[name] = r.reflectStruct(t)
+			}
+			return Ref(name)
+		}
+		return r.reflectStruct(t)
+	}
+
+	return &Schema{}
+}
+
+func (r *Reflector) reflectStruct(t reflect.Type) *Schema {
+	s := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema)}
+	r.addFields(s, t)
+	return s
+}
+
+func (r *Reflector) addFields(s *Schema, t reflect.Type) {
+	// embedded structs are added first so that their fields can be shadowed by fields of the outer struct
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _ := parseJSONTag(f.Tag.Get("json"))
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+			}
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name, omitEmpty := parseJSONTag(tag)
+
+		if tag == "-" || (f.Anonymous && name == "") || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs, required := r.reflectField(f, omitEmpty)
+		s.Properties[name] = fs
+
+		s.Required = removeString(s.Required, name)
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// reflects a struct field, returning its schema and whether it's required
+func (r *Reflector) reflectField(f reflect.StructField, omitEmpty bool) (*Schema, bool) {
+	ft := f.Type
+	var s *Schema
+
+	if ref := f.Tag.Get("jsonschema"); ref != "" {
+		if ft.Kind() == reflect.Slice && ft != rawMessageType {
+			s = &Schema{Type: Types{"array"}, Items: Ref(ref)}
+		} else {
+			s = Ref(ref)
+		}
+	} else {
+		s = r.reflectType(ft, true)
+	}
+
+	required := applyValidateTag(s, ft, f.Tag.Get("validate"))
+
+	// nil pointers, slices and maps are written as null unless they are omitted, and optional values are read the
+	// same whether they're null or omitted
+	nullable := omitEmpty || ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Map || (ft.Kind() == reflect.Slice && ft != rawMessageType)
+	if nullable && !required {
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, "null")
+		} else if s.Ref != "" {
+			s = &Schema{AnyOf: []*Schema{s, {Type: Types{"null"}}}}
+		}
+	}
+
+	return s, required
+}
+
+// applies the constraints from a validate tag to the given schema, returning whether the field is required
+func applyValidateTag(s *Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	required, omitEmpty := false, false
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, param = rule[:i], rule[i+1:]
+		}
+
+		switch key {
+		case "dive":
+			return required // remaining rules apply to items
+		case "required":
+			required = true
+		case "omitempty":
+			omitEmpty = true
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "http", "url":
+			s.Format = "uri"
+		case "min", "max":
+			if omitEmpty {
+				continue
+			}
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setLimit(s, t, key == "min", n)
+		case "oneof":
+			if t.Kind() == reflect.String {
+				s.Enum = stringEnum(strings.Fields(param), omitEmpty)
+			}
+		case "eq":
+			// alternatives like eq=a|eq=b are split out as separate rules
+			if t.Kind() == reflect.String {
+				values := make([]string, 0)
+				for _, alt := range strings.Split(rule, "|") {
+					values = append(values, strings.TrimPrefix(alt, "eq="))
+				}
+				s.Enum = stringEnum(values, omitEmpty)
+			}
+		}
+	}
+	return required
+}
+
+func setLimit(s *Schema, t reflect.Type, isMin bool, n int) {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if isMin {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case reflect.String:
+		if isMin {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		f := float64(n)
+		if isMin {
+			s.Minimum = &f
+		} else {
+			s.Maximum = &f
+		}
+	}
+}
+
+func stringEnum(values []string, allowEmpty bool) []interface{} {
+	enum := make([]interface{}, 0, len(values)+1)
+	for _, v := range values {
+		enum = append(enum, v)
+	}
+	if allowEmpty {
+		enum = append(enum, "")
+	}
+	return enum
+}
+
+func parseJSONTag(tag string) (string, bool) {
+	parts := strings.Split(tag, ",")
+	omitEmpty := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty
+}
+
+func removeString(s []string, v string) []string {
+	for i := range s {
+		if s[i] == v {
+			return append(s[:i], s[i+1:]...)
+		}
+	}
+	return s
+}
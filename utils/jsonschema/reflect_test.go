@@ -0,0 +1,84 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/jsonx"
+	"github.com/nyaruka/goflow/test"
+	"github.com/nyaruka/goflow/utils/jsonschema"
+
+	"github.com/stretchr/testify/require"
+)
+
+type baseThing struct {
+	Type string `json:"type" validate:"required"`
+}
+
+type Owner struct {
+	Name string `json:"name" validate:"required,min=1"`
+}
+
+type thing struct {
+	baseThing
+
+	UUID      string            `json:"uuid"                 validate:"required,uuid4"`
+	Color     string            `json:"color,omitempty"      validate:"omitempty,eq=red|eq=blue"`
+	Tags      []string          `json:"tags"                 validate:"min=1,max=3"`
+	Owner     *Owner            `json:"owner,omitempty"`
+	Counts    map[string]int    `json:"counts"`
+	CreatedOn time.Time         `json:"created_on"`
+	Parts     []json.RawMessage `json:"parts,omitempty"      jsonschema:"part"`
+	Ignored   string            `json:"-"`
+	private   string
+}
+
+func TestReflector(t *testing.T) {
+	r := jsonschema.NewReflector()
+	r.Define("thing", &thing{})
+	r.Define("part.wheel", &Owner{})
+	r.DefineUnion("part", []string{"part.wheel"})
+
+	doc := jsonschema.NewDocument(r.Definitions(), "thing")
+	actual, err := jsonx.Marshal(doc)
+	require.NoError(t, err)
+
+	test.AssertEqualJSON(t, []byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"$ref": "#/definitions/thing",
+		"definitions": {
+			"thing": {
+				"type": "object",
+				"properties": {
+					"type": {"type": "string"},
+					"uuid": {"type": "string", "format": "uuid"},
+					"color": {"type": ["string", "null"], "enum": ["red", "blue", ""]},
+					"tags": {"type": ["array", "null"], "items": {"type": "string"}, "minItems": 1, "maxItems": 3},
+					"owner": {"anyOf": [{"$ref": "#/definitions/jsonschema_test.Owner"}, {"type": "null"}]},
+					"counts": {"type": ["object", "null"], "additionalProperties": {"type": "integer"}},
+					"created_on": {"type": "string", "format": "date-time"},
+					"parts": {"type": ["array", "null"], "items": {"$ref": "#/definitions/part"}}
+				},
+				"required": ["type", "uuid"]
+			},
+			"jsonschema_test.Owner": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "minLength": 1}
+				},
+				"required": ["name"]
+			},
+			"part": {
+				"oneOf": [{"$ref": "#/definitions/part.wheel"}]
+			},
+			"part.wheel": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "minLength": 1}
+				},
+				"required": ["name"]
+			}
+		}
+	}`), actual, "schema mismatch")
+}
@@ -0,0 +1,118 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Draft is the version of the JSON Schema specification that our schemas conform to
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema document or subschema. It only supports the subset of keywords needed to describe
+// our own types.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Ref creates a new schema which references the definition with the given name
+func Ref(name string) *Schema {
+	return &Schema{Ref: definitionsPrefix + name}
+}
+
+// Types is the list of JSON types allowed by a schema
+type Types []string
+
+// MarshalJSON marshals these types into JSON, as a single string if there is only one
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON unmarshals these types from JSON which may be a single string or a list
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (t Types) has(typeName string) bool {
+	for _, n := range t {
+		if n == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+const definitionsPrefix = "#/definitions/"
+
+// NewDocument creates a new schema document for the given root definition. Only those definitions which are
+// reachable from the root are included.
+func NewDocument(definitions map[string]*Schema, root string) *Schema {
+	used := make(map[string]*Schema)
+	var visit func(*Schema)
+	visit = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			name := strings.TrimPrefix(s.Ref, definitionsPrefix)
+			if _, seen := used[name]; !seen && definitions[name] != nil {
+				used[name] = definitions[name]
+				visit(definitions[name])
+			}
+		}
+		visit(s.Items)
+		visit(s.AdditionalProperties)
+		for _, p := range s.Properties {
+			visit(p)
+		}
+		for _, o := range s.OneOf {
+			visit(o)
+		}
+		for _, o := range s.AnyOf {
+			visit(o)
+		}
+	}
+
+	doc := &Schema{Schema: Draft, Ref: definitionsPrefix + root}
+	visit(doc)
+	doc.Definitions = used
+	return doc
+}
+
+// DefinitionNames returns the sorted names of the definitions in this schema
+func (s *Schema) DefinitionNames() []string {
+	names := make([]string, 0, len(s.Definitions))
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
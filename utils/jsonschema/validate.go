@@ -0,0 +1,211 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// ValidationError is a failure of a JSON value to match a schema
+type ValidationError struct {
+	Path    string
+	Keyword string
+	Message string
+}
+
+// Error returns the error message including the path of the value which didn't validate
+func (e *ValidationError) Error() string {
+	p := e.Path
+	if p == "" {
+		p = "/"
+	}
+	return fmt.Sprintf("%s: %s", p, e.Message)
+}
+
+// Validate validates the given JSON against this schema, which must be a document if it references definitions
+func (s *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return errors.Wrap(err, "unable to parse JSON")
+	}
+
+	if verr := s.validate(s, value, ""); verr != nil {
+		return verr
+	}
+	return nil
+}
+
+func (s *Schema) validate(root *Schema, value interface{}, path string) *ValidationError {
+	fail := func(keyword, msg string, args ...interface{}) *ValidationError {
+		return &ValidationError{Path: path, Keyword: keyword, Message: fmt.Sprintf(msg, args...)}
+	}
+
+	if s.Ref != "" {
+		def := root.Definitions[strings.TrimPrefix(s.Ref, definitionsPrefix)]
+		if def == nil {
+			return fail("$ref", "unresolvable reference %s", s.Ref)
+		}
+		return def.validate(root, value, path)
+	}
+
+	if len(s.Type) > 0 && !s.Type.has(typeOf(value)) && !(s.Type.has("number") && typeOf(value) == "integer") {
+		return fail("type", "expected %s, found %s", strings.Join(s.Type, " or "), typeOf(value))
+	}
+
+	if s.Const != nil && !equal(s.Const, value) {
+		return fail("const", "must be %v", s.Const)
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("enum", "must be one of %v", s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fail("minLength", "must have a minimum length of %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fail("maxLength", "must have a maximum length of %d", *s.MaxLength)
+		}
+	case json.Number:
+		n, _ := strconv.ParseFloat(string(v), 64)
+		if s.Minimum != nil && n < *s.Minimum {
+			return fail("minimum", "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return fail("maximum", "must be at most %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fail("minItems", "must have a minimum of %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fail("maxItems", "must have a maximum of %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(root, item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, present := v[name]; !present {
+				return &ValidationError{Path: path + "/" + name, Keyword: "required", Message: "is required"}
+			}
+		}
+		for name, item := range v {
+			ps := s.Properties[name]
+			if ps == nil {
+				ps = s.AdditionalProperties
+			}
+			if ps != nil {
+				if err := ps.validate(root, item, path+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		matches, best := 0, (*ValidationError)(nil)
+		for _, o := range s.OneOf {
+			if err := o.validate(root, value, path); err != nil {
+				best = closest(best, err)
+			} else {
+				matches++
+			}
+		}
+		if matches == 0 {
+			return best
+		} else if matches > 1 {
+			return fail("oneOf", "matches %d schemas when it should only match one", matches)
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		var best *ValidationError
+		for _, o := range s.AnyOf {
+			err := o.validate(root, value, path)
+			if err == nil {
+				best = nil
+				break
+			}
+			best = closest(best, err)
+		}
+		if best != nil {
+			return best
+		}
+	}
+
+	return nil
+}
+
+// when no alternative matches, the most useful error is generally the one which got the furthest into the value,
+// and for equally deep errors, not the one which failed on a discriminator like a type const
+func closest(e1, e2 *ValidationError) *ValidationError {
+	if e1 == nil {
+		return e2
+	}
+	d1, d2 := strings.Count(e1.Path, "/"), strings.Count(e2.Path, "/")
+	if d2 > d1 || (d2 == d1 && e1.Keyword == "const" && e2.Keyword != "const") {
+		return e2
+	}
+	return e1
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func equal(expected, value interface{}) bool {
+	if n, isNumber := value.(json.Number); isNumber {
+		f1, _ := strconv.ParseFloat(string(n), 64)
+		switch e := expected.(type) {
+		case int:
+			return f1 == float64(e)
+		case float64:
+			return f1 == e
+		}
+		return false
+	}
+	return reflect.DeepEqual(expected, value)
+}
@@ -0,0 +1,47 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/goflow/utils/jsonschema"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	r := jsonschema.NewReflector()
+	r.Define("thing", &thing{})
+	r.Define("part.wheel", &Owner{})
+	r.Definitions()["part.wheel"].Properties["type"] = &jsonschema.Schema{Const: "wheel"}
+	r.DefineUnion("part", []string{"part.wheel"})
+
+	doc := jsonschema.NewDocument(r.Definitions(), "thing")
+
+	tcs := []struct {
+		json string
+		err  string
+	}{
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "tags": ["a"], "created_on": "2021-01-01T00:00:00Z"}`, ""},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "color": "red", "owner": null, "counts": {"x": 1}}`, ""},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "parts": [{"type": "wheel", "name": "Front"}]}`, ""},
+		{`[]`, "/: expected object, found array"},
+		{`{"uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2"}`, "/type: is required"},
+		{`{"type": 1, "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2"}`, "/type: expected string, found integer"},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "color": "green"}`, "/color: must be one of [red blue ]"},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "tags": []}`, "/tags: must have a minimum of 1 items"},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "counts": {"x": 1.5}}`, "/counts/x: expected integer, found number"},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "owner": {"name": ""}}`, "/owner/name: must have a minimum length of 1"},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "parts": [{"type": "wheel"}]}`, "/parts/0/name: is required"},
+		{`{"type": "car", "uuid": "c2a6a30e-2a51-4fa8-b1d7-5ea1e2d7a4c2", "parts": [{"type": "door", "name": "Left"}]}`, "/parts/0/type: must be wheel"},
+		{`{"type": "car"`, "unable to parse JSON: unexpected EOF"},
+	}
+
+	for _, tc := range tcs {
+		err := doc.Validate([]byte(tc.json))
+		if tc.err == "" {
+			assert.NoError(t, err, "unexpected error validating %s", tc.json)
+		} else {
+			assert.EqualError(t, err, tc.err, "error mismatch validating %s", tc.json)
+		}
+	}
+}
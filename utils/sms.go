@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// SMSEncoding is an encoding used to send SMS messages
+type SMSEncoding string
+
+// the different SMS encodings
+const (
+	SMSEncodingGSM7 SMSEncoding = "gsm7"
+	SMSEncodingUCS2 SMSEncoding = "ucs2"
+)
+
+// maximum lengths of single and multipart SMS segments in each encoding
+const (
+	gsm7SingleLength = 160
+	gsm7PartLength   = 153
+	ucs2SingleLength = 70
+	ucs2PartLength   = 67
+)
+
+// characters in the GSM 03.38 basic character set
+const gsm7BasicChars = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// characters in the GSM 03.38 extension table, which each take two septets
+const gsm7ExtendedChars = "\f^{}\\[~]|€"
+
+var gsm7Basic, gsm7Extended = runeSet(gsm7BasicChars), runeSet(gsm7ExtendedChars)
+
+func runeSet(s string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
+
+// IsGSM7 returns whether the given text can be encoded using the GSM-7 alphabet
+func IsGSM7(text string) bool {
+	for _, r := range text {
+		if !gsm7Basic[r] && !gsm7Extended[r] {
+			return false
+		}
+	}
+	return true
+}
+
+// SMSSegments returns the number of SMS segments needed to send the given text. If the encoding is GSM-7 but the text
+// contains characters outside of the GSM-7 alphabet then it's counted as UCS-2.
+func SMSSegments(text string, encoding SMSEncoding) int {
+	if text == "" {
+		return 0
+	}
+
+	var length, singleLength, partLength int
+
+	if encoding != SMSEncodingUCS2 && IsGSM7(text) {
+		for _, r := range text {
+			if gsm7Extended[r] {
+				length += 2
+			} else {
+				length++
+			}
+		}
+		singleLength, partLength = gsm7SingleLength, gsm7PartLength
+	} else {
+		length = len(utf16.Encode([]rune(text)))
+		singleLength, partLength = ucs2SingleLength, ucs2PartLength
+	}
+
+	if length <= singleLength {
+		return 1
+	}
+	return (length + partLength - 1) / partLength
+}
+
+// SplitText splits the given text into parts which are no longer than the given number of characters and, if an
+// encoding is given, which each fit in a single SMS segment of that encoding. Text is split on whitespace where
+// possible, and words are only split if they are themselves too long for a part.
+func SplitText(text string, maxLength int, encoding SMSEncoding) []string {
+	fits := func(part []rune) bool {
+		if maxLength > 0 && len(part) > maxLength {
+			return false
+		}
+		return encoding == "" || SMSSegments(string(part), encoding) <= 1
+	}
+
+	rest := []rune(strings.TrimSpace(text))
+
+	if (maxLength <= 0 && encoding == "") || fits(rest) {
+		return []string{string(rest)}
+	}
+
+	parts := make([]string, 0)
+
+	for !fits(rest) {
+		// find the longest prefix which fits, which is always at least one character
+		limit := sort.Search(len(rest), func(n int) bool { return !fits(rest[:n+1]) })
+		if limit == 0 {
+			limit = 1
+		}
+
+		// look for the last whitespace which would let us end this part within the limit
+		end := limit
+		for i := limit; i > 0; i-- {
+			if unicode.IsSpace(rest[i]) {
+				end = i
+				break
+			}
+		}
+
+		parts = append(parts, strings.TrimRightFunc(string(rest[:end]), unicode.IsSpace))
+		rest = []rune(strings.TrimLeftFunc(string(rest[end:]), unicode.IsSpace))
+	}
+
+	if len(rest) > 0 {
+		parts = append(parts, string(rest))
+	}
+
+	return parts
+}
@@ -0,0 +1,80 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/goflow/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGSM7(t *testing.T) {
+	assert.True(t, utils.IsGSM7(""))
+	assert.True(t, utils.IsGSM7("Hello World!"))
+	assert.True(t, utils.IsGSM7("Ça coute 5€ {approx}"))
+	assert.False(t, utils.IsGSM7("Ça coûte 5€"))
+	assert.False(t, utils.IsGSM7("Hello 😀"))
+	assert.False(t, utils.IsGSM7("مرحبا"))
+}
+
+func TestSMSSegments(t *testing.T) {
+	tcs := []struct {
+		text     string
+		encoding utils.SMSEncoding
+		segments int
+	}{
+		{"", utils.SMSEncodingGSM7, 0},
+		{"hello", utils.SMSEncodingGSM7, 1},
+		{"hello", utils.SMSEncodingUCS2, 1},
+		{strings.Repeat("x", 160), utils.SMSEncodingGSM7, 1},
+		{strings.Repeat("x", 161), utils.SMSEncodingGSM7, 2},
+		{strings.Repeat("x", 306), utils.SMSEncodingGSM7, 2},
+		{strings.Repeat("x", 307), utils.SMSEncodingGSM7, 3},
+		{strings.Repeat("€", 80), utils.SMSEncodingGSM7, 1},  // extended chars take two septets
+		{strings.Repeat("€", 81), utils.SMSEncodingGSM7, 2},  // extended chars take two septets
+		{strings.Repeat("x", 70), utils.SMSEncodingUCS2, 1},  // forced UCS-2
+		{strings.Repeat("x", 71), utils.SMSEncodingUCS2, 2},  // forced UCS-2
+		{strings.Repeat("é", 160), utils.SMSEncodingGSM7, 1}, // é is in GSM-7
+		{strings.Repeat("ê", 70), utils.SMSEncodingGSM7, 1},  // ê isn't so falls back to UCS-2
+		{strings.Repeat("ê", 71), utils.SMSEncodingGSM7, 2},
+		{strings.Repeat("ê", 134), utils.SMSEncodingGSM7, 2},
+		{strings.Repeat("ê", 135), utils.SMSEncodingGSM7, 3},
+		{strings.Repeat("😀", 35), utils.SMSEncodingGSM7, 1}, // emojis are surrogate pairs in UCS-2
+		{strings.Repeat("😀", 36), utils.SMSEncodingGSM7, 2},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.segments, utils.SMSSegments(tc.text, tc.encoding), "segments mismatch for '%s' (%s)", tc.text, tc.encoding)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tcs := []struct {
+		text      string
+		maxLength int
+		encoding  utils.SMSEncoding
+		parts     []string
+	}{
+		{"", 10, "", []string{""}},
+		{"hello world", 0, "", []string{"hello world"}},
+		{" hello world ", 11, "", []string{"hello world"}},
+		{"hello world", 10, "", []string{"hello", "world"}},
+		{"one two three four five", 9, "", []string{"one two", "three", "four five"}},
+		{"one two\nthree four", 14, "", []string{"one two\nthree", "four"}},
+		{"one   two", 4, "", []string{"one", "two"}},
+		{"supercalifragilistic is long", 10, "", []string{"supercalif", "ragilistic", "is long"}},
+		{"héllo wörld ça va", 11, "", []string{"héllo wörld", "ça va"}},
+
+		// parts must also fit in a single SMS segment
+		{strings.Repeat("abcd ", 40), 0, utils.SMSEncodingGSM7, []string{strings.Repeat("abcd ", 31) + "abcd", strings.Repeat("abcd ", 7) + "abcd"}},
+		{strings.Repeat("abcd ", 40), 0, utils.SMSEncodingUCS2, []string{strings.Repeat("abcd ", 13) + "abcd", strings.Repeat("abcd ", 13) + "abcd", strings.Repeat("abcd ", 11) + "abcd"}},
+		{strings.Repeat("ab{} ", 40), 0, utils.SMSEncodingGSM7, []string{strings.Repeat("ab{} ", 22) + "ab{}", strings.Repeat("ab{} ", 16) + "ab{}"}},
+		{strings.Repeat("abcê ", 40), 0, utils.SMSEncodingGSM7, []string{strings.Repeat("abcê ", 13) + "abcê", strings.Repeat("abcê ", 13) + "abcê", strings.Repeat("abcê ", 11) + "abcê"}},
+		{strings.Repeat("abcd ", 40), 50, utils.SMSEncodingGSM7, []string{strings.Repeat("abcd ", 9) + "abcd", strings.Repeat("abcd ", 9) + "abcd", strings.Repeat("abcd ", 9) + "abcd", strings.Repeat("abcd ", 9) + "abcd"}},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.parts, utils.SplitText(tc.text, tc.maxLength, tc.encoding), "parts mismatch for '%s' split by %d (%s)", tc.text, tc.maxLength, tc.encoding)
+	}
+}
@@ -0,0 +1,172 @@
+package smtpx
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/gocommon/dates"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDKIMHeaders are the headers which are signed if they're present in a message
+var DefaultDKIMHeaders = []string{"From", "To", "Cc", "Reply-To", "Subject", "Date", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"}
+
+// DKIMConfig configures DKIM signing of messages
+type DKIMConfig struct {
+	Domain     string
+	Selector   string
+	PrivateKey *rsa.PrivateKey
+	Headers    []string
+}
+
+// ParseDKIMKey parses a PEM encoded RSA private key in PKCS #1 or PKCS #8 form
+func ParseDKIMKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found in DKIM key")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if rsaKey, isRSA := key.(*rsa.PrivateKey); isRSA {
+			return rsaKey, nil
+		}
+		return nil, errors.New("DKIM key must be an RSA key")
+	}
+
+	return nil, errors.Errorf("unsupported DKIM key type: %s", block.Type)
+}
+
+// Sign signs the given MIME message (headers and body separated by an empty line, with CRLF line endings) and
+// returns it with a DKIM-Signature header prepended. Both headers and body use relaxed canonicalization.
+func (d *DKIMConfig) Sign(message []byte) ([]byte, error) {
+	rawHeaders, body := splitMessage(message)
+	headers := parseHeaders(rawHeaders)
+
+	bodyHash := sha256.Sum256([]byte(relaxedBody(body)))
+
+	// work out which of the headers to sign we actually have, using the last instance of any repeated header
+	toSign := d.Headers
+	if len(toSign) == 0 {
+		toSign = DefaultDKIMHeaders
+	}
+
+	signedNames := make([]string, 0, len(toSign))
+	signedHeaders := &strings.Builder{}
+
+	for _, name := range toSign {
+		for i := len(headers) - 1; i >= 0; i-- {
+			if strings.EqualFold(headers[i].name, name) {
+				signedNames = append(signedNames, strings.ToLower(name))
+				signedHeaders.WriteString(relaxedHeader(headers[i].name, headers[i].value))
+				signedHeaders.WriteString("\r\n")
+				break
+			}
+		}
+	}
+
+	value := fmt.Sprintf("v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; t=%d;\r\n\th=%s;\r\n\tbh=%s;\r\n\tb=",
+		d.Domain, d.Selector, dates.Now().Unix(), strings.Join(signedNames, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]),
+	)
+
+	// the signature header itself is signed, without its trailing CRLF and with an empty signature
+	signedHeaders.WriteString(relaxedHeader("DKIM-Signature", value))
+
+	headersHash := sha256.Sum256([]byte(signedHeaders.String()))
+	signature, err := d.PrivateKey.Sign(rand.Reader, headersHash[:], crypto.SHA256)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating DKIM signature")
+	}
+
+	signed := &bytes.Buffer{}
+	signed.WriteString("DKIM-Signature: ")
+	signed.WriteString(value)
+	signed.WriteString(base64.StdEncoding.EncodeToString(signature))
+	signed.WriteString("\r\n")
+	signed.Write(message)
+
+	return signed.Bytes(), nil
+}
+
+type header struct {
+	name  string
+	value string
+}
+
+// splits a message into its raw headers and body
+func splitMessage(message []byte) (string, string) {
+	s := string(message)
+	if i := strings.Index(s, "\r\n\r\n"); i >= 0 {
+		return s[:i+2], s[i+4:]
+	}
+	return s, ""
+}
+
+// parses raw headers, keeping folded continuation lines as part of their header's value
+func parseHeaders(raw string) []header {
+	headers := make([]header, 0, 10)
+
+	for _, line := range strings.SplitAfter(raw, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1].value += line
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			continue
+		}
+		headers = append(headers, header{name: line[:colon], value: line[colon+1:]})
+	}
+
+	return headers
+}
+
+var wspRegex = regexp.MustCompile(`[ \t]+`)
+
+// canonicalizes a header using the relaxed algorithm, see https://tools.ietf.org/html/rfc6376#section-3.4.2
+func relaxedHeader(name, value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = wspRegex.ReplaceAllString(value, " ")
+
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(value)
+}
+
+// canonicalizes a body using the relaxed algorithm, see https://tools.ietf.org/html/rfc6376#section-3.4.4
+func relaxedBody(body string) string {
+	lines := strings.Split(body, "\r\n")
+
+	for i := range lines {
+		lines[i] = strings.TrimRight(wspRegex.ReplaceAllString(lines[i], " "), " ")
+	}
+
+	// remove empty lines at the end of the body
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
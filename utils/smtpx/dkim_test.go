@@ -0,0 +1,153 @@
+package smtpx
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/dates"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelaxedCanonicalization(t *testing.T) {
+	// example from https://tools.ietf.org/html/rfc6376#section-3.4.5
+	headers := parseHeaders("A: X\r\nB : Y\t\r\n\tZ  \r\n")
+
+	assert.Equal(t, 2, len(headers))
+	assert.Equal(t, "a:X", relaxedHeader(headers[0].name, headers[0].value))
+	assert.Equal(t, "b:Y Z", relaxedHeader(headers[1].name, headers[1].value))
+
+	assert.Equal(t, " C\r\nD E\r\n", relaxedBody(" C \r\nD \t E\r\n\r\n\r\n"))
+	assert.Equal(t, "", relaxedBody(""))
+	assert.Equal(t, "", relaxedBody("\r\n\r\n"))
+	assert.Equal(t, "Hi\r\n", relaxedBody("Hi"))
+}
+
+func TestParseDKIMKey(t *testing.T) {
+	_, err := ParseDKIMKey([]byte("xyz"))
+	assert.EqualError(t, err, "no PEM data found in DKIM key")
+
+	_, err = ParseDKIMKey(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}}))
+	assert.EqualError(t, err, "unsupported DKIM key type: CERTIFICATE")
+
+	key := newTestDKIMKey(t)
+
+	parsed, err := ParseDKIMKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	assert.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	parsed, err = ParseDKIMKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	assert.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+}
+
+func TestDKIMSign(t *testing.T) {
+	defer dates.SetNowSource(dates.DefaultNowSource)
+
+	dates.SetNowSource(dates.NewFixedNowSource(time.Date(2021, 4, 13, 14, 30, 0, 0, time.UTC)))
+
+	key := newTestDKIMKey(t)
+	dkim := &DKIMConfig{Domain: "temba.io", Selector: "s1", PrivateKey: key}
+
+	message := "From: updates@temba.io\r\nTo: bob@nyaruka.com\r\nSubject: Hi  there\r\nX-Other: foo\r\n\r\nHello \r\nBob\r\n\r\n"
+
+	signed, err := dkim.Sign([]byte(message))
+	require.NoError(t, err)
+
+	// original message is unchanged after the signature header
+	assert.True(t, strings.HasSuffix(string(signed), "\r\n"+message))
+	assert.True(t, strings.HasPrefix(string(signed), "DKIM-Signature: v=1; a=rsa-sha256; c=relaxed/relaxed; d=temba.io; s=s1; t=1618324200;\r\n\th=from:to:subject;\r\n\tbh="))
+
+	verifyDKIM(t, &key.PublicKey, signed)
+}
+
+func TestClientSendWithDKIM(t *testing.T) {
+	server := newTestServer(t, nil, false)
+	defer server.close()
+
+	key := newTestDKIMKey(t)
+
+	c := NewClient("127.0.0.1", server.port(), "leah", "pass123", "updates@temba.io", &Options{
+		TLSMode: TLSModeNone,
+		DKIM:    &DKIMConfig{Domain: "temba.io", Selector: "s1", PrivateKey: key},
+	})
+	defer c.Close()
+
+	msg := NewMessage([]string{"bob@nyaruka.com"}, "Updates", "Have a great week", "<p>Have a great week</p>")
+	msg.Attach("report.csv", "text/csv", []byte("name,age\nBob,32\n"))
+
+	err := c.Send(msg)
+	assert.NoError(t, err)
+
+	_, messages := server.stats()
+	assert.Equal(t, 1, len(messages))
+
+	// message as received by the server (i.e. after dot-unstuffing and with CRLF line endings) should verify
+	data := strings.ReplaceAll(strings.ReplaceAll(messages[0].data, "\r\n", "\n"), "\n", "\r\n")
+	verifyDKIM(t, &key.PublicKey, []byte(data))
+}
+
+var dkimTagRegex = regexp.MustCompile(`(\w+)=([^;]*)`)
+
+// verifies a DKIM signed message independently of the signing code paths
+func verifyDKIM(t *testing.T, publicKey *rsa.PublicKey, message []byte) {
+	rawHeaders, body := splitMessage(message)
+	headers := parseHeaders(rawHeaders)
+
+	require.Equal(t, "DKIM-Signature", headers[0].name)
+
+	tags := make(map[string]string)
+	for _, match := range dkimTagRegex.FindAllStringSubmatch(headers[0].value, -1) {
+		tags[match[1]] = strings.Join(strings.Fields(match[2]), "")
+	}
+
+	bodyHash := sha256.Sum256([]byte(relaxedBody(body)))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(bodyHash[:]), tags["bh"], "body hash mismatch")
+
+	signedHeaders := &strings.Builder{}
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(headers) - 1; i > 0; i-- {
+			if strings.EqualFold(headers[i].name, name) {
+				signedHeaders.WriteString(relaxedHeader(headers[i].name, headers[i].value) + "\r\n")
+				break
+			}
+		}
+	}
+	signedHeaders.WriteString(relaxedHeader("DKIM-Signature", regexp.MustCompile(`b=[^;]*$`).ReplaceAllString(headers[0].value, "b=")))
+
+	signature, err := base64.StdEncoding.DecodeString(tags["b"])
+	require.NoError(t, err)
+
+	headersHash := sha256.Sum256([]byte(signedHeaders.String()))
+	assert.NoError(t, rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, headersHash[:], signature), "signature doesn't verify")
+}
+
+func newTestDKIMKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// writes a new DKIM key to a temporary file and returns its path
+func writeTestDKIMKey(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "dkim.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(newTestDKIMKey(t))})
+
+	require.NoError(t, ioutil.WriteFile(path, keyPEM, 0600))
+	return path
+}
@@ -1,7 +1,10 @@
 package smtpx
 
 import (
+	"bytes"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -33,15 +36,49 @@ func (s *MockSender) Send(c *Client, m *Message) error {
 
 	b := &strings.Builder{}
 	b.WriteString("HELO localhost\n")
-	b.WriteString(fmt.Sprintf("MAIL FROM:<%s>\n", c.from))
-	for _, r := range m.recipients {
-		b.WriteString(fmt.Sprintf("RCPT TO:<%s>\n", r))
+	b.WriteString(fmt.Sprintf("MAIL FROM:<%s>\n", envelopeAddress(c.from)))
+	for _, r := range m.allRecipients() {
+		b.WriteString(fmt.Sprintf("RCPT TO:<%s>\n", envelopeAddress(r)))
 	}
 	b.WriteString("DATA\n")
-	b.WriteString(fmt.Sprintf("%s\n", m.text))
+	b.WriteString(mockData(c, m))
 	b.WriteString(".\n")
 	b.WriteString("QUIT\n")
 
 	s.logs = append(s.logs, b.String())
 	return err
 }
+
+var boundaryRegex = regexp.MustCompile(`boundary=([0-9a-f]+)`)
+
+// generates the DATA of the given message in a deterministic form, i.e. without a date header, with sorted
+// headers, predictable multipart boundaries and LF line endings
+func mockData(c *Client, m *Message) string {
+	buf := &bytes.Buffer{}
+	m.toMIME(c.from).WriteTo(buf)
+
+	data := strings.ReplaceAll(buf.String(), "\r\n", "\n")
+	parts := strings.SplitN(data, "\n\n", 2)
+
+	// collect headers, treating folded continuation lines as part of the previous header
+	headers := make([]string, 0, 10)
+	for _, line := range strings.Split(parts[0], "\n") {
+		if strings.HasPrefix(line, " ") && len(headers) > 0 {
+			headers[len(headers)-1] += "\n" + line
+		} else if !strings.HasPrefix(line, "Date: ") {
+			headers = append(headers, line)
+		}
+	}
+	sort.Strings(headers)
+
+	data = strings.Join(headers, "\n") + "\n\n" + parts[1]
+
+	for i, match := range boundaryRegex.FindAllStringSubmatch(data, -1) {
+		data = strings.ReplaceAll(data, match[1], fmt.Sprintf("BOUNDARY%d", i+1))
+	}
+
+	if !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	return data
+}
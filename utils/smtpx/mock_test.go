@@ -16,7 +16,7 @@ func TestMockSender(t *testing.T) {
 	sender := smtpx.NewMockSender(nil, nil)
 	smtpx.SetSender(sender)
 
-	c := smtpx.NewClient("mail.temba.io", 255, "leah", "pass123", "updates@temba.io")
+	c := smtpx.NewClient("mail.temba.io", 255, "leah", "pass123", "updates@temba.io", nil)
 
 	msg1 := smtpx.NewMessage([]string{"bob@nyaruka.com", "jim@nyaruka.com"}, "Updates", "Have a great week", "<p>Have a great week</p>")
 	msg2 := smtpx.NewMessage([]string{"bob@nyaruka.com", "jim@nyaruka.com"}, "Updates", "Have a great weekend", "")
@@ -27,10 +27,26 @@ func TestMockSender(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.Equal(t, []string{
-		"HELO localhost\nMAIL FROM:<updates@temba.io>\nRCPT TO:<bob@nyaruka.com>\nRCPT TO:<jim@nyaruka.com>\nDATA\nHave a great week\n.\nQUIT\n",
-		"HELO localhost\nMAIL FROM:<updates@temba.io>\nRCPT TO:<bob@nyaruka.com>\nRCPT TO:<jim@nyaruka.com>\nDATA\nHave a great weekend\n.\nQUIT\n",
+		"HELO localhost\nMAIL FROM:<updates@temba.io>\nRCPT TO:<bob@nyaruka.com>\nRCPT TO:<jim@nyaruka.com>\nDATA\nContent-Type: multipart/alternative;\n boundary=BOUNDARY1\nFrom: updates@temba.io\nMIME-Version: 1.0\nSubject: Updates\nTo: bob@nyaruka.com, jim@nyaruka.com\n\n--BOUNDARY1\nContent-Transfer-Encoding: quoted-printable\nContent-Type: text/plain; charset=UTF-8\n\nHave a great week\n--BOUNDARY1\nContent-Transfer-Encoding: quoted-printable\nContent-Type: text/html; charset=UTF-8\n\n<p>Have a great week</p>\n--BOUNDARY1--\n.\nQUIT\n",
+		"HELO localhost\nMAIL FROM:<updates@temba.io>\nRCPT TO:<bob@nyaruka.com>\nRCPT TO:<jim@nyaruka.com>\nDATA\nContent-Transfer-Encoding: quoted-printable\nContent-Type: text/plain; charset=UTF-8\nFrom: updates@temba.io\nMIME-Version: 1.0\nSubject: Updates\nTo: bob@nyaruka.com, jim@nyaruka.com\n\nHave a great weekend\n.\nQUIT\n",
 	}, sender.Logs())
 
+	// a message with CC, BCC, reply-to and an attachment
+	sender = smtpx.NewMockSender(nil)
+	smtpx.SetSender(sender)
+
+	msg3 := smtpx.NewMessage([]string{"bob@nyaruka.com"}, "Report", "See attached", "<p>See attached</p>")
+	msg3.AddCC("jim@nyaruka.com")
+	msg3.AddBCC("ann@nyaruka.com")
+	msg3.SetReplyTo("support@temba.io")
+	msg3.Attach("report.csv", "text/csv", []byte("name,age\nBob,32\n"))
+
+	err = smtpx.Send(c, msg3, nil)
+	assert.NoError(t, err)
+
+	// BCC recipients are sent to but not included in headers
+	assert.Equal(t, []string{"HELO localhost\nMAIL FROM:<updates@temba.io>\nRCPT TO:<bob@nyaruka.com>\nRCPT TO:<jim@nyaruka.com>\nRCPT TO:<ann@nyaruka.com>\nDATA\nCc: jim@nyaruka.com\nContent-Type: multipart/mixed;\n boundary=BOUNDARY1\nFrom: updates@temba.io\nMIME-Version: 1.0\nReply-To: support@temba.io\nSubject: Report\nTo: bob@nyaruka.com\n\n--BOUNDARY1\nContent-Type: multipart/alternative;\n boundary=BOUNDARY2\n\n--BOUNDARY2\nContent-Transfer-Encoding: quoted-printable\nContent-Type: text/plain; charset=UTF-8\n\nSee attached\n--BOUNDARY2\nContent-Transfer-Encoding: quoted-printable\nContent-Type: text/html; charset=UTF-8\n\n<p>See attached</p>\n--BOUNDARY2--\n\n--BOUNDARY1\nContent-Disposition: attachment; filename=\"report.csv\"\nContent-Transfer-Encoding: base64\nContent-Type: text/csv; name=\"report.csv\"\n\nbmFtZSxhZ2UKQm9iLDMyCg==\n--BOUNDARY1--\n.\nQUIT\n"}, sender.Logs())
+
 	// a sender which errors
 	sender = smtpx.NewMockSender(errors.New("oops can't send"), errors.New("421 Service not available, closing transmission channel"))
 	smtpx.SetSender(sender)
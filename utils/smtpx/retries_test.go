@@ -11,8 +11,10 @@ import (
 )
 
 func TestSendWithRetries(t *testing.T) {
+	defer smtpx.SetSender(smtpx.DefaultSender)
+
 	msg := smtpx.NewMessage([]string{"bob@nyaruka.com", "jim@nyaruka.com"}, "Updates", "Have a great weekend", "")
-	c := smtpx.NewClient("mail.temba.io", 255, "leah", "pass123", "updates@temba.io")
+	c := smtpx.NewClient("mail.temba.io", 255, "leah", "pass123", "updates@temba.io", nil)
 
 	// a sender which errors
 	sender := smtpx.NewMockSender(
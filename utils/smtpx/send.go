@@ -1,15 +1,30 @@
 package smtpx
 
 import (
+	"bytes"
+	"io"
 	"time"
+
+	gomail "github.com/go-mail/mail"
 )
 
+// Attachment is a file attached to an email message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
 // Message is email message
 type Message struct {
-	recipients []string
-	subject    string
-	text       string
-	html       string
+	recipients  []string
+	cc          []string
+	bcc         []string
+	replyTo     string
+	subject     string
+	text        string
+	html        string
+	attachments []*Attachment
 }
 
 // NewMessage creates a new message
@@ -22,6 +37,69 @@ func NewMessage(recipients []string, subject, text, html string) *Message {
 	}
 }
 
+// AddCC adds carbon copy recipients to this message
+func (m *Message) AddCC(addresses ...string) {
+	m.cc = append(m.cc, addresses...)
+}
+
+// AddBCC adds blind carbon copy recipients to this message
+func (m *Message) AddBCC(addresses ...string) {
+	m.bcc = append(m.bcc, addresses...)
+}
+
+// SetReplyTo sets the reply-to address of this message
+func (m *Message) SetReplyTo(address string) {
+	m.replyTo = address
+}
+
+// Attach adds a file attachment to this message
+func (m *Message) Attach(filename, contentType string, data []byte) {
+	m.attachments = append(m.attachments, &Attachment{Filename: filename, ContentType: contentType, Data: data})
+}
+
+// gets all the addresses this message will be delivered to, including BCC recipients
+func (m *Message) allRecipients() []string {
+	all := make([]string, 0, len(m.recipients)+len(m.cc)+len(m.bcc))
+	all = append(all, m.recipients...)
+	all = append(all, m.cc...)
+	return append(all, m.bcc...)
+}
+
+// builds the MIME representation of this message. BCC recipients are never included in the headers.
+func (m *Message) toMIME(from string) *gomail.Message {
+	mm := gomail.NewMessage()
+	mm.SetHeader("From", from)
+	mm.SetHeader("To", m.recipients...)
+	if len(m.cc) > 0 {
+		mm.SetHeader("Cc", m.cc...)
+	}
+	if m.replyTo != "" {
+		mm.SetHeader("Reply-To", m.replyTo)
+	}
+	mm.SetHeader("Subject", m.subject)
+	mm.SetBody("text/plain", m.text)
+	if m.html != "" {
+		mm.AddAlternative("text/html", m.html)
+	}
+
+	for _, a := range m.attachments {
+		settings := []gomail.FileSetting{gomail.SetCopyFunc(copyBytes(a.Data))}
+		if a.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType + `; name="` + a.Filename + `"`}}))
+		}
+		mm.Attach(a.Filename, settings...)
+	}
+
+	return mm
+}
+
+func copyBytes(data []byte) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(data))
+		return err
+	}
+}
+
 // Send an email using SMTP
 func Send(c *Client, m *Message, retries *RetryConfig) error {
 	var err error
@@ -0,0 +1,229 @@
+package smtpx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// a message received by the test server
+type receivedMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// a minimal in-process SMTP server for testing which supports AUTH PLAIN, STARTTLS and implicit TLS
+type testServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	implicit  bool
+	username  string
+	password  string
+
+	mutex       sync.Mutex
+	connections int
+	open        map[net.Conn]bool
+	messages    []*receivedMessage
+	dataErrors  []string // responses to use instead of success for the next DATA commands
+}
+
+// starts a new test server. If tlsConfig is provided the server supports STARTTLS, unless implicit is true in
+// which case connections must use TLS from the start.
+func newTestServer(t *testing.T, tlsConfig *tls.Config, implicit bool) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	if implicit {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s := &testServer{listener: listener, tlsConfig: tlsConfig, implicit: implicit, username: "leah", password: "pass123", open: make(map[net.Conn]bool)}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mutex.Lock()
+			s.connections++
+			s.open[conn] = true
+			s.mutex.Unlock()
+
+			go s.handle(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testServer) close() {
+	s.listener.Close()
+}
+
+func (s *testServer) stats() (int, []*receivedMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.connections, s.messages
+}
+
+func (s *testServer) openConns() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.open)
+}
+
+func (s *testServer) failNextData(responses ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dataErrors = append(s.dataErrors, responses...)
+}
+
+// closes all open connections as a server would do with connections that have been idle too long
+func (s *testServer) disconnectAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for conn := range s.open {
+		conn.Close()
+	}
+}
+
+func (s *testServer) handle(conn net.Conn) {
+	rawConn := conn
+	defer func() {
+		conn.Close()
+
+		s.mutex.Lock()
+		delete(s.open, rawConn)
+		s.mutex.Unlock()
+	}()
+
+	tp := textproto.NewConn(conn)
+	isTLS := s.implicit
+	msg := &receivedMessage{}
+
+	tp.PrintfLine("220 localhost ESMTP test server")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		arg := strings.TrimSpace(line[len(cmd):])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			if s.tlsConfig != nil && !isTLS {
+				tp.PrintfLine("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			} else {
+				tp.PrintfLine("250-localhost\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			isTLS = true
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			if string(decoded) == fmt.Sprintf("\x00%s\x00%s", s.username, s.password) {
+				tp.PrintfLine("235 authentication successful")
+			} else {
+				tp.PrintfLine("535 authentication failed")
+			}
+		case "MAIL":
+			msg = &receivedMessage{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 send data")
+			data, err := ioutil.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+
+			s.mutex.Lock()
+			if len(s.dataErrors) > 0 {
+				response := s.dataErrors[0]
+				s.dataErrors = s.dataErrors[1:]
+				s.mutex.Unlock()
+				tp.PrintfLine(response)
+				continue
+			}
+			s.messages = append(s.messages, msg)
+			s.mutex.Unlock()
+
+			tp.PrintfLine("250 OK queued")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// generates a self-signed certificate for 127.0.0.1 and returns server and client TLS configs which use it
+func newTestTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Nyaruka"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	clientConfig := &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+
+	return serverConfig, clientConfig
+}